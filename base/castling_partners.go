@@ -0,0 +1,48 @@
+package base
+
+import (
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+// CastlingPartners maps colour to a slice of initial coords of pieces which king can castle with
+type CastlingPartners map[Colour][]ICoord
+
+// NewCastlingPartners returns new castling partners
+func NewCastlingPartners() CastlingPartners { return CastlingPartners{White: {}, Black: {}} }
+
+// Copy returns a copy of c
+func (c CastlingPartners) Copy() CastlingPartners {
+	res := CastlingPartners{}
+	for colour, coords := range c {
+		res[colour] = make([]ICoord, len(coords))
+		for i := range coords {
+			res[colour][i] = coords[i].Copy()
+		}
+	}
+	return res
+}
+
+// Contains returns true if c contains coord for colour
+func (c CastlingPartners) Contains(colour Colour, coord ICoord) bool {
+	for i := range c[colour] {
+		if c[colour][i].Equals(coord) {
+			return true
+		}
+	}
+	return false
+}
+
+// Equals returns true if c equals to, and returns false otherwise, the order of coords doesn't matter
+func (c CastlingPartners) Equals(to CastlingPartners) bool {
+	for _, colour := range AllColours() {
+		if len(c[colour]) != len(to[colour]) {
+			return false
+		}
+		for i := range c[colour] {
+			if !to.Contains(colour, c[colour][i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,27 @@
+package base
+
+// CastlingRule is a declarative castling rule for boards having files
+type CastlingRule struct {
+	// Partners is a list of names of pieces which king can castle with
+	Partners []string
+
+	// KingFiles contains king's destination files for a-side (index 0) and z-side (index 1) castling.
+	// A-side file is counted from the a-side board edge and z-side file is counted from the z-side edge,
+	// so {3, 2} means c-file and g-file on 8 files wide board like in standard chess.
+	KingFiles [2]int
+
+	// KingSteps is a list of distances king moves towards the partner, if it is not empty
+	// then it is used instead of KingFiles
+	KingSteps []int
+
+	// Free enables free castling: king can move any distance towards the partner,
+	// it is used instead of KingFiles and KingSteps
+	Free bool
+}
+
+// Copy returns a deep copy of r
+func (r CastlingRule) Copy() CastlingRule {
+	r.Partners = append([]string(nil), r.Partners...)
+	r.KingSteps = append([]int(nil), r.KingSteps...)
+	return r
+}
@@ -1,6 +1,6 @@
 package base
 
-// RookCoords is a slice of castlings
+// Castlings is a slice of castlings
 type Castlings []Castling
 
 // Contains returns true if c contains castling
@@ -17,6 +17,9 @@ func NewCoords(s []ICoord) *Coords {
 
 func (s *Coords) Get(i int) ICoord { return s.slice[i] }
 
+// Slice returns an underlying slice
+func (s *Coords) Slice() []ICoord { return s.slice }
+
 // Next returns next coordinates element
 func (s *Coords) Next() interface{} {
 	s.i++
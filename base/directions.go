@@ -0,0 +1,80 @@
+package base
+
+import (
+	. "github.com/mtfelian/utils"
+)
+
+const (
+	MoveAny        = iota // capture / non-capturing move
+	MoveCapture           // only capture
+	MoveNonCapture        // only non-capturing move
+)
+
+// Stroke returns true if mine imaginary beam strokes some piece on coords on board, memorizing it's path
+// it returns false if an imaginary beam is still going meating no barrier
+// to is a destination cell coords
+// moving - set it to true if the func should return possible legal moves, set it to false to return attacked cells
+// on is a board on which piece is moving
+// mine is a moving piece
+// path is a pointer to a slice of coords to add
+// moveType is a type of move: only capturing, only non-capturing, or any
+func Stroke(to ICoord, moving bool, on IBoard, mine IPiece, path *[]ICoord, moveType int) bool {
+	dstPiece := on.Cell(to).Piece()
+	// destination cell contains another piece
+	if dstPiece != nil {
+		// if we are only calculating attacking cells, or if can capture
+		if SliceContains(moveType, []int{MoveAny, MoveCapture}) &&
+			(!moving || !on.Settings().Players.Allied(dstPiece.Colour(), mine.Colour()) &&
+				!PromotionBlocked(on, mine, to)) {
+			*path = append(*path, to)
+		}
+		return true
+	}
+
+	// dstPiece == nil, empty cell
+	if (moveType == MoveAny || (moving && moveType == MoveNonCapture) || (!moving && moveType == MoveCapture)) &&
+		(!moving || !PromotionBlocked(on, mine, to)) {
+		*path = append(*path, to)
+	}
+	return false
+}
+
+// Leaper returns destination coords for pieces which move in one step by offsets o, like knight and king.
+// Set moving to true to exclude check exposing path and defending own piece path.
+func Leaper(piece IPiece, board IBoard, moving bool, o []ICoord, moveType int) []ICoord {
+	result := []ICoord{}
+	for i := range o {
+		to := piece.Coord().Add(o[i])
+		if to.OutOf(board) {
+			continue
+		}
+		if moving && board.Project(piece, to).InCheck(piece.Colour()) {
+			continue
+		}
+		Stroke(to, moving, board, piece, &result, moveType) // here should not break even if true!
+	}
+	return result
+}
+
+// Rider returns destination coords for pieces which move in many steps by offsets o, like rook and bishop.
+// Set moving to true to exclude check exposing path and defending own pieces path.
+// Set max to non-0 value to restrict the maximum steps to move in each one direction.
+// max value of 0 means no maximum steps restriction.
+func Rider(piece IPiece, board IBoard, moving bool, o []ICoord, max int, moveType int) []ICoord {
+	result := []ICoord{}
+directions:
+	for i := range o {
+		for to, step := piece.Coord().Add(o[i]), 0; !to.OutOf(board) && (step < max || max == 0); to, step = to.Add(o[i]), step+1 {
+			if moving && board.Project(piece, to).InCheck(piece.Colour()) {
+				if board.Piece(to) != nil {
+					continue directions // a piece blocks the way even if capturing it doesn't release check
+				}
+				continue // should continue in same direction (may be further capture releases check?)
+			}
+			if Stroke(to, moving, board, piece, &result, moveType) {
+				continue directions // capture occurred, don't go further in that direction
+			}
+		}
+	}
+	return result
+}
@@ -31,15 +31,18 @@ type IBoard interface {
 
 	Outcome() Outcome
 	Resign(colour Colour)
+	AgreeDraw()
 
 	Castlings(colour Colour) Castlings
 	MakeCastling(castling Castling) bool
 
 	SetCanCaptureEnPassantAt(dst ICoord)
 	CanCaptureEnPassantAt() ICoord
+	SetEnPassantTargets(targets []ICoord)
+	EnPassantTargets() []ICoord
 
-	SetRookInitialCoords(colour Colour, i int, coord ICoord)
-	RookInitialCoords(colour Colour) [2]ICoord
+	AddCastlingPartner(colour Colour, coord ICoord)
+	CastlingPartners(colour Colour) []ICoord
 
 	// Project a piece to coords, returns a pointer to a new copy of a board, don't check legality
 	// this don't change coords of a piece
@@ -8,8 +8,8 @@ type INotation interface {
 	// EncodeMove on board with piece to dst coord
 	EncodeMove(IBoard, IPiece, ICoord) string
 
-	// EncodeCastling
-	EncodeCastling(i int) string
+	// EncodeCastling on board
+	EncodeCastling(IBoard, Castling) string
 
 	// DecodeCoord from string
 	DecodeCoord(string) error
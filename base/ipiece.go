@@ -16,6 +16,8 @@ type IPiece interface {
 	Name() string
 	// Capital returns piece's capital letter
 	Capital() rune
+	// Figurine returns piece's Unicode figurine
+	Figurine() rune
 	// Attacks returns a slice of cells coords attacked by piece
 	Attacks(b IBoard) ICoords
 	// Destinations returns a slice of cells coords to destination cells of possible moves
@@ -2,6 +2,7 @@ package base
 
 import (
 	"fmt"
+	"strings"
 
 	. "github.com/mtfelian/mtfchess/colour"
 )
@@ -19,16 +20,41 @@ const (
 	drawByXFoldRepetition
 	drawByXMovesRule
 	drawByNotSufficientMaterial
+	lastStanding
+	points
+	adjudication
 )
 
 // Outcome is a game outcome
 type Outcome struct {
 	Winner Colour
 	Reason reason
+
+	// Winners are colours of all winning sides of a multi-player game
+	Winners []Colour
+
+	// Scores maps sides colours to their points in a multi-player game with scoring
+	Scores map[Colour]int
 }
 
 // Equals returns true if o equals to
-func (o Outcome) Equals(to Outcome) bool { return o.Winner == to.Winner && o.Reason == to.Reason }
+func (o Outcome) Equals(to Outcome) bool {
+	if o.Winner != to.Winner || o.Reason != to.Reason || len(o.Winners) != len(to.Winners) ||
+		len(o.Scores) != len(to.Scores) {
+		return false
+	}
+	for i := range o.Winners {
+		if o.Winners[i] != to.Winners[i] {
+			return false
+		}
+	}
+	for c, score := range o.Scores {
+		if toScore, exists := to.Scores[c]; !exists || score != toScore {
+			return false
+		}
+	}
+	return true
+}
 
 // String makes Outcome to implement fmt.Stringer
 func (o Outcome) String() string {
@@ -51,6 +77,18 @@ func (o Outcome) String() string {
 		return "Draw by 50 moves rule"
 	case drawByNotSufficientMaterial:
 		return "Draw by no sufficient material"
+	case lastStanding:
+		return fmt.Sprintf("%s won as the last standing", joinNames(o.Winners))
+	case points:
+		if len(o.Winners) == 0 {
+			return "Draw by points"
+		}
+		return fmt.Sprintf("%s won by points", joinNames(o.Winners))
+	case adjudication:
+		if o.Winner == Transparent {
+			return "Draw by adjudication"
+		}
+		return fmt.Sprintf("%s won by adjudication", o.Winner.Name())
 	}
 	return ""
 }
@@ -88,3 +126,42 @@ func NewDrawByXMovesRule() Outcome { return Outcome{Winner: Transparent, Reason:
 func NewDrawByNotSufficientMaterial() Outcome {
 	return Outcome{Winner: Transparent, Reason: drawByNotSufficientMaterial}
 }
+
+// NewLastStanding returns an outcome for a multi-player game won by the last standing side or team
+func NewLastStanding(winners []Colour) Outcome {
+	return Outcome{Winner: winners[0], Reason: lastStanding, Winners: winners}
+}
+
+// NewPointsVictory returns an outcome for a multi-player game finished with scores, sides
+// with the most points win. It is a draw if there are no scores or several sides have the most points.
+func NewPointsVictory(scores map[Colour]int) Outcome {
+	best, winners := 0, []Colour{}
+	for _, c := range []Colour{White, Black, Red, Blue, Yellow, Green} {
+		score, exists := scores[c]
+		switch {
+		case !exists || len(winners) > 0 && score < best:
+		case len(winners) == 0 || score > best:
+			best, winners = score, []Colour{c}
+		default:
+			winners = append(winners, c)
+		}
+	}
+	o := Outcome{Winner: Transparent, Reason: points, Scores: scores}
+	if len(winners) == 1 {
+		o.Winner, o.Winners = winners[0], winners
+	}
+	return o
+}
+
+// NewAdjudication returns an outcome of a game adjudicated as won by winner,
+// it is a draw if winner is Transparent
+func NewAdjudication(winner Colour) Outcome { return Outcome{Winner: winner, Reason: adjudication} }
+
+// joinNames returns names of colours joined with "and"
+func joinNames(colours []Colour) string {
+	names := make([]string, len(colours))
+	for i := range colours {
+		names[i] = colours[i].Name()
+	}
+	return strings.Join(names, " and ")
+}
@@ -0,0 +1,56 @@
+package base
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+// reasonNames maps outcome reasons to their names used in JSON
+var reasonNames = map[reason]string{
+	notCompleted:                "notCompleted",
+	checkmate:                   "checkmate",
+	timeOver:                    "timeOver",
+	resignation:                 "resignation",
+	stalemate:                   "stalemate",
+	drawByAgreement:             "drawByAgreement",
+	drawByXFoldRepetition:       "drawByXFoldRepetition",
+	drawByXMovesRule:            "drawByXMovesRule",
+	drawByNotSufficientMaterial: "drawByNotSufficientMaterial",
+	lastStanding:                "lastStanding",
+	points:                      "points",
+	adjudication:                "adjudication",
+}
+
+// outcomeJSON is a JSON representation of Outcome
+type outcomeJSON struct {
+	Reason  string         `json:"reason"`
+	Winner  Colour         `json:"winner"`
+	Winners []Colour       `json:"winners,omitempty"`
+	Scores  map[Colour]int `json:"scores,omitempty"`
+}
+
+// MarshalJSON makes Outcome to implement json.Marshaler
+func (o Outcome) MarshalJSON() ([]byte, error) {
+	name, exists := reasonNames[o.Reason]
+	if !exists {
+		return nil, fmt.Errorf("invalid outcome reason %d", o.Reason)
+	}
+	return json.Marshal(outcomeJSON{Reason: name, Winner: o.Winner, Winners: o.Winners, Scores: o.Scores})
+}
+
+// UnmarshalJSON makes Outcome to implement json.Unmarshaler
+func (o *Outcome) UnmarshalJSON(data []byte) error {
+	var v outcomeJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	for r, name := range reasonNames {
+		if name == v.Reason {
+			*o = Outcome{Winner: v.Winner, Reason: r, Winners: v.Winners, Scores: v.Scores}
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid outcome reason %q", v.Reason)
+}
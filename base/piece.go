@@ -1,6 +1,7 @@
 package base
 
 import (
+	"unicode"
 	"unicode/utf8"
 
 	"github.com/mtfelian/cli"
@@ -16,6 +17,7 @@ const (
 	ArchbishopName = "archbishop"
 	ChancellorName = "chancellor"
 	KingName       = "king"
+	UnicornName    = "unicorn"
 )
 
 // Piece is a base piece
@@ -47,13 +49,29 @@ func (p *Piece) Name() string { return p.name }
 // Capital returns a piece's capital letter
 func (p *Piece) Capital() rune { return rune(p.literals[0]) }
 
-// String makes BasePiece to implement fmt.Stringer
+// Figurine returns a piece's Unicode figurine, outlined for white and solid for other colours
+func (p *Piece) Figurine() rune {
+	runes := []rune(p.literals)
+	if len(runes) < 3 {
+		return p.Capital()
+	}
+	if p.colour == White {
+		return runes[1]
+	}
+	return runes[2]
+}
+
+// String makes BasePiece to implement fmt.Stringer, it returns a capital letter for white and a small one for black.
+// Pieces of other sides of multi-player games are prefixed with the first letter of a side name, like "RK" for red king.
 func (p *Piece) String() string {
-	return map[Colour]string{
-		Transparent: cli.Sprintf("{0|%c", p.Capital()),
-		White:       cli.Sprintf("{W|%c{0|", p.Capital()),
-		Black:       cli.Sprintf("{A|%c{0|", p.Capital()),
-	}[p.Colour()]
+	switch name := p.colour.Name(); {
+	case p.colour == White:
+		return string(p.Capital())
+	case p.colour == Black || name == "":
+		return string(unicode.ToLower(p.Capital()))
+	default:
+		return string([]rune(name)[0]) + string(p.Capital())
+	}
 }
 
 // Colour returns a colour of a piece
@@ -0,0 +1,113 @@
+package base
+
+import (
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+// Players describes sides of a multi-player game, nil players mean a two-player game of white and black
+type Players struct {
+	// TurnOrder is a list of sides colours in order of their turns
+	TurnOrder []Colour
+
+	// Forward maps side colour to the forward direction of its pawns
+	Forward map[Colour]ICoord
+
+	// Teams is a list of allied sides, sides not listed play for themselves
+	Teams [][]Colour
+
+	// Points maps piece names to points given for capturing such a piece, nil points disable scoring.
+	// Capturing a dead piece gives no points.
+	Points map[string]int
+
+	// CheckmatePoints are points given to a side which checkmated another side
+	CheckmatePoints int
+}
+
+// Allied returns true if sides of colours a and b are the same side or they are in the same team.
+// For nil players only the same colours are allied.
+func (p *Players) Allied(a, b Colour) bool {
+	if a == b {
+		return true
+	}
+	if p == nil {
+		return false
+	}
+	for _, team := range p.Teams {
+		inA, inB := false, false
+		for _, c := range team {
+			inA, inB = inA || c == a, inB || c == b
+		}
+		if inA && inB {
+			return true
+		}
+	}
+	return false
+}
+
+// Colours returns colours of all sides in order of their turns
+func (p *Players) Colours() []Colour {
+	if p == nil {
+		return AllColours()
+	}
+	return p.TurnOrder
+}
+
+// Opponents returns colours of sides playing against the side of colour
+func (p *Players) Opponents(of Colour) []Colour {
+	res := []Colour{}
+	for _, c := range p.Colours() {
+		if !p.Allied(of, c) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// Next returns colour of the side which turn follows the turn of colour, skipping sides
+// for which skip returns true. It returns Transparent if all other sides are skipped.
+func (p *Players) Next(after Colour, skip func(Colour) bool) Colour {
+	colours := p.Colours()
+	i := 0
+	for i < len(colours) && colours[i] != after {
+		i++
+	}
+	for j := 1; j < len(colours); j++ {
+		if c := colours[(i+j)%len(colours)]; !skip(c) {
+			return c
+		}
+	}
+	return Transparent
+}
+
+// Last returns true if colour is the last side in order of turns, it finishes a move
+func (p *Players) Last(colour Colour) bool {
+	colours := p.Colours()
+	return len(colours) > 0 && colours[len(colours)-1] == colour
+}
+
+// Copy returns a deep copy of p, it returns nil for nil players
+func (p *Players) Copy() *Players {
+	if p == nil {
+		return nil
+	}
+	res := &Players{
+		TurnOrder:       append([]Colour{}, p.TurnOrder...),
+		CheckmatePoints: p.CheckmatePoints,
+	}
+	if p.Forward != nil {
+		res.Forward = make(map[Colour]ICoord, len(p.Forward))
+		for colour, f := range p.Forward {
+			res.Forward[colour] = f.Copy()
+		}
+	}
+	for _, team := range p.Teams {
+		res.Teams = append(res.Teams, append([]Colour{}, team...))
+	}
+	if p.Points != nil {
+		res.Points = make(map[string]int, len(p.Points))
+		for name, points := range p.Points {
+			res.Points[name] = points
+		}
+	}
+	return res
+}
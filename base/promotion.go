@@ -0,0 +1,107 @@
+package base
+
+import (
+	. "github.com/mtfelian/mtfchess/colour"
+	. "github.com/mtfelian/utils"
+)
+
+// PromotionKind is a kind of promotion available for a piece going to some cell
+type PromotionKind int
+
+const (
+	NoPromotion        PromotionKind = iota // piece can't be promoted
+	OptionalPromotion                       // piece can be promoted or can stay as is
+	MandatoryPromotion                      // piece should be promoted
+)
+
+// PromotionRule is a promotion rule for a piece
+type PromotionRule struct {
+	// ZoneFunc returns a kind of promotion available for piece going to dst
+	ZoneFunc func(board IBoard, piece IPiece, dst ICoord) PromotionKind
+
+	// Targets is a list of piece names to promote to, if it's empty then Settings.AllowedPromotions is used
+	Targets []string
+
+	// Limits maps target piece names to maximum amounts of such pieces of the same colour on a board.
+	// It allows promotion only to pieces previously captured, like in Grand Chess.
+	Limits map[string]int
+}
+
+// PromotionRules maps piece names to their promotion rules, pieces without a rule can't be promoted
+type PromotionRules map[string]PromotionRule
+
+// Kind returns a kind of promotion available for piece going to dst
+func (r PromotionRules) Kind(board IBoard, piece IPiece, dst ICoord) PromotionKind {
+	rule, exists := r[piece.Name()]
+	if !exists || rule.ZoneFunc == nil {
+		return NoPromotion
+	}
+	return rule.ZoneFunc(board, piece, dst)
+}
+
+// Copy returns a deep copy of r
+func (r PromotionRules) Copy() PromotionRules {
+	if r == nil {
+		return nil
+	}
+	res := make(PromotionRules, len(r))
+	for name, rule := range r {
+		rule.Targets = append([]string(nil), rule.Targets...)
+		if rule.Limits != nil {
+			limits := make(map[string]int, len(rule.Limits))
+			for target, n := range rule.Limits {
+				limits[target] = n
+			}
+			rule.Limits = limits
+		}
+		res[name] = rule
+	}
+	return res
+}
+
+// PromotionTargets returns piece names to which piece going to dst can be promoted according to board settings.
+// If there are limits to amount of pieces, the result contains only names of pieces which are below the limit.
+func PromotionTargets(board IBoard, piece IPiece, dst ICoord) []string {
+	settings := board.Settings()
+	if settings.PromotionRules.Kind(board, piece, dst) == NoPromotion {
+		return nil
+	}
+
+	rule, res := settings.PromotionRules[piece.Name()], []string{}
+	targets := rule.Targets
+	if len(targets) == 0 {
+		targets = settings.AllowedPromotions
+	}
+	for _, name := range targets {
+		if limit, exists := rule.Limits[name]; exists {
+			pieces := board.FindPieces(PieceFilter{Names: []string{name}, Colours: []Colour{piece.Colour()}})
+			if len(pieces) >= limit {
+				continue
+			}
+		}
+		res = append(res, name)
+	}
+	return res
+}
+
+// PromotionBlocked returns true if piece going to dst should be promoted but there are no pieces to promote to,
+// so the move is illegal
+func PromotionBlocked(board IBoard, piece IPiece, dst ICoord) bool {
+	return board.Settings().PromotionRules.Kind(board, piece, dst) == MandatoryPromotion &&
+		len(PromotionTargets(board, piece, dst)) == 0
+}
+
+// StandardPromotionConditionFunc is a promotion condition according to board settings promotion rules
+func StandardPromotionConditionFunc(board IBoard, piece IPiece, dst ICoord, to IPiece) bool {
+	return to.Colour() == piece.Colour() && // only to self-colored
+		SliceContains(to.Name(), PromotionTargets(board, piece, dst)) // to piece from list
+}
+
+// Promoted returns a copy of a piece in which p will be promoted, or p itself if it has no promotion
+func Promoted(p IPiece) IPiece {
+	promotion := p.Promotion()
+	if promotion == nil {
+		return p
+	}
+	return promotion.Copy()
+}
@@ -6,22 +6,47 @@ import (
 
 // Settings is a game rectangular board settings
 type Settings struct {
+	// Name is a name of a settings preset, it may be empty for custom settings
+	Name string
+
 	// PawnLongMoveModifier's added to pawn's move vertical absolute offset (to the front)
 	// to allow pawn to move that 1 + number of squares to the front according to this func's logic
 	PawnLongMoveModifier int
 
-	// AllowedPromotions is a list of string piece names to promote to
+	// PawnStartZoneFunc returns true if pawn piece stands within it's start zone,
+	// from where it is allowed to make a long move
+	PawnStartZoneFunc func(board IBoard, piece IPiece) bool
+
+	// AllowedPromotions is a list of string piece names to promote to by default
 	AllowedPromotions []string
 
+	// PromotionRules maps piece names to their promotion rules
+	PromotionRules PromotionRules
+
 	// PromotionConditionFunc returns true if piece going to cell dst can be promoted to
 	PromotionConditionFunc func(board IBoard, piece IPiece, dst ICoord, to IPiece) bool
 
 	// EnPassantFunc returns coords on which a piece can do en passant capturing
-	EnPassantFunc func(board IBoard, piece IPiece) ICoord
+	EnPassantFunc func(board IBoard, piece IPiece) ICoords
 
 	// CastlingsFunc returns available castlings for the given colour
 	CastlingsFunc func(board IBoard, colour Colour) Castlings
 
+	// Castling is a castling rule used by castlings func
+	Castling CastlingRule
+
+	// WrapFiles enables wrap-around on the file axis: a-file and z-file are adjacent like on a cylinder
+	WrapFiles bool
+
+	// WrapRanks enables wrap-around on the rank axis, together with WrapFiles it makes a torus
+	WrapRanks bool
+
+	// Holes are coords of cells missing on a board, pieces can't stand on them or pass through them
+	Holes []ICoord
+
+	// Players describes sides of a multi-player game, nil for a two-player game of white and black
+	Players *Players
+
 	// MoveOrder enables move order control if set to true
 	MoveOrder bool
 
@@ -31,3 +56,19 @@ type Settings struct {
 	// PositionsToDraw specifies amount of positions repetition to declare a draw
 	PositionsToDraw int
 }
+
+// Copy returns a deep copy of s, funcs are shared as they are immutable
+func (s *Settings) Copy() *Settings {
+	res := *s
+	res.AllowedPromotions = append([]string(nil), s.AllowedPromotions...)
+	res.PromotionRules = s.PromotionRules.Copy()
+	res.Castling = s.Castling.Copy()
+	res.Players = s.Players.Copy()
+	if s.Holes != nil {
+		res.Holes = make([]ICoord, len(s.Holes))
+		for i := range s.Holes {
+			res.Holes[i] = s.Holes[i].Copy()
+		}
+	}
+	return &res
+}
@@ -0,0 +1,149 @@
+// Package book implements opening books for rectangular boards: Polyglot books for standard 8x8 chess
+// and native books keyed by board hashes for boards of any dimensions.
+package book
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mtfelian/mtfchess/base"
+	"github.com/mtfelian/mtfchess/rect"
+)
+
+// ErrNotFound is returned if there are no book moves for a position
+var ErrNotFound = errors.New("no book moves for position")
+
+var (
+	moveRegexp       = regexp.MustCompile(`^([a-z]\d{1,2})-([a-z]\d{1,2})$`)
+	castlingRegexp   = regexp.MustCompile(`(?i)^O-O`)
+	moveSuffixRegexp = regexp.MustCompile(`[+#]$`)
+)
+
+// Entry is a weighted book move in a position. A move is written in long algebraic notation
+// like "e2-e4" or "e7-e8=Q", castling is written as a king move to its partner like "e1-h1".
+type Entry struct {
+	Key    uint64 // a position key
+	Move   string // a book move
+	Weight uint16 // a relative probability to choose a move
+	Learn  uint32 // learning data, it's not used by this package
+}
+
+// KeyFunc returns a key of a board position
+type KeyFunc func(b *rect.Board) (uint64, error)
+
+// NativeKey returns a key of a position on a board of any dimensions, it's the board hash
+func NativeKey(b *rect.Board) (uint64, error) { return b.Hash(), nil }
+
+// Book is an opening book
+type Book struct {
+	key     KeyFunc
+	entries []Entry // sorted by keys and descending weights
+}
+
+// New returns a new book with position keys returned by key
+func New(key KeyFunc, entries []Entry) *Book {
+	bk := &Book{key: key, entries: append([]Entry{}, entries...)}
+	sort.SliceStable(bk.entries, func(i, j int) bool {
+		a, b := bk.entries[i], bk.entries[j]
+		return a.Key < b.Key || a.Key == b.Key && a.Weight > b.Weight
+	})
+	return bk
+}
+
+// Entries returns all book entries sorted by keys
+func (bk *Book) Entries() []Entry { return append([]Entry{}, bk.entries...) }
+
+// Lookup returns entries for a position on board sorted by descending weights
+func (bk *Book) Lookup(b *rect.Board) ([]Entry, error) {
+	key, err := bk.key(b)
+	if err != nil {
+		return nil, err
+	}
+	i := sort.Search(len(bk.entries), func(i int) bool { return bk.entries[i].Key >= key })
+	j := i
+	for j < len(bk.entries) && bk.entries[j].Key == key {
+		j++
+	}
+	return append([]Entry{}, bk.entries[i:j]...), nil
+}
+
+// Choose returns a book move for a position on board chosen randomly with a probability proportional
+// to its weight, moves of zero weight are never chosen
+func (bk *Book) Choose(b *rect.Board, rnd *rand.Rand) (Entry, error) {
+	entries, err := bk.Lookup(b)
+	if err != nil {
+		return Entry{}, err
+	}
+	total := 0
+	for _, e := range entries {
+		total += int(e.Weight)
+	}
+	if total == 0 {
+		return Entry{}, ErrNotFound
+	}
+	n := rnd.Intn(total)
+	for _, e := range entries {
+		if n -= int(e.Weight); n < 0 {
+			return e, nil
+		}
+	}
+	return Entry{}, ErrNotFound
+}
+
+// DecodeMove returns a func that tries to make a book move on board, see base.INotation.DecodeMove()
+func DecodeMove(b *rect.Board, move string) (func() bool, error) {
+	if from, to, ok := castlingCoords(b, move); ok {
+		for _, castling := range b.Castlings(b.SideToMove()) {
+			if castling.Piece[0].Coord().Equals(from) && castling.Piece[1].Coord().Equals(to) {
+				castling := castling
+				return func() bool { return b.MakeCastling(castling) }, nil
+			}
+		}
+		return nil, fmt.Errorf("castling %s is not available", move)
+	}
+	return rect.NewLongAlgebraicNotation().DecodeMove(b, move)
+}
+
+// castlingCoords returns coords of a king and its partner if move is a king move to a cell
+// occupied by a piece of the same colour
+func castlingCoords(b *rect.Board, move string) (from, to rect.Coord, ok bool) {
+	m := moveRegexp.FindStringSubmatch(move)
+	if m == nil {
+		return from, to, false
+	}
+	n := rect.NewLongAlgebraicNotation()
+	if n.DecodeCoord(m[1]) != nil {
+		return from, to, false
+	}
+	from = n.Coord.(rect.Coord)
+	if n.DecodeCoord(m[2]) != nil {
+		return from, to, false
+	}
+	to = n.Coord.(rect.Coord)
+	king, partner := b.Piece(from), b.Piece(to)
+	ok = king != nil && king.Name() == base.KingName && partner != nil && partner.Colour() == king.Colour()
+	return from, to, ok
+}
+
+// EncodeMove returns a move in short algebraic notation converted to a book move
+func EncodeMove(b *rect.Board, move string) (string, error) {
+	long, err := rect.NewShortAlgebraicNotation().LongMove(b, move)
+	if err != nil {
+		return "", err
+	}
+	if !castlingRegexp.MatchString(long) {
+		return moveSuffixRegexp.ReplaceAllString(long, ""), nil
+	}
+	castling := strings.ToUpper(moveSuffixRegexp.ReplaceAllString(long, ""))
+	n := rect.NewLongAlgebraicNotation()
+	for _, c := range b.Castlings(b.SideToMove()) {
+		if n.EncodeCastling(b, c) == castling {
+			return n.SetCoord(c.Piece[0].Coord()).EncodeCoord() + "-" + n.SetCoord(c.Piece[1].Coord()).EncodeCoord(), nil
+		}
+	}
+	return "", fmt.Errorf("castling %s is not available", move)
+}
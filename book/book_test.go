@@ -0,0 +1,220 @@
+package book_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/mtfelian/mtfchess/book"
+	"github.com/mtfelian/mtfchess/rect"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// games are games to build books of
+const games = `[Event "1"]
+1. e4 e5 2. Nf3 Nc6 3. Bc4 Bc5 4. O-O Nf6 1-0
+
+[Event "2"]
+1. e4 c5 2. Nf3 d6 1/2-1/2
+
+[Event "3"]
+1. d4 d5 0-1
+`
+
+// board returns a board of X-FEN
+func board(xfen rect.XFEN) *rect.Board {
+	b, err := xfen.Board()
+	Expect(err).NotTo(HaveOccurred())
+	return b.(*rect.Board)
+}
+
+// play makes book moves on b
+func play(b *rect.Board, moves ...string) {
+	for _, move := range moves {
+		makeMove, err := book.DecodeMove(b, move)
+		Expect(err).NotTo(HaveOccurred(), move)
+		Expect(makeMove()).To(BeTrue(), move)
+	}
+}
+
+var _ = Describe("Book test", func() {
+	random := book.NewRandom64(1)
+
+	It("computes Polyglot keys", func() {
+		key := func(xfen rect.XFEN) uint64 {
+			k, err := random.Key(board(xfen))
+			Expect(err).NotTo(HaveOccurred())
+			return k
+		}
+		start := key(rect.NewStandardChessStartingPosition())
+		Expect(start).To(Equal(key(`rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 5 9`)),
+			"move counters are not a part of a key")
+		Expect(start).NotTo(Equal(key(`rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR b KQkq - 0 1`)))
+		Expect(start).NotTo(Equal(key(`rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w Kkq - 0 1`)))
+
+		By("taking en passant into account only if it's possible")
+		Expect(key(`rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1`)).To(
+			Equal(key(`rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1`)))
+		Expect(key(`rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1`)).NotTo(
+			Equal(key(`rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1`)))
+
+		By("keying the same position reached by different moves equally")
+		b1, b2 := board(rect.NewStandardChessStartingPosition()), board(rect.NewStandardChessStartingPosition())
+		play(b1, "g1-f3", "g8-f6", "b1-c3")
+		play(b2, "b1-c3", "g8-f6", "g1-f3")
+		k1, err := random.Key(b1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(random.Key(b2)).To(Equal(k1))
+
+		_, err = random.Key(rect.NewEmptyBoard(10, 8, rect.StandardChessBoardSettings()))
+		Expect(err).To(Equal(book.ErrNotPolyglot))
+	})
+
+	It("computes keys of the Polyglot specification with the standard table", func() {
+		testCases := []struct {
+			moves []string
+			key   uint64
+		}{
+			{nil, 0x463b96181691fc9c},
+			{[]string{"e2-e4"}, 0x823c9b50fd114196},
+			{[]string{"e2-e4", "d7-d5"}, 0x0756b94461c50fb0},
+			{[]string{"e2-e4", "d7-d5", "e4-e5"}, 0x662fafb965db29d4},
+			{[]string{"e2-e4", "d7-d5", "e4-e5", "f7-f5"}, 0x22a48b5a8e47ff78},
+			{[]string{"e2-e4", "d7-d5", "e4-e5", "f7-f5", "e1-e2"}, 0x652a607ca3f242c1},
+			{[]string{"e2-e4", "d7-d5", "e4-e5", "f7-f5", "e1-e2", "e8-f7"}, 0x00fdd303c946bdd9},
+			{[]string{"a2-a4", "b7-b5", "h2-h4", "b5-b4", "c2-c4"}, 0x3c8123ea7b067637},
+			{[]string{"a2-a4", "b7-b5", "h2-h4", "b5-b4", "c2-c4", "b4-c3", "a1-a3"}, 0x5c3f9b829b279560},
+		}
+		standard := book.StandardRandom64()
+		for i, testCase := range testCases {
+			By(fmt.Sprintf("Checking testCase %v at index %d...", testCase.moves, i))
+			b := board(rect.NewStandardChessStartingPosition())
+			play(b, testCase.moves...)
+			Expect(standard.Key(b)).To(Equal(testCase.key))
+		}
+		standard[0] = 0
+		Expect(book.StandardRandom64()[0]).NotTo(BeZero(), "the standard table is not changed")
+	})
+
+	It("reads a random values table", func() {
+		var text bytes.Buffer
+		text.WriteString("const U64 Random64[781] = {\n")
+		for i := range random {
+			fmt.Fprintf(&text, "   U64(0x%016X),\n", random[i])
+		}
+		text.WriteString("};\n")
+		read, err := book.ReadRandom64(&text)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(read).To(Equal(random))
+
+		_, err = book.ReadRandom64(strings.NewReader("0x1 0x2"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("builds a Polyglot book from PGN, writes and reads it", func() {
+		builder := book.NewBuilder(book.PolyglotKey(random))
+		n, err := builder.AddPGN(strings.NewReader(games))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(3))
+		bk := builder.Book(1)
+
+		b := board(rect.NewStandardChessStartingPosition())
+		entries, err := bk.Lookup(b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1), "d4 lost the only game, so it gets no points")
+		Expect(entries[0].Move).To(Equal("e2-e4"))
+		Expect(entries[0].Weight).To(Equal(uint16(3)))
+
+		play(b, "e2-e4")
+		entries, err = bk.Lookup(b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Move).To(Equal("c7-c5"))
+
+		By("encoding castling as a king move to a rook")
+		b = board(`r1bqk2r/pppp1ppp/2n2n2/2b1p3/2B1P3/5N2/PPPP1PPP/RNBQK2R w KQkq - 4 4`)
+		entries, err = bk.Lookup(b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(0), "O-O is made before Nf6 in the game")
+		b = board(`r1bqk1nr/pppp1ppp/2n5/2b1p3/2B1P3/5N2/PPPP1PPP/RNBQK2R w KQkq - 4 4`)
+		entries, err = bk.Lookup(b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(Equal([]book.Entry{{Key: entries[0].Key, Move: "e1-h1", Weight: 2}}))
+		play(b, "e1-h1")
+		Expect(rect.NewXFEN(b)).To(
+			Equal(rect.XFEN(`r1bqk1nr/pppp1ppp/2n5/2b1p3/2B1P3/5N2/PPPP1PPP/RNBQ1RK1 b kq - 5 4`)))
+
+		By("writing and reading a book")
+		var buf bytes.Buffer
+		Expect(bk.WritePolyglot(&buf)).To(Succeed())
+		Expect(buf.Len()).To(Equal(16 * len(bk.Entries())))
+		read, err := book.ReadPolyglot(&buf, random)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(read.Entries()).To(Equal(bk.Entries()))
+
+		_, err = book.ReadPolyglot(bytes.NewReader(make([]byte, 20)), random)
+		Expect(err).To(HaveOccurred(), "a truncated entry")
+
+		By("reading a book keyed by the standard table by default")
+		buf.Reset()
+		entry := make([]byte, 16)
+		binary.BigEndian.PutUint64(entry, 0x463b96181691fc9c)
+		binary.BigEndian.PutUint16(entry[8:], 0x031c) // e2-e4
+		binary.BigEndian.PutUint16(entry[10:], 1)
+		buf.Write(entry)
+		read, err = book.ReadPolyglot(&buf, nil)
+		Expect(err).NotTo(HaveOccurred())
+		entries, err = read.Lookup(board(rect.NewStandardChessStartingPosition()))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Move).To(Equal("e2-e4"))
+	})
+
+	It("builds a native book for a board of other dimensions", func() {
+		start := rect.XFEN(`rnabqkbcnr/pppppppppp/10/10/10/10/PPPPPPPPPP/RNABQKBCNR w KQkq - 0 1`)
+		builder := book.NewBuilder(book.NativeKey)
+		builder.NewBoard = func(fen string) (*rect.Board, error) { return board(start), nil }
+		builder.MaxPly = 2
+		_, err := builder.AddPGN(strings.NewReader("1. f4 f5 2. Ci3 * 1. f4 i6 * 1. Ci3 f5 1-0"))
+		Expect(err).NotTo(HaveOccurred())
+		bk := builder.Book(0)
+		Expect(bk.Entries()).To(HaveLen(4), "moves after MaxPly and moves of lost games are not added")
+
+		var buf bytes.Buffer
+		Expect(bk.WritePolyglot(&buf)).NotTo(Succeed(), "moves on i-file are not in Polyglot format")
+		buf.Reset()
+		Expect(bk.WriteNative(&buf)).To(Succeed())
+		read, err := book.ReadNative(&buf, book.NativeKey)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(read.Entries()).To(Equal(bk.Entries()))
+		_, err = book.ReadNative(strings.NewReader("not a book"), book.NativeKey)
+		Expect(err).To(HaveOccurred())
+
+		By("choosing moves randomly by weights")
+		b, counts, rnd := board(start), map[string]int{}, rand.New(rand.NewSource(1))
+		for i := 0; i < 400; i++ {
+			e, err := read.Choose(b, rnd)
+			Expect(err).NotTo(HaveOccurred())
+			counts[e.Move]++
+		}
+		Expect(counts).To(HaveLen(2))
+		Expect(counts["f2-f4"]).To(BeNumerically("~", 200, 40), "f4 and Ci3 have 2 points each")
+		play(b, "f2-f4")
+		e, err := read.Choose(b, rnd)
+		Expect(err).NotTo(HaveOccurred())
+		Expect([]string{"f7-f5", "i7-i6"}).To(ContainElement(e.Move))
+		play(b, "f7-f5")
+		_, err = read.Choose(b, rnd)
+		Expect(err).To(Equal(book.ErrNotFound))
+	})
+
+	It("reports games with illegal moves", func() {
+		builder := book.NewBuilder(book.NativeKey)
+		n, err := builder.AddPGN(strings.NewReader("1. e4 e5 * 1. e5 *"))
+		Expect(err).To(HaveOccurred())
+		Expect(n).To(Equal(1))
+	})
+})
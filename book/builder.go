@@ -0,0 +1,141 @@
+package book
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	. "github.com/mtfelian/mtfchess/colour"
+	"github.com/mtfelian/mtfchess/pgn"
+	"github.com/mtfelian/mtfchess/rect"
+)
+
+// DefaultMaxPly is a default number of first moves of a game added to a book
+const DefaultMaxPly = 24
+
+// Builder builds a book from games. A move gets 2 points for each game won by a side which made it,
+// 1 point for each drawn or unfinished game and no points for lost games, so moves of winners
+// are chosen more often.
+type Builder struct {
+	// Key returns keys of positions
+	Key KeyFunc
+
+	// NewBoard returns a starting board of a game by its FEN tag, it's empty for a standard start
+	NewBoard func(fen string) (*rect.Board, error)
+
+	// MaxPly is a number of first moves of a game added to a book
+	MaxPly int
+
+	points map[uint64]map[string]int
+}
+
+// NewBuilder returns a builder of a book for standard chess with position keys returned by key
+func NewBuilder(key KeyFunc) *Builder {
+	return &Builder{Key: key, NewBoard: newStandardBoard, MaxPly: DefaultMaxPly, points: map[uint64]map[string]int{}}
+}
+
+// newStandardBoard returns a standard chess board with a starting position fen or the standard one
+func newStandardBoard(fen string) (*rect.Board, error) {
+	xfen := rect.NewStandardChessStartingPosition()
+	if fen != "" {
+		xfen = rect.XFEN(fen)
+	}
+	b, err := xfen.Board()
+	if err != nil {
+		return nil, err
+	}
+	return b.(*rect.Board), nil
+}
+
+// movePoints returns points of a move made by side in a game with result
+func movePoints(side Colour, result string) int {
+	switch {
+	case result == "1-0" && side == White, result == "0-1" && side == Black:
+		return 2
+	case result == "1-0", result == "0-1":
+		return 0
+	}
+	return 1
+}
+
+// AddGame adds first moves of a game to a book
+func (bd *Builder) AddGame(g *pgn.Game) error {
+	b, err := bd.NewBoard(g.FEN())
+	if err != nil {
+		return err
+	}
+	for ply, move := range g.Moves {
+		if ply >= bd.MaxPly {
+			break
+		}
+		key, err := bd.Key(b)
+		if err != nil {
+			return err
+		}
+		bookMove, err := EncodeMove(b, move)
+		if err != nil {
+			return fmt.Errorf("move %d %s: %v", ply+1, move, err)
+		}
+		if bd.points[key] == nil {
+			bd.points[key] = map[string]int{}
+		}
+		bd.points[key][bookMove] += movePoints(b.SideToMove(), g.Result)
+
+		makeMove, err := DecodeMove(b, bookMove)
+		if err != nil {
+			return fmt.Errorf("move %d %s: %v", ply+1, move, err)
+		}
+		if !makeMove() {
+			return fmt.Errorf("move %d %s: illegal move", ply+1, move)
+		}
+	}
+	return nil
+}
+
+// AddPGN adds all games read from PGN to a book, it returns a number of added games
+func (bd *Builder) AddPGN(r io.Reader) (int, error) {
+	reader, n := pgn.NewReader(r), 0
+	for {
+		game, err := reader.Read()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		if err := bd.AddGame(game); err != nil {
+			return n, fmt.Errorf("game %d: %v", n+1, err)
+		}
+		n++
+	}
+}
+
+// Book returns a book of moves having at least minPoints points. Move weights are points
+// scaled down proportionally in positions where points exceed the maximum weight.
+func (bd *Builder) Book(minPoints int) *Book {
+	entries := []Entry{}
+	for key, moves := range bd.points {
+		max := 0
+		for _, points := range moves {
+			if points > max {
+				max = points
+			}
+		}
+		for move, points := range moves {
+			if points < minPoints || points == 0 {
+				continue
+			}
+			weight := points
+			if max > math.MaxUint16 {
+				if weight = points * math.MaxUint16 / max; weight == 0 {
+					weight = 1
+				}
+			}
+			entries = append(entries, Entry{Key: key, Move: move, Weight: uint16(weight)})
+		}
+	}
+	// moves are sorted to make books built from the same games equal
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Move < entries[j].Move })
+	return New(bd.Key, entries)
+}
@@ -0,0 +1,68 @@
+package book
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// nativeMagic starts a native book file, its last byte is a format version
+var nativeMagic = [8]byte{'M', 'T', 'F', 'B', 'O', 'O', 'K', 1}
+
+// nativeEntry is a fixed size part of an entry of a native book file, it's followed by a move
+type nativeEntry struct {
+	Key     uint64
+	Weight  uint16
+	Learn   uint32
+	MoveLen uint8
+}
+
+// ReadNative reads a book in native format, keys of positions to look up are computed by key
+func ReadNative(r io.Reader, key KeyFunc) (*Book, error) {
+	br := bufio.NewReader(r)
+	var magic [8]byte
+	if _, err := io.ReadFull(br, magic[:]); err != nil {
+		return nil, err
+	}
+	if magic != nativeMagic {
+		return nil, fmt.Errorf("not a native book or unsupported version")
+	}
+	entries := []Entry{}
+	for {
+		var e nativeEntry
+		err := binary.Read(br, binary.BigEndian, &e)
+		if err == io.EOF {
+			return New(key, entries), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		move := make([]byte, e.MoveLen)
+		if _, err := io.ReadFull(br, move); err != nil {
+			return nil, err
+		}
+		entries = append(entries, Entry{Key: e.Key, Move: string(move), Weight: e.Weight, Learn: e.Learn})
+	}
+}
+
+// WriteNative writes a book in native format, it suits books for boards of any dimensions
+func (bk *Book) WriteNative(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.Write(nativeMagic[:]); err != nil {
+		return err
+	}
+	for _, e := range bk.entries {
+		if len(e.Move) > 255 {
+			return fmt.Errorf("move %s is too long", e.Move)
+		}
+		if err := binary.Write(bw, binary.BigEndian,
+			nativeEntry{Key: e.Key, Weight: e.Weight, Learn: e.Learn, MoveLen: uint8(len(e.Move))}); err != nil {
+			return err
+		}
+		if _, err := bw.WriteString(e.Move); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
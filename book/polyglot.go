@@ -0,0 +1,206 @@
+package book
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	"github.com/mtfelian/mtfchess/rect"
+)
+
+// Random64Size is a number of random values used to compute Polyglot keys
+const Random64Size = 781
+
+// offsets of Polyglot random values
+const (
+	castlingOffset   = 768
+	enPassantOffset  = 772
+	whiteToMoveIndex = 780
+)
+
+// ErrNotPolyglot is returned for positions and moves which can't be represented in a Polyglot book
+var ErrNotPolyglot = errors.New("not a standard 8x8 chess position or move")
+
+var (
+	random64Regexp = regexp.MustCompile(`0[xX][0-9a-fA-F]{1,16}`)
+	polyglotMove   = regexp.MustCompile(`^([a-h])([1-8])-([a-h])([1-8])(?:=([NBRQ]))?$`)
+)
+
+// polyglotPieces maps piece names to Polyglot piece kinds, a kind of a white piece is greater by 1
+var polyglotPieces = map[string]int{
+	base.PawnName: 0, base.KnightName: 2, base.BishopName: 4, base.RookName: 6, base.QueenName: 8, base.KingName: 10,
+}
+
+// polyglotPromotions are promotion piece letters by Polyglot promotion codes
+var polyglotPromotions = []string{"", "N", "B", "R", "Q"}
+
+// Random64 is a table of random values XORed to compute Polyglot keys: 768 values for pieces on cells,
+// 4 values for castling rights, 8 values for en passant files and a value for white to move.
+// Books made by other Polyglot tools are keyed by the table published in the Polyglot book format
+// specification, it's returned by StandardRandom64.
+type Random64 [Random64Size]uint64
+
+// ReadRandom64 reads a table of random values written as hex numbers with the "0x" prefix
+// in any text, like the array of the Polyglot book format specification or a C source of it
+func ReadRandom64(r io.Reader) (*Random64, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	values := random64Regexp.FindAll(data, -1)
+	if len(values) != Random64Size {
+		return nil, fmt.Errorf("found %d random values instead of %d", len(values), Random64Size)
+	}
+	random := &Random64{}
+	for i := range values {
+		if random[i], err = strconv.ParseUint(string(values[i][2:]), 16, 64); err != nil {
+			return nil, err
+		}
+	}
+	return random, nil
+}
+
+// NewRandom64 returns a table of pseudo-random values generated from seed. Keys computed with it
+// differ from keys of the standard Polyglot table, so such books can be used only with the same table.
+func NewRandom64(seed int64) *Random64 {
+	rnd, random := rand.New(rand.NewSource(seed)), &Random64{}
+	for i := range random {
+		random[i] = rnd.Uint64()
+	}
+	return random
+}
+
+// Key returns a Polyglot key of a standard 8x8 chess position
+func (r *Random64) Key(b *rect.Board) (uint64, error) {
+	if b.Dim() != (rect.Coord{X: 8, Y: 8}) {
+		return 0, ErrNotPolyglot
+	}
+	key := uint64(0)
+	for _, piece := range b.FindPieces(base.PieceFilter{}) {
+		kind, exists := polyglotPieces[piece.Name()]
+		if !exists || piece.Colour() != White && piece.Colour() != Black {
+			return 0, ErrNotPolyglot
+		}
+		if piece.Colour() == White {
+			kind++
+		}
+		c := piece.Coord().(rect.Coord)
+		key ^= r[64*kind+8*(c.Y-1)+c.X-1]
+	}
+
+	castlingFlags := strings.Split(string(rect.NewXFEN(b)), " ")[2]
+	for i, flag := range "KQkq" {
+		if strings.ContainsRune(castlingFlags, flag) {
+			key ^= r[castlingOffset+i]
+		}
+	}
+
+	// en passant file is taken into account only if a pawn of the side to move can capture en passant
+	if at := b.CanCaptureEnPassantAt(); at != nil {
+		c := at.(rect.Coord)
+		for _, x := range []int{c.X - 1, c.X + 1} {
+			if x < 1 || x > 8 {
+				continue
+			}
+			pawn := b.Piece(rect.Coord{X: x, Y: c.Y})
+			if pawn != nil && pawn.Name() == base.PawnName && pawn.Colour() == b.SideToMove() {
+				key ^= r[enPassantOffset+c.X-1]
+				break
+			}
+		}
+	}
+
+	if b.SideToMove() == White {
+		key ^= r[whiteToMoveIndex]
+	}
+	return key, nil
+}
+
+// PolyglotKey returns a func returning Polyglot keys computed with random values r
+func PolyglotKey(r *Random64) KeyFunc { return r.Key }
+
+// polyglotEntry is an entry of a Polyglot book file
+type polyglotEntry struct {
+	Key    uint64
+	Move   uint16
+	Weight uint16
+	Learn  uint32
+}
+
+// encodePolyglotMove returns a book move packed into 16 bits: destination file and rank, source file and rank
+// and a promotion piece by 3 bits each
+func encodePolyglotMove(move string) (uint16, error) {
+	m := polyglotMove.FindStringSubmatch(move)
+	if m == nil {
+		return 0, ErrNotPolyglot
+	}
+	res := uint16(m[3][0]-'a') | uint16(m[4][0]-'1')<<3 | uint16(m[1][0]-'a')<<6 | uint16(m[2][0]-'1')<<9
+	for code := 1; code < len(polyglotPromotions); code++ {
+		if m[5] == polyglotPromotions[code] {
+			res |= uint16(code) << 12
+		}
+	}
+	return res, nil
+}
+
+// decodePolyglotMove returns a book move unpacked from 16 bits
+func decodePolyglotMove(m uint16) (string, error) {
+	promotion := int(m >> 12 & 7)
+	if promotion >= len(polyglotPromotions) {
+		return "", fmt.Errorf("invalid Polyglot promotion code %d", promotion)
+	}
+	move := fmt.Sprintf("%c%d-%c%d", 'a'+rune(m>>6&7), m>>9&7+1, 'a'+rune(m&7), m>>3&7+1)
+	if promotion > 0 {
+		move += "=" + polyglotPromotions[promotion]
+	}
+	return move, nil
+}
+
+// ReadPolyglot reads a Polyglot book, keys of positions to look up are computed with random values,
+// the standard table is used if random is nil
+func ReadPolyglot(r io.Reader, random *Random64) (*Book, error) {
+	if random == nil {
+		random = StandardRandom64()
+	}
+	entries, br := []Entry{}, bufio.NewReader(r)
+	for {
+		var e polyglotEntry
+		err := binary.Read(br, binary.BigEndian, &e)
+		if err == io.EOF {
+			return New(random.Key, entries), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		move, err := decodePolyglotMove(e.Move)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, Entry{Key: e.Key, Move: move, Weight: e.Weight, Learn: e.Learn})
+	}
+}
+
+// WritePolyglot writes a book in Polyglot format, all book moves should be moves on 8x8 board
+func (bk *Book) WritePolyglot(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range bk.entries {
+		move, err := encodePolyglotMove(e.Move)
+		if err != nil {
+			return fmt.Errorf("%s: %v", e.Move, err)
+		}
+		if err := binary.Write(bw, binary.BigEndian,
+			polyglotEntry{Key: e.Key, Move: move, Weight: e.Weight, Learn: e.Learn}); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,39 @@
+package book
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Polyglot moves test", func() {
+	It("packs moves into 16 bits and back", func() {
+		testCases := []struct {
+			move   string
+			packed uint16
+		}{
+			{"e2-e4", 4<<6 | 1<<9 | 4 | 3<<3},
+			{"e1-h1", 4<<6 | 7},
+			{"a8-h1", 7<<9 | 7},
+			{"b7-a8=Q", 4<<12 | 1<<6 | 6<<9 | 7<<3},
+			{"g2-g1=N", 1<<12 | 6<<6 | 1<<9 | 6},
+		}
+		for i, testCase := range testCases {
+			By(fmt.Sprintf("Checking testCase %v at index %d...", testCase, i))
+			packed, err := encodePolyglotMove(testCase.move)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(packed).To(Equal(testCase.packed))
+			move, err := decodePolyglotMove(packed)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(move).To(Equal(testCase.move))
+		}
+
+		for _, move := range []string{"i2-i4", "e9-e10", "O-O", "e7-e8=K"} {
+			_, err := encodePolyglotMove(move)
+			Expect(err).To(Equal(ErrNotPolyglot))
+		}
+		_, err := decodePolyglotMove(5 << 12)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mtfelian/mtfchess/problem"
+	"github.com/mtfelian/mtfchess/rect"
+	"github.com/mtfelian/mtfchess/server"
+	"github.com/mtfelian/mtfchess/syzygy"
+)
+
+// analyseFlags returns an analyse command writing a state of a position, a move of an engine,
+// mates found by the problem solver and a value of Syzygy tablebases
+func analyseFlags(fs *flag.FlagSet) func(args []string, in io.Reader, out io.Writer) error {
+	p := positionFlags(fs)
+	notationName := fs.String("notation", server.LongAlgebraic, "a notation of moves")
+	engine := fs.String("engine", "material", "an engine: "+playerUsage[len(`"human", `):])
+	moveTime := fs.Duration("movetime", time.Second, "a time of an engine to move")
+	mate := fs.Int("mate", 0, "search mates in up to this number of moves on rectangular boards")
+	tables := fs.String("syzygy", "", "a directory of Syzygy tablebases for standard chess positions")
+	return func(_ []string, _ io.Reader, out io.Writer) error {
+		b, v, err := p.board()
+		if err != nil {
+			return err
+		}
+		n, err := notation(v, *notationName, b)
+		if err != nil {
+			return err
+		}
+		side := b.SideToMove()
+		fmt.Fprint(out, diagram(v, b, rect.DefaultDiagramOptions()))
+		fmt.Fprintf(out, "side to move: %s\n", side)
+		fmt.Fprintf(out, "in check: %t\n", b.InCheck(side))
+		moves := b.LegalMoves(n)
+		fmt.Fprintf(out, "legal moves (%d): %s\n", len(moves), strings.Join(moves, " "))
+		if outcome := b.Outcome(); outcome.IsFinished() || len(moves) == 0 {
+			fmt.Fprintf(out, "outcome: %s\n", outcome)
+			return nil
+		}
+
+		e, err := newEngine(*engine)
+		switch {
+		case err != nil:
+			return err
+		case e == nil:
+			return fmt.Errorf("analysis needs an engine")
+		}
+		defer e.Close()
+		m, err := e.Move(b.Copy(), n, *moveTime)
+		if err != nil {
+			return fmt.Errorf("engine: %v", err)
+		}
+		fmt.Fprintf(out, "engine move: %s", m.Move)
+		if m.HasScore {
+			fmt.Fprintf(out, " (score %d)", m.Score)
+		}
+		fmt.Fprintln(out)
+
+		rb, isRect := b.(*rect.Board)
+		if *mate > 0 && isRect && b.Settings().Players == nil {
+			for i := 1; i <= *mate; i++ {
+				s, err := problem.Solve(rb, problem.Direct, i)
+				if err != nil {
+					return err
+				}
+				if len(s.Keys) > 0 {
+					keys := []string{}
+					for _, key := range s.Keys {
+						keys = append(keys, key.Move)
+					}
+					fmt.Fprintf(out, "mate in %d: %s\n", i, strings.Join(keys, " "))
+					break
+				}
+			}
+		}
+
+		if *tables != "" && isRect {
+			tb, err := syzygy.Open(*tables)
+			if err != nil {
+				return err
+			}
+			defer tb.Close()
+			if res, err := tb.Probe(rb); err != nil {
+				fmt.Fprintf(out, "syzygy: %v\n", err)
+			} else {
+				fmt.Fprintf(out, "syzygy: %s, dtz %d\n", res.WDL, res.DTZ)
+			}
+		}
+		return nil
+	}
+}
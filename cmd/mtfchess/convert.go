@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mtfelian/mtfchess/base"
+	"github.com/mtfelian/mtfchess/pgn"
+	"github.com/mtfelian/mtfchess/rect"
+	"github.com/mtfelian/mtfchess/render"
+	"github.com/mtfelian/mtfchess/server"
+)
+
+// formats of the convert command output
+const (
+	movesFormat    = "moves"
+	positionFormat = "position"
+	diagramFormat  = "diagram"
+	jsonFormat     = "json"
+	pgnFormat      = "pgn"
+	svgFormat      = "svg"
+	pngFormat      = "png"
+)
+
+// errRectOnly is returned for formats of rectangular boards only
+var errRectOnly = errors.New("format is available for rectangular boards only")
+
+// convertFlags returns a convert command making moves of arguments and writing them converted to another
+// notation or writing a position after them in another format
+func convertFlags(fs *flag.FlagSet) func(args []string, in io.Reader, out io.Writer) error {
+	p := positionFlags(fs)
+	from := fs.String("from", server.LongAlgebraic, "a notation of moves of arguments")
+	to := fs.String("to", server.LongAlgebraic, "a notation of converted moves")
+	format := fs.String("format", movesFormat, "an output format: moves, position, diagram, json, "+
+		"pgn (moves in short algebraic notation), svg or png")
+	return func(moves []string, _ io.Reader, out io.Writer) error {
+		b, v, err := p.board()
+		if err != nil {
+			return err
+		}
+		start := b.Copy()
+		fromNotation, err := notation(v, *from, b)
+		if err != nil {
+			return err
+		}
+		toName := *to
+		if *format == pgnFormat {
+			toName = shortAlgebraic
+		}
+		toNotation, err := notation(v, toName, b)
+		if err != nil {
+			return err
+		}
+		converted := []string{}
+		for _, move := range moves {
+			c, err := convertMove(b, fromNotation, toNotation, move)
+			if err != nil {
+				return err
+			}
+			converted = append(converted, c)
+			if err := makeMove(b, fromNotation, move); err != nil {
+				return err
+			}
+		}
+
+		rb, isRect := b.(*rect.Board)
+		switch *format {
+		case movesFormat:
+			_, err = fmt.Fprintln(out, strings.Join(converted, " "))
+		case positionFormat:
+			_, err = fmt.Fprintln(out, v.Position(b))
+		case diagramFormat:
+			_, err = fmt.Fprint(out, diagram(v, b, rect.DefaultDiagramOptions()))
+		case jsonFormat:
+			var data []byte
+			if data, err = json.Marshal(b); err == nil {
+				_, err = fmt.Fprintln(out, string(data))
+			}
+		case pgnFormat, svgFormat, pngFormat:
+			if !isRect {
+				return errRectOnly
+			}
+			switch *format {
+			case pgnFormat:
+				g := &pgn.Game{Tags: []pgn.Tag{}, Moves: converted, Result: result(b.Outcome())}
+				if p.position != "" {
+					g.Tags = append(g.Tags, pgn.Tag{Name: "SetUp", Value: "1"},
+						pgn.Tag{Name: "FEN", Value: v.Position(start)})
+				}
+				err = g.Write(out)
+			case svgFormat:
+				_, err = fmt.Fprint(out, render.SVG(rb, render.DefaultOptions()))
+			case pngFormat:
+				err = render.PNG(out, rb, render.DefaultOptions())
+			}
+		default:
+			return fmt.Errorf("unknown format %q", *format)
+		}
+		return err
+	}
+}
+
+// convertMove returns a move in notation from on b converted to notation to. A move is found among legal moves
+// by a position after it, as legal moves are listed in the same order in any notation.
+func convertMove(b base.IBoard, from, to base.INotation, move string) (string, error) {
+	after := b.Copy()
+	if err := makeMove(after, from, move); err != nil {
+		return "", err
+	}
+	fromMoves, toMoves := b.LegalMoves(from), b.LegalMoves(to)
+	for i, m := range fromMoves {
+		next := b.Copy()
+		if makeMove(next, from, m) == nil && next.Equals(after) && i < len(toMoves) {
+			return toMoves[i], nil
+		}
+	}
+	return "", fmt.Errorf("%s: can't be converted", move)
+}
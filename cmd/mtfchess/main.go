@@ -0,0 +1,176 @@
+// Command mtfchess plays and analyses games of chess variants in a terminal.
+//
+// Usage:
+//
+//	mtfchess <command> [flags] [arguments]
+//
+// Commands are play, perft, validate, convert, analyse and variants, "mtfchess help <command>"
+// describes flags of a command.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mtfelian/mtfchess/base"
+	"github.com/mtfelian/mtfchess/rect"
+	"github.com/mtfelian/mtfchess/server"
+)
+
+// shortAlgebraic is a name of short algebraic notation, like "Nf3", it's available on rectangular boards
+const shortAlgebraic = "short"
+
+// command is a subcommand of the tool
+type command struct {
+	summary string
+	flags   func(fs *flag.FlagSet) func(args []string, in io.Reader, out io.Writer) error
+}
+
+// commands are subcommands by names
+var commands = map[string]command{
+	"play":     {"play a game against an engine or a human", playFlags},
+	"perft":    {"count leaf nodes of a move generation tree", perftFlags},
+	"validate": {"validate positions or PGN games", validateFlags},
+	"convert":  {"convert moves between notations and positions between formats", convertFlags},
+	"analyse":  {"analyse a position", analyseFlags},
+	"variants": {"list variants with their starting positions", variantsFlags},
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "mtfchess:", err)
+		os.Exit(1)
+	}
+}
+
+// run runs a command of args reading input from in and writing output to out
+func run(args []string, in io.Reader, out io.Writer) error {
+	if len(args) == 0 || args[0] == "help" && len(args) == 1 {
+		usage(out)
+		return nil
+	}
+	name, help := args[0], false
+	if name == "help" {
+		name, help = args[1], true
+	}
+	cmd, exists := commands[name]
+	if !exists {
+		usage(out)
+		return fmt.Errorf("unknown command %q", name)
+	}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(out)
+	runCommand := cmd.flags(fs)
+	if help {
+		fmt.Fprintf(out, "mtfchess %s: %s\n", name, cmd.summary)
+		fs.PrintDefaults()
+		return nil
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+	return runCommand(fs.Args(), in, out)
+}
+
+// usage writes a list of commands
+func usage(out io.Writer) {
+	fmt.Fprintln(out, "usage: mtfchess <command> [flags] [arguments]\n\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-9s %s\n", name, commands[name].summary)
+	}
+}
+
+// position is a position of a variant given by flags
+type position struct {
+	variant, position string
+}
+
+// positionFlags adds flags of a position to fs
+func positionFlags(fs *flag.FlagSet) *position {
+	p := &position{}
+	fs.StringVar(&p.variant, "variant", rect.StandardSettingsName, "a variant, see the variants command")
+	fs.StringVar(&p.position, "position", "", "a position in the variant format, the starting one if it's empty")
+	return p
+}
+
+// board returns a board of a position and its variant
+func (p *position) board() (base.IBoard, server.Variant, error) {
+	v, err := variant(p.variant)
+	if err != nil {
+		return nil, server.Variant{}, err
+	}
+	b, err := v.New(p.position)
+	return b, v, err
+}
+
+// variant returns a variant by name
+func variant(name string) (server.Variant, error) {
+	v, exists := server.StandardVariants()[name]
+	if !exists {
+		return server.Variant{}, fmt.Errorf("unknown variant %q", name)
+	}
+	return v, nil
+}
+
+// errNotation is returned for notations not available on a board
+var errNotation = errors.New("unknown notation")
+
+// notation returns a notation by name for b
+func notation(v server.Variant, name string, b base.IBoard) (base.INotation, error) {
+	if name == shortAlgebraic {
+		if _, ok := b.(*rect.Board); ok {
+			return rect.NewShortAlgebraicNotation(), nil
+		}
+	}
+	newNotation, exists := v.Notations[name]
+	if !exists {
+		return nil, fmt.Errorf("%v %q, notations are %s", errNotation, name, strings.Join(notations(v, b), ", "))
+	}
+	return newNotation(b), nil
+}
+
+// notations returns sorted names of notations available for b
+func notations(v server.Variant, b base.IBoard) []string {
+	res := []string{}
+	for name := range v.Notations {
+		res = append(res, name)
+	}
+	if _, ok := b.(*rect.Board); ok {
+		res = append(res, shortAlgebraic)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// diagram returns a text diagram of b for rectangular boards or a position of other boards
+func diagram(v server.Variant, b base.IBoard, opts rect.DiagramOptions) string {
+	if rb, ok := b.(*rect.Board); ok {
+		return rb.Diagram(opts)
+	}
+	return v.Position(b) + "\n"
+}
+
+// makeMove makes a move in notation on b
+func makeMove(b base.IBoard, n base.INotation, move string) error {
+	makeMove, err := n.DecodeMove(b, move)
+	if err != nil {
+		return fmt.Errorf("%s: %v", move, err)
+	}
+	if !makeMove() {
+		return fmt.Errorf("%s: illegal move", move)
+	}
+	return nil
+}
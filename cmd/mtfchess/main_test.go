@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("mtfchess", func() {
+	// runWith returns an output of a command of args with input and its error
+	runWith := func(input string, args ...string) (string, error) {
+		out := &bytes.Buffer{}
+		err := run(args, strings.NewReader(input), out)
+		return out.String(), err
+	}
+
+	// mustRun returns an output of a command of args which should succeed
+	mustRun := func(input string, args ...string) string {
+		out, err := runWith(input, args...)
+		Expect(err).NotTo(HaveOccurred(), out)
+		return out
+	}
+
+	It("writes usage", func() {
+		Expect(mustRun("")).To(ContainSubstring("perft"))
+		Expect(mustRun("", "help", "convert")).To(ContainSubstring("-format"))
+		_, err := runWith("", "unknown")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("lists variants", func() {
+		out := mustRun("", "variants")
+		Expect(out).To(MatchRegexp(`standard +long, short +rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1`))
+		Expect(out).To(MatchRegexp(`glinski +long +6/P5p/`))
+	})
+
+	It("counts perft nodes", func() {
+		Expect(mustRun("", "perft", "-depth", "2")).To(HavePrefix("nodes: 400\n"))
+		out := mustRun("", "perft", "-depth", "2", "-divide", "-position", "4k3/8/8/8/8/8/8/R3K3 w Q - 0 1")
+		Expect(out).To(ContainSubstring("O-O-O: 3\n"))
+		Expect(out).To(ContainSubstring("nodes: 71\n"))
+	})
+
+	Context("validate", func() {
+		It("validates positions", func() {
+			out, err := runWith("8/8/8/8/8/8/8/8 w\n\nk7/8/8/8/8/8/8/K7 w - - 0 1\n", "validate")
+			Expect(err).To(MatchError("1 of 2 invalid"))
+			Expect(out).To(ContainSubstring("k7/8/8/8/8/8/8/K7 w - - 0 1: ok\n"))
+			mustRun("", "validate", "-variant", "glinski", "6/P5p/RP4pr/N1P3p1n/Q2P2p2q/BBB1P1p1bbb/K2P2p2k/N1P3p1n/RP4pr/P5p/6 w - - 0 1")
+		})
+
+		It("validates PGN games", func() {
+			games := `[White "a"]
+[Black "b"]
+
+1. e4 e5 (1... c5 2. Nf3) 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0
+
+1. e4 e5 (1... e4) *
+
+1. f3 e5 2. g4 Qh4# 1-0
+`
+			out, err := runWith(games, "validate", "-pgn")
+			Expect(err).To(MatchError("2 of 3 invalid"))
+			Expect(strings.Split(strings.TrimSpace(out), "\n")).To(Equal([]string{
+				"game 1 (a - b): ok, 7 moves, 1-0",
+				"game 2: move 2 e4: illegal move",
+				"game 3: result 1-0 differs from Black won by checkmate",
+			}))
+		})
+	})
+
+	Context("convert", func() {
+		It("converts moves between notations", func() {
+			Expect(mustRun("", "convert", "-to", "short", "e2-e4", "e7-e5", "Ng1-f3", "Nb8-c6", "Bf1-b5", "a7-a6",
+				"b5xc6", "d7xc6", "O-O")).To(Equal("e4 e5 Nf3 Nc6 Bb5 a6 Bxc6 dxc6 O-O\n"))
+			Expect(mustRun("", "convert", "-from", "short", "-position", "3r3k/4P3/8/8/8/8/8/4K3 w - - 0 1",
+				"exd8=N")).To(Equal("e7xd8=N\n"))
+			_, err := runWith("", "convert", "e2-e5")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("converts positions between formats", func() {
+			Expect(mustRun("", "convert", "-format", "position", "e2-e4")).To(
+				Equal("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1\n"))
+			Expect(mustRun("", "convert", "-format", "diagram", "-position", "k7/8/8/8/8/8/8/K7 w - - 0 1")).To(
+				HavePrefix("8 k . . . . . . .\n"))
+			Expect(mustRun("", "convert", "-format", "json", "e2-e4")).To(HavePrefix(`{"version":1,`))
+			Expect(mustRun("", "convert", "-format", "svg")).To(HavePrefix("<svg"))
+			Expect(mustRun("", "convert", "-format", "png")).To(HavePrefix("\x89PNG"))
+
+			Expect(mustRun("", "convert", "-format", "pgn", "-position", "k7/8/1K6/8/8/8/8/7R w - - 0 1",
+				"Rh1-h8")).To(Equal("[SetUp \"1\"]\n[FEN \"k7/8/1K6/8/8/8/8/7R w - - 0 1\"]\n\n1. Rh8# 1-0\n"))
+			_, err := runWith("", "convert", "-variant", "glinski", "-format", "png")
+			Expect(err).To(Equal(errRectOnly))
+		})
+	})
+
+	It("analyses a position", func() {
+		out := mustRun("", "analyse", "-position", "k4/5/5/1K3/5/4R w - - 0 1", "-mate", "2")
+		Expect(out).To(ContainSubstring("side to move: White\nin check: false\n"))
+		Expect(out).To(ContainSubstring("mate in 2: Kb3-b4\n"))
+		Expect(out).To(MatchRegexp(`engine move: \S+ \(score 500\)`))
+
+		out = mustRun("", "analyse", "-position", "k7/1Q6/1K6/8/8/8/8/8 b - - 0 1")
+		Expect(out).To(ContainSubstring("outcome: White won by checkmate"))
+	})
+
+	It("plays a game", func() {
+		out := mustRun("moves\ne2-e5\nf2-f3\ng2-g4\n", "play", "-white", "human", "-black", "human",
+			"-colour=false", "-unicode")
+		Expect(out).To(ContainSubstring("Ng1-h3"))
+		Expect(out).To(ContainSubstring("e2-e5: illegal move"))
+		Expect(out).To(HaveSuffix("Black to move (a move, moves, resign, draw or quit): \n"))
+
+		out = mustRun("e2-e4\nresign\n", "play", "-black", "random", "-colour=false")
+		Expect(out).To(MatchRegexp(`Black plays \S+\n`))
+		Expect(out).To(ContainSubstring("Black won by resignation\nmoves: e2-e4 "))
+
+		_, err := runWith("", "play", "-white", "nobody")
+		Expect(err).To(HaveOccurred())
+	})
+})
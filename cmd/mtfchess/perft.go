@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mtfelian/mtfchess/base"
+	"github.com/mtfelian/mtfchess/server"
+)
+
+// perftFlags returns a perft command counting positions reached after all sequences of legal moves of depth
+func perftFlags(fs *flag.FlagSet) func(args []string, in io.Reader, out io.Writer) error {
+	p := positionFlags(fs)
+	depth := fs.Int("depth", 3, "a number of plies")
+	divide := fs.Bool("divide", false, "write counts of positions after each first move")
+	return func(_ []string, _ io.Reader, out io.Writer) error {
+		if *depth < 1 {
+			return fmt.Errorf("invalid depth %d", *depth)
+		}
+		b, v, err := p.board()
+		if err != nil {
+			return err
+		}
+		n, err := notation(v, server.LongAlgebraic, b)
+		if err != nil {
+			return err
+		}
+
+		start, nodes := time.Now(), 0
+		for _, move := range b.LegalMoves(n) {
+			next := b.Copy()
+			if err := makeMove(next, n, move); err != nil {
+				return err
+			}
+			count := perft(next, n, *depth-1)
+			if *divide {
+				fmt.Fprintf(out, "%s: %d\n", move, count)
+			}
+			nodes += count
+		}
+		elapsed := time.Since(start)
+		fmt.Fprintf(out, "nodes: %d\ntime: %v\n", nodes, elapsed.Round(time.Millisecond))
+		return nil
+	}
+}
+
+// perft returns a number of positions reached from b after all sequences of legal moves of depth plies
+func perft(b base.IBoard, n base.INotation, depth int) int {
+	if depth == 0 {
+		return 1
+	}
+	moves := b.LegalMoves(n)
+	if depth == 1 {
+		return len(moves)
+	}
+	nodes := 0
+	for _, move := range moves {
+		next := b.Copy()
+		if makeMove(next, n, move) == nil {
+			nodes += perft(next, n, depth-1)
+		}
+	}
+	return nodes
+}
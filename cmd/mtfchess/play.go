@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mtfelian/mtfchess"
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	"github.com/mtfelian/mtfchess/rect"
+	"github.com/mtfelian/mtfchess/server"
+	"github.com/mtfelian/mtfchess/tournament"
+)
+
+// human is a player spec of a human entering moves
+const human = "human"
+
+// playerUsage describes player specs
+const playerUsage = `"human", "random", "material", "uci:<engine command>" or "cecp:<engine command>"`
+
+// newEngine returns an engine by a player spec, it's nil for a human
+func newEngine(spec string) (tournament.Engine, error) {
+	seed := time.Now().UnixNano()
+	parts := strings.SplitN(spec, ":", 2)
+	switch {
+	case spec == human:
+		return nil, nil
+	case spec == "random":
+		return tournament.NewRandomEngine(seed), nil
+	case spec == "material":
+		return tournament.NewMaterialEngine(seed), nil
+	case len(parts) == 2 && (parts[0] == "uci" || parts[0] == "cecp"):
+		fields := strings.Fields(parts[1])
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("no engine command: %s", spec)
+		}
+		cmd := tournament.Command{Path: fields[0], Args: fields[1:]}
+		if parts[0] == "uci" {
+			return tournament.NewUCIEngine(cmd)
+		}
+		return tournament.NewCECPEngine(cmd)
+	}
+	return nil, fmt.Errorf("unknown player %q, players are %s", spec, playerUsage)
+}
+
+// playFlags returns a play command playing an interactive game in a terminal
+func playFlags(fs *flag.FlagSet) func(args []string, in io.Reader, out io.Writer) error {
+	p := positionFlags(fs)
+	white := fs.String("white", human, "a white player: "+playerUsage)
+	black := fs.String("black", "material", "a black player, other sides of multi-player variants are humans")
+	notationName := fs.String("notation", server.LongAlgebraic, "a notation of moves")
+	moveTime := fs.Duration("movetime", time.Second, "a time of engines to move")
+	opts := rect.DefaultDiagramOptions()
+	fs.BoolVar(&opts.Unicode, "unicode", false, "draw pieces as Unicode figurines")
+	fs.BoolVar(&opts.Colour, "colour", true, "colour pieces")
+	fs.BoolVar(&opts.Flipped, "flip", false, "draw a board from the black side")
+
+	return func(_ []string, in io.Reader, out io.Writer) error {
+		b, v, err := p.board()
+		if err != nil {
+			return err
+		}
+		n, err := notation(v, *notationName, b)
+		if err != nil {
+			return err
+		}
+		engines := map[Colour]tournament.Engine{}
+		for colour, spec := range map[Colour]string{White: *white, Black: *black} {
+			e, err := newEngine(spec)
+			if err != nil {
+				return err
+			}
+			if e != nil {
+				defer e.Close()
+				engines[colour] = e
+			}
+		}
+		return play(mtfchess.NewGame(b), v, n, engines, *moveTime, opts, bufio.NewScanner(in), out)
+	}
+}
+
+// play plays game g with moves in notation n of engines and humans entering moves by lines of input,
+// it returns when a game is finished, a human quits or input ends
+func play(g *mtfchess.Game, v server.Variant, n base.INotation, engines map[Colour]tournament.Engine,
+	moveTime time.Duration, opts rect.DiagramOptions, input *bufio.Scanner, out io.Writer) error {
+	for !g.Outcome().IsFinished() {
+		b, side := g.Snapshot(), g.SideToMove()
+		fmt.Fprint(out, "\n"+diagram(v, b, opts))
+
+		if e, exists := engines[side]; exists {
+			m, err := e.Move(b, n, moveTime)
+			switch {
+			case err == tournament.ErrResigned:
+				g.Resign(side)
+			case err != nil:
+				return fmt.Errorf("%s engine: %v", side, err)
+			default:
+				if err := g.Move(n, m.Move); err != nil {
+					return fmt.Errorf("%s engine move %s: %v", side, m.Move, err)
+				}
+				fmt.Fprintf(out, "%s plays %s\n", side, m.Move)
+			}
+			continue
+		}
+
+		fmt.Fprintf(out, "%s to move (a move, moves, resign, draw or quit): ", side)
+		if !input.Scan() {
+			fmt.Fprintln(out)
+			return input.Err()
+		}
+		switch line := strings.TrimSpace(input.Text()); line {
+		case "":
+		case "moves":
+			fmt.Fprintln(out, strings.Join(b.LegalMoves(n), " "))
+		case "resign":
+			g.Resign(side)
+		case "draw":
+			g.AgreeDraw()
+		case "quit":
+			return nil
+		default:
+			if err := g.Move(n, line); err != nil {
+				fmt.Fprintf(out, "%s: %v\n", line, err)
+			}
+		}
+	}
+	fmt.Fprint(out, "\n"+diagram(v, g.Snapshot(), opts))
+	fmt.Fprintf(out, "%s\nmoves: %s\n", g.Outcome(), strings.Join(g.History(), " "))
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mtfelian/mtfchess"
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	"github.com/mtfelian/mtfchess/pgn"
+	"github.com/mtfelian/mtfchess/rect"
+	"github.com/mtfelian/mtfchess/server"
+)
+
+// validateFlags returns a validate command checking positions given by arguments or by lines of input,
+// or PGN games of files given by arguments or of input. Moves of games and their variations are checked
+// in short algebraic notation on rectangular boards and in long algebraic notation on others.
+func validateFlags(fs *flag.FlagSet) func(args []string, in io.Reader, out io.Writer) error {
+	variantName := fs.String("variant", rect.StandardSettingsName, "a variant, see the variants command")
+	games := fs.Bool("pgn", false, "validate PGN games instead of positions")
+	return func(args []string, in io.Reader, out io.Writer) error {
+		v, err := variant(*variantName)
+		if err != nil {
+			return err
+		}
+		var invalid, total int
+		if *games {
+			invalid, total, err = validateGames(v, args, in, out)
+		} else {
+			invalid, total, err = validatePositions(v, args, in, out)
+		}
+		if err != nil {
+			return err
+		}
+		if invalid > 0 {
+			return fmt.Errorf("%d of %d invalid", invalid, total)
+		}
+		return nil
+	}
+}
+
+// validatePositions writes results of checking positions of args or of lines of in,
+// it returns numbers of invalid and all positions
+func validatePositions(v server.Variant, args []string, in io.Reader, out io.Writer) (int, int, error) {
+	positions := args
+	if len(positions) == 0 {
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				positions = append(positions, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return 0, 0, err
+		}
+	}
+	invalid := 0
+	for _, position := range positions {
+		if _, err := v.New(position); err != nil {
+			fmt.Fprintf(out, "%s: %v\n", position, err)
+			invalid++
+			continue
+		}
+		fmt.Fprintf(out, "%s: ok\n", position)
+	}
+	return invalid, len(positions), nil
+}
+
+// validateGames writes results of checking PGN games of files of args or of in,
+// it returns numbers of invalid and all games
+func validateGames(v server.Variant, args []string, in io.Reader, out io.Writer) (int, int, error) {
+	readers := map[string]io.Reader{"-": in}
+	names := []string{"-"}
+	if len(args) > 0 {
+		readers, names = map[string]io.Reader{}, args
+		for _, name := range args {
+			f, err := os.Open(name)
+			if err != nil {
+				return 0, 0, err
+			}
+			defer f.Close()
+			readers[name] = f
+		}
+	}
+
+	invalid, total := 0, 0
+	for _, name := range names {
+		games, err := pgn.ReadAll(readers[name])
+		if err != nil {
+			return 0, 0, fmt.Errorf("%s: %v", name, err)
+		}
+		for i, g := range games {
+			total++
+			prefix := fmt.Sprintf("game %d", i+1)
+			if name != "-" {
+				prefix = name + ": " + prefix
+			}
+			if white, black := g.Tag("White"), g.Tag("Black"); white != "" || black != "" {
+				prefix += fmt.Sprintf(" (%s - %s)", white, black)
+			}
+			t, err := replay(v, g)
+			if err != nil {
+				fmt.Fprintf(out, "%s: %v\n", prefix, err)
+				invalid++
+				continue
+			}
+			fmt.Fprintf(out, "%s: ok, %d moves, %s\n", prefix, len(t.MainLine()), g.Result)
+		}
+	}
+	return invalid, total, nil
+}
+
+// replay returns a game tree of g with all variations, it checks that a result of a finished game
+// agrees with a result marker
+func replay(v server.Variant, g *pgn.Game) (*mtfchess.GameTree, error) {
+	b, err := v.New(g.FEN())
+	if err != nil {
+		return nil, err
+	}
+	name := server.LongAlgebraic
+	if _, ok := b.(*rect.Board); ok {
+		name = shortAlgebraic
+	}
+	n, err := notation(v, name, b)
+	if err != nil {
+		return nil, err
+	}
+	t, err := mtfchess.NewGameTreeFromPGN(g, b, n)
+	if err != nil {
+		return nil, err
+	}
+	t.End()
+	if outcome := t.Board().Outcome(); outcome.IsFinished() && g.Result != "*" && result(outcome) != g.Result {
+		return nil, fmt.Errorf("result %s differs from %s", g.Result, outcome)
+	}
+	return t, nil
+}
+
+// result returns a PGN result marker of outcome
+func result(outcome base.Outcome) string {
+	switch {
+	case !outcome.IsFinished():
+		return "*"
+	case outcome.Winner == White:
+		return "1-0"
+	case outcome.Winner == Black:
+		return "0-1"
+	}
+	return "1/2-1/2"
+}
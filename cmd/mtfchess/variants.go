@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mtfelian/mtfchess/server"
+)
+
+// variantsFlags returns a variants command listing variants with notations and starting positions
+func variantsFlags(fs *flag.FlagSet) func(args []string, in io.Reader, out io.Writer) error {
+	return func(_ []string, _ io.Reader, out io.Writer) error {
+		variants := server.StandardVariants()
+		names := make([]string, 0, len(variants))
+		for name := range variants {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "variant\tnotations\tstarting position")
+		for _, name := range names {
+			v := variants[name]
+			b, err := v.New("")
+			if err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", name, strings.Join(notations(v, b), ", "), v.Position(b))
+		}
+		return tw.Flush()
+	}
+}
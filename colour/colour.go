@@ -1,6 +1,11 @@
 package colour
 
-import "github.com/mtfelian/cli"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mtfelian/cli"
+)
 
 // Colour is a side colour
 type Colour int
@@ -9,11 +14,19 @@ const (
 	Transparent Colour = iota
 	White
 	Black
+	Red
+	Blue
+	Yellow
+	Green
+	Dead // colour of pieces of an eliminated side in a multi-player game
 )
 
 // AllColours returns a slice of all colours
 func AllColours() []Colour { return []Colour{White, Black} }
 
+// FourPlayerColours returns a slice of colours of four-player chess in order of turns
+func FourPlayerColours() []Colour { return []Colour{Red, Blue, Yellow, Green} }
+
 // Name returns a side name
 func (c Colour) Name() string {
 	switch c {
@@ -21,22 +34,46 @@ func (c Colour) Name() string {
 		return "White"
 	case Black:
 		return "Black"
+	case Red:
+		return "Red"
+	case Blue:
+		return "Blue"
+	case Yellow:
+		return "Yellow"
+	case Green:
+		return "Green"
+	case Dead:
+		return "Dead"
 	}
 	return ""
 }
 
-// String make Colour to implement fmt.Stringer
+// String make Colour to implement fmt.Stringer, it returns a side name without terminal colour markup
 func (c Colour) String() string {
-	switch c {
-	case White:
-		return cli.Sprintf("{W|W{0|")
-	case Black:
-		return cli.Sprintf("{A|B{0|")
+	if name := c.Name(); name != "" {
+		return name
 	}
-	return cli.Sprintf("{R|?{0|")
+	return "?"
 }
 
-// Invert returns an inverted colour
+// Paint returns s coloured with cli markup for terminal output
+func (c Colour) Paint(s string) string {
+	tag, exists := map[Colour]string{
+		White:  "W",
+		Black:  "A",
+		Red:    "R",
+		Blue:   "B",
+		Yellow: "Y",
+		Green:  "G",
+		Dead:   "a",
+	}[c]
+	if !exists {
+		return cli.Sprintf("{0|%s", s)
+	}
+	return cli.Sprintf("{"+tag+"|%s{0|", s)
+}
+
+// Invert returns an inverted colour, only white and black colours have inverted colours
 func (c Colour) Invert() Colour {
 	switch c {
 	case White:
@@ -46,3 +83,17 @@ func (c Colour) Invert() Colour {
 	}
 	return Transparent
 }
+
+// MarshalText makes Colour to implement encoding.TextMarshaler, it returns a side name
+func (c Colour) MarshalText() ([]byte, error) { return []byte(c.Name()), nil }
+
+// UnmarshalText makes Colour to implement encoding.TextUnmarshaler, it parses a side name case insensitively
+func (c *Colour) UnmarshalText(text []byte) error {
+	for _, colour := range []Colour{Transparent, White, Black, Red, Blue, Yellow, Green, Dead} {
+		if strings.EqualFold(colour.Name(), string(text)) {
+			*c = colour
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid colour: %s", text)
+}
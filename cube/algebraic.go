@@ -0,0 +1,194 @@
+package cube
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"unicode"
+
+	"github.com/mtfelian/mtfchess/base"
+)
+
+const (
+	longAlgebraic = iota
+)
+
+const (
+	moveDelimiter    = "-"
+	captureDelimiter = "x"
+)
+
+const (
+	noPostfix        = ""
+	checkPostfix     = "+"
+	checkmatePostfix = "#"
+)
+
+const promotionDelimiter = "="
+
+var (
+	longAlgebraicCoordRegexp = regexp.MustCompile(`^([A-Z])([a-z])(\d{1,2})$`)
+	longAlgebraicMoveRegexp  = regexp.MustCompile(
+		`^([A-Z])?([A-Z][a-z]\d{1,2})[-x]([A-Z][a-z]\d{1,2})(?:=?([A-Za-z]))?[+#]?$`)
+)
+
+// algebraicNotation implementation for INotation.
+// Cells are named by an upper case level letter, a file letter and a rank, like Cc3 for the Raumschach central cell.
+// Moves are prefixed with a piece letter except pawn moves, like NAb1-Cb2 or Bb2-Cb2, so notation is case-sensitive.
+type algebraicNotation struct {
+	Coord base.ICoord
+	dim   Coord
+	mode  int
+}
+
+// NewLongAlgebraicNotation returns new long algebraic notation for a board with dimensions dim
+func NewLongAlgebraicNotation(dim base.ICoord) *algebraicNotation {
+	return &algebraicNotation{dim: dim.(Coord), mode: longAlgebraic}
+}
+
+// FromLetter returns x coord from the given file letter
+func FromLetter(letter rune) int { return int(unicode.ToLower(letter) - 'a' + 1) }
+
+// ToLetter returns file letter from the given x coord
+func ToLetter(x int) rune { return 'a' - 1 + rune(x) }
+
+// FromLevelLetter returns z coord from the given level letter
+func FromLevelLetter(letter rune) int { return int(unicode.ToUpper(letter) - 'A' + 1) }
+
+// ToLevelLetter returns level letter from the given z coord
+func ToLevelLetter(z int) rune { return 'A' - 1 + rune(z) }
+
+// SetCoords sets notation coord to
+func (n *algebraicNotation) SetCoord(to base.ICoord) base.INotation {
+	n.Coord = to
+	return n
+}
+
+// DecodeMove returns a func that tries to make a decoded move on a board
+func (n *algebraicNotation) DecodeMove(board base.IBoard, move string) (func() bool, error) {
+	n.dim = board.Dim().(Coord)
+	re := longAlgebraicMoveRegexp.Copy()
+	if !re.MatchString(move) {
+		return nil, fmt.Errorf("wrong move format: %s", move)
+	}
+
+	parts := re.FindStringSubmatch(move)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("wrong move format: %s", move)
+	}
+
+	if err := n.DecodeCoord(parts[2]); err != nil {
+		return nil, err
+	}
+	fromCoord := n.Coord.Copy()
+	if err := n.DecodeCoord(parts[3]); err != nil {
+		return nil, err
+	}
+	toCoord := n.Coord.Copy()
+
+	// isMoving returns true if piece matches the piece letter of a move
+	isMoving := func(piece base.IPiece) bool {
+		if piece == nil {
+			return false
+		}
+		if parts[1] == "" {
+			return piece.Name() == base.PawnName
+		}
+		return piece.Name() != base.PawnName && string(piece.Capital()) == parts[1]
+	}
+
+	if parts[4] == "" {
+		return func() bool {
+			piece := board.Piece(fromCoord)
+			return isMoving(piece) && board.MakeMove(toCoord, piece)
+		}, nil
+	}
+
+	newPromotion, exists := pieceConstructors[unicode.ToLower([]rune(parts[4])[0])]
+	if !exists {
+		return nil, fmt.Errorf("wrong promotion piece: %s", parts[4])
+	}
+	return func() bool {
+		piece := board.Piece(fromCoord)
+		if !isMoving(piece) {
+			return false
+		}
+		piece.SetPromote(newPromotion(piece.Colour()))
+		if !board.MakeMove(toCoord, piece) {
+			piece.SetPromote(nil)
+			return false
+		}
+		return true
+	}, nil
+}
+
+// EncodeMove on board with piece to dst coord
+func (n *algebraicNotation) EncodeMove(board base.IBoard, piece base.IPiece, dst base.ICoord) string {
+	anFrom := NewLongAlgebraicNotation(board.Dim()).SetCoord(piece.Coord())
+	anTo := NewLongAlgebraicNotation(board.Dim()).SetCoord(dst)
+	delimiter := moveDelimiter
+	if board.Piece(dst) != nil {
+		delimiter = captureDelimiter
+	}
+
+	fig := string(piece.Capital())
+	if piece.Name() == base.PawnName {
+		fig = ""
+	}
+
+	promotion, projection := "", board.Project(piece, dst)
+	if piece.Promotion() != nil {
+		promotion = promotionDelimiter + string(piece.Promotion().Capital())
+		projection = board.Copy().Empty(piece.Coord()).PlacePiece(dst, piece.Promote())
+	}
+	projection.SetSideToMove(projection.SideToMove().Invert())
+
+	check := noPostfix
+	if projection.InCheckmate(projection.SideToMove()) {
+		check = checkmatePostfix
+	} else if projection.InCheck(projection.SideToMove()) {
+		check = checkPostfix
+	}
+
+	return fig + anFrom.EncodeCoord() + delimiter + anTo.EncodeCoord() + promotion + check
+}
+
+// EncodeCastling on board, castling is not supported in Raumschach
+func (n *algebraicNotation) EncodeCastling(_ base.IBoard, castling base.Castling) string {
+	return []string{"O-O-O", "O-O"}[castling.I]
+}
+
+// DecodeCoord coord string to three-dimensional coords
+func (n *algebraicNotation) DecodeCoord(coord string) error {
+	re := longAlgebraicCoordRegexp.Copy()
+	if !re.MatchString(coord) {
+		return fmt.Errorf("wrong coord format: %s", coord)
+	}
+
+	parts := re.FindStringSubmatch(coord)
+	if len(parts) != 4 {
+		return fmt.Errorf("wrong coord format: %s", coord)
+	}
+
+	z, x := FromLevelLetter([]rune(parts[1])[0]), FromLetter([]rune(parts[2])[0])
+	y, err := strconv.Atoi(parts[3])
+	if err != nil {
+		return err
+	}
+
+	if x > n.dim.X || y < 1 || y > n.dim.Y || z > n.dim.Z {
+		return fmt.Errorf("coord is out of board: %s", coord)
+	}
+
+	n.Coord = Coord{X: x, Y: y, Z: z}
+	return nil
+}
+
+// EncodeCoord n.Coord as string
+func (n *algebraicNotation) EncodeCoord() string {
+	if n.Coord == nil {
+		return ""
+	}
+	c := n.Coord.(Coord)
+	return fmt.Sprintf("%c%c%d", ToLevelLetter(c.Z), ToLetter(c.X), c.Y)
+}
@@ -0,0 +1,83 @@
+package cube_test
+
+import (
+	"fmt"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	"github.com/mtfelian/mtfchess/cube"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Three-dimensional algebraic notation tests", func() {
+	It("checks coords encoding and decoding", func() {
+		n := cube.NewLongAlgebraicNotation(cube.Coord{5, 5, 5})
+		testCases := []struct {
+			s     string
+			coord cube.Coord
+		}{
+			{"Aa1", cube.Coord{1, 1, 1}},
+			{"Cc3", cube.Coord{3, 3, 3}},
+			{"Ee5", cube.Coord{5, 5, 5}},
+			{"Bd1", cube.Coord{4, 1, 2}},
+		}
+		for i, testCase := range testCases {
+			By(fmt.Sprintf("Checking testCase at index %d...", i))
+			Expect(n.DecodeCoord(testCase.s)).To(Succeed())
+			Expect(n.Coord).To(Equal(testCase.coord))
+			Expect(n.SetCoord(testCase.coord).EncodeCoord()).To(Equal(testCase.s))
+		}
+
+		for _, s := range []string{"Fa1", "Af1", "Aa6", "Aa0", "aa1", "AA1", "a1"} {
+			Expect(n.DecodeCoord(s)).NotTo(Succeed(), s)
+		}
+	})
+
+	It("checks moves decoding", func() {
+		b, err := cube.NewRaumschachStartingPosition().Board()
+		Expect(err).NotTo(HaveOccurred())
+		n := cube.NewLongAlgebraicNotation(b.Dim())
+
+		testCases := []struct {
+			move string
+			ok   bool
+		}{
+			{"Ab2-Ab4", false},
+			{"Ab1-Cb2", false},  // knight move without a piece letter
+			{"BAb1-Cb2", false}, // wrong piece letter
+			{"NAb1-Cb2", true},
+			{"Ec5-Dc5", false}, // it's not a black's piece
+			{"UDb5-Cc4", true},
+			{"QBc1xEc4+", true},
+		}
+		for i, testCase := range testCases {
+			By(fmt.Sprintf("Checking testCase %s at index %d...", testCase.move, i))
+			f, err := n.DecodeMove(b, testCase.move)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(f()).To(Equal(testCase.ok))
+		}
+		Expect(b.Piece(cube.Coord{3, 4, 5}).Name()).To(Equal(base.QueenName))
+		Expect(b.InCheck(Black)).To(BeTrue())
+
+		for _, move := range []string{"Ab2Ab3", "ab2-ab3", "Ab2-Ab3=X", "Ab2-Fb3"} {
+			_, err := n.DecodeMove(b, move)
+			Expect(err).To(HaveOccurred(), move)
+		}
+	})
+
+	It("checks pawn promotion move encoding", func() {
+		b, err := cube.FEN(`k4/4P/5/5/5|5/5/5/5/5|5/5/5/5/5|5/5/5/5/5|5/5/5/5/K4 w - - 0 1`).Board()
+		Expect(err).NotTo(HaveOccurred())
+		n := cube.NewLongAlgebraicNotation(b.Dim())
+		moves := b.LegalMoves(n)
+		Expect(moves).To(ContainElement("Ee4-Ee5=U"))
+		Expect(moves).To(ContainElement("Ee4-Ee5=Q+"))
+		Expect(moves).NotTo(ContainElement("Ee4-Ee5"))
+
+		f, err := n.DecodeMove(b, "Ee4-Ee5=u")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f()).To(BeTrue())
+		Expect(b.Piece(cube.Coord{5, 5, 5}).Name()).To(Equal(base.UnicornName))
+	})
+})
@@ -0,0 +1,41 @@
+package cube
+
+import (
+	"github.com/mtfelian/mtfchess/base"
+)
+
+// Row is a row of cells
+type Row []base.Cell
+
+// Copy returns a deep copy of row
+func (r Row) Copy(board base.IBoard) Row {
+	newRow := make(Row, len(r))
+	for i := range r {
+		newRow[i] = r[i].Copy(board)
+	}
+	return newRow
+}
+
+// Level is a matrix of cells ordered from the first rank
+type Level []Row
+
+// Copy returns a deep copy of level
+func (l Level) Copy(board base.IBoard) Level {
+	newLevel := make(Level, len(l))
+	for i := range l {
+		newLevel[i] = l[i].Copy(board)
+	}
+	return newLevel
+}
+
+// Cells is a slice of levels ordered from the lowest level
+type Cells []Level
+
+// Copy returns a deep copy of cells
+func (s Cells) Copy(board base.IBoard) base.ICells {
+	newCells := make(Cells, len(s))
+	for i := range s {
+		newCells[i] = s[i].Copy(board)
+	}
+	return newCells
+}
@@ -0,0 +1,99 @@
+package cube
+
+import (
+	"fmt"
+
+	"github.com/mtfelian/mtfchess/base"
+)
+
+// Coord is a three-dimensional coordinates: X is a file, Y is a rank and Z is a level
+type Coord struct {
+	X, Y, Z int
+}
+
+// String makes Coord to implement fmt.Stringer
+func (c Coord) String() string {
+	return fmt.Sprintf("(%d,%d,%d)", c.X, c.Y, c.Z)
+}
+
+// Add adds o to c and returns the sum as a result
+func (c Coord) Add(o base.ICoord) base.ICoord {
+	return Coord{X: c.X + o.(Coord).X, Y: c.Y + o.(Coord).Y, Z: c.Z + o.(Coord).Z}
+}
+
+// OutOf returns true if c is a coords out of board
+func (c Coord) OutOf(b base.IBoard) bool {
+	dim := b.Dim().(Coord)
+	return c.X < 1 || c.Y < 1 || c.Z < 1 || c.X > dim.X || c.Y > dim.Y || c.Z > dim.Z
+}
+
+// Equals returns true if c equals c1
+func (c Coord) Equals(to base.ICoord) bool {
+	return c.X == to.(Coord).X && c.Y == to.(Coord).Y && c.Z == to.(Coord).Z
+}
+
+// Copy returns a copy of c
+func (c Coord) Copy() base.ICoord {
+	return Coord{X: c.X, Y: c.Y, Z: c.Z}
+}
+
+// Distance returns a number of king's steps between c and to
+func (c Coord) Distance(to Coord) int {
+	return max(abs(to.X-c.X), max(abs(to.Y-c.Y), abs(to.Z-c.Z)))
+}
+
+// pawnMove returns true if coords are on the same file and differ only by a rank or only by a level,
+// like the pawn's non-capturing moves
+func pawnMove(from, to Coord) bool {
+	dX, dY, dZ := to.X-from.X, to.Y-from.Y, to.Z-from.Z
+	return dX == 0 && (dY == 0) != (dZ == 0)
+}
+
+// passedCoords returns coords passed over by a piece moving in a straight line from one coord to another,
+// coords are ordered from the source to the destination
+func passedCoords(from, to Coord) []base.ICoord {
+	n := from.Distance(to)
+	if n == 0 || (to.X-from.X)%n != 0 || (to.Y-from.Y)%n != 0 || (to.Z-from.Z)%n != 0 {
+		return []base.ICoord{}
+	}
+	d, res := Coord{(to.X - from.X) / n, (to.Y - from.Y) / n, (to.Z - from.Z) / n}, []base.ICoord{}
+	for c := from.Add(d); !c.Equals(to); c = c.Add(d) {
+		res = append(res, c)
+	}
+	return res
+}
+
+// abs returns an absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// max returns a maximal of a and b
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// NewCoords returns new three-dimensional coordinates
+func NewCoords(c []base.ICoord) Coords {
+	return Coords{Coords: base.NewCoords(c)}
+}
+
+// Coords is a slice of three-dimensional coordinates
+type Coords struct {
+	*base.Coords
+}
+
+// Less makes Coords to implement sort.Interface, coords are ordered by levels, then by ranks and then by files
+func (s Coords) Less(i, j int) bool {
+	ci, cj := s.Get(i).(Coord), s.Get(j).(Coord)
+	if ci.Z != cj.Z {
+		return ci.Z < cj.Z
+	}
+	return ci.Y < cj.Y || (ci.Y == cj.Y && ci.X < cj.X)
+}
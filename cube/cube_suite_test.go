@@ -0,0 +1,13 @@
+package cube_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestCube(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Three-dimensional Board Suite")
+}
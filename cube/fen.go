@@ -0,0 +1,213 @@
+package cube
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+// levelDelimiter separates levels in a position part of FEN
+const levelDelimiter = "|"
+
+// FEN is a position description for three-dimensional boards similar to FEN. The position part lists levels
+// separated by "|" from the top level, each level lists its ranks separated by "/" from the last rank,
+// like an ordinary FEN does. For example, Raumschach starting position is
+// "rnknr/ppppp/5/5/5|buqbu/ppppp/5/5/5|5/5/5/5/5|5/5/5/PPPPP/BUQBU|5/5/5/PPPPP/RNKNR w - - 0 1".
+// Next fields are side to move, castling flags (always "-"), en passant target cell, half-moves and move number.
+type FEN string
+
+// NewRaumschachStartingPosition returns a starting position for Raumschach
+func NewRaumschachStartingPosition() FEN {
+	return FEN(`rnknr/ppppp/5/5/5|buqbu/ppppp/5/5/5|5/5/5/5/5|5/5/5/PPPPP/BUQBU|5/5/5/PPPPP/RNKNR w - - 0 1`)
+}
+
+// parseRank parses a rank of a position part, returns pieces by cell indices from a-file
+// and a number of cells in the rank
+func parseRank(line string) (map[int]base.IPiece, int, error) {
+	pieces, n, runes := map[int]base.IPiece{}, 0, []rune(line)
+	for i := 0; i < len(runes); i++ {
+		if unicode.IsDigit(runes[i]) {
+			j := i
+			for j < len(runes) && unicode.IsDigit(runes[j]) {
+				j++
+			}
+			empty, err := strconv.Atoi(string(runes[i:j]))
+			if err != nil {
+				return nil, 0, err
+			}
+			n, i = n+empty, j-1
+			continue
+		}
+		f, exists := pieceConstructors[unicode.ToLower(runes[i])]
+		if !exists {
+			return nil, 0, fmt.Errorf("invalid piece token: %c", runes[i])
+		}
+		colour := White
+		if unicode.IsLower(runes[i]) {
+			colour = Black
+		}
+		pieces[n] = f(colour)
+		n++
+	}
+	return pieces, n, nil
+}
+
+// PositionPart returns a position part of a FEN
+func (s FEN) PositionPart() string {
+	return strings.Join(strings.Split(string(s), " ")[:4], " ")
+}
+
+// Board returns a new three-dimensional chess board position from FEN with Raumschach settings
+func (s FEN) Board() (base.IBoard, error) { return s.BoardWithSettings(RaumschachSettings()) }
+
+// BoardWithSettings returns a new three-dimensional chess board position from FEN with the given settings
+func (s FEN) BoardWithSettings(settings *base.Settings) (base.IBoard, error) {
+	fenParts := strings.Split(string(s), " ")
+	if len(fenParts) != 6 {
+		return nil, fmt.Errorf("invalid FEN, should be 6 fields: %s", s)
+	}
+
+	levels := strings.Split(fenParts[0], levelDelimiter)
+	depth, width := len(strings.Split(levels[0], "/")), -1
+	cells := make([][]map[int]base.IPiece, len(levels))
+	for i := range levels {
+		lines := strings.Split(levels[i], "/")
+		if len(lines) != depth {
+			return nil, fmt.Errorf("invalid FEN, level %c should have %d ranks",
+				ToLevelLetter(len(levels)-i), depth)
+		}
+		cells[i] = make([]map[int]base.IPiece, len(lines))
+		for j := range lines {
+			pieces, n, err := parseRank(lines[j])
+			if err != nil {
+				return nil, err
+			}
+			if width == -1 {
+				width = n
+			}
+			if n != width {
+				return nil, fmt.Errorf("invalid FEN, rank %d of level %c should have %d cells",
+					depth-j, ToLevelLetter(len(levels)-i), width)
+			}
+			cells[i][j] = pieces
+		}
+	}
+
+	b := NewEmptyBoard(width, depth, len(levels), settings)
+	for i := range cells {
+		for j := range cells[i] {
+			for k, piece := range cells[i][j] {
+				b.PlacePiece(Coord{X: k + 1, Y: depth - j, Z: len(levels) - i}, piece)
+			}
+		}
+	}
+
+	switch fenParts[1] {
+	case "w":
+		b.SetSideToMove(White)
+	case "b":
+		b.SetSideToMove(Black)
+	default:
+		return nil, fmt.Errorf("invalid side to move: %s", fenParts[1])
+	}
+
+	if fenParts[2] != "-" {
+		return nil, fmt.Errorf("castling is not supported: %s", fenParts[2])
+	}
+
+	if err := parseEP(fenParts[3], b); err != nil {
+		return nil, err
+	}
+
+	halfMoves, err := strconv.Atoi(fenParts[4])
+	if err != nil {
+		return nil, err
+	}
+	b.SetHalfMoveCount(halfMoves)
+
+	moveNumber, err := strconv.Atoi(fenParts[5])
+	if err != nil {
+		return nil, err
+	}
+	b.SetMoveNumber(moveNumber)
+	b.increasePositionCounter()
+	return b, nil
+}
+
+// parseEP parses en passant target cell, the cell is passed over by opponent's pawn which is found
+// further in one of the directions of it's moves. This func changes board parameter.
+func parseEP(line string, board *Board) error {
+	if line == "-" {
+		return nil
+	}
+	n := NewLongAlgebraicNotation(board.Dim())
+	if err := n.DecodeCoord(line); err != nil {
+		return err
+	}
+
+	opponent := board.SideToMove().Invert()
+directions:
+	for _, forward := range forColour(opponent, pawnMoves...) {
+		targets := []base.ICoord{}
+		for c := n.Coord; !c.OutOf(board); c = c.Add(forward) {
+			piece := board.Piece(c)
+			if piece == nil {
+				targets = append(targets, c)
+				continue
+			}
+			if piece.Name() != base.PawnName || piece.Colour() != opponent || len(targets) == 0 {
+				continue directions
+			}
+			board.SetCanCaptureEnPassantAt(c)
+			board.SetEnPassantTargets(targets)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid en passant target cell: %s", line)
+}
+
+// NewFEN converts three-dimensional board position to FEN
+func NewFEN(board *Board) FEN {
+	levels := []string{}
+	for i := len(board.cells) - 1; i >= 0; i-- {
+		lines := []string{}
+		for j := len(board.cells[i]) - 1; j >= 0; j-- {
+			line, empty := "", 0
+			for k := range board.cells[i][j] {
+				piece := board.cells[i][j][k].Piece()
+				if piece == nil {
+					empty++
+					continue
+				}
+				if empty > 0 {
+					line += strconv.Itoa(empty)
+					empty = 0
+				}
+				token := unicode.ToLower(piece.Capital())
+				if piece.Colour() == White {
+					token = unicode.ToUpper(token)
+				}
+				line += string(token)
+			}
+			if empty > 0 {
+				line += strconv.Itoa(empty)
+			}
+			lines = append(lines, line)
+		}
+		levels = append(levels, strings.Join(lines, "/"))
+	}
+
+	sideToMove := string(unicode.ToLower([]rune(board.SideToMove().Name())[0]))
+
+	ep := "-"
+	if targets := board.EnPassantTargets(); board.CanCaptureEnPassantAt() != nil && len(targets) > 0 {
+		ep = NewLongAlgebraicNotation(board.Dim()).SetCoord(targets[0]).EncodeCoord()
+	}
+
+	return FEN(fmt.Sprintf("%s %s - %s %d %d",
+		strings.Join(levels, levelDelimiter), sideToMove, ep, board.HalfMoveCount(), board.MoveNumber()))
+}
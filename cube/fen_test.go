@@ -0,0 +1,81 @@
+package cube_test
+
+import (
+	"fmt"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	"github.com/mtfelian/mtfchess/cube"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Three-dimensional FEN tests", func() {
+	It("checks Raumschach starting position", func() {
+		b, err := cube.NewRaumschachStartingPosition().Board()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b.Dim()).To(Equal(cube.Coord{5, 5, 5}))
+		for _, colour := range AllColours() {
+			Expect(b.FindPieces(base.PieceFilter{Colours: []Colour{colour}})).To(HaveLen(20))
+			Expect(b.FindPieces(base.PieceFilter{
+				Colours: []Colour{colour},
+				Names:   []string{base.UnicornName},
+			})).To(HaveLen(2))
+		}
+		Expect(b.King(White).Coord()).To(Equal(cube.Coord{3, 1, 1}))
+		Expect(b.King(Black).Coord()).To(Equal(cube.Coord{3, 5, 5}))
+		Expect(b.LegalMoves(cube.NewLongAlgebraicNotation(b.Dim()))).To(HaveLen(61))
+		Expect(cube.NewFEN(b.(*cube.Board))).To(Equal(cube.NewRaumschachStartingPosition()))
+	})
+
+	It("checks n×m×k boards", func() {
+		testCases := []struct {
+			fen  cube.FEN
+			dim  cube.Coord
+			king cube.Coord
+		}{
+			{cube.FEN(`k2/3|3/3 w - - 0 1`), cube.Coord{3, 2, 2}, cube.Coord{1, 2, 2}},
+			{cube.FEN(`4/4/4|4/4/4|4/4/4|1K2/4/4 b - - 3 7`), cube.Coord{4, 3, 4}, cube.Coord{2, 3, 1}},
+			{cube.FEN(`6/K5 w - - 0 1`), cube.Coord{6, 2, 1}, cube.Coord{1, 1, 1}},
+		}
+		for i, testCase := range testCases {
+			By(fmt.Sprintf("Checking testCase at index %d...", i))
+			b, err := testCase.fen.Board()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(b.Dim()).To(Equal(testCase.dim))
+			king := b.FindPieces(base.PieceFilter{Names: []string{base.KingName}})
+			Expect(king).To(HaveLen(1))
+			Expect(king[0].Coord()).To(Equal(testCase.king))
+			Expect(cube.NewFEN(b.(*cube.Board))).To(Equal(testCase.fen))
+		}
+	})
+
+	It("checks FEN after moves", func() {
+		b, err := cube.NewRaumschachStartingPosition().Board()
+		Expect(err).NotTo(HaveOccurred())
+		n := cube.NewLongAlgebraicNotation(b.Dim())
+		for _, move := range []string{"Bc2-Cc2", "Dc4-Cc4", "NAb1-Cb2"} {
+			f, err := n.DecodeMove(b, move)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(f()).To(BeTrue(), move)
+		}
+		Expect(cube.NewFEN(b.(*cube.Board))).To(Equal(cube.FEN(
+			`rnknr/ppppp/5/5/5|buqbu/pp1pp/5/5/5|5/2p2/5/1NP2/5|5/5/5/PP1PP/BUQBU|5/5/5/PPPPP/R1KNR b - - 1 2`)))
+	})
+
+	It("checks invalid FEN", func() {
+		for _, fen := range []cube.FEN{
+			`5/5|5/5/5 w - - 0 1`,
+			`5/4|5/5 w - - 0 1`,
+			`5/5|5/x4 w - - 0 1`,
+			`5/5|5/5 x - - 0 1`,
+			`5/5|5/5 w KQ - 0 1`,
+			`5/5|5/5 w - - 0`,
+			`5/5|5/5 w - Aa1 0 1`,
+		} {
+			By(fmt.Sprintf("Checking %s...", fen))
+			_, err := fen.Board()
+			Expect(err).To(HaveOccurred())
+		}
+	})
+})
@@ -0,0 +1,137 @@
+package cube
+
+import (
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+const (
+	NoPawnLongMove       = 0 // disable pawn long move
+	StandardPawnLongMove = 1 // pawn from starting cell can go 1 cell further
+)
+
+const (
+	NoMovesToDraw         = 0  // disable N moves draw rule
+	Standard50MovesToDraw = 50 // 50 moves draw rule
+)
+
+const (
+	NoXFoldRepetitionDraw       = 0 // disable X-fold repetition draw rule
+	Standard3FoldRepetitionDraw = 3 // 3-fold repetition draw rule
+)
+
+// pieceConstructors maps lower case FEN piece tokens to piece constructors
+var pieceConstructors = map[rune]func(Colour) base.IPiece{
+	'p': NewPawn, 'n': NewKnight, 'b': NewBishop, 'r': NewRook, 'q': NewQueen, 'k': NewKing, 'u': NewUnicorn,
+}
+
+// NewPieceByName returns a new piece of colour by the given piece name, or nil if there is no such piece
+func NewPieceByName(name string, colour Colour) base.IPiece {
+	for _, f := range pieceConstructors {
+		if piece := f(colour); piece.Name() == name {
+			return piece
+		}
+	}
+	return nil
+}
+
+// RaumschachSettings returns a set of settings for Raumschach, they also suit boards of other dimensions
+func RaumschachSettings() *base.Settings {
+	return &base.Settings{
+		PawnLongMoveModifier:   NoPawnLongMove,
+		PawnStartZoneFunc:      NewPawnStartCellsFunc(true, nil),
+		AllowedPromotions:      StandardAllowedPromotions(),
+		PromotionRules:         StandardPromotionRules(),
+		PromotionConditionFunc: base.StandardPromotionConditionFunc,
+		CastlingsFunc:          NoCastlingFunc,
+		EnPassantFunc:          NoEnPassantFunc,
+		MoveOrder:              true,
+		MovesToDraw:            Standard50MovesToDraw,
+		PositionsToDraw:        Standard3FoldRepetitionDraw,
+	}
+}
+
+// testBoardSettings returns a set of settings for tests
+func testBoardSettings() *base.Settings {
+	return &base.Settings{
+		PawnLongMoveModifier:   NoPawnLongMove,
+		PawnStartZoneFunc:      NewPawnStartCellsFunc(true, nil),
+		AllowedPromotions:      StandardAllowedPromotions(),
+		PromotionRules:         StandardPromotionRules(),
+		PromotionConditionFunc: base.StandardPromotionConditionFunc,
+		CastlingsFunc:          NoCastlingFunc,
+		EnPassantFunc:          NoEnPassantFunc,
+		MoveOrder:              false,
+		MovesToDraw:            NoMovesToDraw,
+		PositionsToDraw:        NoXFoldRepetitionDraw,
+	}
+}
+
+// NewPawnStartCellsFunc returns a pawn start zone func allowing pawn long move from the given cells,
+// cells maps pawn colour to a slice of coords. Set unmovedOnly to true to allow long move only to unmoved pawns.
+func NewPawnStartCellsFunc(unmovedOnly bool, cells map[Colour][]Coord) func(base.IBoard, base.IPiece) bool {
+	return func(board base.IBoard, piece base.IPiece) bool {
+		if unmovedOnly && piece.WasMoved() {
+			return false
+		}
+		for _, c := range cells[piece.Colour()] {
+			if c.Equals(piece.Coord()) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// NoEnPassantFunc always disables en passant capturing
+func NoEnPassantFunc(_ base.IBoard, _ base.IPiece) base.ICoords { return NewCoords([]base.ICoord{}) }
+
+// StandardEnPassantFunc enables en passant capturing, returns coords to capture, piece is a capturing piece.
+// Pawn can capture en passant on any of cells passed over by an opponent's pawn making a long move
+// if the cell is one of its capturing cells. Raumschach has no pawn long moves, it is for custom boards.
+func StandardEnPassantFunc(board base.IBoard, piece base.IPiece) base.ICoords {
+	res := NewCoords([]base.ICoord{})
+	if piece.Name() != base.PawnName {
+		return res
+	}
+
+	// pieceAt is a coord of a piece which can be captured en passant
+	pieceAt := board.CanCaptureEnPassantAt()
+	if pieceAt == nil || board.Piece(pieceAt) == nil || board.Piece(pieceAt).Colour() == piece.Colour() {
+		return res
+	}
+
+	targets := NewCoords(board.EnPassantTargets())
+	for _, o := range forColour(piece.Colour(), pawnCaptures...) {
+		if to := piece.Coord().Add(o); targets.Contains(to) {
+			res.Add(to)
+		}
+	}
+	return res
+}
+
+// NoCastlingFunc is a castling func which disables castling
+func NoCastlingFunc(_ base.IBoard, _ Colour) base.Castlings { return base.Castlings{} }
+
+// StandardAllowedPromotions returns allowed pawn promotions pieces names list for Raumschach
+func StandardAllowedPromotions() []string {
+	return []string{base.KnightName, base.BishopName, base.RookName, base.UnicornName, base.QueenName}
+}
+
+// StandardPromotionRules returns promotion rules for Raumschach: pawn should be promoted
+// on the last rank of the last level to any of Settings.AllowedPromotions pieces
+func StandardPromotionRules() base.PromotionRules {
+	return base.PromotionRules{
+		base.PawnName: {ZoneFunc: PromotionLastRankAndLevelFunc},
+	}
+}
+
+// PromotionLastRankAndLevelFunc is a promotion zone func making promotion mandatory on the last rank
+// of the last level, it is the top level for white and the bottom level for black
+func PromotionLastRankAndLevelFunc(board base.IBoard, piece base.IPiece, dst base.ICoord) base.PromotionKind {
+	c, dim := dst.(Coord), board.Dim().(Coord)
+	if piece.Colour() == White && c.Y == dim.Y && c.Z == dim.Z || piece.Colour() == Black && c.Y == 1 && c.Z == 1 {
+		return base.MandatoryPromotion
+	}
+	return base.NoPromotion
+}
@@ -0,0 +1,72 @@
+package cube
+
+import (
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+var (
+	// orthogonal are offsets to adjacent cells through the cell faces, rook moves along them
+	orthogonal = unitOffsets(1)
+
+	// diagonal are offsets to adjacent cells through the cell edges, bishop moves along them
+	diagonal = unitOffsets(2)
+
+	// triagonal are offsets to adjacent cells through the cell vertices, unicorn moves along them
+	triagonal = unitOffsets(3)
+
+	// allDirections are offsets to all adjacent cells, queen and king move along them
+	allDirections = append(append(append([]base.ICoord{}, orthogonal...), diagonal...), triagonal...)
+
+	// knightLeaps are offsets of the knight: two steps along one axis and one step along another one
+	knightLeaps = leaps(1, 2)
+
+	// pawnMoves are white pawn's move offsets: forward and upward, they are inverted for black
+	pawnMoves = []base.ICoord{Coord{0, 1, 0}, Coord{0, 0, 1}}
+
+	// pawnCaptures are white pawn's capture offsets: one bishop's step forward or upward,
+	// they are inverted for black
+	pawnCaptures = []base.ICoord{Coord{-1, 1, 0}, Coord{1, 1, 0}, Coord{-1, 0, 1}, Coord{1, 0, 1}, Coord{0, 1, 1}}
+)
+
+// unitOffsets returns offsets to adjacent cells which differ from the cell by exactly n coordinates
+func unitOffsets(n int) []base.ICoord {
+	res := []base.ICoord{}
+	for x := -1; x <= 1; x++ {
+		for y := -1; y <= 1; y++ {
+			for z := -1; z <= 1; z++ {
+				if x*x+y*y+z*z == n {
+					res = append(res, Coord{x, y, z})
+				}
+			}
+		}
+	}
+	return res
+}
+
+// leaps returns offsets of a leaper moving by a steps along one axis and by b steps along another one
+func leaps(a, b int) []base.ICoord {
+	res := []base.ICoord{}
+	for x := -b; x <= b; x++ {
+		for y := -b; y <= b; y++ {
+			for z := -b; z <= b; z++ {
+				if x*x+y*y+z*z == a*a+b*b && (x == 0 || y == 0 || z == 0) {
+					res = append(res, Coord{x, y, z})
+				}
+			}
+		}
+	}
+	return res
+}
+
+// forColour returns offsets o for piece of the given colour: black's offsets are inverted
+func forColour(colour Colour, o ...base.ICoord) []base.ICoord {
+	res := make([]base.ICoord, len(o))
+	for i := range o {
+		res[i] = o[i]
+		if c := o[i].(Coord); colour == Black {
+			res[i] = Coord{-c.X, -c.Y, -c.Z}
+		}
+	}
+	return res
+}
@@ -0,0 +1,13 @@
+package cube
+
+import (
+	"github.com/mtfelian/mtfchess/base"
+)
+
+// PieceFilter is a piece filter for three-dimensional board
+type PieceFilter struct {
+	base.PieceFilter
+	X []int
+	Y []int
+	Z []int
+}
@@ -0,0 +1,35 @@
+package cube
+
+import (
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+// Queen is a three-dimensional chess queen, it moves like a rook, a bishop or a unicorn
+type Queen struct{ *base.Piece }
+
+// NewQueen creates new queen with colour
+func NewQueen(colour Colour) base.IPiece {
+	return &Queen{Piece: base.NewPiece(colour, base.QueenName, "Q♕♛")}
+}
+
+// dst returns a slice of destination cells coords, making it's legal moves
+// if moving is false then pairs leading to check-exposing moves also included
+func (p *Queen) dst(b *Board, moving bool) base.ICoords {
+	return NewCoords(base.Rider(p, b, moving, allDirections, 0, base.MoveAny))
+}
+
+// Attacks returns a slice of coords pairs of cells attacked by a piece
+func (p *Queen) Attacks(b base.IBoard) base.ICoords { return p.dst(b.(*Board), false) }
+
+// Destinations returns a slice of cells coords, making it's legal moves
+func (p *Queen) Destinations(b base.IBoard) base.ICoords { return p.dst(b.(*Board), true) }
+
+// Copy a piece
+func (p *Queen) Copy() base.IPiece { return &Queen{Piece: p.Piece.Copy()} }
+
+// Promote returns a promoted piece
+func (p *Queen) Promote() base.IPiece { return base.Promoted(p) }
+
+// Set sets a piece to p1
+func (p *Queen) Set(p1 base.IPiece) { *p = *(p1.(*Queen)) }
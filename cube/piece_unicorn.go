@@ -0,0 +1,35 @@
+package cube
+
+import (
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+// Unicorn is a three-dimensional chess unicorn, it moves through the cell vertices (triagonally)
+type Unicorn struct{ *base.Piece }
+
+// NewUnicorn creates new unicorn with colour
+func NewUnicorn(colour Colour) base.IPiece {
+	return &Unicorn{Piece: base.NewPiece(colour, base.UnicornName, "UUu")}
+}
+
+// dst returns a slice of destination cells coords, making it's legal moves
+// if moving is false then pairs leading to check-exposing moves also included
+func (p *Unicorn) dst(b *Board, moving bool) base.ICoords {
+	return NewCoords(base.Rider(p, b, moving, triagonal, 0, base.MoveAny))
+}
+
+// Attacks returns a slice of coords pairs of cells attacked by a piece
+func (p *Unicorn) Attacks(b base.IBoard) base.ICoords { return p.dst(b.(*Board), false) }
+
+// Destinations returns a slice of cells coords, making it's legal moves
+func (p *Unicorn) Destinations(b base.IBoard) base.ICoords { return p.dst(b.(*Board), true) }
+
+// Copy a piece
+func (p *Unicorn) Copy() base.IPiece { return &Unicorn{Piece: p.Piece.Copy()} }
+
+// Promote returns a promoted piece
+func (p *Unicorn) Promote() base.IPiece { return base.Promoted(p) }
+
+// Set sets a piece to p1
+func (p *Unicorn) Set(p1 base.IPiece) { *p = *(p1.(*Unicorn)) }
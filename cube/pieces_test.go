@@ -0,0 +1,117 @@
+package cube
+
+import (
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Three-dimensional pieces test", func() {
+	var b base.IBoard
+	BeforeEach(func() {
+		b = NewEmptyTestBoard()
+	})
+
+	It("checks movement offsets", func() {
+		Expect(orthogonal).To(HaveLen(6))
+		Expect(diagonal).To(HaveLen(12))
+		Expect(triagonal).To(HaveLen(8))
+		Expect(allDirections).To(HaveLen(26))
+		Expect(knightLeaps).To(HaveLen(24))
+	})
+
+	It("generates moves from the central cell", func() {
+		testCases := []struct {
+			piece base.IPiece
+			n     int
+		}{
+			{NewRook(White), 12},
+			{NewBishop(White), 24},
+			{NewUnicorn(White), 16},
+			{NewQueen(White), 52},
+			{NewKing(White), 26},
+			{NewKnight(White), 24},
+		}
+		for _, testCase := range testCases {
+			By("Checking " + testCase.piece.Name() + "...")
+			b = NewEmptyTestBoard()
+			b.PlacePiece(Coord{3, 3, 3}, testCase.piece)
+			Expect(testCase.piece.Destinations(b).Len()).To(Equal(testCase.n))
+		}
+	})
+
+	It("generates moves from the corner cell", func() {
+		testCases := []struct {
+			piece base.IPiece
+			n     int
+		}{
+			{NewRook(White), 12},
+			{NewBishop(White), 12},
+			{NewUnicorn(White), 4},
+			{NewKing(White), 7},
+			{NewKnight(White), 6},
+		}
+		for _, testCase := range testCases {
+			By("Checking " + testCase.piece.Name() + "...")
+			b = NewEmptyTestBoard()
+			b.PlacePiece(Coord{1, 1, 1}, testCase.piece)
+			Expect(testCase.piece.Destinations(b).Len()).To(Equal(testCase.n))
+		}
+	})
+
+	It("checks unicorn moves only through the cell vertices", func() {
+		wu := NewUnicorn(White)
+		b.PlacePiece(Coord{1, 1, 1}, wu)
+		b.PlacePiece(Coord{4, 4, 4}, NewPawn(Black))
+		d := wu.Destinations(b)
+		Expect(d.Len()).To(Equal(3))
+		Expect(d.Contains(Coord{4, 4, 4})).To(BeTrue())
+		Expect(d.Contains(Coord{5, 5, 5})).To(BeFalse())
+		Expect(d.Contains(Coord{2, 2, 1})).To(BeFalse())
+	})
+
+	It("checks pawn moves and captures", func() {
+		wp, bp := NewPawn(White), NewPawn(Black)
+		b.PlacePiece(Coord{3, 2, 2}, wp)
+		b.PlacePiece(Coord{3, 4, 4}, bp)
+		Expect(wp.Destinations(b).Len()).To(Equal(2))
+		Expect(wp.Destinations(b).Contains(Coord{3, 3, 2})).To(BeTrue())
+		Expect(wp.Destinations(b).Contains(Coord{3, 2, 3})).To(BeTrue())
+		Expect(bp.Destinations(b).Contains(Coord{3, 3, 4})).To(BeTrue())
+		Expect(bp.Destinations(b).Contains(Coord{3, 4, 3})).To(BeTrue())
+
+		for _, c := range []Coord{{2, 3, 2}, {4, 3, 2}, {2, 2, 3}, {4, 2, 3}, {3, 3, 3}} {
+			b.PlacePiece(c, NewKnight(Black))
+		}
+		b.PlacePiece(Coord{3, 1, 3}, NewKnight(Black))
+		d := wp.Destinations(b)
+		Expect(d.Len()).To(Equal(7))
+		Expect(d.Contains(Coord{3, 3, 3})).To(BeTrue())
+		Expect(d.Contains(Coord{3, 1, 3})).To(BeFalse(), "pawn can't capture backward")
+	})
+
+	It("checks pawn promotion on the last rank of the last level", func() {
+		b.Settings().MoveOrder = true
+		wk, bk, wp := NewKing(White), NewKing(Black), NewPawn(White)
+		b.PlacePiece(Coord{1, 1, 1}, wk)
+		b.PlacePiece(Coord{5, 1, 1}, bk)
+		b.PlacePiece(Coord{1, 5, 4}, wp)
+		Expect(b.Settings().PromotionRules.Kind(b, wp, Coord{1, 5, 5})).To(Equal(base.MandatoryPromotion))
+		Expect(b.Settings().PromotionRules.Kind(b, wp, Coord{2, 5, 4})).To(Equal(base.NoPromotion))
+		Expect(b.MakeMove(Coord{1, 5, 5}, wp)).To(BeFalse())
+
+		wp.SetPromote(NewUnicorn(White))
+		Expect(b.MakeMove(Coord{1, 5, 5}, wp)).To(BeTrue())
+		Expect(b.Piece(Coord{1, 5, 5}).Name()).To(Equal(base.UnicornName))
+	})
+
+	It("checks king can't move to the attacked cell", func() {
+		wk := NewKing(White)
+		b.PlacePiece(Coord{1, 1, 1}, wk)
+		b.PlacePiece(Coord{5, 5, 5}, NewUnicorn(Black))
+		Expect(wk.Destinations(b).Contains(Coord{2, 2, 2})).To(BeFalse())
+		Expect(wk.Destinations(b).Len()).To(Equal(6))
+		Expect(b.InCheck(White)).To(BeTrue())
+	})
+})
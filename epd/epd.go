@@ -0,0 +1,269 @@
+// Package epd reads and writes Extended Position Description records of rectangular boards
+// and runs engines against test suites of them. A record is a position part of an X-FEN
+// followed by operations like `bm Nf3; id "test 1";`.
+package epd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mtfelian/mtfchess/rect"
+)
+
+// opcodes of operations known to this package
+const (
+	BestMoves  = "bm"  // best moves in short algebraic notation
+	AvoidMoves = "am"  // moves to avoid in short algebraic notation
+	ID         = "id"  // an identifier of a record
+	Comment    = "c0"  // a comment, "c1" to "c9" are other comments
+	Eval       = "ce"  // a centipawn evaluation from the side to move point of view
+	PV         = "pv"  // a predicted variation in short algebraic notation
+	Depth      = "acd" // an analysis depth in plies
+	HalfMoves  = "hmvc"
+	MoveNumber = "fmvn"
+)
+
+// positionFields is a number of fields of a position part
+const positionFields = 4
+
+// Operation is an opcode with operands
+type Operation struct {
+	Opcode   string
+	Operands []string
+}
+
+// Record is an EPD record
+type Record struct {
+	// Position is a position part of an X-FEN: pieces, a side to move, castlings and en passant
+	Position   string
+	Operations []Operation
+}
+
+// NewRecord returns a record of a position on b without operations
+func NewRecord(b *rect.Board) *Record {
+	return &Record{Position: rect.NewXFEN(b).PositionPart(), Operations: []Operation{}}
+}
+
+// Parse parses an EPD record. Operations are separated by ";", operands containing spaces or ";"
+// are written in double quotes with escape sequences like strconv.Quote writes them.
+func Parse(line string) (*Record, error) {
+	fields := strings.Fields(line)
+	if len(fields) < positionFields {
+		return nil, fmt.Errorf("invalid EPD: %s", line)
+	}
+	r := &Record{Position: strings.Join(fields[:positionFields], " "), Operations: []Operation{}}
+
+	// skipping the position part
+	rest := strings.TrimSpace(line)
+	for i := 0; i < positionFields; i++ {
+		rest = strings.TrimSpace(rest[len(strings.Fields(rest)[0]):])
+	}
+
+	tokens, token, quoted, inQuotes := []string{}, &strings.Builder{}, false, false
+	raw, escaped := &strings.Builder{}, false // a quoted operand with escape sequences
+	flush := func() {
+		if token.Len() > 0 || quoted {
+			tokens = append(tokens, token.String())
+		}
+		token.Reset()
+		quoted = false
+	}
+	for _, c := range rest {
+		switch {
+		case inQuotes && (escaped || c == '\\'):
+			raw.WriteRune(c)
+			escaped = !escaped
+		case c == '"':
+			if inQuotes {
+				token.WriteString(unescape(raw.String()))
+				raw.Reset()
+			}
+			inQuotes, quoted = !inQuotes, true
+		case inQuotes:
+			raw.WriteRune(c)
+		case c == ';':
+			flush()
+			if len(tokens) > 0 {
+				r.Operations = append(r.Operations, Operation{Opcode: tokens[0], Operands: tokens[1:]})
+			}
+			tokens = []string{}
+		case c == ' ' || c == '\t':
+			flush()
+		default:
+			token.WriteRune(c)
+		}
+	}
+	flush()
+	if inQuotes || len(tokens) > 0 {
+		return nil, fmt.Errorf("unterminated EPD operation: %s", rest)
+	}
+	return r, nil
+}
+
+// unescape returns quoted operand s with escape sequences replaced, invalid sequences are kept as they are
+func unescape(s string) string {
+	res := &strings.Builder{}
+	for len(s) > 0 {
+		c, multibyte, tail, err := strconv.UnquoteChar(s, '"')
+		switch {
+		case err != nil:
+			res.WriteByte(s[0])
+			tail = s[1:]
+		case multibyte:
+			res.WriteRune(c)
+		default:
+			res.WriteByte(byte(c))
+		}
+		s = tail
+	}
+	return res.String()
+}
+
+// String makes Record to implement fmt.Stringer, it returns an EPD line
+func (r *Record) String() string {
+	parts := []string{r.Position}
+	for _, op := range r.Operations {
+		s := op.Opcode
+		for _, operand := range op.Operands {
+			if quoted(op.Opcode) || operand == "" || strings.ContainsAny(operand, " \t;\"") {
+				operand = strconv.Quote(operand)
+			}
+			s += " " + operand
+		}
+		parts = append(parts, s+";")
+	}
+	return strings.Join(parts, " ")
+}
+
+// quoted returns true if operands of opcode are strings which are always quoted
+func quoted(opcode string) bool {
+	return opcode == ID || len(opcode) == 2 && opcode[0] == 'c' && opcode[1] >= '0' && opcode[1] <= '9'
+}
+
+// Get returns operands of an operation with opcode, it returns false if there is no such operation
+func (r *Record) Get(opcode string) ([]string, bool) {
+	for _, op := range r.Operations {
+		if op.Opcode == opcode {
+			return op.Operands, true
+		}
+	}
+	return nil, false
+}
+
+// Set sets operands of an operation with opcode, the operation is added to the end if there is no such one
+func (r *Record) Set(opcode string, operands ...string) {
+	for i, op := range r.Operations {
+		if op.Opcode == opcode {
+			r.Operations[i].Operands = operands
+			return
+		}
+	}
+	r.Operations = append(r.Operations, Operation{Opcode: opcode, Operands: operands})
+}
+
+// Delete deletes an operation with opcode
+func (r *Record) Delete(opcode string) {
+	for i, op := range r.Operations {
+		if op.Opcode == opcode {
+			r.Operations = append(r.Operations[:i], r.Operations[i+1:]...)
+			return
+		}
+	}
+}
+
+// operands returns operands of an operation with opcode, it's nil if there is no such operation
+func (r *Record) operands(opcode string) []string {
+	operands, _ := r.Get(opcode)
+	return operands
+}
+
+// str returns the first operand of an operation with opcode or an empty string
+func (r *Record) str(opcode string) string {
+	operands := r.operands(opcode)
+	if len(operands) == 0 {
+		return ""
+	}
+	return operands[0]
+}
+
+// integer returns the first operand of an operation with opcode as an integer, it returns false
+// if there is no such operation or it's not an integer
+func (r *Record) integer(opcode string) (int, bool) {
+	n, err := strconv.Atoi(r.str(opcode))
+	return n, err == nil
+}
+
+// ID returns an identifier of a record
+func (r *Record) ID() string { return r.str(ID) }
+
+// Comment returns a comment of a record
+func (r *Record) Comment() string { return r.str(Comment) }
+
+// BestMoves returns best moves of a record
+func (r *Record) BestMoves() []string { return r.operands(BestMoves) }
+
+// AvoidMoves returns moves to avoid of a record
+func (r *Record) AvoidMoves() []string { return r.operands(AvoidMoves) }
+
+// PV returns a predicted variation of a record
+func (r *Record) PV() []string { return r.operands(PV) }
+
+// Eval returns a centipawn evaluation of a record
+func (r *Record) Eval() (int, bool) { return r.integer(Eval) }
+
+// Depth returns an analysis depth of a record
+func (r *Record) Depth() (int, bool) { return r.integer(Depth) }
+
+// XFEN returns an X-FEN of a record, the half-move clock and the move number are taken from
+// hmvc and fmvn operations, they are 0 and 1 if there are no such operations
+func (r *Record) XFEN() rect.XFEN {
+	halfMoves, ok := r.integer(HalfMoves)
+	if !ok {
+		halfMoves = 0
+	}
+	moveNumber, ok := r.integer(MoveNumber)
+	if !ok {
+		moveNumber = 1
+	}
+	return rect.XFEN(fmt.Sprintf("%s %d %d", r.Position, halfMoves, moveNumber))
+}
+
+// Board returns a board of a position of a record
+func (r *Record) Board() (*rect.Board, error) {
+	b, err := r.XFEN().Board()
+	if err != nil {
+		return nil, err
+	}
+	return b.(*rect.Board), nil
+}
+
+// Read reads records written one per line, empty lines are skipped
+func Read(rd io.Reader) ([]*Record, error) {
+	res := []*Record{}
+	scanner, n := bufio.NewScanner(rd), 0
+	for scanner.Scan() {
+		n++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		r, err := Parse(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		res = append(res, r)
+	}
+	return res, scanner.Err()
+}
+
+// Write writes records one per line
+func Write(w io.Writer, records []*Record) error {
+	for _, r := range records {
+		if _, err := fmt.Fprintln(w, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package epd_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestEPD(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "EPD Suite")
+}
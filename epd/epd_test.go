@@ -0,0 +1,141 @@
+package epd_test
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"github.com/mtfelian/mtfchess/base"
+	"github.com/mtfelian/mtfchess/epd"
+	"github.com/mtfelian/mtfchess/rect"
+	"github.com/mtfelian/mtfchess/tournament"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// failingEngine is an engine which always fails
+type failingEngine struct{}
+
+func (failingEngine) Move(base.IBoard, base.INotation, time.Duration) (tournament.Move, error) {
+	return tournament.Move{}, tournament.ErrTimeout
+}
+
+func (failingEngine) Close() error { return nil }
+
+var _ = Describe("EPD", func() {
+	const line = `r1bqk2r/pppp1ppp/2n2n2/2b1p3/2B1P3/5N2/PPPP1PPP/RNBQK2R w KQkq - ` +
+		`bm O-O; am Ng5 Nc3; id "Italian; main line"; c0 "castle early"; ce 35; pv O-O Nxe4 Re1; acd 12;`
+
+	It("parses records", func() {
+		r, err := epd.Parse(line)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Position).To(Equal("r1bqk2r/pppp1ppp/2n2n2/2b1p3/2B1P3/5N2/PPPP1PPP/RNBQK2R w KQkq -"))
+		Expect(r.BestMoves()).To(Equal([]string{"O-O"}))
+		Expect(r.AvoidMoves()).To(Equal([]string{"Ng5", "Nc3"}))
+		Expect(r.ID()).To(Equal("Italian; main line"))
+		Expect(r.Comment()).To(Equal("castle early"))
+		Expect(r.PV()).To(Equal([]string{"O-O", "Nxe4", "Re1"}))
+		eval, ok := r.Eval()
+		Expect(ok).To(BeTrue())
+		Expect(eval).To(Equal(35))
+		depth, ok := r.Depth()
+		Expect(ok).To(BeTrue())
+		Expect(depth).To(Equal(12))
+		Expect(r.String()).To(Equal(line))
+
+		_, err = epd.Parse("8/8/8/8 w")
+		Expect(err).To(HaveOccurred())
+		_, err = epd.Parse(`k7/8/8/8/8/8/8/K7 w - - id "unterminated;`)
+		Expect(err).To(HaveOccurred())
+		_, err = epd.Parse(`k7/8/8/8/8/8/8/K7 w - - bm Ka2`)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("converts records to boards and back", func() {
+		r, err := epd.Parse("k4/5/5/5/4K b - - hmvc 7; fmvn 30;")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.XFEN()).To(Equal(rect.XFEN("k4/5/5/5/4K b - - 7 30")))
+		b, err := r.Board()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b.MoveNumber()).To(Equal(30))
+
+		r = epd.NewRecord(b)
+		Expect(r.Position).To(Equal("k4/5/5/5/4K b - -"))
+		r.Set(epd.ID, "bare kings")
+		r.Set(epd.Eval, "0")
+		r.Set(epd.ID, "kings")
+		Expect(r.String()).To(Equal(`k4/5/5/5/4K b - - id "kings"; ce 0;`))
+		r.Delete(epd.ID)
+		Expect(r.String()).To(Equal(`k4/5/5/5/4K b - - ce 0;`))
+	})
+
+	It("unescapes quoted operands written by records", func() {
+		r, err := epd.Parse(`k4/5/5/5/4K b - - bm Ka2; c0 C:\path;`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Comment()).To(Equal(`C:\path`))
+		r.Set(epd.ID, `the "bare" kings; a\b`)
+		r.Set(epd.Comment, "tab\tand \\q line\n")
+		r.Set(epd.BestMoves, `Kb2"`)
+
+		r1, err := epd.Parse(r.String())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r1).To(Equal(r))
+		Expect(r1.String()).To(Equal(r.String()))
+		Expect(r1.ID()).To(Equal(`the "bare" kings; a\b`))
+
+		r1, err = epd.Parse(`k4/5/5/5/4K b - - id "C:\path \"1\"";`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r1.ID()).To(Equal(`C:\path "1"`))
+	})
+
+	It("reads and writes suites", func() {
+		records, err := epd.Read(strings.NewReader(line + "\n\nk4/5/5/5/4K b - -\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).To(HaveLen(2))
+		buf := &bytes.Buffer{}
+		Expect(epd.Write(buf, records)).To(Succeed())
+		Expect(buf.String()).To(Equal(line + "\nk4/5/5/5/4K b - -\n"))
+
+		_, err = epd.Read(strings.NewReader("k4/5/5/5/4K b - -\nbad\n"))
+		Expect(err).To(MatchError(HavePrefix("line 2:")))
+	})
+
+	Context("runner", func() {
+		suite := `6k1/5ppp/8/8/8/8/8/R5K1 w - - bm Ra8#; id "mate";
+3qk3/8/8/8/8/8/8/3RK3 w - - bm Rxd8+; id "queen";
+3qk3/8/8/8/8/8/8/3RK3 w - - am Rd1xd8; id "avoid";
+3qk3/8/8/8/8/8/8/3RK3 w - - id "no solution";
+`
+		It("runs an engine against a suite", func() {
+			records, err := epd.Read(strings.NewReader(suite))
+			Expect(err).NotTo(HaveOccurred())
+			report := epd.Run(tournament.NewMaterialEngine(1), records, time.Second)
+			Expect(report.Results).To(HaveLen(4))
+			Expect(report.Solved).To(Equal(2))
+
+			solved, moves := []bool{}, []string{}
+			for _, res := range report.Results {
+				solved, moves = append(solved, res.Solved), append(moves, res.Move)
+			}
+			Expect(solved).To(Equal([]bool{true, true, false, false}))
+			Expect(moves).To(Equal([]string{"Ra8#", "Rxd8+", "Rxd8+", ""}))
+			Expect(report.Results[3].Err).To(Equal(epd.ErrNoSolution))
+
+			buf := &bytes.Buffer{}
+			Expect(report.Write(buf)).To(Succeed())
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			Expect(lines).To(HaveLen(5))
+			Expect(strings.Fields(lines[0])).To(Equal([]string{"mate", "ok", "Ra8#", "bm", "Ra8#"}))
+			Expect(strings.Fields(lines[2])).To(Equal([]string{"avoid", "fail", "Rxd8+", "am", "Rd1xd8"}))
+			Expect(lines[4]).To(HavePrefix("solved 2 of 4 (50.0%) in "))
+		})
+
+		It("reports engine errors", func() {
+			records, err := epd.Read(strings.NewReader(suite))
+			Expect(err).NotTo(HaveOccurred())
+			report := epd.Run(failingEngine{}, records[:1], time.Second)
+			Expect(report.Solved).To(BeZero())
+			Expect(report.Results[0].Err).To(Equal(tournament.ErrTimeout))
+		})
+	})
+})
@@ -0,0 +1,133 @@
+package epd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mtfelian/mtfchess/rect"
+	"github.com/mtfelian/mtfchess/tournament"
+)
+
+// ErrNoSolution is returned for records of a suite without best moves and moves to avoid
+var ErrNoSolution = errors.New("record has neither best moves nor moves to avoid")
+
+// Result is a result of an engine on a record of a suite
+type Result struct {
+	Record   *Record
+	Move     string // a move of an engine in short algebraic notation, it's empty on errors
+	Score    int    // a score reported by an engine, it's valid if HasScore is true
+	HasScore bool
+	Solved   bool // true if a move is one of best moves and none of moves to avoid
+	Time     time.Duration
+	Err      error
+}
+
+// Report is a result of an engine on a suite
+type Report struct {
+	Results []Result
+	Solved  int
+	Time    time.Duration // a total time of an engine
+}
+
+// Run runs engine on records of a suite giving it limit to move in each position, moves are
+// in short algebraic notation. Errors of an engine are reported in results and don't stop a run.
+func Run(engine tournament.Engine, records []*Record, limit time.Duration) *Report {
+	report := &Report{Results: []Result{}}
+	for _, r := range records {
+		res := run(engine, r, limit)
+		if res.Solved {
+			report.Solved++
+		}
+		report.Time += res.Time
+		report.Results = append(report.Results, res)
+	}
+	return report
+}
+
+// run runs engine on a record
+func run(engine tournament.Engine, r *Record, limit time.Duration) Result {
+	res := Result{Record: r}
+	best, avoid := r.BestMoves(), r.AvoidMoves()
+	if len(best) == 0 && len(avoid) == 0 {
+		res.Err = ErrNoSolution
+		return res
+	}
+	b, err := r.Board()
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	start := time.Now()
+	m, err := engine.Move(b.Copy(), rect.NewShortAlgebraicNotation(), limit)
+	res.Time = time.Since(start)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.Move, res.Score, res.HasScore = m.Move, m.Score, m.HasScore
+	res.Solved = (len(best) == 0 || contains(b, best, m.Move)) && !contains(b, avoid, m.Move)
+	return res
+}
+
+// longMove returns a move in short algebraic notation on b converted to long algebraic notation
+// without check and annotation suffixes, or the move itself if it can't be converted
+func longMove(b *rect.Board, move string) string {
+	move = strings.Replace(strings.TrimRight(move, "+#!?"), "0-0", "O-O", -1)
+	long, err := rect.NewShortAlgebraicNotation().LongMove(b, move)
+	if err != nil {
+		return move
+	}
+	return long
+}
+
+// contains returns true if moves on b contain move, moves are compared as moves of pieces
+// so suffixes and disambiguation don't matter
+func contains(b *rect.Board, moves []string, move string) bool {
+	long := longMove(b, move)
+	for _, m := range moves {
+		if longMove(b, m) == long {
+			return true
+		}
+	}
+	return false
+}
+
+// Write writes a result of each record: its identifier or a number if it has no identifier, "ok" or "fail",
+// a move of an engine or an error and expected moves, followed by a summary
+func (r *Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, res := range r.Results {
+		id := res.Record.ID()
+		if id == "" {
+			id = fmt.Sprint(i + 1)
+		}
+		status, move := "fail", res.Move
+		if res.Solved {
+			status = "ok"
+		}
+		if res.Err != nil {
+			move = res.Err.Error()
+		}
+		expected := []string{}
+		if best := res.Record.BestMoves(); len(best) > 0 {
+			expected = append(expected, "bm "+strings.Join(best, " "))
+		}
+		if avoid := res.Record.AvoidMoves(); len(avoid) > 0 {
+			expected = append(expected, "am "+strings.Join(avoid, " "))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", id, status, move, strings.Join(expected, "; "))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	percent := 0.0
+	if len(r.Results) > 0 {
+		percent = 100 * float64(r.Solved) / float64(len(r.Results))
+	}
+	_, err := fmt.Fprintf(w, "solved %d of %d (%.1f%%) in %v\n", r.Solved, len(r.Results), percent, r.Time)
+	return err
+}
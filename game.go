@@ -1 +1,220 @@
+// Package mtfchess is a chess board engine for non-standard chess on non-standard boards.
+// Boards are implemented in packages rect, hex and cube, Game makes any of them safe for concurrent use.
 package mtfchess
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+var (
+	// ErrFinished is returned on an action in a finished game
+	ErrFinished = errors.New("game is finished")
+	// ErrIllegalMove is returned when a move can't be made
+	ErrIllegalMove = errors.New("illegal move")
+	// ErrNotSerializable is returned on marshaling a game with a board which can't be marshaled to JSON
+	ErrNotSerializable = errors.New("board can't be marshaled to JSON")
+)
+
+// JSONVersion is a version of the game JSON schema, it's increased on incompatible schema changes
+const JSONVersion = 1
+
+// gameJSON is a JSON representation of a game
+type gameJSON struct {
+	Version int             `json:"version"`
+	Board   json.RawMessage `json:"board"`
+	History []string        `json:"history"`
+}
+
+// EventKind is a kind of game event
+type EventKind int
+
+const (
+	MoveEvent   EventKind = iota // a move was made
+	ResignEvent                  // a side resigned
+	DrawEvent                    // a draw was agreed
+	FinishEvent                  // a game was finished, it follows an event which finished the game
+)
+
+// Event is a game change
+type Event struct {
+	Kind    EventKind
+	Move    string // a move for MoveEvent
+	Colour  Colour // a resigned side for ResignEvent
+	Ply     int    // amount of moves made in the game
+	Outcome base.Outcome
+}
+
+// Game is a game safe for concurrent use: it owns a board and a history of moves, serializes moves
+// and provides snapshots of a board to readers
+type Game struct {
+	mu          sync.RWMutex
+	board       base.IBoard
+	history     []string
+	subscribers map[chan Event]bool
+}
+
+// NewGame returns a game started from a copy of board, so later changes of board don't affect the game
+func NewGame(board base.IBoard) *Game {
+	return &Game{board: snapshot(board), history: []string{}, subscribers: make(map[chan Event]bool)}
+}
+
+// snapshot returns a deep copy of board having its own copy of settings
+func snapshot(board base.IBoard) base.IBoard {
+	b := board.Copy()
+	b.SetSettings(board.Settings().Copy())
+	return b
+}
+
+// Snapshot returns a deep copy of the game board, it may be read and changed without affecting the game
+func (g *Game) Snapshot() base.IBoard {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return snapshot(g.board)
+}
+
+// History returns moves made in the game
+func (g *Game) History() []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return append([]string{}, g.history...)
+}
+
+// Outcome returns the game outcome
+func (g *Game) Outcome() base.Outcome {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.board.Outcome()
+}
+
+// SideToMove returns a colour of the side to move
+func (g *Game) SideToMove() Colour {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.board.SideToMove()
+}
+
+// Move decodes a move in notation and makes it
+func (g *Game) Move(notation base.INotation, move string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.board.Outcome().IsFinished() {
+		return ErrFinished
+	}
+	makeMove, err := notation.DecodeMove(g.board, move)
+	if err != nil || !makeMove() {
+		return ErrIllegalMove
+	}
+	g.history = append(g.history, move)
+	g.emit(Event{Kind: MoveEvent, Move: move})
+	return nil
+}
+
+// Resign resigns the side of colour
+func (g *Game) Resign(colour Colour) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.board.Outcome().IsFinished() {
+		return ErrFinished
+	}
+	g.board.Resign(colour)
+	g.emit(Event{Kind: ResignEvent, Colour: colour})
+	return nil
+}
+
+// AgreeDraw finishes the game by a draw agreed by all sides
+func (g *Game) AgreeDraw() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.board.Outcome().IsFinished() {
+		return ErrFinished
+	}
+	g.board.AgreeDraw()
+	g.emit(Event{Kind: DrawEvent})
+	return nil
+}
+
+// Subscribe returns a channel of game events with the given buffer size and a func to unsubscribe.
+// A subscriber which doesn't receive events in time is unsubscribed when its buffer is full,
+// a channel of an unsubscribed subscriber is closed.
+func (g *Game) Subscribe(buffer int) (<-chan Event, func()) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	events := make(chan Event, buffer)
+	g.subscribers[events] = true
+	return events, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.unsubscribe(events)
+	}
+}
+
+// unsubscribe removes a subscriber and closes its channel, it should be called under the lock
+func (g *Game) unsubscribe(events chan Event) {
+	if g.subscribers[events] {
+		delete(g.subscribers, events)
+		close(events)
+	}
+}
+
+// emit sends an event to subscribers, it's followed by FinishEvent if the game is finished.
+// It should be called under the lock.
+func (g *Game) emit(event Event) {
+	event.Ply, event.Outcome = len(g.history), g.board.Outcome()
+	events := []Event{event}
+	if event.Outcome.IsFinished() {
+		events = append(events, Event{Kind: FinishEvent, Ply: event.Ply, Outcome: event.Outcome})
+	}
+subscribers:
+	for subscriber := range g.subscribers {
+		for _, e := range events {
+			select {
+			case subscriber <- e:
+			default:
+				g.unsubscribe(subscriber)
+				continue subscribers
+			}
+		}
+	}
+}
+
+// MarshalJSON makes Game to implement json.Marshaler, the game board should implement json.Marshaler
+func (g *Game) MarshalJSON() ([]byte, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if _, ok := g.board.(json.Marshaler); !ok {
+		return nil, ErrNotSerializable
+	}
+	board, err := json.Marshal(g.board)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(gameJSON{Version: JSONVersion, Board: board, History: g.history})
+}
+
+// UnmarshalGame returns a game read from JSON written by Game.MarshalJSON. A board is read into board
+// which should implement json.Unmarshaler, like &rect.Board{}.
+func UnmarshalGame(data []byte, board base.IBoard) (*Game, error) {
+	var v gameJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	if v.Version != JSONVersion {
+		return nil, fmt.Errorf("unsupported game JSON version %d", v.Version)
+	}
+	if _, ok := board.(json.Unmarshaler); !ok {
+		return nil, ErrNotSerializable
+	}
+	if err := json.Unmarshal(v.Board, board); err != nil {
+		return nil, err
+	}
+	if v.History == nil {
+		v.History = []string{}
+	}
+	return &Game{board: board, history: v.History, subscribers: make(map[chan Event]bool)}, nil
+}
@@ -0,0 +1,120 @@
+package mtfchess_test
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/mtfelian/mtfchess"
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	"github.com/mtfelian/mtfchess/rect"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Game", func() {
+	var (
+		board base.IBoard
+		game  *mtfchess.Game
+	)
+
+	BeforeEach(func() {
+		var err error
+		board, err = rect.NewStandardChessStartingPosition().Board()
+		Expect(err).NotTo(HaveOccurred())
+		game = mtfchess.NewGame(board)
+	})
+
+	It("makes moves and keeps history", func() {
+		Expect(game.Move(rect.NewLongAlgebraicNotation(), "e2-e4")).To(Succeed())
+		Expect(game.Move(rect.NewLongAlgebraicNotation(), "e2-e4")).To(Equal(mtfchess.ErrIllegalMove))
+		Expect(game.Move(rect.NewLongAlgebraicNotation(), "bad")).To(Equal(mtfchess.ErrIllegalMove))
+		Expect(game.History()).To(Equal([]string{"e2-e4"}))
+		Expect(game.SideToMove()).To(Equal(Black))
+
+		Expect(game.Resign(Black)).To(Succeed())
+		Expect(game.Outcome()).To(Equal(base.NewResignation(Black)))
+		Expect(game.Move(rect.NewLongAlgebraicNotation(), "e7-e5")).To(Equal(mtfchess.ErrFinished))
+		Expect(game.AgreeDraw()).To(Equal(mtfchess.ErrFinished))
+	})
+
+	It("isolates a game from its initial board and from snapshots", func() {
+		board.Empty(rect.Coord{X: 5, Y: 1})
+		snapshot := game.Snapshot()
+		Expect(snapshot.Piece(rect.Coord{X: 5, Y: 1})).NotTo(BeNil())
+
+		snapshot.Empty(rect.Coord{X: 4, Y: 1})
+		snapshot.Settings().MoveOrder = false
+		Expect(game.Snapshot().Piece(rect.Coord{X: 4, Y: 1})).NotTo(BeNil())
+		Expect(game.Snapshot().Settings().MoveOrder).To(BeTrue())
+	})
+
+	It("emits events to subscribers", func() {
+		events, unsubscribe := game.Subscribe(10)
+		Expect(game.Move(rect.NewLongAlgebraicNotation(), "f2-f3")).To(Succeed())
+		Expect(game.Move(rect.NewLongAlgebraicNotation(), "e7-e5")).To(Succeed())
+		Expect(game.Move(rect.NewLongAlgebraicNotation(), "g2-g4")).To(Succeed())
+		Expect(game.Move(rect.NewLongAlgebraicNotation(), "d8-h4")).To(Succeed())
+
+		Expect(<-events).To(Equal(mtfchess.Event{Kind: mtfchess.MoveEvent, Move: "f2-f3", Ply: 1,
+			Outcome: base.NewOutcomeNotCompleted()}))
+		Expect((<-events).Move).To(Equal("e7-e5"))
+		Expect((<-events).Move).To(Equal("g2-g4"))
+		Expect(<-events).To(Equal(mtfchess.Event{Kind: mtfchess.MoveEvent, Move: "d8-h4", Ply: 4,
+			Outcome: base.NewCheckmate(Black)}))
+		Expect(<-events).To(Equal(mtfchess.Event{Kind: mtfchess.FinishEvent, Ply: 4,
+			Outcome: base.NewCheckmate(Black)}))
+
+		unsubscribe()
+		unsubscribe()
+		_, open := <-events
+		Expect(open).To(BeFalse())
+	})
+
+	It("unsubscribes slow subscribers", func() {
+		events, _ := game.Subscribe(1)
+		Expect(game.Move(rect.NewLongAlgebraicNotation(), "e2-e4")).To(Succeed())
+		Expect(game.Move(rect.NewLongAlgebraicNotation(), "e7-e5")).To(Succeed())
+		Expect((<-events).Move).To(Equal("e2-e4"))
+		_, open := <-events
+		Expect(open).To(BeFalse())
+	})
+
+	It("serializes concurrent moves and snapshots", func() {
+		moves := []string{"a2-a3", "b2-b3", "c2-c3", "d2-d3", "e2-e3", "f2-f3", "g2-g3", "h2-h3"}
+		var wg sync.WaitGroup
+		for _, move := range moves {
+			wg.Add(2)
+			go func(move string) {
+				defer wg.Done()
+				game.Move(rect.NewLongAlgebraicNotation(), move)
+			}(move)
+			go func() {
+				defer wg.Done()
+				game.Snapshot().LegalMoves(rect.NewLongAlgebraicNotation())
+			}()
+		}
+		wg.Wait()
+		Expect(game.History()).To(HaveLen(1), "only one white move is legal before black moves")
+		Expect(game.SideToMove()).To(Equal(Black))
+	})
+
+	It("is restored from JSON", func() {
+		for _, move := range []string{"e2-e4", "e7-e5", "g1-f3"} {
+			Expect(game.Move(rect.NewLongAlgebraicNotation(), move)).To(Succeed())
+		}
+		data, err := json.Marshal(game)
+		Expect(err).NotTo(HaveOccurred())
+
+		restored, err := mtfchess.UnmarshalGame(data, &rect.Board{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(restored.History()).To(Equal(game.History()))
+		Expect(restored.Snapshot().Equals(game.Snapshot())).To(BeTrue())
+		Expect(restored.Move(rect.NewLongAlgebraicNotation(), "b8-c6")).To(Succeed())
+		Expect(restored.SideToMove()).To(Equal(White))
+		Expect(game.SideToMove()).To(Equal(Black))
+
+		_, err = mtfchess.UnmarshalGame([]byte(`{"version":2}`), &rect.Board{})
+		Expect(err).To(HaveOccurred())
+	})
+})
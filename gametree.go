@@ -0,0 +1,304 @@
+package mtfchess
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mtfelian/mtfchess/base"
+	"github.com/mtfelian/mtfchess/pgn"
+)
+
+// ErrNotInTree is returned for nodes which don't belong to a game tree
+var ErrNotInTree = errors.New("node is not in the game tree")
+
+// Node is a node of a game tree. The root has no move, other nodes have a move made after the move
+// of the parent node. The first child continues the main line, other children are alternative variations.
+type Node struct {
+	Move          string // a move in notation of the tree
+	CommentBefore string // a comment before the move
+	Comment       string // a comment after the move, for the root it's a comment before the first move
+	NAGs          []int  // numeric annotation glyphs of the move, like 1 for "!" and 2 for "?"
+
+	// Clock is a remaining time of a side after the move, it's valid if HasClock is true
+	Clock    time.Duration
+	HasClock bool
+
+	parent   *Node
+	children []*Node
+}
+
+// Parent returns a parent node, it's nil for the root
+func (n *Node) Parent() *Node { return n.parent }
+
+// Children returns the main line continuation followed by alternative variations
+func (n *Node) Children() []*Node { return append([]*Node{}, n.children...) }
+
+// Next returns the main line continuation, it's nil if there are no moves after the node
+func (n *Node) Next() *Node {
+	if len(n.children) == 0 {
+		return nil
+	}
+	return n.children[0]
+}
+
+// Ply returns a number of moves made from the tree start to the node
+func (n *Node) Ply() int {
+	ply := 0
+	for ; n.parent != nil; n = n.parent {
+		ply++
+	}
+	return ply
+}
+
+// IsMainLine returns true if the node is on the main line of the tree
+func (n *Node) IsMainLine() bool {
+	for ; n.parent != nil; n = n.parent {
+		if n.parent.children[0] != n {
+			return false
+		}
+	}
+	return true
+}
+
+// path returns nodes from the first move to n
+func (n *Node) path() []*Node {
+	path := []*Node{}
+	for ; n.parent != nil; n = n.parent {
+		path = append([]*Node{n}, path...)
+	}
+	return path
+}
+
+// index returns an index of the node among its siblings
+func (n *Node) index() int {
+	for i, sibling := range n.parent.children {
+		if sibling == n {
+			return i
+		}
+	}
+	return -1
+}
+
+// GameTree is a game with alternative variations, comments and annotations of moves. It keeps a current node
+// and a board with a position after its move. A game tree is not safe for concurrent use.
+type GameTree struct {
+	start    base.IBoard // a starting position
+	notation base.INotation
+	root     *Node
+	current  *Node
+	board    base.IBoard // a position at the current node
+}
+
+// NewGameTree returns an empty game tree started from a copy of board, moves are written in notation
+func NewGameTree(board base.IBoard, notation base.INotation) *GameTree {
+	root := &Node{}
+	return &GameTree{start: snapshot(board), notation: notation, root: root, current: root, board: snapshot(board)}
+}
+
+// Root returns the root of the tree
+func (t *GameTree) Root() *Node { return t.root }
+
+// Current returns the current node
+func (t *GameTree) Current() *Node { return t.current }
+
+// Board returns a copy of a board with a position at the current node
+func (t *GameTree) Board() base.IBoard { return snapshot(t.board) }
+
+// MainLine returns nodes of the main line from the first move
+func (t *GameTree) MainLine() []*Node {
+	line := []*Node{}
+	for n := t.root.Next(); n != nil; n = n.Next() {
+		line = append(line, n)
+	}
+	return line
+}
+
+// sameMove returns true if moves a and b differ only by check and annotation suffixes
+func sameMove(a, b string) bool { return strings.TrimRight(a, "+#!?") == strings.TrimRight(b, "+#!?") }
+
+// Move makes a move at the current node and goes to its node. If the current node already has a child
+// with this move it's used, otherwise a new child is added: it continues the main line if there are no
+// children yet, otherwise it's a new variation.
+func (t *GameTree) Move(move string) (*Node, error) {
+	for _, child := range t.current.children {
+		if sameMove(child.Move, move) {
+			t.advance(child)
+			return child, nil
+		}
+	}
+	if t.board.Outcome().IsFinished() {
+		return nil, ErrFinished
+	}
+	makeMove, err := t.notation.DecodeMove(t.board, move)
+	if err != nil || !makeMove() {
+		t.board = t.replay(t.current.path())
+		return nil, ErrIllegalMove
+	}
+	node := &Node{Move: move, parent: t.current}
+	t.current.children = append(t.current.children, node)
+	t.current = node
+	return node, nil
+}
+
+// replay returns a board with moves of nodes of path made from the starting position
+func (t *GameTree) replay(path []*Node) base.IBoard {
+	b := snapshot(t.start)
+	for _, n := range path {
+		if makeMove, err := t.notation.DecodeMove(b, n.Move); err != nil || !makeMove() {
+			panic(fmt.Sprintf("move %s of a game tree can't be made", n.Move))
+		}
+	}
+	return b
+}
+
+// contains returns true if n belongs to the tree
+func (t *GameTree) contains(n *Node) bool {
+	for ; n.parent != nil; n = n.parent {
+		if n.index() < 0 {
+			return false
+		}
+	}
+	return n == t.root
+}
+
+// GoTo makes n the current node reproducing a position after its move
+func (t *GameTree) GoTo(n *Node) error {
+	if !t.contains(n) {
+		return ErrNotInTree
+	}
+	t.board, t.current = t.replay(n.path()), n
+	return nil
+}
+
+// Forward goes to the main line continuation of the current node, it returns false if there are no moves
+func (t *GameTree) Forward() bool {
+	next := t.current.Next()
+	if next == nil {
+		return false
+	}
+	t.advance(next)
+	return true
+}
+
+// advance makes a move of a child n of the current node and makes n current
+func (t *GameTree) advance(n *Node) {
+	makeMove, err := t.notation.DecodeMove(t.board, n.Move)
+	if err != nil || !makeMove() {
+		panic(fmt.Sprintf("move %s of a game tree can't be made", n.Move))
+	}
+	t.current = n
+}
+
+// Back goes to the parent of the current node, it returns false at the root
+func (t *GameTree) Back() bool {
+	if t.current == t.root {
+		return false
+	}
+	t.GoTo(t.current.parent)
+	return true
+}
+
+// Start goes to the root
+func (t *GameTree) Start() { t.GoTo(t.root) }
+
+// End goes to the last move of the main line continuation of the current node
+func (t *GameTree) End() {
+	for t.Forward() {
+	}
+}
+
+// PromoteVariation moves the variation started by n one place up among alternatives,
+// the first alternative becomes the main line continuation
+func (t *GameTree) PromoteVariation(n *Node) error {
+	if !t.contains(n) || n == t.root {
+		return ErrNotInTree
+	}
+	i := n.index()
+	if i == 0 {
+		return fmt.Errorf("%s is the main continuation already", n.Move)
+	}
+	n.parent.children[i-1], n.parent.children[i] = n.parent.children[i], n.parent.children[i-1]
+	return nil
+}
+
+// DemoteVariation moves the variation started by n one place down among alternatives
+func (t *GameTree) DemoteVariation(n *Node) error {
+	if !t.contains(n) || n == t.root {
+		return ErrNotInTree
+	}
+	i := n.index()
+	if i == len(n.parent.children)-1 {
+		return fmt.Errorf("%s is the last alternative already", n.Move)
+	}
+	n.parent.children[i+1], n.parent.children[i] = n.parent.children[i], n.parent.children[i+1]
+	return nil
+}
+
+// DeleteVariation deletes n with all following moves. If the current node is deleted the parent of n
+// becomes current.
+func (t *GameTree) DeleteVariation(n *Node) error {
+	if !t.contains(n) || n == t.root {
+		return ErrNotInTree
+	}
+	for c := t.current; c != nil; c = c.parent {
+		if c == n {
+			t.GoTo(n.parent)
+			break
+		}
+	}
+	i := n.index()
+	n.parent.children = append(n.parent.children[:i], n.parent.children[i+1:]...)
+	n.parent = nil
+	return nil
+}
+
+// NewGameTreeFromPGN returns a game tree of a PGN game started from a copy of board,
+// moves are decoded in notation which should be a short algebraic notation
+func NewGameTreeFromPGN(g *pgn.Game, board base.IBoard, notation base.INotation) (*GameTree, error) {
+	t := NewGameTree(board, notation)
+	t.root.Comment = g.Tree.Comment
+	var add func(parent *pgn.Node) error
+	add = func(parent *pgn.Node) error {
+		board, current := t.board.Copy(), t.current
+		for _, child := range parent.Children {
+			n, err := t.Move(child.Move)
+			if err != nil {
+				return fmt.Errorf("move %d %s: %v", current.Ply()+1, child.Move, err)
+			}
+			n.CommentBefore, n.NAGs = child.CommentBefore, append([]int{}, child.NAGs...)
+			n.Clock, n.Comment, n.HasClock = pgn.ParseClock(child.Comment)
+			if err := add(child); err != nil {
+				return err
+			}
+			t.board, t.current = board.Copy(), current
+		}
+		return nil
+	}
+	if err := add(g.Tree); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// PGN returns a PGN game of the tree with tags and a result
+func (t *GameTree) PGN(tags []pgn.Tag, result string) *pgn.Game {
+	var convert func(n *Node) *pgn.Node
+	convert = func(n *Node) *pgn.Node {
+		res := &pgn.Node{Move: n.Move, NAGs: append([]int{}, n.NAGs...), CommentBefore: n.CommentBefore,
+			Comment: n.Comment}
+		if n.HasClock {
+			res.Comment = strings.TrimSpace(pgn.FormatClock(n.Clock) + " " + n.Comment)
+		}
+		for _, child := range n.children {
+			res.Children = append(res.Children, convert(child))
+		}
+		return res
+	}
+	g := &pgn.Game{Tags: append([]pgn.Tag{}, tags...), Tree: convert(t.root), Result: result}
+	for n := t.root.Next(); n != nil; n = n.Next() {
+		g.Moves = append(g.Moves, n.Move)
+	}
+	return g
+}
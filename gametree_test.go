@@ -0,0 +1,154 @@
+package mtfchess_test
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"github.com/mtfelian/mtfchess"
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	"github.com/mtfelian/mtfchess/pgn"
+	"github.com/mtfelian/mtfchess/rect"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GameTree", func() {
+	var (
+		board base.IBoard
+		tree  *mtfchess.GameTree
+	)
+
+	// moves returns moves of nodes
+	moves := func(nodes []*mtfchess.Node) []string {
+		res := []string{}
+		for _, n := range nodes {
+			res = append(res, n.Move)
+		}
+		return res
+	}
+
+	// play makes moves in the tree
+	play := func(moves ...string) *mtfchess.Node {
+		var n *mtfchess.Node
+		for _, move := range moves {
+			var err error
+			n, err = tree.Move(move)
+			Expect(err).NotTo(HaveOccurred(), move)
+		}
+		return n
+	}
+
+	BeforeEach(func() {
+		var err error
+		board, err = rect.NewStandardChessStartingPosition().Board()
+		Expect(err).NotTo(HaveOccurred())
+		tree = mtfchess.NewGameTree(board, rect.NewShortAlgebraicNotation())
+	})
+
+	It("adds variations and navigates", func() {
+		nf3 := play("e4", "e5", "Nf3")
+		Expect(tree.Back()).To(BeTrue())
+		Expect(tree.Back()).To(BeTrue())
+		c5 := play("c5")
+		Expect(tree.Current()).To(Equal(c5))
+		Expect(c5.IsMainLine()).To(BeFalse())
+		Expect(c5.Ply()).To(Equal(2))
+		Expect(tree.Board().SideToMove()).To(Equal(White))
+
+		e4 := tree.Root().Next()
+		Expect(moves(e4.Children())).To(Equal([]string{"e5", "c5"}))
+		Expect(moves(tree.MainLine())).To(Equal([]string{"e4", "e5", "Nf3"}))
+		Expect(nf3.Parent().Parent()).To(Equal(e4))
+
+		By("reusing existing moves")
+		Expect(tree.Back()).To(BeTrue())
+		n, err := tree.Move("e5")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(e4.Next()))
+		Expect(e4.Children()).To(HaveLen(2))
+
+		By("jumping to nodes")
+		Expect(tree.GoTo(c5)).To(Succeed())
+		Expect(tree.Board().Piece(rect.Coord{X: 3, Y: 5})).NotTo(BeNil())
+		Expect(tree.Board().Piece(rect.Coord{X: 5, Y: 5})).To(BeNil())
+
+		tree.Start()
+		Expect(tree.Current()).To(Equal(tree.Root()))
+		Expect(tree.Back()).To(BeFalse())
+		Expect(tree.Board().Equals(board)).To(BeTrue())
+		tree.End()
+		Expect(tree.Current().Move).To(Equal("Nf3"))
+		Expect(tree.Forward()).To(BeFalse())
+		Expect(tree.Board().SideToMove()).To(Equal(Black))
+	})
+
+	It("rejects illegal moves", func() {
+		play("e4")
+		_, err := tree.Move("e4")
+		Expect(err).To(Equal(mtfchess.ErrIllegalMove))
+		Expect(tree.Current().Move).To(Equal("e4"))
+		play("e5")
+		Expect(tree.GoTo(&mtfchess.Node{Move: "e4"})).To(Equal(mtfchess.ErrNotInTree))
+	})
+
+	It("promotes, demotes and deletes variations", func() {
+		play("e4", "e5")
+		tree.Back()
+		c5 := play("c5")
+		tree.Back()
+		e6 := play("e6", "d4")
+		e6 = e6.Parent()
+
+		Expect(tree.PromoteVariation(c5)).To(Succeed())
+		Expect(moves(tree.MainLine())).To(Equal([]string{"e4", "c5"}))
+		Expect(tree.PromoteVariation(c5)).NotTo(Succeed())
+		Expect(tree.DemoteVariation(e6)).NotTo(Succeed())
+		Expect(tree.DemoteVariation(c5)).To(Succeed())
+		Expect(moves(tree.Root().Next().Children())).To(Equal([]string{"e5", "c5", "e6"}))
+
+		Expect(tree.DeleteVariation(e6)).To(Succeed())
+		Expect(moves(tree.Root().Next().Children())).To(Equal([]string{"e5", "c5"}))
+		Expect(tree.Current()).To(Equal(tree.Root().Next()), "the deleted current node is replaced by its parent")
+		Expect(tree.Board().SideToMove()).To(Equal(Black))
+		Expect(tree.DeleteVariation(e6)).To(Equal(mtfchess.ErrNotInTree))
+	})
+
+	It("reads and writes PGN", func() {
+		const text = `[Event "Tree"]
+
+{Start} 1. e4 {[%clk 0:05:00] best} e5 (1... c5 $1 2. Nf3 (2. c3) 2... d6) ({French} 1...
+e6 $2) 2. Nf3 {[%clk 0:04:58]} 1-0
+`
+		g, err := pgn.NewReader(strings.NewReader(text)).Read()
+		Expect(err).NotTo(HaveOccurred())
+		tree, err = mtfchess.NewGameTreeFromPGN(g, board, rect.NewShortAlgebraicNotation())
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(tree.Root().Comment).To(Equal("Start"))
+		e4 := tree.Root().Next()
+		Expect(e4.HasClock).To(BeTrue())
+		Expect(e4.Clock).To(Equal(5 * time.Minute))
+		Expect(e4.Comment).To(Equal("best"))
+		Expect(moves(e4.Children())).To(Equal([]string{"e5", "c5", "e6"}))
+		Expect(e4.Children()[1].NAGs).To(Equal([]int{1}))
+		Expect(e4.Children()[2].CommentBefore).To(Equal("French"))
+		Expect(moves(tree.MainLine())).To(Equal([]string{"e4", "e5", "Nf3"}))
+		Expect(tree.Current()).To(Equal(tree.Root()))
+
+		var buf bytes.Buffer
+		Expect(tree.PGN(g.Tags, g.Result).Write(&buf)).To(Succeed())
+		Expect(buf.String()).To(Equal(`[Event "Tree"]
+
+{Start} 1. e4 {[%clk 0:05:00] best} 1... e5 (1... c5 $1 2. Nf3 (2. c3) 2... d6)
+({French} 1... e6 $2) 2. Nf3 {[%clk 0:04:58]} 1-0
+`))
+
+		By("reporting illegal moves")
+		g, err = pgn.NewReader(strings.NewReader(`1. e4 e5 (1... e4) 1-0`)).Read()
+		Expect(err).NotTo(HaveOccurred())
+		_, err = mtfchess.NewGameTreeFromPGN(g, board, rect.NewShortAlgebraicNotation())
+		Expect(err).To(MatchError(ContainSubstring("move 2 e4")))
+	})
+})
@@ -0,0 +1,189 @@
+package hex
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mtfelian/mtfchess/base"
+)
+
+const (
+	longAlgebraic = iota
+)
+
+const (
+	moveDelimiter    = "-"
+	captureDelimiter = "x"
+)
+
+const (
+	noPostfix        = ""
+	checkPostfix     = "+"
+	checkmatePostfix = "#"
+)
+
+const promotionDelimiter = "="
+
+// fileLetters are letters of files from a-side, letter j is skipped like in Glinski's hexagonal chess
+const fileLetters = "abcdefghiklmnopqrstuvwxyz"
+
+var (
+	longAlgebraicCoordRegexp = regexp.MustCompile(`^([a-z])(\d{1,2})$`)
+	longAlgebraicMoveRegexp  = regexp.MustCompile(`^([a-z])?([a-z]\d{1,2})[-x]([a-z]\d{1,2})(?:=?([a-z]))?[+#]?$`)
+)
+
+// algebraicNotation implementation for INotation.
+// Cells are named by a file letter and a rank counted from the lowest cell of the file, like f5.
+type algebraicNotation struct {
+	Coord base.ICoord
+	dim   Coord
+	mode  int
+}
+
+// NewLongAlgebraicNotation returns new long algebraic notation for a board with dimensions dim
+func NewLongAlgebraicNotation(dim base.ICoord) *algebraicNotation {
+	return &algebraicNotation{dim: dim.(Coord), mode: longAlgebraic}
+}
+
+// FromLetter returns a file index (starting from 1 for a-file) from the given letter, or 0 if there is no such file
+func FromLetter(letter rune) int { return strings.IndexRune(fileLetters, letter) + 1 }
+
+// ToLetter returns letter from the given file index
+func ToLetter(file int) rune { return rune(fileLetters[file-1]) }
+
+// SetCoords sets notation coord to
+func (n *algebraicNotation) SetCoord(to base.ICoord) base.INotation {
+	n.Coord = to
+	return n
+}
+
+// DecodeMove returns a func that tries to make a decoded move on a board
+func (n *algebraicNotation) DecodeMove(board base.IBoard, move string) (func() bool, error) {
+	n.dim = board.Dim().(Coord)
+	move, re := strings.ToLower(move), longAlgebraicMoveRegexp.Copy()
+	if !re.MatchString(move) {
+		return nil, fmt.Errorf("wrong move format: %s", move)
+	}
+
+	parts := re.FindStringSubmatch(move)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("wrong move format: %s", move)
+	}
+
+	if err := n.DecodeCoord(parts[2]); err != nil {
+		return nil, err
+	}
+	fromCoord := n.Coord.Copy()
+	if err := n.DecodeCoord(parts[3]); err != nil {
+		return nil, err
+	}
+	toCoord := n.Coord.Copy()
+
+	// isMoving returns true if piece matches the optional piece letter of a move
+	isMoving := func(piece base.IPiece) bool {
+		return piece != nil && (parts[1] == "" || strings.ToLower(string(piece.Capital())) == parts[1])
+	}
+
+	if parts[4] == "" {
+		return func() bool {
+			piece := board.Piece(fromCoord)
+			return isMoving(piece) && board.MakeMove(toCoord, piece)
+		}, nil
+	}
+
+	newPromotion, exists := pieceConstructors[[]rune(parts[4])[0]]
+	if !exists {
+		return nil, fmt.Errorf("wrong promotion piece: %s", parts[4])
+	}
+	return func() bool {
+		piece := board.Piece(fromCoord)
+		if !isMoving(piece) {
+			return false
+		}
+		piece.SetPromote(newPromotion(piece.Colour()))
+		if !board.MakeMove(toCoord, piece) {
+			piece.SetPromote(nil)
+			return false
+		}
+		return true
+	}, nil
+}
+
+// EncodeMove on board with piece to dst coord
+func (n *algebraicNotation) EncodeMove(board base.IBoard, piece base.IPiece, dst base.ICoord) string {
+	anFrom := NewLongAlgebraicNotation(board.Dim()).SetCoord(piece.Coord())
+	anTo := NewLongAlgebraicNotation(board.Dim()).SetCoord(dst)
+	delimiter := moveDelimiter
+	if board.Piece(dst) != nil {
+		delimiter = captureDelimiter
+	}
+
+	fig := string(piece.Capital())
+	if piece.Name() == base.PawnName {
+		fig = ""
+	}
+
+	promotion, projection := "", board.Project(piece, dst)
+	if piece.Promotion() != nil {
+		promotion = promotionDelimiter + string(piece.Promotion().Capital())
+		projection = board.Copy().Empty(piece.Coord()).PlacePiece(dst, piece.Promote())
+	}
+	projection.SetSideToMove(projection.SideToMove().Invert())
+
+	check := noPostfix
+	if projection.InCheckmate(projection.SideToMove()) {
+		check = checkmatePostfix
+	} else if projection.InCheck(projection.SideToMove()) {
+		check = checkPostfix
+	}
+
+	return fig + anFrom.EncodeCoord() + delimiter + anTo.EncodeCoord() + promotion + check
+}
+
+// EncodeCastling on board, castling is not supported in hexagonal chess variants of this package
+func (n *algebraicNotation) EncodeCastling(_ base.IBoard, castling base.Castling) string {
+	return []string{"O-O-O", "O-O"}[castling.I]
+}
+
+// DecodeCoord coord string (case-insensitive) to axial coords
+func (n *algebraicNotation) DecodeCoord(coord string) error {
+	coord = strings.ToLower(coord)
+	re := longAlgebraicCoordRegexp.Copy()
+	if !re.MatchString(coord) {
+		return fmt.Errorf("wrong coord format: %s", coord)
+	}
+
+	parts := re.FindStringSubmatch(coord)
+	if len(parts) != 3 {
+		return fmt.Errorf("wrong coord format: %s", coord)
+	}
+
+	file := FromLetter([]rune(parts[1])[0])
+	rank, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return err
+	}
+
+	qMax, _, _ := bounds(n.dim)
+	q := file - 1 - qMax
+	bottom, top := fileBounds(n.dim, q)
+	if file < 1 || file > n.dim.Q || rank < 1 || bottom+rank-1 > top {
+		return fmt.Errorf("coord is out of board: %s", coord)
+	}
+
+	n.Coord = Coord{Q: q, R: bottom + rank - 1}
+	return nil
+}
+
+// EncodeCoord n.Coord as string
+func (n *algebraicNotation) EncodeCoord() string {
+	if n.Coord == nil {
+		return ""
+	}
+	c := n.Coord.(Coord)
+	qMax, _, _ := bounds(n.dim)
+	bottom, _ := fileBounds(n.dim, c.Q)
+	return fmt.Sprintf("%c%d", ToLetter(c.Q+qMax+1), c.R-bottom+1)
+}
@@ -0,0 +1,60 @@
+package hex_test
+
+import (
+	"github.com/mtfelian/mtfchess/base"
+	"github.com/mtfelian/mtfchess/hex"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Hexagonal algebraic notation test", func() {
+	var b base.IBoard
+	var n base.INotation
+	BeforeEach(func() {
+		var err error
+		b, err = hex.NewGlinskiStartingPosition().Board()
+		Expect(err).NotTo(HaveOccurred())
+		n = hex.NewLongAlgebraicNotation(b.Dim())
+	})
+
+	It("encodes moves", func() {
+		moves := b.LegalMoves(n)
+		Expect(moves).To(ContainElement("f5-f6"))
+		Expect(moves).To(ContainElement("Nh1-i3"))
+		Expect(moves).To(ContainElement("Bf2-b6"))
+		Expect(moves).NotTo(ContainElement("f5-f7"), "f7 is occupied by black pawn")
+	})
+
+	It("decodes and makes moves", func() {
+		for _, move := range []string{"e4-e5", "f7-f6", "e5xf6", "c7-c6"} {
+			By("Checking " + move + "...")
+			f, err := n.DecodeMove(b, move)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(f()).To(BeTrue())
+		}
+		Expect(b.Piece(hex.Coord{0, 0}).Name()).To(Equal(base.PawnName))
+		Expect(b.MoveNumber()).To(Equal(3))
+		Expect(hex.NewFEN(b.(*hex.Board))).To(Equal(
+			hex.FEN(`6/P5p/RP3p1r/N1P3p1n/Q5p2q/BBB1PP2bbb/K2P2p2k/N1P3p1n/RP4pr/P5p/6 w - - 0 3`)))
+	})
+
+	It("decodes moves with piece letters", func() {
+		f, err := n.DecodeMove(b, "Bh1-i3")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f()).To(BeFalse(), "there is a knight on h1, not a bishop")
+		f, err = n.DecodeMove(b, "Nh1-i3")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f()).To(BeTrue())
+	})
+
+	It("checks errors", func() {
+		for _, move := range []string{"f5-j6", "f5", "O-O", "f5-f16"} {
+			By("Checking " + move + "...")
+			_, err := n.DecodeMove(b, move)
+			Expect(err).To(HaveOccurred())
+		}
+		f, err := n.DecodeMove(b, "f5-f7")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f()).To(BeFalse())
+	})
+})
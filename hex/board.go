@@ -0,0 +1,540 @@
+package hex
+
+import (
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	. "github.com/mtfelian/utils"
+)
+
+// Board is a game hexagonal board with vertical files like in Glinski's hexagonal chess
+type Board struct {
+	cells                 Cells
+	files, ranks          int // number of files and number of cells in the central file
+	king                  map[Colour]base.IPiece
+	canCaptureEnPassantAt base.ICoord
+	enPassantTargets      []base.ICoord
+	castlingPartners      base.CastlingPartners
+	settings              *base.Settings
+	sideToMove            Colour
+	moveNumber            int
+	halfMoveCounter       int
+	outcome               base.Outcome
+	positionsCounter      map[string]int // maps string position description (part of FEN) to counter it's occurred
+}
+
+// String makes Board to implement Stringer, it returns a FEN of a position
+func (b *Board) String() string { return string(NewFEN(b)) }
+
+// Dim returns a board dimensions: Q is a number of files and R is a number of cells in the central file
+func (b *Board) Dim() base.ICoord { return Coord{Q: b.files, R: b.ranks} }
+
+// SetSettings of a board to s
+func (b *Board) SetSettings(s *base.Settings) { b.settings = s }
+
+// Settings returns board settings
+func (b *Board) Settings() *base.Settings { return b.settings }
+
+// SetDim sets board dimensions to dim, see Dim()
+func (b *Board) SetDim(dim base.ICoord) { b.files, b.ranks = dim.(Coord).Q, dim.(Coord).R }
+
+// initializeKing initializes board king
+func (b *Board) initializeKing() {
+	if b.king == nil {
+		b.king = map[Colour]base.IPiece{White: nil, Black: nil}
+	}
+}
+
+// initializeCastlingPartners initializes board castling partners
+func (b *Board) initializeCastlingPartners() {
+	if b.castlingPartners == nil {
+		b.castlingPartners = base.NewCastlingPartners()
+	}
+}
+
+// initializePositionsCounter initialized a positions counter
+func (b *Board) initializePositionsCounter() {
+	if b.positionsCounter == nil {
+		b.positionsCounter = make(map[string]int)
+	}
+}
+
+// SetKing sets a board king
+func (b *Board) SetKing(of Colour, to base.IPiece) {
+	b.initializeKing()
+	b.king[of] = to
+}
+
+// SetCanCaptureEnPassantAt sets a piece dst coords which can be captured en passant
+func (b *Board) SetCanCaptureEnPassantAt(dst base.ICoord) { b.canCaptureEnPassantAt = dst }
+
+// CanCaptureEnPassantAt returns a piece dst coords which can be captured en passant
+func (b *Board) CanCaptureEnPassantAt() base.ICoord { return b.canCaptureEnPassantAt }
+
+// SetEnPassantTargets sets coords passed over by a piece which can be captured en passant
+func (b *Board) SetEnPassantTargets(targets []base.ICoord) { b.enPassantTargets = targets }
+
+// EnPassantTargets returns coords passed over by a piece which can be captured en passant,
+// capturing piece should go to one of these coords to capture it
+func (b *Board) EnPassantTargets() []base.ICoord { return b.enPassantTargets }
+
+// copyEnPassantTargets returns a copy of en passant targets
+func (b *Board) copyEnPassantTargets() []base.ICoord {
+	if b.enPassantTargets == nil {
+		return nil
+	}
+	targets := make([]base.ICoord, len(b.enPassantTargets))
+	for i := range b.enPassantTargets {
+		targets[i] = b.enPassantTargets[i].Copy()
+	}
+	return targets
+}
+
+// AddCastlingPartner adds the initial coords of a piece which king of colour can castle with
+func (b *Board) AddCastlingPartner(colour Colour, coord base.ICoord) {
+	if !b.castlingPartners.Contains(colour, coord) {
+		b.castlingPartners[colour] = append(b.castlingPartners[colour], coord.Copy())
+	}
+}
+
+// HaveCastlings returns whether side of colour have castling or not
+func (b *Board) HaveCastlings(colour Colour) bool { return len(b.Castlings(colour)) > 0 }
+
+// CastlingPartners returns initial coords of pieces which king of colour can castle with
+func (b *Board) CastlingPartners(colour Colour) []base.ICoord { return b.castlingPartners[colour] }
+
+// createCells creates cells for the board
+func (b *Board) createCells() {
+	dim, num := b.Dim().(Coord), 0
+	qMax, _, _ := bounds(dim)
+	b.cells = make(Cells, b.files)
+	for i := range b.cells {
+		q := i - qMax
+		bottom, top := fileBounds(dim, q)
+		b.cells[i] = make(File, top-bottom+1)
+		for j := range b.cells[i] {
+			num++
+			b.cells[i][j] = base.NewCell(b, num, Coord{Q: q, R: bottom + j})
+			b.cells[i][j].Empty()
+		}
+	}
+}
+
+// Cell returns a pointer to cell at coords
+func (b *Board) Cell(at base.ICoord) *base.Cell {
+	c, dim := at.(Coord), b.Dim().(Coord)
+	qMax, _, _ := bounds(dim)
+	bottom, _ := fileBounds(dim, c.Q)
+	return &b.cells[c.Q+qMax][c.R-bottom]
+}
+
+// Cells returns a cells slice
+func (b *Board) Cells() base.ICells { return b.cells }
+
+// SetCells sets cells to s
+func (b *Board) SetCells(s base.ICells) { b.cells = s.(Cells) }
+
+// Piece returns a piece at coords
+func (b *Board) Piece(at base.ICoord) base.IPiece { return b.Cell(at).Piece() }
+
+// PlacePiece places piece at coords
+func (b *Board) PlacePiece(to base.ICoord, p base.IPiece) base.IBoard {
+	if to.OutOf(b) {
+		panic("out of board")
+	}
+	p.SetCoords(b, to)
+	b.Cell(to).SetPiece(p)
+	return b
+}
+
+// Empty removes piece at coords
+func (b *Board) Empty(at base.ICoord) base.IBoard {
+	piece := b.Cell(at).Piece()
+	if piece != nil {
+		piece.SetCoords(b, nil)
+	}
+	b.Cell(at).Empty()
+	return b
+}
+
+// King returns a king of specified colour
+func (b *Board) King(of Colour) base.IPiece { return b.king[of] }
+
+// copyKing returns a copy of a board king
+func (b *Board) copyKings() map[Colour]base.IPiece {
+	newKing := map[Colour]base.IPiece{}
+	for colour := range b.king {
+		king := b.King(colour)
+		if king != nil {
+			newKing[colour] = king.Copy()
+		}
+	}
+	return newKing
+}
+
+// copyPositionsCounter returns a deep copy of a positions counter
+func (b *Board) copyPositionsCounter() map[string]int {
+	c := make(map[string]int)
+	for key, value := range b.positionsCounter {
+		c[key] = value
+	}
+	return c
+}
+
+// Copy returns a pointer to a deep copy of a board
+func (b *Board) Copy() base.IBoard {
+	newBoard := &Board{}
+	newBoard.SetCells(b.Cells().Copy(newBoard))
+	newBoard.SetDim(b.Dim())
+	newBoard.king = b.copyKings()
+	newBoard.castlingPartners = b.castlingPartners.Copy()
+	newBoard.SetSettings(b.Settings())
+	newBoard.SetCanCaptureEnPassantAt(b.CanCaptureEnPassantAt())
+	newBoard.SetEnPassantTargets(b.copyEnPassantTargets())
+	newBoard.SetSideToMove(b.SideToMove())
+	newBoard.SetMoveNumber(b.MoveNumber())
+	newBoard.SetHalfMoveCount(b.HalfMoveCount())
+	newBoard.setOutcome(b.Outcome())
+	newBoard.positionsCounter = b.copyPositionsCounter()
+	return newBoard
+}
+
+// Set changes b to b1
+func (b *Board) Set(b1 base.IBoard) { *b = *(b1.(*Board)) }
+
+// Projects returns a copy of board with projected piece copy to given coords
+func (b *Board) Project(piece base.IPiece, to base.ICoord) base.IBoard {
+	return b.Copy().Empty(piece.Coord()).PlacePiece(to, piece.Copy())
+}
+
+// MakeMove makes move with piece to coords
+// It returns true if move successful (legal), otherwise it returns false.
+func (b *Board) MakeMove(to base.ICoord, piece base.IPiece) bool {
+	if b.Outcome().IsFinished() || (b.Settings().MoveOrder && b.SideToMove() != piece.Colour()) || to.OutOf(b) {
+		return false
+	}
+
+	destinations, capturedPiece := piece.Destinations(b), b.Piece(to)
+
+	if !destinations.Contains(to) {
+		return false
+	}
+
+	fromCoords, isPawn := piece.Coord().Copy(), piece.Name() == base.PawnName
+
+	if piece.Promotion() != nil {
+		newPiece := piece.Promote()
+		if !b.Settings().PromotionConditionFunc(b, piece, to, newPiece) {
+			return false
+		}
+		piece = newPiece
+		b.Empty(fromCoords)
+		piece.SetCoords(b, fromCoords)
+	} else if b.Settings().PromotionRules.Kind(b, piece, to) == base.MandatoryPromotion {
+		return false
+	}
+
+	if capturedPiece != nil {
+		capturedPiece.SetCoords(b, nil)
+		b.SetHalfMoveCount(-1) // capture, reset counting: next it will be increased to 0
+	}
+
+	if isPawn {
+		from, dst, epCaptureAt := fromCoords.(Coord), to.(Coord), b.CanCaptureEnPassantAt()
+		if epCaptureAt != nil && capturedPiece == nil && from.Q != dst.Q && NewCoords(b.EnPassantTargets()).Contains(to) {
+			b.Empty(epCaptureAt)
+		}
+		b.SetCanCaptureEnPassantAt(nil)
+		b.SetEnPassantTargets(nil)
+
+		if from.Q == dst.Q && from.Distance(dst) > 1 { // long pawn move
+			b.SetCanCaptureEnPassantAt(to)
+			b.SetEnPassantTargets(passedCoords(from, dst))
+		}
+		b.SetHalfMoveCount(-1) // pawn advance, reset counting: next it will be increased to 0
+	} else {
+		b.SetCanCaptureEnPassantAt(nil)
+		b.SetEnPassantTargets(nil)
+	}
+
+	piece.MarkMoved()
+	b.Set(b.Project(piece, to))
+	// first project (and empty source piece cell, and only then set piece)
+	piece.Set(b.Piece(to)) // set piece to copy of itself on the new board
+
+	b.SetSideToMove(b.SideToMove().Invert())
+	if piece.Colour() == Black {
+		b.SetMoveNumber(b.MoveNumber() + 1)
+	}
+	b.SetHalfMoveCount(b.HalfMoveCount() + 1)
+	b.increasePositionCounter()
+	b.computeOutcome()
+	return true
+}
+
+// Position returns a string position description
+func (b *Board) Position() string { return NewFEN(b).PositionPart() }
+
+// PositionOccurred returns a number of times current position occurred through the game
+func (b *Board) PositionOccurred() int { return b.positionsCounter[b.Position()] }
+
+// increasePositionCounter
+func (b *Board) increasePositionCounter() { b.positionsCounter[b.Position()]++ }
+
+// MakeCastling makes a castling.
+// It returns true if castling successful (legal), otherwise it returns false.
+func (b *Board) MakeCastling(castling base.Castling) bool {
+	if b.Outcome().IsFinished() || (b.Settings().MoveOrder && b.SideToMove() != castling.Piece[0].Colour()) {
+		return false
+	}
+
+	castlings := b.Castlings(castling.Piece[0].Colour())
+	if !castlings.Contains(castling) {
+		return false
+	}
+
+	castling.Piece[0].MarkMoved()
+	castling.Piece[1].MarkMoved()
+
+	kingCopy, rookCopy := b.Piece(castling.Piece[0].Coord()).Copy(), b.Piece(castling.Piece[1].Coord()).Copy()
+	b.Empty(kingCopy.Coord())
+	b.Empty(rookCopy.Coord())
+	b.PlacePiece(castling.To[0], kingCopy)
+	b.PlacePiece(castling.To[1], rookCopy)
+	castling.Piece[0].Set(b.Piece(castling.To[0]))
+	castling.Piece[1].Set(b.Piece(castling.To[1]))
+
+	b.SetSideToMove(b.SideToMove().Invert())
+	if castling.Piece[0].Colour() == Black {
+		b.SetMoveNumber(b.MoveNumber() + 1)
+	}
+	b.SetHalfMoveCount(b.HalfMoveCount() + 1)
+	b.increasePositionCounter()
+	b.computeOutcome()
+	return true
+}
+
+// baseFindPieces finds and returns pieces by base.PieceFilter
+func (b *Board) baseFindPieces(f base.PieceFilter) base.Pieces {
+	pieces := base.Pieces{}
+	for i := range b.cells {
+		for j := range b.cells[i] {
+			p := b.cells[i][j].Piece()
+			if p == nil {
+				continue
+			}
+			if len(f.Colours) > 0 && !SliceContains(p.Colour(), f.Colours) {
+				continue
+			}
+			if len(f.Names) > 0 && !SliceContains(p.Name(), f.Names) {
+				continue
+			}
+			if f.Condition != nil && !f.Condition(p) {
+				continue
+			}
+			pieces = append(pieces, p)
+		}
+	}
+	return pieces
+}
+
+// FindPieces finds and returns pieces by base.PieceFilter or hex.PieceFilter
+func (b *Board) FindPieces(pf base.IPieceFilter) base.Pieces {
+	pieces := base.Pieces{}
+	switch filter := pf.(type) {
+	case base.PieceFilter:
+		return b.baseFindPieces(filter)
+	case PieceFilter:
+		pieces = b.baseFindPieces(filter.PieceFilter)
+	}
+
+	f := pf.(PieceFilter)
+	r := base.Pieces{}
+	for i := range pieces {
+		if len(f.Q) > 0 && !SliceContains(pieces[i].Coord().(Coord).Q, f.Q) {
+			continue
+		}
+		if len(f.R) > 0 && !SliceContains(pieces[i].Coord().(Coord).R, f.R) {
+			continue
+		}
+		r = append(r, pieces[i])
+	}
+	return r
+}
+
+// FindAttackedCellsBy returns a slice of coords of cells attacked by filter of pieces.
+// For ex., call b.FindAttackedCells(White) to get cell coords attacked by white pieces.
+func (b *Board) FindAttackedCellsBy(f base.IPieceFilter) base.ICoords {
+	pieces, pairs := b.FindPieces(f), NewCoords([]base.ICoord{})
+	for i := range pieces {
+		attackedCoords := pieces[i].Attacks(b)
+		for attackedCoords.HasNext() {
+			pair := attackedCoords.Next().(base.ICoord)
+			if !pairs.Contains(pair) {
+				pairs.Add(pair)
+			}
+		}
+	}
+	return pairs
+}
+
+// Equals returns true if two boards are equal
+func (b *Board) Equals(to base.IBoard) bool {
+	b1 := to.(*Board)
+	canEP, canEP1 := b.CanCaptureEnPassantAt(), to.CanCaptureEnPassantAt()
+	if b.files != b1.files || b.ranks != b1.ranks || b.sideToMove != b1.sideToMove ||
+		b.halfMoveCounter != b1.halfMoveCounter || b.moveNumber != b1.moveNumber ||
+		!b.castlingPartners.Equals(b1.castlingPartners) ||
+		((canEP == nil) != (canEP1 == nil)) || (canEP != nil && canEP1 != nil && !canEP.Equals(canEP1)) ||
+		!NewCoords(b.EnPassantTargets()).Equals(NewCoords(b1.EnPassantTargets())) ||
+		!b.Outcome().Equals(b1.Outcome()) {
+		return false
+	}
+	for i := range b.cells {
+		for j := range b.cells[i] {
+			p1, p2 := b.cells[i][j].Piece(), b1.cells[i][j].Piece()
+			if (p1 == nil) != (p2 == nil) {
+				return false
+			}
+			if p1 != nil && p2 != nil && !p1.Equals(p2) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Castlings returns available castlings for colour
+func (b *Board) Castlings(colour Colour) base.Castlings { return b.Settings().CastlingsFunc(b, colour) }
+
+// HasMoves true if side of colour has any moves (except castlings)
+func (b *Board) HasMoves(colour Colour) bool {
+	pieces, c := b.FindPieces(base.PieceFilter{Colours: []Colour{colour}}), 0
+	for i := range pieces {
+		c += pieces[i].Destinations(b).Len()
+	}
+	return c > 0 || b.HaveCastlings(colour)
+}
+
+// InChecks returns true if king of colour is in check
+func (b *Board) InCheck(colour Colour) bool {
+	king := b.King(colour)
+	return king != nil && b.FindAttackedCellsBy(base.PieceFilter{Colours: []Colour{colour.Invert()}}).Contains(king.Coord())
+}
+
+// InCheckmate if king of colour is in check and have no moves
+func (b *Board) InCheckmate(colour Colour) bool { return b.InCheck(colour) && !b.HasMoves(colour) }
+
+// InStalemate if king of colour is not in check and have no moves
+func (b *Board) InStalemate(colour Colour) bool { return !b.InCheck(colour) && !b.HasMoves(colour) }
+
+// MoveNumber returns current move number
+func (b *Board) MoveNumber() int { return b.moveNumber }
+
+// SetMoveNumber sets the current move number to n
+func (b *Board) SetMoveNumber(n int) { b.moveNumber = n }
+
+// HalfMoveCount returns current half-move counter since the last capture or pawn advance
+func (b *Board) HalfMoveCount() int { return b.halfMoveCounter }
+
+// SetHalfMoveCount sets the current half-move counter since the last capture or pawn advance to n
+func (b *Board) SetHalfMoveCount(n int) { b.halfMoveCounter = n }
+
+// Outcome returns the game outcome
+func (b *Board) Outcome() base.Outcome { return b.outcome }
+
+// Resigns the given colour
+func (b *Board) Resign(colour Colour) { b.setOutcome(base.NewResignation(colour)) }
+
+// AgreeDraw finishes the game by a draw agreed by all sides
+func (b *Board) AgreeDraw() { b.setOutcome(base.NewDrawByAgreement()) }
+
+// setOutcome to
+func (b *Board) setOutcome(to base.Outcome) { b.outcome = to }
+
+// computeOutcome computes outcome and sets it
+func (b *Board) computeOutcome() {
+	settings := b.Settings()
+	if !settings.MoveOrder {
+		return
+	}
+
+	sideToMove := b.SideToMove()
+	switch {
+	case b.InCheckmate(sideToMove):
+		b.setOutcome(base.NewCheckmate(sideToMove.Invert()))
+	case b.InStalemate(sideToMove):
+		b.setOutcome(base.NewStalemate())
+	case settings.MovesToDraw > 0 && b.HalfMoveCount()/2 == settings.MovesToDraw:
+		b.setOutcome(base.NewDrawByXMovesRule())
+	case settings.PositionsToDraw > 0 && b.PositionOccurred() >= settings.PositionsToDraw:
+		b.setOutcome(base.NewDrawByXFoldRepetition())
+	}
+}
+
+// LegalMoves returns strings for legal moves
+func (b *Board) LegalMoves(notation base.INotation) []string {
+	sideToMove, res := b.SideToMove(), []string{}
+	pieces := b.FindPieces(base.PieceFilter{Colours: []Colour{sideToMove}})
+	for i := range pieces {
+		dst := pieces[i].Destinations(b)
+		for dst.HasNext() {
+			to := dst.Next().(base.ICoord)
+			kind := b.Settings().PromotionRules.Kind(b, pieces[i], to)
+			if kind != base.MandatoryPromotion {
+				res = append(res, notation.EncodeMove(b, pieces[i], to))
+			}
+			if kind == base.NoPromotion {
+				continue
+			}
+			for _, name := range base.PromotionTargets(b, pieces[i], to) {
+				piece := pieces[i].Copy()
+				piece.SetPromote(NewPieceByName(name, piece.Colour()))
+				if piece.Promotion() != nil && b.Settings().PromotionConditionFunc(b, piece, to, piece.Promote()) {
+					res = append(res, notation.EncodeMove(b, piece, to))
+				}
+			}
+		}
+	}
+
+	castlings := b.Castlings(sideToMove)
+	for i := range castlings {
+		res = append(res, notation.EncodeCastling(b, castlings[i]))
+	}
+
+	return res
+}
+
+// SideToMove returns colour of side to move
+func (b *Board) SideToMove() Colour { return b.sideToMove }
+
+// SetSideToMove to colour
+func (b *Board) SetSideToMove(to Colour) { b.sideToMove = to }
+
+// NewEmptyGlinskiBoard creates new empty 91 cells board for Glinski's hexagonal chess
+func NewEmptyGlinskiBoard() *Board { return NewEmptyBoard(11, 11, GlinskiSettings()) }
+
+// NewEmptyMcCooeyBoard creates new empty 91 cells board for McCooey's hexagonal chess
+func NewEmptyMcCooeyBoard() *Board { return NewEmptyBoard(11, 11, McCooeySettings()) }
+
+// NewEmptyShafranBoard creates new empty 70 cells board for Shafran's hexagonal chess
+func NewEmptyShafranBoard() *Board { return NewEmptyBoard(9, 10, ShafranSettings()) }
+
+// NewEmptyTestBoard creates new empty 37 cells board for tests
+func NewEmptyTestBoard() *Board { return NewEmptyBoard(7, 7, testBoardSettings()) }
+
+// NewEmptyBoard creates new empty hexagonal board with the given number of files and number of cells
+// in the central file. A number of files should be odd.
+func NewEmptyBoard(files, ranks int, settings *base.Settings) *Board {
+	b := &Board{}
+	b.files, b.ranks = files, ranks
+	b.createCells()
+	b.initializeKing()
+	b.initializeCastlingPartners()
+	b.SetSettings(settings)
+	b.SetSideToMove(White)
+	b.SetMoveNumber(1)
+	b.SetHalfMoveCount(0)
+	b.initializePositionsCounter()
+	b.setOutcome(base.NewOutcomeNotCompleted())
+	return b
+}
@@ -0,0 +1,29 @@
+package hex
+
+import (
+	"github.com/mtfelian/mtfchess/base"
+)
+
+// File is a file of cells ordered from the first rank
+type File []base.Cell
+
+// Copy returns a deep copy of file
+func (f File) Copy(board base.IBoard) File {
+	newFile := make(File, len(f))
+	for i := range f {
+		newFile[i] = f[i].Copy(board)
+	}
+	return newFile
+}
+
+// Cells is a slice of files ordered from a-file
+type Cells []File
+
+// Copy returns a deep copy of cells
+func (s Cells) Copy(board base.IBoard) base.ICells {
+	newCells := make(Cells, len(s))
+	for i := range s {
+		newCells[i] = s[i].Copy(board)
+	}
+	return newCells
+}
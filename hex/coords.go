@@ -0,0 +1,136 @@
+package hex
+
+import (
+	"fmt"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+// Coord is an axial hexagonal coordinates of a board with vertical files.
+// Q is a file offset from the central file, it grows to the right (z-side).
+// R grows along a file to the top (black side). The third cube coordinate is -Q-R.
+type Coord struct {
+	Q, R int
+}
+
+// String makes Coord to implement fmt.Stringer
+func (c Coord) String() string {
+	return fmt.Sprintf("(%d,%d)", c.Q, c.R)
+}
+
+// Add adds o to c and returns the sum as a result
+func (c Coord) Add(o base.ICoord) base.ICoord {
+	return Coord{Q: c.Q + o.(Coord).Q, R: c.R + o.(Coord).R}
+}
+
+// OutOf returns true if c is a coords out of board
+func (c Coord) OutOf(b base.IBoard) bool {
+	qMax, rMin, rMax := bounds(b.Dim().(Coord))
+	return c.Q < -qMax || c.Q > qMax || c.R < rMin || c.R > rMax || c.Q+c.R < rMin || c.Q+c.R > rMax
+}
+
+// Equals returns true if c equals c1
+func (c Coord) Equals(to base.ICoord) bool {
+	return c.Q == to.(Coord).Q && c.R == to.(Coord).R
+}
+
+// Copy returns a copy of c
+func (c Coord) Copy() base.ICoord {
+	return Coord{Q: c.Q, R: c.R}
+}
+
+// Distance returns a number of steps between c and to through adjacent cells
+func (c Coord) Distance(to Coord) int {
+	dQ, dR := to.Q-c.Q, to.R-c.R
+	return (abs(dQ) + abs(dR) + abs(dQ+dR)) / 2
+}
+
+// bounds returns bounds of a hexagonal board with dimensions dim, where dim.Q is a number of files
+// and dim.R is a number of cells in the central file. Board cells satisfy -qMax <= Q <= qMax,
+// rMin <= R <= rMax and rMin <= Q+R <= rMax.
+func bounds(dim Coord) (qMax, rMin, rMax int) { return (dim.Q - 1) / 2, -(dim.R / 2), (dim.R - 1) / 2 }
+
+// fileBounds returns R of the lowest and the highest cells of a file q on a board with dimensions dim
+func fileBounds(dim Coord, q int) (bottom, top int) {
+	_, rMin, rMax := bounds(dim)
+	return max(rMin, rMin-q), min(rMax, rMax-q)
+}
+
+// mirror returns coords mirrored by the horizontal axis of a board with dimensions dim:
+// the first rank cell of a file becomes the last one and vice versa
+func mirror(dim Coord, c Coord) Coord {
+	bottom, top := fileBounds(dim, c.Q)
+	return Coord{Q: c.Q, R: bottom + top - c.R}
+}
+
+// stepsToCentre returns a number of steps forward along a file from coords c of a piece of colour
+// to the farthest cell not beyond the centre line of a board with dimensions dim.
+// The centre line is a horizontal line through the centre of a board, it crosses middle cells of odd length files.
+func stepsToCentre(dim Coord, colour Colour, c Coord) int {
+	if colour == Black {
+		c = mirror(dim, c)
+	}
+	_, rMin, rMax := bounds(dim)
+	n := 0
+	for r := c.R + 1; 2*r+c.Q <= rMin+rMax; r++ { // a cell centre is R+Q/2 cells high
+		n++
+	}
+	return n
+}
+
+// passedCoords returns coords passed over by a piece moving in a straight line from one coord to another,
+// coords are ordered from the source to the destination
+func passedCoords(from, to Coord) []base.ICoord {
+	n := from.Distance(to)
+	if n == 0 || (to.Q-from.Q)%n != 0 || (to.R-from.R)%n != 0 {
+		return []base.ICoord{}
+	}
+	dQ, dR := (to.Q-from.Q)/n, (to.R-from.R)/n
+	res := []base.ICoord{}
+	for c := (Coord{from.Q + dQ, from.R + dR}); !c.Equals(to); c = (Coord{c.Q + dQ, c.R + dR}) {
+		res = append(res, c)
+	}
+	return res
+}
+
+// abs returns an absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// min returns a minimal of a and b
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// max returns a maximal of a and b
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// NewCoords returns new hexagonal coordinates
+func NewCoords(c []base.ICoord) Coords {
+	return Coords{Coords: base.NewCoords(c)}
+}
+
+// Coords is a slice of hexagonal coordinates
+type Coords struct {
+	*base.Coords
+}
+
+// Less makes Coords to implement sort.Interface, coords are ordered by files and then by ranks
+func (s Coords) Less(i, j int) bool {
+	siQ, siR := s.Get(i).(Coord).Q, s.Get(i).(Coord).R
+	sjQ, sjR := s.Get(j).(Coord).Q, s.Get(j).(Coord).R
+	return siQ < sjQ || (siQ == sjQ && siR < sjR)
+}
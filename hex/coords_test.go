@@ -0,0 +1,83 @@
+package hex_test
+
+import (
+	"fmt"
+
+	"github.com/mtfelian/mtfchess/base"
+	"github.com/mtfelian/mtfchess/hex"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Coords test", func() {
+	countCells := func(b *hex.Board) int {
+		n := 0
+		for _, file := range b.Cells().(hex.Cells) {
+			n += len(file)
+		}
+		return n
+	}
+
+	It("checks boards shapes", func() {
+		Expect(countCells(hex.NewEmptyGlinskiBoard())).To(Equal(91))
+		Expect(countCells(hex.NewEmptyShafranBoard())).To(Equal(70))
+		Expect(countCells(hex.NewEmptyTestBoard())).To(Equal(37))
+
+		b := hex.NewEmptyGlinskiBoard()
+		for _, c := range []hex.Coord{{0, 0}, {-5, 0}, {-5, 5}, {5, -5}, {0, 5}, {0, -5}, {5, 0}} {
+			Expect(c.OutOf(b)).To(BeFalse(), "%v", c)
+		}
+		for _, c := range []hex.Coord{{-5, -1}, {5, 1}, {6, 0}, {0, 6}, {3, 3}, {-3, -3}} {
+			Expect(c.OutOf(b)).To(BeTrue(), "%v", c)
+		}
+	})
+
+	It("checks distance", func() {
+		Expect(hex.Coord{0, 0}.Distance(hex.Coord{0, 0})).To(Equal(0))
+		Expect(hex.Coord{0, 0}.Distance(hex.Coord{1, 0})).To(Equal(1))
+		Expect(hex.Coord{0, 0}.Distance(hex.Coord{1, 1})).To(Equal(2))
+		Expect(hex.Coord{-5, 0}.Distance(hex.Coord{5, 0})).To(Equal(10))
+		Expect(hex.Coord{-5, 5}.Distance(hex.Coord{5, -5})).To(Equal(10))
+	})
+
+	It("checks coords notation", func() {
+		testCases := []struct {
+			dim   hex.Coord
+			coord hex.Coord
+			s     string
+		}{
+			{hex.Coord{11, 11}, hex.Coord{-5, 0}, "a1"},
+			{hex.Coord{11, 11}, hex.Coord{-5, 5}, "a6"},
+			{hex.Coord{11, 11}, hex.Coord{0, -5}, "f1"},
+			{hex.Coord{11, 11}, hex.Coord{0, 0}, "f6"},
+			{hex.Coord{11, 11}, hex.Coord{0, 5}, "f11"},
+			{hex.Coord{11, 11}, hex.Coord{4, -5}, "k1"},
+			{hex.Coord{11, 11}, hex.Coord{5, 0}, "l6"},
+			{hex.Coord{9, 10}, hex.Coord{-4, -1}, "a1"},
+			{hex.Coord{9, 10}, hex.Coord{0, 4}, "e10"},
+			{hex.Coord{9, 10}, hex.Coord{4, -5}, "i1"},
+		}
+		for i, testCase := range testCases {
+			By(fmt.Sprintf("Checking testCase %v at index %d...", testCase, i))
+			n := hex.NewLongAlgebraicNotation(testCase.dim)
+			Expect(n.SetCoord(testCase.coord).EncodeCoord()).To(Equal(testCase.s))
+			Expect(n.DecodeCoord(testCase.s)).To(Succeed())
+			Expect(n.Coord).To(Equal(testCase.coord))
+		}
+
+		n := hex.NewLongAlgebraicNotation(hex.Coord{11, 11})
+		for _, s := range []string{"j1", "a7", "f12", "l7", "m1", "f0", "ff"} {
+			Expect(n.DecodeCoord(s)).NotTo(Succeed(), s)
+		}
+	})
+
+	It("checks iterations over coords", func() {
+		data := []base.ICoord{hex.Coord{1, 1}, hex.Coord{-2, 1}, hex.Coord{0, 3}}
+		coords, i := hex.NewCoords(data), 0
+		for coords.HasNext() {
+			Expect(coords.Next().(hex.Coord).Equals(data[i])).To(BeTrue(), "not equals on iter %d", i)
+			i++
+		}
+		Expect(i).To(Equal(3))
+	})
+})
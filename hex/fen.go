@@ -0,0 +1,211 @@
+package hex
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+// FEN is a position description for hexagonal boards similar to FEN. Unlike FEN, the position part lists files
+// separated by "/" from a-file, each file lists its cells from the first rank, for example:
+// "6/P5p/RP4pr/N1P3p1n/Q2P2p2q/BBB1P1p1bbb/K2P2p2k/N1P3p1n/RP4pr/P5p/6 w - - 0 1".
+// Next fields are side to move, castling flags (always "-"), en passant target cell, half-moves and move number.
+type FEN string
+
+// NewGlinskiStartingPosition returns a starting position for Glinski's hexagonal chess
+func NewGlinskiStartingPosition() FEN {
+	return FEN(`6/P5p/RP4pr/N1P3p1n/Q2P2p2q/BBB1P1p1bbb/K2P2p2k/N1P3p1n/RP4pr/P5p/6 w - - 0 1`)
+}
+
+// NewMcCooeyStartingPosition returns a starting position for McCooey's hexagonal chess
+func NewMcCooeyStartingPosition() FEN {
+	return FEN(`6/7/P6p/RP5pr/QNP4pnq/BBBP3pbbb/KNP4pnk/RP5pr/P6p/7/6 w - - 0 1`)
+}
+
+// NewShafranStartingPosition returns a starting position for Shafran's hexagonal chess on 70 cells board
+func NewShafranStartingPosition() FEN {
+	return FEN(`P4p/P5p/RP4pr/QNP3pnq/BBBP2pbbb/KNP3pnk/RP4pr/P5p/P4p w - - 0 1`)
+}
+
+// parseFile parses a file of a position part, returns pieces by cell indices from the first rank
+// and a number of cells in the file
+func parseFile(line string) (map[int]base.IPiece, int, error) {
+	pieces, n, runes := map[int]base.IPiece{}, 0, []rune(line)
+	for i := 0; i < len(runes); i++ {
+		if unicode.IsDigit(runes[i]) {
+			j := i
+			for j < len(runes) && unicode.IsDigit(runes[j]) {
+				j++
+			}
+			empty, err := strconv.Atoi(string(runes[i:j]))
+			if err != nil {
+				return nil, 0, err
+			}
+			n, i = n+empty, j-1
+			continue
+		}
+		f, exists := pieceConstructors[unicode.ToLower(runes[i])]
+		if !exists {
+			return nil, 0, fmt.Errorf("invalid piece token: %c", runes[i])
+		}
+		colour := White
+		if unicode.IsLower(runes[i]) {
+			colour = Black
+		}
+		pieces[n] = f(colour)
+		n++
+	}
+	return pieces, n, nil
+}
+
+// PositionPart returns a position part of a FEN
+func (s FEN) PositionPart() string {
+	return strings.Join(strings.Split(string(s), " ")[:4], " ")
+}
+
+// Board returns a new hexagonal chess board position from FEN, board settings are chosen by a number of files:
+// Shafran's settings for 9 files board and Glinski's settings for other boards.
+// Positions of McCooey's hexagonal chess should be parsed by BoardWithSettings.
+func (s FEN) Board() (base.IBoard, error) {
+	settings := GlinskiSettings()
+	if files := strings.Split(strings.Split(string(s), " ")[0], "/"); len(files) == 9 {
+		settings = ShafranSettings()
+	}
+	return s.BoardWithSettings(settings)
+}
+
+// BoardWithSettings returns a new hexagonal chess board position from FEN with the given settings
+func (s FEN) BoardWithSettings(settings *base.Settings) (base.IBoard, error) {
+	fenParts := strings.Split(string(s), " ")
+	if len(fenParts) != 6 {
+		return nil, fmt.Errorf("invalid FEN, should be 6 fields: %s", s)
+	}
+
+	lines := strings.Split(fenParts[0], "/")
+	if len(lines)%2 == 0 {
+		return nil, fmt.Errorf("invalid FEN, number of files should be odd: %d", len(lines))
+	}
+	files := make([]map[int]base.IPiece, len(lines))
+	lengths := make([]int, len(lines))
+	for i := range lines {
+		var err error
+		if files[i], lengths[i], err = parseFile(lines[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	b := NewEmptyBoard(len(lines), lengths[len(lines)/2], settings)
+	dim := b.Dim().(Coord)
+	qMax, _, _ := bounds(dim)
+	for i := range files {
+		q := i - qMax
+		bottom, top := fileBounds(dim, q)
+		if lengths[i] != top-bottom+1 {
+			return nil, fmt.Errorf("invalid FEN, file %c should have %d cells", ToLetter(i+1), top-bottom+1)
+		}
+		for j, piece := range files[i] {
+			b.PlacePiece(Coord{Q: q, R: bottom + j}, piece)
+		}
+	}
+
+	switch fenParts[1] {
+	case "w":
+		b.SetSideToMove(White)
+	case "b":
+		b.SetSideToMove(Black)
+	default:
+		return nil, fmt.Errorf("invalid side to move: %s", fenParts[1])
+	}
+
+	if fenParts[2] != "-" {
+		return nil, fmt.Errorf("castling is not supported: %s", fenParts[2])
+	}
+
+	if err := parseEP(fenParts[3], b); err != nil {
+		return nil, err
+	}
+
+	halfMoves, err := strconv.Atoi(fenParts[4])
+	if err != nil {
+		return nil, err
+	}
+	b.SetHalfMoveCount(halfMoves)
+
+	moveNumber, err := strconv.Atoi(fenParts[5])
+	if err != nil {
+		return nil, err
+	}
+	b.SetMoveNumber(moveNumber)
+	b.increasePositionCounter()
+	return b, nil
+}
+
+// parseEP parses en passant target cell, the cell is passed over by opponent's pawn which is found
+// further in the direction of it's move. This func changes board parameter.
+func parseEP(line string, board *Board) error {
+	if line == "-" {
+		return nil
+	}
+	n := NewLongAlgebraicNotation(board.Dim())
+	if err := n.DecodeCoord(line); err != nil {
+		return err
+	}
+
+	opponent := board.SideToMove().Invert()
+	forward, targets := forColour(opponent, pawnForward)[0], []base.ICoord{}
+	for c := n.Coord; !c.OutOf(board); c = c.Add(forward) {
+		piece := board.Piece(c)
+		if piece == nil {
+			targets = append(targets, c)
+			continue
+		}
+		if piece.Name() != base.PawnName || piece.Colour() != opponent || len(targets) == 0 {
+			break
+		}
+		board.SetCanCaptureEnPassantAt(c)
+		board.SetEnPassantTargets(targets)
+		return nil
+	}
+	return fmt.Errorf("invalid en passant target cell: %s", line)
+}
+
+// NewFEN converts hexagonal board position to FEN
+func NewFEN(board *Board) FEN {
+	lines := make([]string, len(board.cells))
+	for i := range board.cells {
+		empty := 0
+		for j := range board.cells[i] {
+			piece := board.cells[i][j].Piece()
+			if piece == nil {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				lines[i] += strconv.Itoa(empty)
+				empty = 0
+			}
+			token := unicode.ToLower(piece.Capital())
+			if piece.Colour() == White {
+				token = unicode.ToUpper(token)
+			}
+			lines[i] += string(token)
+		}
+		if empty > 0 {
+			lines[i] += strconv.Itoa(empty)
+		}
+	}
+
+	sideToMove := string(unicode.ToLower([]rune(board.SideToMove().Name())[0]))
+
+	ep := "-"
+	if targets := board.EnPassantTargets(); board.CanCaptureEnPassantAt() != nil && len(targets) > 0 {
+		ep = NewLongAlgebraicNotation(board.Dim()).SetCoord(targets[0]).EncodeCoord()
+	}
+
+	return FEN(fmt.Sprintf("%s %s - %s %d %d",
+		strings.Join(lines, "/"), sideToMove, ep, board.HalfMoveCount(), board.MoveNumber()))
+}
@@ -0,0 +1,85 @@
+package hex_test
+
+import (
+	"fmt"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	"github.com/mtfelian/mtfchess/hex"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Hexagonal FEN tests", func() {
+	It("checks starting positions", func() {
+		testCases := []struct {
+			fen      hex.FEN
+			settings *base.Settings
+			pieces   int
+			king     hex.Coord
+			moves    int
+		}{
+			{hex.NewGlinskiStartingPosition(), hex.GlinskiSettings(), 18, hex.Coord{1, -5}, 51},
+			{hex.NewMcCooeyStartingPosition(), hex.McCooeySettings(), 16, hex.Coord{1, -5}, 32},
+			{hex.NewShafranStartingPosition(), hex.ShafranSettings(), 18, hex.Coord{1, -5}, 33},
+		}
+		for i, testCase := range testCases {
+			By(fmt.Sprintf("Checking testCase at index %d...", i))
+			b, err := testCase.fen.BoardWithSettings(testCase.settings)
+			Expect(err).NotTo(HaveOccurred())
+			for _, colour := range AllColours() {
+				Expect(b.FindPieces(base.PieceFilter{Colours: []Colour{colour}})).To(HaveLen(testCase.pieces))
+			}
+			Expect(b.King(White).Coord()).To(Equal(testCase.king))
+			Expect(b.LegalMoves(hex.NewLongAlgebraicNotation(b.Dim()))).To(HaveLen(testCase.moves))
+			Expect(hex.NewFEN(b.(*hex.Board))).To(Equal(testCase.fen))
+		}
+	})
+
+	It("parses boards of other dimensions", func() {
+		b, err := hex.NewShafranStartingPosition().Board()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b.Dim()).To(Equal(hex.Coord{9, 10}))
+		Expect(b.Settings().PawnLongMoveModifier).To(Equal(hex.CentrePawnLongMove), "Shafran's settings for 9 files")
+		Expect(b.Equals(b.Copy())).To(BeTrue())
+
+		b, err = hex.NewGlinskiStartingPosition().Board()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b.Dim()).To(Equal(hex.Coord{11, 11}))
+		Expect(b.Piece(hex.Coord{1, 4}).Name()).To(Equal(base.KingName), "black king is on g10")
+	})
+
+	It("checks en passant target cell", func() {
+		b, err := hex.NewGlinskiStartingPosition().Board()
+		Expect(err).NotTo(HaveOccurred())
+
+		f, err := hex.NewLongAlgebraicNotation(b.Dim()).DecodeMove(b, "e4-e6")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f()).To(BeTrue())
+
+		fen := hex.NewFEN(b.(*hex.Board))
+		Expect(fen).To(Equal(hex.FEN(`6/P5p/RP4pr/N1P3p1n/Q4Pp2q/BBB1P1p1bbb/K2P2p2k/N1P3p1n/RP4pr/P5p/6 b - e5 0 1`)))
+
+		b1, err := fen.Board()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b1.CanCaptureEnPassantAt()).To(Equal(hex.Coord{-1, 1}))
+		Expect(b1.EnPassantTargets()).To(Equal([]base.ICoord{hex.Coord{-1, 0}}))
+	})
+
+	It("checks errors on invalid FEN", func() {
+		for _, fen := range []hex.FEN{
+			`6/P5p/RP4pr/N1P3p1n/Q2P2p2q/BBB1P1p1bbb/K2P2p2k/N1P3p1n/RP4pr/P5p w - - 0 1`,
+			`6/P5p/RP4pr/N1P3p1n/Q2P2p2q/BBB1P1p1bbb/K2P2p2k/N1P3p1n/RP4pr/P5p/7 w - - 0 1`,
+			`6/P5p/RP4pr/N1P3p1n/Q2P2p2q/BBB1P1p1bbb/K2P2p2k/N1P3p1n/RP4pr/P5p/5x w - - 0 1`,
+			`6/P5p/RP4pr/N1P3p1n/Q2P2p2q/BBB1P1p1bbb/K2P2p2k/N1P3p1n/RP4pr/P5p/6 w KQkq - 0 1`,
+			`6/P5p/RP4pr/N1P3p1n/Q2P2p2q/BBB1P1p1bbb/K2P2p2k/N1P3p1n/RP4pr/P5p/6 x - - 0 1`,
+			`6/P5p/RP4pr/N1P3p1n/Q2P2p2q/BBB1P1p1bbb/K2P2p2k/N1P3p1n/RP4pr/P5p/6 w - f6 0 1`,
+			`6/P5p/RP4pr/N1P3p1n/Q2P2p2q/BBB1P1p1bbb/K2P2p2k/N1P3p1n/RP4pr/P5p/6 w - - 0`,
+		} {
+			By(fmt.Sprintf("Checking %s...", fen))
+			b, err := fen.Board()
+			Expect(err).To(HaveOccurred())
+			Expect(b).To(BeNil())
+		}
+	})
+})
@@ -0,0 +1,172 @@
+package hex
+
+import (
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+const (
+	NoPawnLongMove       = 0  // disable pawn long move
+	StandardPawnLongMove = 1  // pawn from starting cell can go 1 cell further
+	CentrePawnLongMove   = -1 // pawn from starting cell can go further up to the centre line of a board
+)
+
+const (
+	NoMovesToDraw         = 0  // disable N moves draw rule
+	Standard50MovesToDraw = 50 // 50 moves draw rule
+)
+
+const (
+	NoXFoldRepetitionDraw       = 0 // disable X-fold repetition draw rule
+	Standard3FoldRepetitionDraw = 3 // 3-fold repetition draw rule
+)
+
+// pieceConstructors maps lower case FEN piece tokens to piece constructors
+var pieceConstructors = map[rune]func(Colour) base.IPiece{
+	'p': NewPawn, 'n': NewKnight, 'b': NewBishop, 'r': NewRook, 'q': NewQueen, 'k': NewKing,
+}
+
+// NewPieceByName returns a new piece of colour by the given piece name, or nil if there is no such piece
+func NewPieceByName(name string, colour Colour) base.IPiece {
+	for _, f := range pieceConstructors {
+		if piece := f(colour); piece.Name() == name {
+			return piece
+		}
+	}
+	return nil
+}
+
+// newSettings returns a set of settings for hexagonal chess with pawns making long moves from start cells
+func newSettings(pawnStartCells map[Colour][]Coord) *base.Settings {
+	return &base.Settings{
+		PawnLongMoveModifier:   StandardPawnLongMove,
+		PawnStartZoneFunc:      NewPawnStartCellsFunc(false, pawnStartCells),
+		AllowedPromotions:      StandardAllowedPromotions(),
+		PromotionRules:         StandardPromotionRules(),
+		PromotionConditionFunc: base.StandardPromotionConditionFunc,
+		CastlingsFunc:          NoCastlingFunc,
+		EnPassantFunc:          StandardEnPassantFunc,
+		MoveOrder:              true,
+		MovesToDraw:            Standard50MovesToDraw,
+		PositionsToDraw:        Standard3FoldRepetitionDraw,
+	}
+}
+
+// GlinskiSettings returns a set of settings for Glinski's hexagonal chess
+func GlinskiSettings() *base.Settings {
+	return newSettings(pawnStartCells(Coord{11, 11}, "b1", "c2", "d3", "e4", "f5", "g4", "h3", "i2", "k1"))
+}
+
+// McCooeySettings returns a set of settings for McCooey's hexagonal chess
+func McCooeySettings() *base.Settings {
+	return newSettings(pawnStartCells(Coord{11, 11}, "c1", "d2", "e3", "f4", "g3", "h2", "i1"))
+}
+
+// ShafranSettings returns a set of settings for Shafran's hexagonal chess: pawns start on a wedge line
+// and can go from there up to the centre line of a board
+func ShafranSettings() *base.Settings {
+	settings := newSettings(pawnStartCells(Coord{9, 10}, "a1", "b1", "c2", "d3", "e4", "f3", "g2", "h1", "i1"))
+	settings.PawnLongMoveModifier = CentrePawnLongMove
+	return settings
+}
+
+// testBoardSettings returns a set of settings for tests
+func testBoardSettings() *base.Settings {
+	return &base.Settings{
+		PawnLongMoveModifier:   NoPawnLongMove,
+		PawnStartZoneFunc:      NewPawnStartCellsFunc(true, nil),
+		AllowedPromotions:      StandardAllowedPromotions(),
+		PromotionRules:         StandardPromotionRules(),
+		PromotionConditionFunc: base.StandardPromotionConditionFunc,
+		CastlingsFunc:          NoCastlingFunc,
+		EnPassantFunc:          NoEnPassantFunc,
+		MoveOrder:              false,
+		MovesToDraw:            NoMovesToDraw,
+		PositionsToDraw:        NoXFoldRepetitionDraw,
+	}
+}
+
+// pawnStartCells returns pawn start cells on a board with dimensions dim by white pawn cells
+// in algebraic notation, black pawn cells are mirrored
+func pawnStartCells(dim Coord, white ...string) map[Colour][]Coord {
+	res := map[Colour][]Coord{}
+	n := NewLongAlgebraicNotation(dim)
+	for _, cell := range white {
+		if err := n.DecodeCoord(cell); err != nil {
+			panic(err)
+		}
+		res[White] = append(res[White], n.Coord.(Coord))
+		res[Black] = append(res[Black], mirror(dim, n.Coord.(Coord)))
+	}
+	return res
+}
+
+// NewPawnStartCellsFunc returns a pawn start zone func allowing pawn long move from the given cells,
+// cells maps pawn colour to a slice of coords. Set unmovedOnly to true to allow long move only to unmoved pawns.
+func NewPawnStartCellsFunc(unmovedOnly bool, cells map[Colour][]Coord) func(base.IBoard, base.IPiece) bool {
+	return func(board base.IBoard, piece base.IPiece) bool {
+		if unmovedOnly && piece.WasMoved() {
+			return false
+		}
+		for _, c := range cells[piece.Colour()] {
+			if c.Equals(piece.Coord()) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// NoEnPassantFunc always disables en passant capturing
+func NoEnPassantFunc(_ base.IBoard, _ base.IPiece) base.ICoords { return NewCoords([]base.ICoord{}) }
+
+// StandardEnPassantFunc enables en passant capturing, returns coords to capture, piece is a capturing piece.
+// Pawn can capture en passant on any of cells passed over by an opponent's pawn making a long move
+// if the cell is one of its capturing cells.
+func StandardEnPassantFunc(board base.IBoard, piece base.IPiece) base.ICoords {
+	res := NewCoords([]base.ICoord{})
+	if piece.Name() != base.PawnName {
+		return res
+	}
+
+	// pieceAt is a coord of a piece which can be captured en passant
+	pieceAt := board.CanCaptureEnPassantAt()
+	if pieceAt == nil || board.Piece(pieceAt) == nil || board.Piece(pieceAt).Colour() == piece.Colour() {
+		return res
+	}
+
+	targets := NewCoords(board.EnPassantTargets())
+	for _, o := range forColour(piece.Colour(), pawnCaptures...) {
+		if to := piece.Coord().Add(o); targets.Contains(to) {
+			res.Add(to)
+		}
+	}
+	return res
+}
+
+// NoCastlingFunc is a castling func which disables castling
+func NoCastlingFunc(_ base.IBoard, _ Colour) base.Castlings { return base.Castlings{} }
+
+// StandardAllowedPromotions returns allowed pawn promotions pieces names list for hexagonal chess
+func StandardAllowedPromotions() []string {
+	return []string{base.KnightName, base.BishopName, base.RookName, base.QueenName}
+}
+
+// StandardPromotionRules returns promotion rules for hexagonal chess: pawn should be promoted
+// on the last cell of a file to any of Settings.AllowedPromotions pieces
+func StandardPromotionRules() base.PromotionRules {
+	return base.PromotionRules{
+		base.PawnName: {ZoneFunc: PromotionFileEndFunc},
+	}
+}
+
+// PromotionFileEndFunc is a promotion zone func making promotion mandatory on the last cell of a file,
+// it is the top cell for white and the bottom cell for black
+func PromotionFileEndFunc(board base.IBoard, piece base.IPiece, dst base.ICoord) base.PromotionKind {
+	c := dst.(Coord)
+	bottom, top := fileBounds(board.Dim().(Coord), c.Q)
+	if piece.Colour() == White && c.R == top || piece.Colour() == Black && c.R == bottom {
+		return base.MandatoryPromotion
+	}
+	return base.NoPromotion
+}
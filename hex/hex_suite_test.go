@@ -0,0 +1,13 @@
+package hex_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestHex(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Hexagonal Board Suite")
+}
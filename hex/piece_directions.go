@@ -0,0 +1,38 @@
+package hex
+
+import (
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+var (
+	// orthogonal are offsets to adjacent cells through the cell edges
+	orthogonal = []base.ICoord{Coord{0, 1}, Coord{1, 0}, Coord{1, -1}, Coord{0, -1}, Coord{-1, 0}, Coord{-1, 1}}
+
+	// diagonal are offsets to the nearest cells through the cell vertices
+	diagonal = []base.ICoord{Coord{1, 1}, Coord{2, -1}, Coord{1, -2}, Coord{-1, -1}, Coord{-2, 1}, Coord{-1, 2}}
+
+	// knightLeaps are offsets of the Glinski's knight: two orthogonal steps and one more step turned by 60 degrees
+	knightLeaps = []base.ICoord{
+		Coord{1, 2}, Coord{2, 1}, Coord{3, -1}, Coord{3, -2}, Coord{2, -3}, Coord{1, -3},
+		Coord{-1, -2}, Coord{-2, -1}, Coord{-3, 1}, Coord{-3, 2}, Coord{-2, 3}, Coord{-1, 3},
+	}
+
+	// pawnForward is a white pawn's move offset, it is inverted for black
+	pawnForward = Coord{0, 1}
+
+	// pawnCaptures are white pawn's capture offsets, they are inverted for black
+	pawnCaptures = []base.ICoord{Coord{-1, 1}, Coord{1, 0}}
+)
+
+// forColour returns offsets o for piece of the given colour: black's offsets are inverted
+func forColour(colour Colour, o ...base.ICoord) []base.ICoord {
+	res := make([]base.ICoord, len(o))
+	for i := range o {
+		res[i] = o[i]
+		if c := o[i].(Coord); colour == Black {
+			res[i] = Coord{-c.Q, -c.R}
+		}
+	}
+	return res
+}
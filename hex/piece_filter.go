@@ -0,0 +1,12 @@
+package hex
+
+import (
+	"github.com/mtfelian/mtfchess/base"
+)
+
+// PieceFilter is a piece filter for hexagonal board
+type PieceFilter struct {
+	base.PieceFilter
+	Q []int
+	R []int
+}
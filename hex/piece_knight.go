@@ -0,0 +1,35 @@
+package hex
+
+import (
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+// Knight is a hexagonal chess knight
+type Knight struct{ *base.Piece }
+
+// NewKnight creates new knight with colour
+func NewKnight(colour Colour) base.IPiece {
+	return &Knight{Piece: base.NewPiece(colour, base.KnightName, "N♘♞")}
+}
+
+// dst returns a slice of destination cells coords, making it's legal moves
+// if moving is false then pairs leading to check-exposing moves also included
+func (p *Knight) dst(b *Board, moving bool) base.ICoords {
+	return NewCoords(base.Leaper(p, b, moving, knightLeaps, base.MoveAny))
+}
+
+// Attacks returns a slice of coords pairs of cells attacked by a piece
+func (p *Knight) Attacks(b base.IBoard) base.ICoords { return p.dst(b.(*Board), false) }
+
+// Destinations returns a slice of cells coords, making it's legal moves
+func (p *Knight) Destinations(b base.IBoard) base.ICoords { return p.dst(b.(*Board), true) }
+
+// Copy a piece
+func (p *Knight) Copy() base.IPiece { return &Knight{Piece: p.Piece.Copy()} }
+
+// Promote returns a promoted piece
+func (p *Knight) Promote() base.IPiece { return base.Promoted(p) }
+
+// Set sets a piece to p1
+func (p *Knight) Set(p1 base.IPiece) { *p = *(p1.(*Knight)) }
@@ -0,0 +1,57 @@
+package hex
+
+import (
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+// Pawn is a hexagonal chess pawn, it moves forward along a file
+// and captures to the adjacent cells forward to the left and forward to the right
+type Pawn struct{ *base.Piece }
+
+// NewPawn creates new pawn with colour
+func NewPawn(colour Colour) base.IPiece {
+	return &Pawn{Piece: base.NewPiece(colour, base.PawnName, "P♙♟")}
+}
+
+// dst returns a slice of destination cells coords, making it's legal moves
+// if moving is false then pairs leading to check-exposing moves also included
+func (p *Pawn) dst(b *Board, moving bool) base.ICoords {
+	long := 0
+	if b.Settings().PawnStartZoneFunc(b, p) {
+		long = b.Settings().PawnLongMoveModifier
+		if long == CentrePawnLongMove {
+			long = max(0, stepsToCentre(b.Dim().(Coord), p.Colour(), p.Coord().(Coord))-1)
+		}
+	}
+
+	d := NewCoords(append(base.Rider(p, b, moving, forColour(p.Colour(), pawnForward), 1+long, base.MoveNonCapture),
+		base.Leaper(p, b, moving, forColour(p.Colour(), pawnCaptures...), base.MoveCapture)...))
+
+	if moving {
+		// search through the possible en passant capturing coords and add if appropriate coords is found
+		epCoords := b.Settings().EnPassantFunc(b, p)
+		for epCoords.HasNext() {
+			if to := epCoords.Next().(base.ICoord); !base.PromotionBlocked(b, p, to) {
+				d.Add(to)
+			}
+		}
+	}
+
+	return d
+}
+
+// Attacks returns a slice of coords pairs of cells attacked by a piece
+func (p *Pawn) Attacks(b base.IBoard) base.ICoords { return p.dst(b.(*Board), false) }
+
+// Destinations returns a slice of cells coords, making it's legal moves
+func (p *Pawn) Destinations(b base.IBoard) base.ICoords { return p.dst(b.(*Board), true) }
+
+// Copy a piece
+func (p *Pawn) Copy() base.IPiece { return &Pawn{Piece: p.Piece.Copy()} }
+
+// Promote returns a promoted piece
+func (p *Pawn) Promote() base.IPiece { return base.Promoted(p) }
+
+// Set sets a piece to p1
+func (p *Pawn) Set(p1 base.IPiece) { *p = *(p1.(*Pawn)) }
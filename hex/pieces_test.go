@@ -0,0 +1,148 @@
+package hex
+
+import (
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Hexagonal pieces test", func() {
+	var b base.IBoard
+	BeforeEach(func() {
+		b = NewEmptyGlinskiBoard()
+		b.Settings().MoveOrder = false
+	})
+
+	It("generates moves from the central cell", func() {
+		testCases := []struct {
+			piece base.IPiece
+			n     int
+		}{
+			{NewRook(White), 30},
+			{NewBishop(White), 12},
+			{NewQueen(White), 42},
+			{NewKing(White), 12},
+			{NewKnight(White), 12},
+		}
+		for _, testCase := range testCases {
+			By("Checking " + testCase.piece.Name() + "...")
+			b = NewEmptyGlinskiBoard()
+			b.PlacePiece(Coord{0, 0}, testCase.piece)
+			Expect(testCase.piece.Destinations(b).Len()).To(Equal(testCase.n))
+		}
+	})
+
+	It("generates moves from the corner cell", func() {
+		wk, wn := NewKing(White), NewKnight(White)
+		b.PlacePiece(Coord{-5, 0}, wk)
+		Expect(wk.Destinations(b).Len()).To(Equal(5))
+		b.Empty(Coord{-5, 0}).PlacePiece(Coord{-5, 0}, wn)
+		Expect(wn.Destinations(b).Len()).To(Equal(4))
+	})
+
+	It("checks rook blocked by own piece and capturing opponent's piece", func() {
+		wr := NewRook(White)
+		b.PlacePiece(Coord{0, 0}, wr)
+		b.PlacePiece(Coord{0, 2}, NewKnight(White))
+		b.PlacePiece(Coord{2, 0}, NewPawn(Black))
+
+		d := wr.Destinations(b)
+		Expect(d.Len()).To(Equal(23))
+		Expect(d.Contains(Coord{2, 0})).To(BeTrue())
+		Expect(d.Contains(Coord{3, 0})).To(BeFalse())
+		Expect(d.Contains(Coord{0, 2})).To(BeFalse())
+		Expect(wr.Attacks(b).Contains(Coord{0, 2})).To(BeTrue(), "rook defends own piece")
+	})
+
+	It("checks king can't move to the attacked cell", func() {
+		wk := NewKing(White)
+		b.PlacePiece(Coord{0, -5}, wk)
+		b.PlacePiece(Coord{0, 5}, NewRook(Black))
+		Expect(b.InCheck(White)).To(BeTrue())
+		d := wk.Destinations(b)
+		Expect(d.Contains(Coord{0, -4})).To(BeFalse())
+		Expect(d.Contains(Coord{1, -5})).To(BeTrue())
+	})
+
+	Context("pawn", func() {
+		It("moves from the start cell", func() {
+			wp := NewPawn(White)
+			b.PlacePiece(Coord{-1, -1}, wp) // e4
+			Expect(wp.Destinations(b).Equals(NewCoords([]base.ICoord{Coord{-1, 0}, Coord{-1, 1}}))).To(BeTrue())
+
+			b.PlacePiece(Coord{-2, 0}, NewKnight(Black)) // d4
+			b.PlacePiece(Coord{0, -1}, NewKnight(Black)) // f5
+			Expect(wp.Destinations(b).Len()).To(Equal(4))
+
+			b.PlacePiece(Coord{-1, 0}, NewKnight(Black)) // e5
+			Expect(wp.Destinations(b).Equals(NewCoords([]base.ICoord{Coord{-2, 0}, Coord{0, -1}}))).To(BeTrue())
+		})
+
+		It("makes a long move from another pawn's start cell", func() {
+			wp, bp := NewPawn(White), NewPawn(Black)
+			b.PlacePiece(Coord{0, -1}, wp) // f5
+			wp.MarkMoved()
+			b.PlacePiece(Coord{-1, 0}, bp) // e5
+			Expect(wp.Destinations(b).Len()).To(Equal(3), "two steps forward and capture")
+			Expect(bp.Destinations(b).Len()).To(Equal(2), "one step forward and capture")
+
+			b.Empty(Coord{-1, 0}).PlacePiece(Coord{0, 1}, bp) // f7
+			Expect(bp.Destinations(b).Equals(NewCoords([]base.ICoord{Coord{0, 0}}))).To(BeTrue())
+			b.Empty(Coord{0, -1})
+			Expect(bp.Destinations(b).Equals(NewCoords([]base.ICoord{Coord{0, 0}, Coord{0, -1}}))).To(BeTrue())
+		})
+
+		It("captures en passant", func() {
+			wp, bp := NewPawn(White), NewPawn(Black)
+			b.PlacePiece(Coord{-3, 2}, wp) // c5
+			b.PlacePiece(Coord{-2, 3}, bp) // d7
+
+			Expect(b.MakeMove(Coord{-2, 1}, bp)).To(BeTrue())
+			Expect(b.CanCaptureEnPassantAt()).To(Equal(Coord{-2, 1}))
+			Expect(b.EnPassantTargets()).To(Equal([]base.ICoord{Coord{-2, 2}}))
+			Expect(wp.Destinations(b).Contains(Coord{-2, 2})).To(BeTrue())
+
+			Expect(b.MakeMove(Coord{-2, 2}, wp)).To(BeTrue())
+			Expect(b.Piece(Coord{-2, 1})).To(BeNil())
+			Expect(b.Piece(Coord{-2, 2}).Name()).To(Equal(base.PawnName))
+			Expect(b.CanCaptureEnPassantAt()).To(BeNil())
+		})
+
+		It("goes up to the centre line from the start cell in Shafran's hexagonal chess", func() {
+			b = NewEmptyShafranBoard()
+			b.Settings().MoveOrder = false
+			wb, we, bb := NewPawn(White), NewPawn(White), NewPawn(Black)
+			b.PlacePiece(Coord{-3, -2}, wb) // b1
+			b.PlacePiece(Coord{0, -2}, we)  // e4
+			b.PlacePiece(Coord{-3, 4}, bb)  // b7
+			Expect(wb.Destinations(b).Equals(NewCoords([]base.ICoord{Coord{-3, -1}, Coord{-3, 0}, Coord{-3, 1}}))).
+				To(BeTrue(), "b4 is on the centre line")
+			Expect(we.Destinations(b).Equals(NewCoords([]base.ICoord{Coord{0, -1}}))).To(BeTrue(), "e5 is below the centre line")
+			Expect(bb.Destinations(b).Equals(NewCoords([]base.ICoord{Coord{-3, 3}, Coord{-3, 2}, Coord{-3, 1}}))).To(BeTrue())
+
+			Expect(b.MakeMove(Coord{-3, 1}, wb)).To(BeTrue())
+			Expect(b.EnPassantTargets()).To(Equal([]base.ICoord{Coord{-3, -1}, Coord{-3, 0}}))
+			Expect(bb.Destinations(b).Equals(NewCoords([]base.ICoord{Coord{-3, 3}, Coord{-3, 2}}))).To(BeTrue())
+		})
+
+		It("promotes on the last cell of a file", func() {
+			b.Settings().MoveOrder = true
+			wp := NewPawn(White)
+			b.PlacePiece(Coord{0, 4}, wp) // f10
+			b.PlacePiece(Coord{-5, 1}, NewPawn(Black))
+
+			moves := b.LegalMoves(NewLongAlgebraicNotation(b.Dim()))
+			Expect(moves).To(ConsistOf("f10-f11=N", "f10-f11=B", "f10-f11=R", "f10-f11=Q"))
+
+			Expect(b.MakeMove(Coord{0, 5}, wp)).To(BeFalse(), "promotion is mandatory")
+			wp.SetPromote(NewQueen(White))
+			Expect(b.MakeMove(Coord{0, 5}, wp)).To(BeTrue())
+			Expect(b.Piece(Coord{0, 5}).Name()).To(Equal(base.QueenName))
+
+			Expect(b.LegalMoves(NewLongAlgebraicNotation(b.Dim()))).To(ConsistOf(
+				"a2-a1=N", "a2-a1=B", "a2-a1=R", "a2-a1=Q",
+			))
+		})
+	})
+})
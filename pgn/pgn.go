@@ -0,0 +1,322 @@
+// Package pgn reads and writes games in Portable Game Notation with variations, comments and annotations.
+// Moves are kept in short algebraic notation as they are written, so they may be decoded
+// by rect.NewShortAlgebraicNotation().
+package pgn
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// results are game termination markers
+var results = map[string]bool{"1-0": true, "0-1": true, "1/2-1/2": true, "*": true}
+
+// Tag is a tag pair of a game
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// Game is a game read from PGN
+type Game struct {
+	Tags   []Tag
+	Moves  []string // moves of the main line
+	Tree   *Node    // a root of a tree of all moves with variations, comments and annotations
+	Result string   // game termination marker like "1-0" or "*"
+}
+
+// Tag returns a value of a tag by name, it's empty if there is no such tag
+func (g *Game) Tag(name string) string {
+	for _, tag := range g.Tags {
+		if tag.Name == name {
+			return tag.Value
+		}
+	}
+	return ""
+}
+
+// FEN returns a starting position of a game set up by SetUp and FEN tags, it's empty for a standard start
+func (g *Game) FEN() string {
+	if g.Tag("SetUp") == "0" {
+		return ""
+	}
+	return g.Tag("FEN")
+}
+
+// Reader reads games from PGN one by one
+type Reader struct {
+	r         *bufio.Reader
+	line      int
+	lineStart bool // true if the next rune starts a line
+	pending   *Tag // a tag of the next game read after a game without a termination marker
+}
+
+// NewReader returns a new reader of games from r
+func NewReader(r io.Reader) *Reader { return &Reader{r: bufio.NewReader(r), line: 1, lineStart: true} }
+
+// ReadAll reads all games from r
+func ReadAll(r io.Reader) ([]*Game, error) {
+	games, reader := []*Game{}, NewReader(r)
+	for {
+		game, err := reader.Read()
+		if err == io.EOF {
+			return games, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		games = append(games, game)
+	}
+}
+
+// errorf returns an error at the current line
+func (r *Reader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("pgn: line %d: %s", r.line, fmt.Sprintf(format, args...))
+}
+
+// readRune returns the next rune counting lines
+func (r *Reader) readRune() (rune, error) {
+	c, _, err := r.r.ReadRune()
+	if c == '\n' {
+		r.line++
+	}
+	r.lineStart = c == '\n'
+	return c, err
+}
+
+// unreadRune returns rune c back to the reader
+func (r *Reader) unreadRune(c rune) {
+	if c == '\n' {
+		r.line--
+	}
+	r.lineStart = false
+	r.r.UnreadRune()
+}
+
+// skipUntil skips runes until delimiter inclusively
+func (r *Reader) skipUntil(delimiter rune) error {
+	for {
+		c, err := r.readRune()
+		if err != nil {
+			return err
+		}
+		if c == delimiter {
+			return nil
+		}
+	}
+}
+
+// readUntil returns runes read until delimiter, the delimiter is skipped
+func (r *Reader) readUntil(delimiter rune) (string, error) {
+	text := []rune{}
+	for {
+		c, err := r.readRune()
+		if err != nil {
+			return string(text), err
+		}
+		if c == delimiter {
+			return string(text), nil
+		}
+		text = append(text, c)
+	}
+}
+
+// token returns the next token: "(", ")", a symbol, "[" with a tag pair or a comment text prefixed by "{".
+// Escaped lines are skipped.
+func (r *Reader) token() (token string, tag Tag, err error) {
+	for {
+		lineStart := r.lineStart
+		c, err := r.readRune()
+		if err != nil {
+			return "", Tag{}, err
+		}
+		switch {
+		case unicode.IsSpace(c):
+			continue
+		case c == '%' && lineStart:
+			if err := r.skipUntil('\n'); err != nil {
+				return "", Tag{}, err
+			}
+			continue
+		case c == '{':
+			text, err := r.readUntil('}')
+			if err != nil {
+				return "", Tag{}, r.errorf("unterminated comment")
+			}
+			return "{" + text, Tag{}, nil
+		case c == ';':
+			text, err := r.readUntil('\n')
+			if err != nil && err != io.EOF {
+				return "", Tag{}, err
+			}
+			return "{" + text, Tag{}, nil
+		case c == '[':
+			tag, err := r.tag()
+			return "[", tag, err
+		case c == '(' || c == ')':
+			return string(c), Tag{}, nil
+		}
+		symbol := []rune{c}
+		for {
+			c, err := r.readRune()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", Tag{}, err
+			}
+			if unicode.IsSpace(c) || strings.ContainsRune("[]{}();", c) {
+				r.unreadRune(c)
+				break
+			}
+			symbol = append(symbol, c)
+		}
+		return string(symbol), Tag{}, nil
+	}
+}
+
+// tag reads a tag pair after "["
+func (r *Reader) tag() (Tag, error) {
+	var name, value []rune
+	c, err := r.readRune()
+	for ; err == nil && c != '"'; c, err = r.readRune() {
+		if c == ']' || c == '\n' {
+			return Tag{}, r.errorf("tag without value")
+		}
+		if !unicode.IsSpace(c) {
+			name = append(name, c)
+		}
+	}
+	for c, err = r.readRune(); err == nil && c != '"'; c, err = r.readRune() {
+		if c == '\\' {
+			if c, err = r.readRune(); err != nil {
+				break
+			}
+		}
+		value = append(value, c)
+	}
+	if err == nil {
+		err = r.skipUntil(']')
+	}
+	if err != nil {
+		return Tag{}, r.errorf("unterminated tag")
+	}
+	if len(name) == 0 {
+		return Tag{}, r.errorf("tag without name")
+	}
+	return Tag{Name: string(name), Value: string(value)}, nil
+}
+
+// Read returns the next game, it returns io.EOF if there are no more games
+func (r *Reader) Read() (*Game, error) {
+	game, empty := &Game{Tags: []Tag{}, Tree: &Node{}}, true
+	if r.pending != nil {
+		game.Tags, empty, r.pending = append(game.Tags, *r.pending), false, nil
+	}
+	// path is a path from the root to the last node of the current line, lines are paths of outer lines
+	path, lines, commentBefore := []*Node{game.Tree}, [][]*Node{}, ""
+	for {
+		token, tag, err := r.token()
+		if err == io.EOF {
+			switch {
+			case empty:
+				return nil, io.EOF
+			case len(lines) > 0:
+				return nil, r.errorf("unterminated variation")
+			}
+			return game.withMoves(), nil // a game without a termination marker
+		}
+		if err != nil {
+			return nil, err
+		}
+		empty = false
+		last := path[len(path)-1]
+
+		switch {
+		case token == "[" && len(lines) > 0:
+			return nil, r.errorf("tag %s inside of a variation", tag.Name)
+		case token == "[" && len(game.Tree.Children) > 0: // the next game starts without a termination marker
+			r.pending = &tag
+			return game.withMoves(), nil
+		case token == "[":
+			game.Tags = append(game.Tags, tag)
+		case strings.HasPrefix(token, "{"):
+			switch comment := strings.TrimSpace(token[1:]); {
+			case len(lines) > 0 && len(path) == len(lines[len(lines)-1])-1: // before the first move of a variation
+				commentBefore = joinComments(commentBefore, comment)
+			default:
+				last.Comment = joinComments(last.Comment, comment)
+			}
+		case token == "(":
+			if last == game.Tree {
+				return nil, r.errorf("variation without a preceding move")
+			}
+			lines, path = append(lines, append([]*Node{}, path...)), path[:len(path)-1]
+		case token == ")":
+			if len(lines) == 0 {
+				return nil, r.errorf("unexpected )")
+			}
+			path, lines = lines[len(lines)-1], lines[:len(lines)-1]
+		case strings.HasPrefix(token, "$"):
+			nag, err := strconv.Atoi(token[1:])
+			if err != nil {
+				return nil, r.errorf("invalid annotation %s", token)
+			}
+			if last != game.Tree {
+				last.NAGs = append(last.NAGs, nag)
+			}
+		case results[token] && len(lines) == 0:
+			game.Result = token
+			return game.withMoves(), nil
+		case results[token]:
+		default:
+			move, suffix := moveOf(token)
+			if nag, exists := suffixNAGs[suffix]; exists && last != game.Tree && move == "" {
+				last.NAGs = append(last.NAGs, nag) // a suffix annotation separated from a move
+				continue
+			}
+			if move == "" {
+				continue
+			}
+			node := &Node{Move: move, CommentBefore: commentBefore}
+			if nag, exists := suffixNAGs[suffix]; exists {
+				node.NAGs = []int{nag}
+			}
+			last.Children, path, commentBefore = append(last.Children, node), append(path, node), ""
+		}
+	}
+}
+
+// withMoves returns g with moves of the main line of the game tree
+func (g *Game) withMoves() *Game {
+	g.Moves = []string{}
+	for node := g.Tree; len(node.Children) > 0; node = node.Children[0] {
+		g.Moves = append(g.Moves, node.Children[0].Move)
+	}
+	return g
+}
+
+// joinComments returns comments joined by a space
+func joinComments(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	return a + " " + b
+}
+
+// moveOf returns a move of a movetext symbol without a move number and a suffix annotation like "!?",
+// the move is empty if a symbol is a move number only. Castlings written with zeros are converted to "O-O" and "O-O-O".
+func moveOf(symbol string) (move, suffix string) {
+	if number := strings.TrimLeft(symbol, "0123456789"); strings.HasPrefix(number, ".") {
+		symbol = strings.TrimLeft(number, ".")
+	}
+	if strings.HasPrefix(symbol, "0-0") {
+		symbol = strings.Replace(symbol, "0", "O", -1)
+	}
+	move = strings.TrimRight(symbol, "!?")
+	return move, symbol[len(move):]
+}
@@ -0,0 +1,13 @@
+package pgn_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestPGN(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "PGN Suite")
+}
@@ -0,0 +1,127 @@
+package pgn_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mtfelian/mtfchess/pgn"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PGN test", func() {
+	It("reads games with tags, comments, variations and annotations", func() {
+		games, err := pgn.ReadAll(strings.NewReader(`% an escaped line
+[Event "Test \"quoted\""]
+[White "A"]
+
+1. e4 {best by test} e5 2.Nf3 (2. f4 exf4 (2... d5)) Nc6 $1 3. Bb5!? a6 ; a comment
+4. 0-0 1/2-1/2
+
+[SetUp "1"]
+[FEN "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"]
+
+1. e4 Kd7 *
+[Event "No result"]
+1. d4
+[Event "Next"]
+1. c4 1-0
+`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(games).To(HaveLen(4))
+
+		Expect(games[0].Tag("Event")).To(Equal(`Test "quoted"`))
+		Expect(games[0].Tag("White")).To(Equal("A"))
+		Expect(games[0].Tag("Black")).To(BeEmpty())
+		Expect(games[0].FEN()).To(BeEmpty())
+		Expect(games[0].Moves).To(Equal([]string{"e4", "e5", "Nf3", "Nc6", "Bb5", "a6", "O-O"}))
+		Expect(games[0].Result).To(Equal("1/2-1/2"))
+
+		Expect(games[1].FEN()).To(Equal("4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"))
+		Expect(games[1].Moves).To(Equal([]string{"e4", "Kd7"}))
+		Expect(games[1].Result).To(Equal("*"))
+
+		Expect(games[2].Tag("Event")).To(Equal("No result"))
+		Expect(games[2].Moves).To(Equal([]string{"d4"}))
+		Expect(games[2].Result).To(BeEmpty())
+		Expect(games[3].Tag("Event")).To(Equal("Next"))
+		Expect(games[3].Moves).To(Equal([]string{"c4"}))
+	})
+
+	It("reports malformed PGN", func() {
+		for i, s := range []string{`1. e4 (e5`, `1. e4 ) 1-0`, `[Event "x`, `1. e4 {comment`, `1. e4 ([Event "x"])`} {
+			By(fmt.Sprintf("Checking testCase %q at index %d...", s, i))
+			_, err := pgn.ReadAll(strings.NewReader(s))
+			Expect(err).To(HaveOccurred())
+		}
+	})
+
+	It("reads game trees", func() {
+		g, err := pgn.NewReader(strings.NewReader(
+			`{Intro} 1. e4! {King pawn} e5 (1... c5 ?! ({Sicilian?} 1... c6)) 2. Nf3 $14 *`)).Read()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(g.Moves).To(Equal([]string{"e4", "e5", "Nf3"}))
+		Expect(g.Tree.Comment).To(Equal("Intro"))
+
+		e4 := g.Tree.Children[0]
+		Expect(e4.Move).To(Equal("e4"))
+		Expect(e4.NAGs).To(Equal([]int{1}))
+		Expect(e4.Comment).To(Equal("King pawn"))
+		Expect(e4.Children).To(HaveLen(3))
+		Expect(e4.Children[1].Move).To(Equal("c5"))
+		Expect(e4.Children[1].NAGs).To(Equal([]int{6}))
+		Expect(e4.Children[2].Move).To(Equal("c6"))
+		Expect(e4.Children[2].CommentBefore).To(Equal("Sicilian?"))
+		Expect(e4.Children[0].Children[0].NAGs).To(Equal([]int{14}))
+	})
+
+	It("writes games", func() {
+		g := &pgn.Game{Tags: []pgn.Tag{{Name: "Event", Value: `A "quoted" event`}},
+			Moves: []string{"e4", "e5", "Nf3", "Nc6", "Bb5", "a6", "Ba4", "Nf6", "O-O", "Be7", "Re1", "b5", "Bb3",
+				"d6", "c3", "O-O", "h3", "Nb8", "d4", "Nbd7", "c4", "c6"}}
+		var buf bytes.Buffer
+		Expect(g.Write(&buf)).To(Succeed())
+		Expect(buf.String()).To(Equal(`[Event "A \"quoted\" event"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 4. Ba4 Nf6 5. O-O Be7 6. Re1 b5 7. Bb3 d6 8. c3
+O-O 9. h3 Nb8 10. d4 Nbd7 11. c4 c6 *
+`))
+
+		read, err := pgn.NewReader(&buf).Read()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(read.Tags).To(Equal(g.Tags))
+		Expect(read.Moves).To(Equal(g.Moves))
+
+		By("numbering moves from a set up position")
+		g = &pgn.Game{Tags: []pgn.Tag{{Name: "FEN", Value: "4k3/8/8/8/8/8/4P3/4K3 b - - 0 12"}},
+			Moves: []string{"Kd7", "e4"}, Result: "1/2-1/2"}
+		buf.Reset()
+		Expect(g.Write(&buf)).To(Succeed())
+		Expect(buf.String()).To(HaveSuffix("\n12... Kd7 13. e4 1/2-1/2\n"))
+	})
+
+	It("round trips game trees", func() {
+		const text = `{Intro} 1. e4 $1 {KP} 1... e5 (1... c5 $6) ({Sicilian?} 1... c6) 2. Nf3 $14 *
+`
+		g, err := pgn.NewReader(strings.NewReader(text)).Read()
+		Expect(err).NotTo(HaveOccurred())
+		var buf bytes.Buffer
+		Expect(g.Write(&buf)).To(Succeed())
+		Expect(buf.String()).To(Equal(text))
+	})
+
+	It("parses and formats clocks", func() {
+		d, rest, ok := pgn.ParseClock("good [%clk 1:02:03.5] move")
+		Expect(ok).To(BeTrue())
+		Expect(d).To(Equal(time.Hour + 2*time.Minute + 3500*time.Millisecond))
+		Expect(rest).To(Equal("good move"))
+		Expect(pgn.FormatClock(d)).To(Equal("[%clk 1:02:03.5]"))
+		Expect(pgn.FormatClock(59 * time.Second)).To(Equal("[%clk 0:00:59]"))
+
+		_, rest, ok = pgn.ParseClock("no clock")
+		Expect(ok).To(BeFalse())
+		Expect(rest).To(Equal("no clock"))
+	})
+})
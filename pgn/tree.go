@@ -0,0 +1,50 @@
+package pgn
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Node is a node of a game tree. The root has no move, its children are the first moves of a game.
+// The first child of a node continues the main line, other children are alternative variations.
+type Node struct {
+	Move          string // a move in short algebraic notation
+	NAGs          []int  // numeric annotation glyphs of the move
+	CommentBefore string // a comment before the first move of a variation
+	Comment       string // a comment after the move, for the root it's a comment before the first move
+	Children      []*Node
+}
+
+// suffixNAGs maps move suffix annotations to numeric annotation glyphs
+var suffixNAGs = map[string]int{"!": 1, "?": 2, "!!": 3, "??": 4, "!?": 5, "?!": 6}
+
+// clockRegexp matches a clock command of a comment like "[%clk 1:05:30]"
+var clockRegexp = regexp.MustCompile(`\s*\[%clk\s+(\d+):(\d{1,2}):(\d{1,2}(?:\.\d+)?)\]\s*`)
+
+// ParseClock returns a remaining time of a clock command of comment like "[%clk 1:05:30]" and the comment
+// without it. It returns false if there is no clock command.
+func ParseClock(comment string) (time.Duration, string, bool) {
+	loc := clockRegexp.FindStringSubmatchIndex(comment)
+	if loc == nil {
+		return 0, comment, false
+	}
+	h, _ := strconv.Atoi(comment[loc[2]:loc[3]])
+	m, _ := strconv.Atoi(comment[loc[4]:loc[5]])
+	s, _ := strconv.ParseFloat(comment[loc[6]:loc[7]], 64)
+	d := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s*float64(time.Second))
+	rest := comment[:loc[0]] + " " + comment[loc[1]:]
+	return d, strings.TrimSpace(rest), true
+}
+
+// FormatClock returns a clock command of remaining time d like "[%clk 1:05:30]"
+func FormatClock(d time.Duration) string {
+	h, m := d/time.Hour, d%time.Hour/time.Minute
+	s := strconv.FormatFloat((d % time.Minute).Seconds(), 'f', -1, 64)
+	if (d%time.Minute)/time.Second < 10 {
+		s = "0" + s
+	}
+	return fmt.Sprintf("[%%clk %d:%02d:%s]", h, m, s)
+}
@@ -0,0 +1,147 @@
+package pgn
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxLineLength is a maximum length of movetext lines
+const maxLineLength = 79
+
+// movetext writes movetext tokens wrapping lines
+type movetext struct {
+	w          *bufio.Writer
+	lineLength int
+	glue       bool // true if the next token follows the previous one without a space
+}
+
+// token writes a movetext token
+func (m *movetext) token(s string) {
+	switch {
+	case m.lineLength == 0:
+	case m.glue || s == ")":
+		m.glue = false
+		m.w.WriteString(s)
+		m.lineLength += len(s)
+		return
+	case m.lineLength+1+len(s) > maxLineLength:
+		m.w.WriteString("\n")
+		m.lineLength = 0
+	default:
+		m.w.WriteString(" ")
+		m.lineLength++
+	}
+	m.glue = s == "("
+	m.w.WriteString(s)
+	m.lineLength += len(s)
+}
+
+// comment writes a comment, braces are removed from text as comments can't be nested
+func (m *movetext) comment(text string) {
+	words := strings.Fields(strings.NewReplacer("{", "", "}", "").Replace(text))
+	if len(words) == 0 {
+		return
+	}
+	for i, word := range words {
+		if i == 0 {
+			word = "{" + word
+		}
+		m.token(word)
+	}
+	m.glue = true
+	m.token("}")
+}
+
+// move writes a move of node made at ply counted from 0 for the first white move,
+// a move number is written for white moves and for black moves if withNumber is true
+func (m *movetext) move(node *Node, ply int, withNumber bool) {
+	if node.CommentBefore != "" {
+		m.comment(node.CommentBefore)
+		withNumber = true
+	}
+	switch {
+	case ply%2 == 0:
+		m.token(strconv.Itoa(ply/2+1) + ".")
+	case withNumber:
+		m.token(strconv.Itoa(ply/2+1) + "...")
+	}
+	m.token(node.Move)
+	for _, nag := range node.NAGs {
+		m.token("$" + strconv.Itoa(nag))
+	}
+	if node.Comment != "" {
+		m.comment(node.Comment)
+	}
+}
+
+// line writes a line of moves following node with variations, the first move is made at ply
+func (m *movetext) line(node *Node, ply int) {
+	for withNumber := true; len(node.Children) > 0; ply++ {
+		main := node.Children[0]
+		m.move(main, ply, withNumber)
+		withNumber = main.Comment != ""
+		for _, variation := range node.Children[1:] {
+			m.token("(")
+			m.move(variation, ply, true)
+			m.line(variation, ply+1)
+			m.token(")")
+			withNumber = true
+		}
+		node = main
+	}
+}
+
+// firstPly returns a ply of the first move of a game, it's counted from 0 for the first white move
+func (g *Game) firstPly() int {
+	fields := strings.Fields(g.FEN())
+	if len(fields) < 2 {
+		return 0
+	}
+	ply := 0
+	if len(fields) >= 6 {
+		if n, err := strconv.Atoi(fields[5]); err == nil && n > 0 {
+			ply = 2 * (n - 1)
+		}
+	}
+	if fields[1] == "b" {
+		ply++
+	}
+	return ply
+}
+
+// Write writes a game in PGN: tag pairs, movetext of the game tree or of moves of the main line
+// if there is no tree, and a result which is "*" if it's unknown
+func (g *Game) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, tag := range g.Tags {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(tag.Value)
+		fmt.Fprintf(bw, "[%s \"%s\"]\n", tag.Name, value)
+	}
+	if len(g.Tags) > 0 {
+		bw.WriteString("\n")
+	}
+
+	tree := g.Tree
+	if tree == nil {
+		tree = &Node{}
+		for node, i := tree, 0; i < len(g.Moves); i++ {
+			node.Children = []*Node{{Move: g.Moves[i]}}
+			node = node.Children[0]
+		}
+	}
+	m := &movetext{w: bw}
+	if tree.Comment != "" {
+		m.comment(tree.Comment)
+	}
+	m.line(tree, g.firstPly())
+	result := g.Result
+	if result == "" {
+		result = "*"
+	}
+	m.token(result)
+	bw.WriteString("\n")
+	return bw.Flush()
+}
@@ -0,0 +1,205 @@
+// Package problem solves chess problems on rectangular boards with any pieces: direct mates, helpmates
+// and selfmates in N moves. It finds all key moves with full solution trees, so unsound problems
+// with several solutions (cooks) are detected.
+package problem
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mtfelian/mtfchess/rect"
+)
+
+// Kind is a kind of a problem
+type Kind int
+
+const (
+	// Direct is a direct mate: the side to move mates in N moves against any defence
+	Direct Kind = iota
+	// Help is a helpmate: the side to move starts and both sides cooperate, so the opponent mates it in N moves
+	Help
+	// Self is a selfmate: the side to move forces the opponent to mate it in N moves against any defence
+	Self
+)
+
+// String makes Kind to implement fmt.Stringer
+func (k Kind) String() string { return [...]string{"#", "h#", "s#"}[k] }
+
+// ErrMultiPlayer is returned for boards of multi-player games
+var ErrMultiPlayer = errors.New("problems of multi-player games are not supported")
+
+// Node is a move of a solution tree. Children of a move of a solving side are all replies of the opponent,
+// children of a reply are all moves of the solving side which still solve the problem.
+type Node struct {
+	Move     string // a move in long algebraic notation
+	Children []*Node
+}
+
+// Solution is a solution of a problem
+type Solution struct {
+	Kind  Kind
+	Moves int
+	Keys  []*Node // first moves solving a problem with their solution trees
+}
+
+// Lines returns all lines of solution trees from key moves to last moves
+func (s *Solution) Lines() [][]string {
+	res := [][]string{}
+	var walk func(nodes []*Node, line []string)
+	walk = func(nodes []*Node, line []string) {
+		for _, n := range nodes {
+			next := append(append([]string{}, line...), n.Move)
+			if len(n.Children) == 0 {
+				res = append(res, next)
+			}
+			walk(n.Children, next)
+		}
+	}
+	walk(s.Keys, []string{})
+	return res
+}
+
+// Cooked returns true if a problem has several solutions: several key moves of a direct mate or a selfmate,
+// or several lines of a helpmate
+func (s *Solution) Cooked() bool {
+	if s.Kind == Help {
+		return len(s.Lines()) > 1
+	}
+	return len(s.Keys) > 1
+}
+
+// Solve returns a solution of a problem of kind in n moves with a position on board, it has no keys
+// if there is no solution. The board isn't changed.
+func Solve(b *rect.Board, kind Kind, n int) (*Solution, error) {
+	switch {
+	case n < 1:
+		return nil, fmt.Errorf("invalid number of moves %d", n)
+	case kind < Direct || kind > Self:
+		return nil, fmt.Errorf("invalid problem kind %d", kind)
+	case b.Settings().Players != nil:
+		return nil, ErrMultiPlayer
+	case b.Outcome().IsFinished():
+		return nil, fmt.Errorf("game is finished: %s", b.Outcome())
+	}
+	s := &Solution{Kind: kind, Moves: n}
+	switch kind {
+	case Direct:
+		s.Keys, _ = direct(b, n)
+	case Help:
+		s.Keys, _ = help(b, 2*n)
+	case Self:
+		s.Keys, _ = self(b, n)
+	}
+	return s, nil
+}
+
+// SolveXFEN returns a solution of a problem of kind in n moves with a position of X-FEN
+func SolveXFEN(xfen rect.XFEN, kind Kind, n int) (*Solution, error) {
+	b, err := xfen.Board()
+	if err != nil {
+		return nil, err
+	}
+	return Solve(b.(*rect.Board), kind, n)
+}
+
+// move is a legal move with a board after it
+type move struct {
+	notation string
+	board    *rect.Board
+}
+
+// moves returns all legal moves of the side to move on b
+func moves(b *rect.Board) []move {
+	notation, res := rect.NewLongAlgebraicNotation(), []move{}
+	for _, m := range b.LegalMoves(notation) {
+		next := b.Copy().(*rect.Board)
+		if makeMove, err := notation.DecodeMove(next, m); err == nil && makeMove() {
+			res = append(res, move{notation: m, board: next})
+		}
+	}
+	return res
+}
+
+// checkmated returns true if the side to move on b is checkmated
+func checkmated(b *rect.Board) bool { return b.InCheckmate(b.SideToMove()) }
+
+// direct returns moves of the side to move on b mating in n moves against any defence with their trees
+func direct(b *rect.Board, n int) ([]*Node, bool) {
+	res := []*Node{}
+	for _, m := range moves(b) {
+		if checkmated(m.board) {
+			res = append(res, &Node{Move: m.notation})
+			continue
+		}
+		if n == 1 || m.board.Outcome().IsFinished() {
+			continue
+		}
+		if replies, ok := defend(m.board, func(b *rect.Board) ([]*Node, bool) { return direct(b, n-1) }); ok {
+			res = append(res, &Node{Move: m.notation, Children: replies})
+		}
+	}
+	return res, len(res) > 0
+}
+
+// defend returns all replies of the defending side to move on b with moves of the solving side after them
+// found by solve. It returns false if the defending side has no moves or some reply has no solving moves.
+func defend(b *rect.Board, solve func(b *rect.Board) ([]*Node, bool)) ([]*Node, bool) {
+	replies := moves(b)
+	if len(replies) == 0 {
+		return nil, false
+	}
+	res := make([]*Node, 0, len(replies))
+	for _, r := range replies {
+		children, ok := solve(r.board)
+		if !ok {
+			return nil, false
+		}
+		res = append(res, &Node{Move: r.notation, Children: children})
+	}
+	return res, true
+}
+
+// help returns lines of plies moves starting on b after which the side to move on b is checkmated
+func help(b *rect.Board, plies int) ([]*Node, bool) {
+	res := []*Node{}
+	for _, m := range moves(b) {
+		switch {
+		case plies == 1:
+			if checkmated(m.board) {
+				res = append(res, &Node{Move: m.notation})
+			}
+		case m.board.Outcome().IsFinished():
+		default:
+			if children, ok := help(m.board, plies-1); ok {
+				res = append(res, &Node{Move: m.notation, Children: children})
+			}
+		}
+	}
+	return res, len(res) > 0
+}
+
+// self returns moves of the side to move on b forcing the opponent to mate it in n moves with their trees
+func self(b *rect.Board, n int) ([]*Node, bool) {
+	res := []*Node{}
+	for _, m := range moves(b) {
+		if m.board.Outcome().IsFinished() {
+			continue // the opponent is mated or stalemated, or the game is drawn
+		}
+		if replies, ok := defend(m.board, func(b *rect.Board) ([]*Node, bool) { return forcedMate(b, n-1) }); ok {
+			res = append(res, &Node{Move: m.notation, Children: replies})
+		}
+	}
+	return res, len(res) > 0
+}
+
+// forcedMate returns true if the side to move on b is checkmated by the last reply of the opponent,
+// or if it can force the opponent to mate it in n more moves
+func forcedMate(b *rect.Board, n int) ([]*Node, bool) {
+	if checkmated(b) {
+		return nil, true
+	}
+	if n == 0 {
+		return nil, false
+	}
+	return self(b, n)
+}
@@ -0,0 +1,88 @@
+package problem_test
+
+import (
+	"github.com/mtfelian/mtfchess/problem"
+	"github.com/mtfelian/mtfchess/rect"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("problem", func() {
+	// solve returns a solution of a problem
+	solve := func(xfen rect.XFEN, kind problem.Kind, n int) *problem.Solution {
+		s, err := problem.SolveXFEN(xfen, kind, n)
+		Expect(err).NotTo(HaveOccurred())
+		return s
+	}
+
+	// keys returns key moves of s
+	keys := func(s *problem.Solution) []string {
+		res := []string{}
+		for _, key := range s.Keys {
+			res = append(res, key.Move)
+		}
+		return res
+	}
+
+	Context("direct mates", func() {
+		It("solves mate in 1", func() {
+			s := solve("6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", problem.Direct, 1)
+			Expect(s.Lines()).To(Equal([][]string{{"Ra1-a8#"}}))
+			Expect(s.Cooked()).To(BeFalse())
+
+			s = solve("6k1/5ppp/8/8/8/8/8/RR4K1 w - - 0 1", problem.Direct, 1)
+			Expect(keys(s)).To(Equal([]string{"Ra1-a8#", "Rb1-b8#"}))
+			Expect(s.Cooked()).To(BeTrue())
+		})
+
+		It("solves mate in 2 with all defences", func() {
+			s := solve("k4/5/5/1K3/5/4R w - - 0 1", problem.Direct, 2)
+			Expect(keys(s)).To(Equal([]string{"Kb3-b4"}))
+			Expect(s.Cooked()).To(BeFalse())
+			Expect(s.Keys[0].Children).NotTo(BeEmpty())
+			for _, defence := range s.Keys[0].Children {
+				Expect(defence.Children).NotTo(BeEmpty(), defence.Move)
+				for _, mate := range defence.Children {
+					Expect(mate.Move).To(HaveSuffix("#"))
+				}
+			}
+			Expect(solve("k4/5/5/1K3/5/4R w - - 0 1", problem.Direct, 1).Keys).To(BeEmpty())
+
+			s = solve("k4/5/2K2/5/5/3R1 w - - 0 1", problem.Direct, 2)
+			Expect(keys(s)).To(Equal([]string{"Kc4-b4", "Kc4-c5"}))
+			Expect(s.Cooked()).To(BeTrue())
+		})
+
+		It("solves problems with fairy pieces", func() {
+			s := solve("k4/5/K4/4A/5/5 w - - 0 1", problem.Direct, 1)
+			Expect(keys(s)).To(Equal([]string{"Ae3-c5#", "Ae3-c4#"}))
+			Expect(s.Cooked()).To(BeTrue())
+		})
+	})
+
+	It("solves helpmates", func() {
+		s := solve("k7/8/1K6/8/8/8/8/7R b - - 0 1", problem.Help, 1)
+		Expect(s.Lines()).To(Equal([][]string{{"Ka8-b8", "Rh1-h8#"}}))
+		Expect(s.Cooked()).To(BeFalse())
+	})
+
+	It("solves selfmates", func() {
+		s := solve("k3N3/8/2N5/8/8/8/p5PP/rn5K w - - 0 1", problem.Self, 1)
+		Expect(keys(s)).To(Equal([]string{"Ne8-d6"}))
+		Expect(s.Cooked()).To(BeFalse())
+		Expect(s.Lines()).To(HaveLen(3))
+		for _, line := range s.Lines() {
+			Expect(line[1]).To(HavePrefix("Nb1-"))
+			Expect(line[1]).To(HaveSuffix("#"))
+		}
+	})
+
+	It("rejects invalid problems", func() {
+		_, err := problem.SolveXFEN("k7/8/1K6/8/8/8/8/7R b - - 0 1", problem.Direct, 0)
+		Expect(err).To(HaveOccurred())
+		_, err = problem.SolveXFEN("k7/8/1K6/8/8/8/8/7R b - - 0 1", problem.Kind(5), 1)
+		Expect(err).To(HaveOccurred())
+		_, err = problem.SolveXFEN("bad", problem.Direct, 1)
+		Expect(err).To(HaveOccurred())
+	})
+})
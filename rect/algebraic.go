@@ -8,10 +8,12 @@ import (
 	"unicode"
 
 	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
 )
 
 const (
 	longAlgebraic = iota
+	shortAlgebraic
 )
 
 const (
@@ -25,15 +27,19 @@ const (
 	checkmatePostfix = "#"
 )
 
+const promotionDelimiter = "="
+
 const (
-	aSideCastling = "O-O-O"
-	zSideCastling = "O-O"
+	aSideCastling     = "O-O-O"
+	zSideCastling     = "O-O"
+	castlingDelimiter = "/"
 )
 
 var (
-	castlingRegexp           = regexp.MustCompile(`(?i)^(O-O(?:-O)?)[+#]?$`)
+	castlingRegexp           = regexp.MustCompile(`(?i)^(O-O(?:-O)?)(?:/([a-z]\d{1,2}))?(?:/([a-z]\d{1,2}))?[+#]?$`)
 	longAlgebraicCoordRegexp = regexp.MustCompile(`^([a-z])(\d{1,2})$`)
-	longAlgebraicMoveRegexp  = regexp.MustCompile(`^([a-z]\d{1,2})[-x]([a-z]\d{1,2})[+#]?$`)
+	longAlgebraicMoveRegexp  = regexp.MustCompile(`^([a-z])?([a-z]\d{1,2})[-x]([a-z]\d{1,2})(?:=?([a-z]))?[+#]?$`)
+	shortAlgebraicMoveRegexp = regexp.MustCompile(`^([A-Z])?([a-z])??(\d{1,2})?x?([a-z]\d{1,2})(?:=?([A-Za-z]))?[+#]?[!?]*$`)
 )
 
 // algebraicNotation implementation for INotation
@@ -45,6 +51,9 @@ type algebraicNotation struct {
 // NewLongAlgebraicNotation returns new long algebraic notation
 func NewLongAlgebraicNotation() *algebraicNotation { return &algebraicNotation{mode: longAlgebraic} }
 
+// NewShortAlgebraicNotation returns new short (standard) algebraic notation used in PGN, like "Nbd7" or "exd8=Q+"
+func NewShortAlgebraicNotation() *algebraicNotation { return &algebraicNotation{mode: shortAlgebraic} }
+
 // FromLetter returns x coord from the given letter
 func FromLetter(letter rune) int { return int(unicode.ToLower(letter) - 'a' + 1) }
 
@@ -66,50 +75,186 @@ func (n *algebraicNotation) DecodeMove(board base.IBoard, move string) (func() b
 		move = strings.ToUpper(move)
 
 		parts := re.FindStringSubmatch(move)
-		if len(parts) != 2 {
+		if len(parts) != 4 {
 			return nil, fmt.Errorf("wrong casling move format: %s", move)
 		}
 
-		castlings := board.Castlings(board.SideToMove())
+		// optional parts are king's destination and castling partner coords
+		filter := make([]base.ICoord, 2)
+		for i, part := range parts[2:] {
+			if part == "" {
+				continue
+			}
+			if err := n.DecodeCoord(part); err != nil {
+				return nil, err
+			}
+			filter[i] = n.Coord.Copy()
+		}
+
+		castlings, found := board.Castlings(board.SideToMove()), base.Castlings{}
 		castlingStrings := []string{aSideCastling, zSideCastling}
 		for i := range castlings {
-			if parts[1] == castlingStrings[castlings[i].I] {
-				return func() bool { return board.MakeCastling(castlings[i]) }, nil
+			if parts[1] != castlingStrings[castlings[i].I] ||
+				filter[0] != nil && !filter[0].Equals(castlings[i].To[0]) ||
+				filter[1] != nil && !filter[1].Equals(castlings[i].Piece[1].Coord()) {
+				continue
 			}
+			found = append(found, castlings[i])
+		}
+		switch len(found) {
+		case 0:
+			return nil, fmt.Errorf("this castling move is not available")
+		case 1:
+			return func() bool { return board.MakeCastling(found[0]) }, nil
 		}
-		return nil, fmt.Errorf("this castling move is not available")
+		return nil, fmt.Errorf("ambiguous castling move: %s", move)
 	}
 
 	// move is not a castling
 
+	if n.mode == shortAlgebraic {
+		return n.decodeShortMove(board, move)
+	}
+
 	move, re = strings.ToLower(move), longAlgebraicMoveRegexp.Copy()
 	if !re.MatchString(move) {
 		return nil, fmt.Errorf("wrong move format: %s", move)
 	}
 
 	parts := re.FindStringSubmatch(move)
-	if len(parts) != 3 {
+	if len(parts) != 5 {
 		return nil, fmt.Errorf("wrong move format: %s", move)
 	}
 
-	if err := n.DecodeCoord(parts[1]); err != nil {
+	if err := n.DecodeCoord(parts[2]); err != nil {
 		return nil, err
 	}
 	fromCoord := n.Coord.Copy()
-	if err := n.DecodeCoord(parts[2]); err != nil {
+	if err := n.DecodeCoord(parts[3]); err != nil {
 		return nil, err
 	}
 	toCoord := n.Coord.Copy()
 
-	return func() bool { return board.MakeMove(toCoord, board.Piece(fromCoord)) }, nil
+	// isMoving returns true if piece matches the optional piece letter of a move
+	isMoving := func(piece base.IPiece) bool {
+		return piece != nil && (parts[1] == "" || string(unicode.ToLower(piece.Capital())) == parts[1])
+	}
+
+	if parts[4] == "" {
+		return func() bool {
+			piece := board.Piece(fromCoord)
+			return isMoving(piece) && board.MakeMove(toCoord, piece)
+		}, nil
+	}
+
+	newPromotion, exists := StandardPieceRegistry()[parts[4]]
+	if !exists {
+		return nil, fmt.Errorf("wrong promotion piece: %s", parts[4])
+	}
+	return func() bool {
+		piece := board.Piece(fromCoord)
+		if !isMoving(piece) {
+			return false
+		}
+		piece.SetPromote(newPromotion(piece.Colour()))
+		if !board.MakeMove(toCoord, piece) {
+			piece.SetPromote(nil)
+			return false
+		}
+		return true
+	}, nil
+}
+
+// decodeShortMove returns a func that tries to make a move in short algebraic notation
+func (n *algebraicNotation) decodeShortMove(board base.IBoard, move string) (func() bool, error) {
+	long, err := n.LongMove(board, move)
+	if err != nil {
+		return nil, err
+	}
+	return NewLongAlgebraicNotation().DecodeMove(board, long)
+}
+
+// LongMove returns a move converted to long algebraic notation like "g1-f3" or "e7-e8=Q",
+// castlings and moves in long algebraic notation are returned as they are.
+// A moving piece of a move in short algebraic notation is found by its letter, optional file and rank
+// and destination.
+func (n *algebraicNotation) LongMove(board base.IBoard, move string) (string, error) {
+	if n.mode == longAlgebraic || castlingRegexp.MatchString(move) {
+		return move, nil
+	}
+	parts := shortAlgebraicMoveRegexp.FindStringSubmatch(move)
+	if len(parts) != 6 {
+		return "", fmt.Errorf("wrong move format: %s", move)
+	}
+	an := NewLongAlgebraicNotation()
+	if err := an.DecodeCoord(parts[4]); err != nil {
+		return "", err
+	}
+	to := an.Coord
+
+	found := base.Pieces{}
+	for _, piece := range board.FindPieces(base.PieceFilter{Colours: []Colour{board.SideToMove()}}) {
+		c := piece.Coord().(Coord)
+		switch {
+		case parts[1] == "" && piece.Name() != base.PawnName, parts[1] != "" && string(piece.Capital()) != parts[1]:
+		case parts[2] != "" && string(ToLetter(c.X)) != parts[2], parts[3] != "" && strconv.Itoa(c.Y) != parts[3]:
+		case piece.Destinations(board).Contains(to):
+			found = append(found, piece)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("no piece can make move: %s", move)
+	case 1:
+		long := an.SetCoord(found[0].Coord()).EncodeCoord() + moveDelimiter + parts[4]
+		if parts[5] != "" {
+			long += promotionDelimiter + strings.ToUpper(parts[5])
+		}
+		return long, nil
+	}
+	return "", fmt.Errorf("ambiguous move: %s", move)
+}
+
+// disambiguation returns a file, a rank or coords of piece needed to distinguish its move to dst in short
+// algebraic notation from moves of other same pieces to dst. Pawns are distinguished by a file on captures.
+func disambiguation(board base.IBoard, piece base.IPiece, dst base.ICoord, capture bool) string {
+	from := piece.Coord().(Coord)
+	if piece.Name() == base.PawnName {
+		if capture {
+			return string(ToLetter(from.X))
+		}
+		return ""
+	}
+	ambiguous, sameFile, sameRank := false, false, false
+	for _, other := range board.FindPieces(base.PieceFilter{Colours: []Colour{piece.Colour()}}) {
+		c := other.Coord().(Coord)
+		if other.Name() != piece.Name() || c.Equals(from) || !other.Destinations(board).Contains(dst) {
+			continue
+		}
+		ambiguous, sameFile, sameRank = true, sameFile || c.X == from.X, sameRank || c.Y == from.Y
+	}
+	switch {
+	case !ambiguous:
+		return ""
+	case !sameFile:
+		return string(ToLetter(from.X))
+	case !sameRank:
+		return strconv.Itoa(from.Y)
+	}
+	return NewLongAlgebraicNotation().SetCoord(from).EncodeCoord()
 }
 
 // EncodeMove on board with piece to dst coord
 func (n *algebraicNotation) EncodeMove(board base.IBoard, piece base.IPiece, dst base.ICoord) string {
 	anFrom := NewLongAlgebraicNotation().SetCoord(piece.Coord())
 	anTo := NewLongAlgebraicNotation().SetCoord(dst)
-	delimiter := moveDelimiter
-	if board.Piece(dst) != nil {
+	from, delimiter, capture := anFrom.EncodeCoord(), moveDelimiter, board.Piece(dst) != nil
+	if n.mode == shortAlgebraic {
+		// en passant capture is a diagonal pawn move to an empty cell
+		capture = capture || piece.Name() == base.PawnName && piece.Coord().(Coord).X != dst.(Coord).X
+		from, delimiter = disambiguation(board, piece, dst, capture), ""
+	}
+	if capture {
 		delimiter = captureDelimiter
 	}
 
@@ -118,28 +263,47 @@ func (n *algebraicNotation) EncodeMove(board base.IBoard, piece base.IPiece, dst
 		fig = ""
 	}
 
-	projection := board.Project(piece, dst)
+	promotion, projection := "", board.Project(piece, dst)
+	if piece.Promotion() != nil {
+		promotion = promotionDelimiter + string(piece.Promotion().Capital())
+		projection = board.Copy().Empty(piece.Coord()).PlacePiece(dst, piece.Promote())
+	}
 	projection.SetSideToMove(projection.SideToMove().Invert())
 
 	check := noPostfix
 	if projection.InCheckmate(projection.SideToMove()) {
 		check = checkmatePostfix
-		return fig + anFrom.EncodeCoord() + delimiter + anTo.EncodeCoord() + check
+		return fig + from + delimiter + anTo.EncodeCoord() + promotion + check
 	}
 
 	if projection.InCheck(projection.SideToMove()) {
 		check = checkPostfix
 	}
 
-	return fig + anFrom.EncodeCoord() + delimiter + anTo.EncodeCoord() + check
+	return fig + from + delimiter + anTo.EncodeCoord() + promotion + check
 }
 
-// EncodeCastling on board
-func (n *algebraicNotation) EncodeCastling(i int) string {
-	if i == 0 {
-		return aSideCastling
+// EncodeCastling on board, king's destination and then partner coords are appended
+// to "O-O" or "O-O-O" if there are several castlings to the same side, like "O-O/f1/h1"
+func (n *algebraicNotation) EncodeCastling(board base.IBoard, castling base.Castling) string {
+	res := []string{aSideCastling, zSideCastling}[castling.I]
+	sameSide, sameDst := 0, 0
+	for _, c := range board.Castlings(castling.Piece[0].Colour()) {
+		if c.I != castling.I {
+			continue
+		}
+		sameSide++
+		if c.To[0].Equals(castling.To[0]) {
+			sameDst++
+		}
+	}
+	if sameSide > 1 {
+		res += castlingDelimiter + NewLongAlgebraicNotation().SetCoord(castling.To[0]).EncodeCoord()
+	}
+	if sameDst > 1 {
+		res += castlingDelimiter + NewLongAlgebraicNotation().SetCoord(castling.Piece[1].Coord()).EncodeCoord()
 	}
-	return zSideCastling
+	return res
 }
 
 // DecodeCoord coord string (case-insensitive) to (x,y) coords
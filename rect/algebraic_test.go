@@ -63,4 +63,91 @@ var _ = Describe("Algebraic test", func() {
 			Expect(notation.EncodeCoord()).To(Equal(testCase.algebraic))
 		}
 	})
+
+	It("decodes its own encoded moves", func() {
+		b, err := rect.NewStandardChessStartingPosition().Board()
+		Expect(err).NotTo(HaveOccurred())
+		n := rect.NewLongAlgebraicNotation()
+		for _, move := range []string{"Ng1-f3", "g8-f6", "nb1-c3"} {
+			By(fmt.Sprintf("Checking %s...", move))
+			f, err := n.DecodeMove(b, move)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(f()).To(BeTrue())
+		}
+		f, err := n.DecodeMove(b, "Bb8-c6")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f()).To(BeFalse(), "there is a knight on b8, not a bishop")
+		Expect(b.MoveNumber()).To(Equal(2))
+	})
+
+	It("encodes and decodes moves in short algebraic notation", func() {
+		testCases := []struct {
+			xfen   rect.XFEN
+			moves  []string // all of moves should be legal and decoded back to the same moves
+			absent []string // moves which should not be encoded
+		}{
+			{rect.NewStandardChessStartingPosition(), []string{"e4", "Nf3", "Na3", "h3"}, []string{"Ng1f3", "e2-e4"}},
+			{`4k3/8/8/8/8/2N3N1/8/4K3 w - - 0 1`, []string{"Nce4", "Nge4", "Nce2", "Nb5", "Kd2"}, []string{"Ne4"}},
+			{`4k3/8/8/R7/8/8/8/R3K3 w - - 0 1`, []string{"R1a3", "R5a3", "Rb1", "Rb5"}, []string{"Raa3"}},
+			{`4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1`, []string{"exd6", "e6"}, []string{"d6"}},
+			{`3r3k/4P3/8/8/8/8/8/4K3 w - - 0 1`, []string{"exd8=Q+", "e8=N", "exd8=R+", "e8=Q+"}, []string{"e8"}},
+			{`3qk3/8/8/8/8/8/8/3RK3 w - - 0 1`, []string{"Rxd8+", "Rd7"}, []string{"Rdxd8+"}},
+		}
+
+		for i, testCase := range testCases {
+			By(fmt.Sprintf("Checking testCase %v at index %d...", testCase, i))
+			b, err := testCase.xfen.Board()
+			Expect(err).NotTo(HaveOccurred())
+			legalMoves := b.LegalMoves(rect.NewShortAlgebraicNotation())
+			for _, move := range testCase.moves {
+				Expect(legalMoves).To(ContainElement(move))
+			}
+			for _, move := range testCase.absent {
+				Expect(legalMoves).NotTo(ContainElement(move))
+			}
+			for _, move := range legalMoves {
+				b1 := b.Copy()
+				f, err := rect.NewShortAlgebraicNotation().DecodeMove(b1, move)
+				Expect(err).NotTo(HaveOccurred(), move)
+				Expect(f()).To(BeTrue(), move)
+			}
+		}
+	})
+
+	It("plays a game in short algebraic notation", func() {
+		b, err := rect.NewStandardChessStartingPosition().Board()
+		Expect(err).NotTo(HaveOccurred())
+		n := rect.NewShortAlgebraicNotation()
+		for _, move := range []string{"e4", "e5", "Nf3", "Nc6", "Bc4", "Bc5", "O-O", "Nf6", "d4", "exd4",
+			"e5", "d5", "exf6", "dxc4", "Re1+", "Be6", "Ng5", "Qd5", "Nc3!", "Qf5", "Nce4", "O-O-O"} {
+			f, err := n.DecodeMove(b, move)
+			Expect(err).NotTo(HaveOccurred(), move)
+			Expect(f()).To(BeTrue(), move)
+		}
+		Expect(rect.NewXFEN(b.(*rect.Board))).To(
+			Equal(rect.XFEN(`2kr3r/ppp2ppp/2n1bP2/2b2qN1/2ppN3/8/PPP2PPP/R1BQR1K1 w - - 8 12`)))
+
+		for _, move := range []string{"Nb4", "Qd8", "e4", "K2"} {
+			_, err := n.DecodeMove(b, move)
+			Expect(err).To(HaveOccurred(), move)
+		}
+	})
+
+	It("converts moves in short algebraic notation to long algebraic notation", func() {
+		b, err := rect.XFEN(`3r3k/4P3/8/8/8/8/8/R3K1N1 w Q - 0 1`).Board()
+		Expect(err).NotTo(HaveOccurred())
+		for short, long := range map[string]string{
+			"Nf3": "g1-f3", "exd8=q": "e7-d8=Q", "Ra7+": "a1-a7", "O-O-O": "O-O-O",
+		} {
+			By(fmt.Sprintf("Checking %s...", short))
+			move, err := rect.NewShortAlgebraicNotation().LongMove(b, short)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(move).To(Equal(long))
+		}
+		_, err = rect.NewShortAlgebraicNotation().LongMove(b, "e2-e4")
+		Expect(err).To(HaveOccurred())
+		move, err := rect.NewLongAlgebraicNotation().LongMove(b, "e2-e4")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(move).To(Equal("e2-e4"))
+	})
 })
@@ -2,6 +2,7 @@ package rect
 
 import (
 	"fmt"
+	"hash/fnv"
 
 	"github.com/mtfelian/mtfchess/base"
 	. "github.com/mtfelian/mtfchess/colour"
@@ -14,13 +15,19 @@ type Board struct {
 	width, height         int
 	king                  map[Colour]base.IPiece
 	canCaptureEnPassantAt base.ICoord
-	rookCoords            base.RookCoords
+	enPassantTargets      []base.ICoord
+	castlingPartners      base.CastlingPartners
 	settings              *base.Settings
 	sideToMove            Colour
 	moveNumber            int
 	halfMoveCounter       int
 	outcome               base.Outcome
-	positionsCounter      map[string]int // maps string position description (part of X-FEN) to counter it's occurred
+	positionsCounter      map[string]int         // maps string position description (part of X-FEN) to counter it's occurred
+	hands                 map[Colour]base.Pieces // pieces in hand, nil if variant has no hands
+	checksGiven           map[Colour]int         // amount of checks given by colour, nil if not counted
+	eliminated            map[Colour]bool        // sides eliminated from a multi-player game
+	scores                map[Colour]int         // points of sides in a multi-player game with scoring
+	holes                 holeSet                // hole set built from settings holes, shared by board copies
 }
 
 // X converts x1 to slice index
@@ -29,25 +36,17 @@ func (b *Board) X(x int) int { return x - 1 }
 // Y converts y1 to slice index
 func (b *Board) Y(y int) int { return b.height - y }
 
-// String makes Board to implement Stringer
+// String makes Board to implement Stringer, it returns a plain text diagram and a side to move
 func (b *Board) String() string {
-	var s string
-	for i := range b.cells {
-		for j := range b.cells[i] {
-			s += fmt.Sprintf("%s", b.cells[i][j])
-		}
-		s += "\n"
-	}
-	s += fmt.Sprintf("Side: %s, Rook: %v, EP: %v, King: %v, M/HM: %d/%d\n",
-		b.sideToMove, b.rookCoords, b.canCaptureEnPassantAt, b.king, b.moveNumber, b.halfMoveCounter)
-	return s
+	return b.Diagram(DefaultDiagramOptions()) +
+		fmt.Sprintf("%s to move, move %d\n", b.sideToMove, b.moveNumber)
 }
 
 // Dim returns a board dimensions
 func (b *Board) Dim() base.ICoord { return Coord{X: b.width, Y: b.height} }
 
 // SetSettings of a board to s
-func (b *Board) SetSettings(s *base.Settings) { b.settings = s }
+func (b *Board) SetSettings(s *base.Settings) { b.settings, b.holes = s, newHoleSet(s) }
 
 // Settings returns board settings
 func (b *Board) Settings() *base.Settings { return b.settings }
@@ -62,10 +61,10 @@ func (b *Board) initializeKing() {
 	}
 }
 
-// initializeRookCoords initializes board rook coords
-func (b *Board) initializeRookCoords() {
-	if b.rookCoords == nil {
-		b.rookCoords = base.NewRookCoords()
+// initializeCastlingPartners initializes board castling partners
+func (b *Board) initializeCastlingPartners() {
+	if b.castlingPartners == nil {
+		b.castlingPartners = base.NewCastlingPartners()
 	}
 }
 
@@ -88,23 +87,37 @@ func (b *Board) SetCanCaptureEnPassantAt(dst base.ICoord) { b.canCaptureEnPassan
 // CanCaptureEnPassantAt returns a piece dst coords which can be captured en passant
 func (b *Board) CanCaptureEnPassantAt() base.ICoord { return b.canCaptureEnPassantAt }
 
-// SetRookInitialCoords sets the rook initial coords
-// set parameter i to 0 for most aSide rook and set i to 1 for most zSide rook
-func (b *Board) SetRookInitialCoords(colour Colour, i int, coord base.ICoord) {
-	if i != 0 && i != 1 {
-		panic(fmt.Sprintf("SetRookInitialCoords(): it should be 0 or 1, i: %d", i))
+// SetEnPassantTargets sets coords passed over by a piece which can be captured en passant
+func (b *Board) SetEnPassantTargets(targets []base.ICoord) { b.enPassantTargets = targets }
+
+// EnPassantTargets returns coords passed over by a piece which can be captured en passant,
+// capturing piece should go to one of these coords to capture it
+func (b *Board) EnPassantTargets() []base.ICoord { return b.enPassantTargets }
+
+// copyEnPassantTargets returns a copy of en passant targets
+func (b *Board) copyEnPassantTargets() []base.ICoord {
+	if b.enPassantTargets == nil {
+		return nil
+	}
+	targets := make([]base.ICoord, len(b.enPassantTargets))
+	for i := range b.enPassantTargets {
+		targets[i] = b.enPassantTargets[i].Copy()
+	}
+	return targets
+}
+
+// AddCastlingPartner adds the initial coords of a piece which king of colour can castle with
+func (b *Board) AddCastlingPartner(colour Colour, coord base.ICoord) {
+	if !b.castlingPartners.Contains(colour, coord) {
+		b.castlingPartners[colour] = append(b.castlingPartners[colour], coord.Copy())
 	}
-	states := b.rookCoords[colour]
-	states[i] = coord.Copy()
-	b.rookCoords[colour] = states
 }
 
 // HaveCastlings returns whether side of colour have castling or not
 func (b *Board) HaveCastlings(colour Colour) bool { return len(b.Castlings(colour)) > 0 }
 
-// RookInitialCoords returns rooks coords for castlings as array where
-// 0 index means most aSide rook and 1 index means most zSide rook, coord contains nil if no such rook
-func (b *Board) RookInitialCoords(colour Colour) [2]base.ICoord { return b.rookCoords[colour] }
+// CastlingPartners returns initial coords of pieces which king of colour can castle with
+func (b *Board) CastlingPartners(colour Colour) []base.ICoord { return b.castlingPartners[colour] }
 
 // createCells returns a slice of Cell for the board
 func (b *Board) createCells() {
@@ -174,6 +187,68 @@ func (b *Board) copyKings() map[Colour]base.IPiece {
 	return newKing
 }
 
+// copyHands returns a deep copy of board hands
+func (b *Board) copyHands() map[Colour]base.Pieces {
+	if b.hands == nil {
+		return nil
+	}
+	hands := make(map[Colour]base.Pieces)
+	for colour := range b.hands {
+		hands[colour] = make(base.Pieces, len(b.hands[colour]))
+		for i := range b.hands[colour] {
+			hands[colour][i] = b.hands[colour][i].Copy()
+		}
+	}
+	return hands
+}
+
+// copyChecksGiven returns a copy of checks given counters
+func (b *Board) copyChecksGiven() map[Colour]int {
+	if b.checksGiven == nil {
+		return nil
+	}
+	c := make(map[Colour]int)
+	for key, value := range b.checksGiven {
+		c[key] = value
+	}
+	return c
+}
+
+// Hand returns pieces in hand of the given colour
+func (b *Board) Hand(of Colour) base.Pieces { return b.hands[of] }
+
+// SetHand sets pieces in hand of the given colour, it enables hands on a board
+func (b *Board) SetHand(of Colour, pieces base.Pieces) {
+	if b.hands == nil {
+		b.hands = make(map[Colour]base.Pieces)
+	}
+	b.hands[of] = pieces
+}
+
+// HasHands returns true if hands are enabled on a board
+func (b *Board) HasHands() bool { return b.hands != nil }
+
+// ChecksGiven returns amount of checks given by the given colour
+func (b *Board) ChecksGiven(by Colour) int { return b.checksGiven[by] }
+
+// SetChecksGiven sets amount of checks given by the given colour to n, it enables checks counting on a board
+func (b *Board) SetChecksGiven(by Colour, n int) {
+	if b.checksGiven == nil {
+		b.checksGiven = make(map[Colour]int)
+	}
+	b.checksGiven[by] = n
+}
+
+// countCheck increases checks given by mover counter if checks are counted and side to move is in check
+func (b *Board) countCheck(mover Colour) {
+	if b.checksGiven == nil {
+		return
+	}
+	if b.InCheck(b.SideToMove()) {
+		b.checksGiven[mover]++
+	}
+}
+
 // copyPositionsCounter returns a deep copy of a positions counter
 func (b *Board) copyPositionsCounter() map[string]int {
 	c := make(map[string]int)
@@ -184,19 +259,27 @@ func (b *Board) copyPositionsCounter() map[string]int {
 }
 
 // Copy returns a pointer to a deep copy of a board
-func (b *Board) Copy() base.IBoard {
+func (b *Board) Copy() base.IBoard { return b.copyWith(b.copyPositionsCounter()) }
+
+// copyWith returns a pointer to a deep copy of a board with the given positions counter
+func (b *Board) copyWith(positionsCounter map[string]int) *Board {
 	newBoard := &Board{}
 	newBoard.SetCells(b.Cells().Copy(newBoard))
 	newBoard.SetDim(Coord{X: b.width, Y: b.height})
 	newBoard.king = b.copyKings()
-	newBoard.rookCoords = b.rookCoords.Copy()
-	newBoard.SetSettings(b.Settings())
+	newBoard.castlingPartners = b.castlingPartners.Copy()
+	newBoard.settings, newBoard.holes = b.settings, b.holes
 	newBoard.SetCanCaptureEnPassantAt(b.CanCaptureEnPassantAt())
+	newBoard.SetEnPassantTargets(b.copyEnPassantTargets())
 	newBoard.SetSideToMove(b.SideToMove())
 	newBoard.SetMoveNumber(b.MoveNumber())
 	newBoard.SetHalfMoveCount(b.HalfMoveCount())
 	newBoard.setOutcome(b.Outcome())
-	newBoard.positionsCounter = b.copyPositionsCounter()
+	newBoard.positionsCounter = positionsCounter
+	newBoard.hands = b.copyHands()
+	newBoard.checksGiven = b.copyChecksGiven()
+	newBoard.eliminated = b.copyEliminated()
+	newBoard.scores = b.copyScores()
 	return newBoard
 }
 
@@ -221,7 +304,7 @@ func (b *Board) MakeMove(to base.ICoord, piece base.IPiece) bool {
 		return false
 	}
 
-	fromCoords := piece.Coord().Copy()
+	fromCoords, isPawn := piece.Coord().Copy(), piece.Name() == base.PawnName
 
 	if piece.Promotion() != nil {
 		newPiece := piece.Promote()
@@ -231,29 +314,35 @@ func (b *Board) MakeMove(to base.ICoord, piece base.IPiece) bool {
 		piece = newPiece
 		b.Empty(fromCoords)
 		piece.SetCoords(b, fromCoords)
+	} else if b.Settings().PromotionRules.Kind(b, piece, to) == base.MandatoryPromotion {
+		return false
 	}
 
+	mover := piece.Colour()
 	if capturedPiece != nil {
 		capturedPiece.SetCoords(b, nil)
+		b.addPoints(mover, capturedPiece)
 		b.SetHalfMoveCount(-1) // capture, reset counting: next it will be increased to 0
 	}
 
-	if piece.Name() == base.PawnName {
-		epCaptureAt := b.CanCaptureEnPassantAt()
-		if epCaptureAt != nil && to.(Coord).X == epCaptureAt.(Coord).X && fromCoords.(Coord).X != to.(Coord).X {
+	if isPawn {
+		from, dst, epCaptureAt := fromCoords.(Coord), to.(Coord), b.CanCaptureEnPassantAt()
+		if epCaptureAt != nil && capturedPiece == nil && from.X != dst.X && NewCoords(b.EnPassantTargets()).Contains(to) {
 			b.Empty(epCaptureAt)
-		} else {
-			b.SetCanCaptureEnPassantAt(nil)
 		}
+		b.SetCanCaptureEnPassantAt(nil)
+		b.SetEnPassantTargets(nil)
 
-		pY, toY := piece.Coord().(Coord).Y, to.(Coord).Y
-		diff := pY - toY
-		if diff != 1 && diff != -1 { // long pawn move
-			b.SetCanCaptureEnPassantAt(to)
+		if f := forward(b, piece.Colour()); f.X == 0 && from.X == dst.X || f.Y == 0 && from.Y == dst.Y {
+			if passed := walk(b, from, dst, f); len(passed) > 0 { // long pawn move
+				b.SetCanCaptureEnPassantAt(to)
+				b.SetEnPassantTargets(passed)
+			}
 		}
 		b.SetHalfMoveCount(-1) // pawn advance, reset counting: next it will be increased to 0
 	} else {
 		b.SetCanCaptureEnPassantAt(nil)
+		b.SetEnPassantTargets(nil)
 	}
 
 	piece.MarkMoved()
@@ -261,18 +350,33 @@ func (b *Board) MakeMove(to base.ICoord, piece base.IPiece) bool {
 	// first project (and empty source piece square, and only then set piece)
 	piece.Set(b.Piece(to)) // set piece to copy of itself on the new board
 
-	b.SetSideToMove(b.SideToMove().Invert())
-	if piece.Colour() == Black {
-		b.SetMoveNumber(b.MoveNumber() + 1)
+	if capturedPiece != nil && capturedPiece.Name() == base.KingName && capturedPiece.Colour() != Dead &&
+		b.Settings().Players != nil {
+		b.captureKing(mover, capturedPiece.Colour())
 	}
+	b.passTurn(mover)
 	b.SetHalfMoveCount(b.HalfMoveCount() + 1)
 	b.increasePositionCounter()
-	b.computeOutcome()
+	b.countCheck(mover)
+	b.computeOutcome(mover)
 	return true
 }
 
 // Position returns a string position description
-func (b *Board) Position() string { return NewXFEN(b).PositionPart() }
+func (b *Board) Position() string {
+	if b.Settings().Players != nil {
+		return b.multiPlayerPosition()
+	}
+	return NewXFEN(b).PositionPart()
+}
+
+// Hash returns a 64-bit FNV-1a hash of a position description, so positions counted as repeated
+// have equal hashes
+func (b *Board) Hash() uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(b.Position()))
+	return h.Sum64()
+}
 
 // PositionOccurred returns a number of times current position occurred through the game
 func (b *Board) PositionOccurred() int { return b.positionsCounter[b.Position()] }
@@ -296,6 +400,8 @@ func (b *Board) MakeCastling(castling base.Castling) bool {
 	castling.Piece[1].MarkMoved()
 
 	kingCopy, rookCopy := b.Piece(castling.Piece[0].Coord()).Copy(), b.Piece(castling.Piece[1].Coord()).Copy()
+	kingCopy.MarkMoved() // castling pieces may be copies of board pieces, like kings of a copied board
+	rookCopy.MarkMoved()
 	b.Empty(kingCopy.Coord())
 	b.Empty(rookCopy.Coord())
 	b.PlacePiece(castling.To[0], kingCopy)
@@ -303,13 +409,11 @@ func (b *Board) MakeCastling(castling base.Castling) bool {
 	castling.Piece[0].Set(b.Piece(castling.To[0]))
 	castling.Piece[1].Set(b.Piece(castling.To[1]))
 
-	b.SetSideToMove(b.SideToMove().Invert())
-	if castling.Piece[0].Colour() == Black {
-		b.SetMoveNumber(b.MoveNumber() + 1)
-	}
+	b.passTurn(castling.Piece[0].Colour())
 	b.SetHalfMoveCount(b.HalfMoveCount() + 1)
 	b.increasePositionCounter()
-	b.computeOutcome()
+	b.countCheck(castling.Piece[0].Colour())
+	b.computeOutcome(castling.Piece[0].Colour())
 	return true
 }
 
@@ -383,9 +487,11 @@ func (b *Board) Equals(to base.IBoard) bool {
 	canEP, canEP1 := b.CanCaptureEnPassantAt(), to.CanCaptureEnPassantAt()
 	if b.width != b1.width || b.height != b1.height || b.sideToMove != b1.sideToMove ||
 		b.halfMoveCounter != b1.halfMoveCounter || b.moveNumber != b1.moveNumber ||
-		!b.rookCoords.Equals(b1.rookCoords) ||
+		!b.castlingPartners.Equals(b1.castlingPartners) ||
 		((canEP == nil) != (canEP1 == nil)) || (canEP != nil && canEP1 != nil && !canEP.Equals(canEP1)) ||
-		!b.Outcome().Equals(b1.Outcome()) {
+		!NewCoords(b.EnPassantTargets()).Equals(NewCoords(b1.EnPassantTargets())) ||
+		!b.Outcome().Equals(b1.Outcome()) || !b.handsEqual(b1) || !b.checksGivenEqual(b1) ||
+		!b.playersEqual(b1) {
 		return false
 	}
 	for y := 1; y <= b.height; y++ {
@@ -402,7 +508,44 @@ func (b *Board) Equals(to base.IBoard) bool {
 	return true
 }
 
-// RookCoords returns available castlings for colour
+// handsEqual returns true if pieces in hands of b and b1 are the same
+func (b *Board) handsEqual(b1 *Board) bool {
+	if (b.hands == nil) != (b1.hands == nil) {
+		return false
+	}
+	// counts maps pieces colours and names to an amount
+	counts := map[Colour]map[string]int{}
+	for _, colour := range AllColours() {
+		counts[colour] = map[string]int{}
+		for _, p := range b.hands[colour] {
+			counts[colour][p.Name()]++
+		}
+		for _, p := range b1.hands[colour] {
+			counts[colour][p.Name()]--
+		}
+		for _, n := range counts[colour] {
+			if n != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// checksGivenEqual returns true if checks given counters of b and b1 are the same
+func (b *Board) checksGivenEqual(b1 *Board) bool {
+	if (b.checksGiven == nil) != (b1.checksGiven == nil) {
+		return false
+	}
+	for _, colour := range AllColours() {
+		if b.checksGiven[colour] != b1.checksGiven[colour] {
+			return false
+		}
+	}
+	return true
+}
+
+// Castlings returns available castlings for colour
 func (b *Board) Castlings(colour Colour) base.Castlings { return b.Settings().CastlingsFunc(b, colour) }
 
 // HasMoves true if side of colour has any moves (except castlings)
@@ -417,7 +560,11 @@ func (b *Board) HasMoves(colour Colour) bool {
 // InChecks returns true if king of colour is in check
 func (b *Board) InCheck(colour Colour) bool {
 	king := b.King(colour)
-	return king != nil && b.FindAttackedCellsBy(base.PieceFilter{Colours: []Colour{colour.Invert()}}).Contains(king.Coord())
+	if king == nil || king.Coord() == nil {
+		return false
+	}
+	opponents := b.Settings().Players.Opponents(colour)
+	return b.FindAttackedCellsBy(base.PieceFilter{Colours: opponents}).Contains(king.Coord())
 }
 
 // InCheckmate if king of colour is in check and have no moves
@@ -441,18 +588,34 @@ func (b *Board) SetHalfMoveCount(n int) { b.halfMoveCounter = n }
 // Outcome returns the game outcome
 func (b *Board) Outcome() base.Outcome { return b.outcome }
 
-// Resigns the given colour
-func (b *Board) Resign(colour Colour) { b.setOutcome(base.NewResignation(colour)) }
+// Resigns the given colour, in a multi-player game the side is eliminated
+func (b *Board) Resign(colour Colour) {
+	if b.Settings().Players != nil {
+		b.resignMultiPlayer(colour)
+		return
+	}
+	b.setOutcome(base.NewResignation(colour))
+}
+
+// AgreeDraw finishes the game by a draw agreed by all sides
+func (b *Board) AgreeDraw() { b.setOutcome(base.NewDrawByAgreement()) }
+
+// Adjudicate finishes the game as won by winner, or as a draw if winner is Transparent
+func (b *Board) Adjudicate(winner Colour) { b.setOutcome(base.NewAdjudication(winner)) }
 
 // setOutcome to
 func (b *Board) setOutcome(to base.Outcome) { b.outcome = to }
 
-// computeOutcome computes outcome and sets it
-func (b *Board) computeOutcome() {
+// computeOutcome computes outcome after a move of mover and sets it
+func (b *Board) computeOutcome(mover Colour) {
 	settings := b.Settings()
 	if !settings.MoveOrder {
 		return
 	}
+	if settings.Players != nil {
+		b.computeMultiPlayerOutcome(mover)
+		return
+	}
 
 	sideToMove := b.SideToMove()
 	switch {
@@ -474,13 +637,27 @@ func (b *Board) LegalMoves(notation base.INotation) []string {
 	for i := range pieces {
 		dst := pieces[i].Destinations(b)
 		for dst.HasNext() {
-			res = append(res, notation.EncodeMove(b, pieces[i], dst.Next().(base.ICoord)))
+			to := dst.Next().(base.ICoord)
+			kind := b.Settings().PromotionRules.Kind(b, pieces[i], to)
+			if kind != base.MandatoryPromotion {
+				res = append(res, notation.EncodeMove(b, pieces[i], to))
+			}
+			if kind == base.NoPromotion {
+				continue
+			}
+			for _, name := range base.PromotionTargets(b, pieces[i], to) {
+				piece := pieces[i].Copy()
+				piece.SetPromote(StandardPieceRegistry().NewByName(name, piece.Colour()))
+				if piece.Promotion() != nil && b.Settings().PromotionConditionFunc(b, piece, to, piece.Promote()) {
+					res = append(res, notation.EncodeMove(b, piece, to))
+				}
+			}
 		}
 	}
 
 	castlings := b.Castlings(sideToMove)
 	for i := range castlings {
-		res = append(res, notation.EncodeCastling(castlings[i].I))
+		res = append(res, notation.EncodeCastling(b, castlings[i]))
 	}
 
 	return res
@@ -504,7 +681,7 @@ func NewEmptyBoard(i, j int, settings *base.Settings) *Board {
 	b.width, b.height = i, j
 	b.createCells()
 	b.initializeKing()
-	b.initializeRookCoords()
+	b.initializeCastlingPartners()
 	b.SetSettings(settings)
 	b.SetSideToMove(White)
 	b.SetMoveNumber(1)
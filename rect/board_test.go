@@ -174,10 +174,10 @@ var _ = Describe("Board test", func() {
 		resetBoard = func() {
 			b = rect.NewEmptyStandardChessBoard()
 			// set rook initial coords to enable castling
-			//b.SetRookInitialCoords(White, 0, rect.Coord{1, 1}) // should not set it, rook moved
-			b.SetRookInitialCoords(White, 1, rect.Coord{7, 1})
-			b.SetRookInitialCoords(Black, 0, rect.Coord{1, 8})
-			b.SetRookInitialCoords(Black, 1, rect.Coord{7, 8})
+			//b.AddCastlingPartner(White, rect.Coord{1, 1}) // should not set it, rook moved
+			b.AddCastlingPartner(White, rect.Coord{7, 1})
+			b.AddCastlingPartner(Black, rect.Coord{1, 8})
+			b.AddCastlingPartner(Black, rect.Coord{7, 8})
 		}
 		var wr1, wr2, wk, br1, br2, bk base.IPiece
 		setupPosition := func() {
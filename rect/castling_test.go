@@ -57,10 +57,10 @@ var _ = Describe("Castling test", func() {
 				b = rect.NewEmptyStandardChessBoard()
 				b.Settings().MoveOrder = false
 				// set rook initial coords to enable castling
-				b.SetRookInitialCoords(White, 0, rect.Coord{1, 1})
-				b.SetRookInitialCoords(White, 1, rect.Coord{8, 1})
-				b.SetRookInitialCoords(Black, 0, rect.Coord{1, 8})
-				b.SetRookInitialCoords(Black, 1, rect.Coord{8, 8})
+				b.AddCastlingPartner(White, rect.Coord{1, 1})
+				b.AddCastlingPartner(White, rect.Coord{8, 1})
+				b.AddCastlingPartner(Black, rect.Coord{1, 8})
+				b.AddCastlingPartner(Black, rect.Coord{8, 8})
 			}
 		})
 
@@ -271,10 +271,10 @@ var _ = Describe("Castling test", func() {
 			resetBoard = func() {
 				b = rect.NewEmptyStandardChessBoard()
 				// set rook initial coords to enable castling
-				b.SetRookInitialCoords(White, 0, rect.Coord{1, 1})
-				b.SetRookInitialCoords(White, 1, rect.Coord{7, 1})
-				b.SetRookInitialCoords(Black, 0, rect.Coord{1, 8})
-				b.SetRookInitialCoords(Black, 1, rect.Coord{7, 8})
+				b.AddCastlingPartner(White, rect.Coord{1, 1})
+				b.AddCastlingPartner(White, rect.Coord{7, 1})
+				b.AddCastlingPartner(Black, rect.Coord{1, 8})
+				b.AddCastlingPartner(Black, rect.Coord{7, 8})
 			}
 		})
 
@@ -345,3 +345,122 @@ var _ = Describe("Castling test", func() {
 		})
 	})
 })
+
+var _ = Describe("Generalized castling test", func() {
+	var b base.IBoard
+	var settings *base.Settings
+	BeforeEach(func() { settings = rect.StandardChessBoardSettings() })
+
+	// setupPosition places white king at kingX on the first rank of the board with width w,
+	// partners are placed on the first rank and marked as castling partners
+	setupPosition := func(w, kingX int, partners map[int]base.IPiece) {
+		b = rect.NewEmptyBoard(w, 8, settings)
+		b.Settings().MoveOrder = false
+		b.SetSideToMove(White)
+		b.PlacePiece(rect.Coord{kingX, 1}, rect.NewKing(White))
+		b.PlacePiece(rect.Coord{kingX, 8}, rect.NewKing(Black))
+		for x, piece := range partners {
+			b.PlacePiece(rect.Coord{x, 1}, piece)
+			b.AddCastlingPartner(White, rect.Coord{x, 1})
+		}
+	}
+
+	kingDestinations := func(castlings base.Castlings) []base.ICoord {
+		res := []base.ICoord{}
+		for i := range castlings {
+			res = append(res, castlings[i].To[0])
+		}
+		return res
+	}
+
+	It("checks castling on 10 files wide board like in capablanca chess", func() {
+		setupPosition(10, 6, map[int]base.IPiece{1: rect.NewRook(White), 10: rect.NewRook(White)})
+		castlings := b.Castlings(White)
+		Expect(castlings).To(HaveLen(2))
+		Expect(castlings[0].I).To(Equal(0))
+		Expect(castlings[0].To).To(Equal([2]base.ICoord{rect.Coord{3, 1}, rect.Coord{4, 1}}))
+		Expect(castlings[1].I).To(Equal(1))
+		Expect(castlings[1].To).To(Equal([2]base.ICoord{rect.Coord{9, 1}, rect.Coord{8, 1}}))
+		Expect(b.LegalMoves(rect.NewLongAlgebraicNotation())).To(ContainElement("O-O-O"))
+		Expect(b.LegalMoves(rect.NewLongAlgebraicNotation())).To(ContainElement("O-O"))
+	})
+
+	It("checks castling with king steps", func() {
+		settings.Castling.KingSteps = []int{1, 3}
+		setupPosition(10, 5, map[int]base.IPiece{1: rect.NewRook(White), 10: rect.NewRook(White)})
+		castlings := b.Castlings(White)
+		Expect(kingDestinations(castlings)).To(Equal([]base.ICoord{
+			rect.Coord{2, 1}, rect.Coord{4, 1}, rect.Coord{6, 1}, rect.Coord{8, 1},
+		}))
+		Expect(castlings[3].To[1]).To(Equal(rect.Coord{7, 1}))
+
+		moves := b.LegalMoves(rect.NewLongAlgebraicNotation())
+		Expect(moves).To(ContainElement("O-O-O/b1"))
+		Expect(moves).To(ContainElement("O-O/h1"))
+
+		f, err := rect.NewLongAlgebraicNotation().DecodeMove(b, "O-O/h1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f()).To(BeTrue())
+		Expect(b.Piece(rect.Coord{8, 1}).Name()).To(Equal(base.KingName))
+		Expect(b.Piece(rect.Coord{7, 1}).Name()).To(Equal(base.RookName))
+	})
+
+	It("checks free castling and its notation", func() {
+		settings.Castling.Free = true
+		setupPosition(8, 5, map[int]base.IPiece{1: rect.NewRook(White), 8: rect.NewRook(White)})
+		b.PlacePiece(rect.Coord{3, 8}, rect.NewRook(Black))
+
+		castlings := b.Castlings(White)
+		Expect(kingDestinations(castlings)).To(Equal([]base.ICoord{
+			rect.Coord{4, 1}, rect.Coord{6, 1}, rect.Coord{7, 1}, rect.Coord{8, 1},
+		}), "king can't pass through c1 attacked by a rook")
+
+		notation := rect.NewLongAlgebraicNotation()
+		Expect(notation.EncodeCastling(b, castlings[0])).To(Equal("O-O-O"))
+		Expect(notation.EncodeCastling(b, castlings[2])).To(Equal("O-O/g1"))
+
+		_, err := notation.DecodeMove(b, "O-O")
+		Expect(err).To(HaveOccurred(), "z-side castling is ambiguous")
+		_, err = notation.DecodeMove(b, "O-O/c1")
+		Expect(err).To(HaveOccurred(), "no such castling")
+
+		f, err := notation.DecodeMove(b, "O-O-O")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f()).To(BeTrue())
+		Expect(b.Piece(rect.Coord{4, 1}).Name()).To(Equal(base.KingName))
+		Expect(b.Piece(rect.Coord{5, 1}).Name()).To(Equal(base.RookName))
+	})
+
+	It("checks castling with non-rook partners", func() {
+		settings.Castling.Partners = []string{base.RookName, base.ArchbishopName}
+		setupPosition(10, 6, map[int]base.IPiece{
+			1: rect.NewRook(White), 3: rect.NewRook(White), 10: rect.NewArchbishop(White),
+		})
+		b.PlacePiece(rect.Coord{9, 1}, rect.NewChancellor(White))
+		b.AddCastlingPartner(White, rect.Coord{9, 1})
+
+		castlings := b.Castlings(White)
+		Expect(castlings).To(HaveLen(1), "castling with the outer rook and archbishop are blocked")
+		Expect(castlings[0].Piece[1].Coord()).To(Equal(rect.Coord{3, 1}))
+		Expect(castlings[0].To).To(Equal([2]base.ICoord{rect.Coord{3, 1}, rect.Coord{4, 1}}))
+
+		b.Empty(rect.Coord{9, 1})
+		castlings = b.Castlings(White)
+		Expect(castlings).To(HaveLen(2))
+		Expect(castlings[1].Piece[1].Name()).To(Equal(base.ArchbishopName))
+		Expect(castlings[1].To).To(Equal([2]base.ICoord{rect.Coord{9, 1}, rect.Coord{8, 1}}))
+	})
+
+	It("checks that castling on a copied board marks king and partner moved", func() {
+		board, err := rect.XFEN(`r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1`).Board()
+		Expect(err).NotTo(HaveOccurred())
+		b := board.Copy()
+		castlings := b.Castlings(White)
+		Expect(castlings).To(HaveLen(2))
+		Expect(b.MakeCastling(castlings[1])).To(BeTrue())
+		Expect(b.King(White).WasMoved()).To(BeTrue())
+		Expect(b.Piece(rect.Coord{6, 1}).WasMoved()).To(BeTrue())
+		Expect(b.Castlings(White)).To(BeEmpty())
+		Expect(rect.NewXFEN(b.(*rect.Board))).To(Equal(rect.XFEN(`r3k2r/8/8/8/8/8/8/R4RK1 b kq - 1 1`)))
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
 )
 
 // Coord is a rectangular coordinates
@@ -21,9 +22,9 @@ func (c Coord) Add(o base.ICoord) base.ICoord {
 	return Coord{X: c.X + o.(Coord).X, Y: c.Y + o.(Coord).Y}
 }
 
-// OutOf returns true if c is a coords out of board
+// OutOf returns true if c is a coords out of board or a hole in it
 func (c Coord) OutOf(b base.IBoard) bool {
-	return c.X < 1 || c.Y < 1 || c.X > b.Dim().(Coord).X || c.Y > b.Dim().(Coord).Y
+	return c.X < 1 || c.Y < 1 || c.X > b.Dim().(Coord).X || c.Y > b.Dim().(Coord).Y || isHole(b, c)
 }
 
 // Equals returns true if c equals c1
@@ -36,6 +37,106 @@ func (c Coord) Copy() base.ICoord {
 	return Coord{X: c.X, Y: c.Y}
 }
 
+// holeSet is a set of hole coords of a board
+type holeSet map[Coord]bool
+
+// newHoleSet returns a hole set built from settings holes, it returns nil if there are no holes
+func newHoleSet(settings *base.Settings) holeSet {
+	if settings == nil || len(settings.Holes) == 0 {
+		return nil
+	}
+	res := make(holeSet, len(settings.Holes))
+	for _, c := range settings.Holes {
+		res[c.(Coord)] = true
+	}
+	return res
+}
+
+// isHole returns true if c is a hole on a board according to board settings.
+// A board builds its hole set when settings are set, so holes changed later take effect after SetSettings.
+func isHole(b base.IBoard, c Coord) bool {
+	if board, ok := b.(*Board); ok {
+		return board.holes[c]
+	}
+	settings := b.Settings()
+	return settings != nil && NewCoords(settings.Holes).Contains(c)
+}
+
+// normalize returns coords c wrapped around a board according to board settings,
+// it returns false if the resulting coords are out of board
+func normalize(b base.IBoard, c Coord) (Coord, bool) {
+	dim, settings := b.Dim().(Coord), b.Settings()
+	wrap := func(n, size int) int { return ((n-1)%size+size)%size + 1 }
+	if settings != nil && settings.WrapFiles {
+		c.X = wrap(c.X, dim.X)
+	}
+	if settings != nil && settings.WrapRanks {
+		c.Y = wrap(c.Y, dim.Y)
+	}
+	return c, !c.OutOf(b)
+}
+
+// walk returns coords passed over by a piece moving from one coord to another by steps d on a board,
+// coords are ordered from the source to the destination. It returns nil if the destination is not reached.
+func walk(b base.IBoard, from, to, d Coord) []base.ICoord {
+	res := []base.ICoord{}
+	for c, ok := normalize(b, Coord{from.X + d.X, from.Y + d.Y}); ok; c, ok = normalize(b, Coord{c.X + d.X, c.Y + d.Y}) {
+		if c.Equals(to) {
+			return res
+		}
+		if c.Equals(from) {
+			break
+		}
+		res = append(res, c)
+	}
+	return nil
+}
+
+// passedCoords returns coords passed over by a piece moving in a straight line from one coord to another,
+// coords are ordered from the source to the destination
+func passedCoords(from, to Coord) []base.ICoord {
+	dx, dy := sign(to.X-from.X), sign(to.Y-from.Y)
+	res := []base.ICoord{}
+	for c := (Coord{from.X + dx, from.Y + dy}); !c.Equals(to); c = (Coord{c.X + dx, c.Y + dy}) {
+		res = append(res, c)
+	}
+	return res
+}
+
+// sign returns -1, 0 or 1 depending on the sign of n
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// abs returns an absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// relativeRank returns a rank of c counted from the side of the given colour along its pawns forward direction,
+// and a number of such ranks on a board. So rank 1 is the 1st rank for white and the last rank for black.
+func relativeRank(board base.IBoard, colour Colour, c Coord) (rank, ranks int) {
+	dim, f := board.Dim().(Coord), forward(board, colour)
+	switch {
+	case f.Y > 0:
+		return c.Y, dim.Y
+	case f.Y < 0:
+		return dim.Y - c.Y + 1, dim.Y
+	case f.X > 0:
+		return c.X, dim.X
+	}
+	return dim.X - c.X + 1, dim.X
+}
+
 // NewCoords returns new rectangular coordinates
 func NewCoords(c []base.ICoord) Coords {
 	return Coords{Coords: base.NewCoords(c)}
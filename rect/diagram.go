@@ -0,0 +1,84 @@
+package rect
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+// DiagramOptions are options of a text board diagram
+type DiagramOptions struct {
+	Unicode     bool // draw pieces as Unicode figurines instead of letters
+	Coordinates bool // draw rank numbers and file letters
+	Flipped     bool // draw a board from the black side: h8 is at the bottom left corner
+	Colour      bool // colour pieces with cli markup, it produces terminal escape sequences
+	Empty, Hole rune // runes of empty cells and holes
+}
+
+// DefaultDiagramOptions returns default options of a plain ASCII diagram with coordinates
+func DefaultDiagramOptions() DiagramOptions {
+	return DiagramOptions{Coordinates: true, Empty: '.', Hole: ' '}
+}
+
+// Diagram returns a text diagram of a board position, one line per rank
+func (b *Board) Diagram(opts DiagramOptions) string {
+	xs, ys := make([]int, b.width), make([]int, b.height)
+	for i := range xs {
+		xs[i] = i + 1
+		if opts.Flipped {
+			xs[i] = b.width - i
+		}
+	}
+	for i := range ys {
+		ys[i] = b.height - i
+		if opts.Flipped {
+			ys[i] = i + 1
+		}
+	}
+
+	labelWidth := len(fmt.Sprint(b.height))
+	var s strings.Builder
+	for _, y := range ys {
+		if opts.Coordinates {
+			fmt.Fprintf(&s, "%*d ", labelWidth, y)
+		}
+		cells := make([]string, len(xs))
+		for i, x := range xs {
+			cells[i] = b.diagramCell(Coord{X: x, Y: y}, opts)
+		}
+		s.WriteString(strings.TrimRight(strings.Join(cells, " "), " ") + "\n")
+	}
+
+	if opts.Coordinates {
+		files := make([]string, len(xs))
+		for i, x := range xs {
+			files[i] = string(ToLetter(x))
+		}
+		s.WriteString(strings.Repeat(" ", labelWidth+1) + strings.Join(files, " ") + "\n")
+	}
+	return s.String()
+}
+
+// diagramCell returns a cell at c of a text diagram
+func (b *Board) diagramCell(c Coord, opts DiagramOptions) string {
+	if c.OutOf(b) {
+		return string(opts.Hole)
+	}
+	piece := b.Piece(c)
+	if piece == nil {
+		return string(opts.Empty)
+	}
+	s := string(unicode.ToLower(piece.Capital())) // one rune per cell, sides of multi-player games differ by colour
+	if piece.Colour() == White {
+		s = string(piece.Capital())
+	}
+	if opts.Unicode {
+		s = string(piece.Figurine())
+	}
+	if opts.Colour {
+		return piece.Colour().Paint(s)
+	}
+	return s
+}
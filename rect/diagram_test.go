@@ -0,0 +1,65 @@
+package rect_test
+
+import (
+	"strings"
+
+	"github.com/mtfelian/mtfchess/rect"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Diagram test", func() {
+	var b *rect.Board
+
+	BeforeEach(func() {
+		board, err := rect.XFEN(`4k3/8/8/8/8/8/4P3/4K2R w K - 0 1`).Board()
+		Expect(err).NotTo(HaveOccurred())
+		b = board.(*rect.Board)
+	})
+
+	It("draws a plain ASCII diagram with coordinates", func() {
+		Expect(b.Diagram(rect.DefaultDiagramOptions())).To(Equal(strings.Join([]string{
+			"8 . . . . k . . .",
+			"7 . . . . . . . .",
+			"6 . . . . . . . .",
+			"5 . . . . . . . .",
+			"4 . . . . . . . .",
+			"3 . . . . . . . .",
+			"2 . . . . P . . .",
+			"1 . . . . K . . R",
+			"  a b c d e f g h",
+		}, "\n") + "\n"))
+		Expect(b.String()).To(HaveSuffix("\nWhite to move, move 1\n"))
+	})
+
+	It("draws a flipped Unicode diagram without coordinates", func() {
+		opts := rect.DefaultDiagramOptions()
+		opts.Unicode, opts.Flipped, opts.Coordinates, opts.Empty = true, true, false, '·'
+		Expect(b.Diagram(opts)).To(Equal(strings.Join([]string{
+			"♖ · · ♔ · · · ·",
+			"· · · ♙ · · · ·",
+			"· · · · · · · ·",
+			"· · · · · · · ·",
+			"· · · · · · · ·",
+			"· · · · · · · ·",
+			"· · · · · · · ·",
+			"· · · ♚ · · · ·",
+		}, "\n") + "\n"))
+	})
+
+	It("draws holes and wide rank labels", func() {
+		diagram := rect.NewFourPlayerChessBoard(false).Diagram(rect.DefaultDiagramOptions())
+		lines := strings.Split(diagram, "\n")
+		Expect(lines[0]).To(Equal("14       r n b k q b n r"))
+		Expect(lines[13]).To(Equal(" 1       r n b q k b n r"))
+		Expect(lines[14]).To(Equal("   a b c d e f g h i j k l m n"))
+		Expect(diagram).NotTo(ContainSubstring("\x1b"))
+	})
+
+	It("colours pieces only when asked", func() {
+		opts := rect.DefaultDiagramOptions()
+		Expect(b.Diagram(opts)).NotTo(ContainSubstring("\x1b"))
+		opts.Colour = true
+		Expect(b.Diagram(opts)).NotTo(Equal(b.Diagram(rect.DefaultDiagramOptions())))
+	})
+})
@@ -0,0 +1,145 @@
+package rect
+
+import (
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+// PieceValues are values of pieces in centipawns by piece names used by static exchange evaluation.
+// A king should be valued high so it never captures into an attack.
+type PieceValues map[string]int
+
+// standardPieceValues are values of pieces of this package used if no values are given
+var standardPieceValues = PieceValues{
+	base.PawnName: 100, base.KnightName: 300, base.BishopName: 300, base.RookName: 500, base.QueenName: 900,
+	base.ArchbishopName: 700, base.ChancellorName: 800, base.KingName: 100000,
+}
+
+// StandardPieceValues returns values of pieces of this package, values of custom pieces may be added to them
+func StandardPieceValues() PieceValues {
+	res := make(PieceValues, len(standardPieceValues))
+	for name, value := range standardPieceValues {
+		res[name] = value
+	}
+	return res
+}
+
+// orStandard returns v, or values of pieces of this package if v is nil
+func (v PieceValues) orStandard() PieceValues {
+	if v == nil {
+		return standardPieceValues
+	}
+	return v
+}
+
+// AttackMap maps cells to pieces attacking them by colours
+type AttackMap map[Coord]map[Colour]base.Pieces
+
+// AttackMap returns an attack map of all pieces on a board
+func (b *Board) AttackMap() AttackMap {
+	m := AttackMap{}
+	for _, piece := range b.FindPieces(base.PieceFilter{}) {
+		attacked := piece.Attacks(b)
+		for i := 0; i < attacked.Len(); i++ {
+			c := attacked.Get(i).(Coord)
+			if m[c] == nil {
+				m[c] = map[Colour]base.Pieces{}
+			}
+			m[c][piece.Colour()] = append(m[c][piece.Colour()], piece)
+		}
+	}
+	return m
+}
+
+// Attackers returns pieces of colour attacking a cell at, it's nil if there are no such pieces
+func (m AttackMap) Attackers(at Coord, colour Colour) base.Pieces { return m[at][colour] }
+
+// Count returns a number of pieces of colour attacking a cell at
+func (m AttackMap) Count(at Coord, colour Colour) int { return len(m[at][colour]) }
+
+// SEE returns a static exchange evaluation of captures on a cell at started by side by: a material balance
+// in centipawns of values for side by after captures by the least valuable pieces in turn, values of pieces
+// of this package are used if values are nil. The first capture is made
+// anyway, so it's negative if it loses material, then each side may stop capturing. Attacks are found again
+// after each capture, so x-ray attacks of pieces behind moved ones and attacks of fairy pieces are taken
+// into account, pins and promotions are not. It's 0 if there is no piece of an opponent of side by at or side by can't capture it.
+func (b *Board) SEE(at base.ICoord, by Colour, values PieceValues) int {
+	return b.see(at, by, nil, values.orStandard())
+}
+
+// CaptureSEE returns a static exchange evaluation of a capture by piece on a cell at followed by captures
+// by the least valuable pieces as for SEE, it's 0 if piece doesn't attack a piece of its opponent at
+func (b *Board) CaptureSEE(piece base.IPiece, at base.ICoord, values PieceValues) int {
+	if !piece.Attacks(b).Contains(at) {
+		return 0
+	}
+	return b.see(at, piece.Colour(), piece, values.orStandard())
+}
+
+// see returns a static exchange evaluation of captures on a cell at started by side by with piece first,
+// or with the least valuable piece if first is nil
+func (b *Board) see(at base.ICoord, by Colour, first base.IPiece, values PieceValues) int {
+	target := b.Piece(at)
+	if target == nil || target.Colour() == Dead || b.Settings().Players.Allied(by, target.Colour()) {
+		return 0
+	}
+	next := b.Copy().(*Board)
+	sides := [][]Colour{{by}, b.Settings().Players.Opponents(by)}
+	gains, value := []int{}, values[target.Name()]
+	for i := 0; ; i++ {
+		var attacker base.IPiece
+		if i == 0 && first != nil {
+			attacker = next.Piece(first.Coord())
+		} else {
+			attacker = next.leastValuableAttacker(at, sides[i%2], values)
+		}
+		if attacker == nil {
+			break
+		}
+		gains = append(gains, value)
+		value = values[attacker.Name()]
+		from := attacker.Coord()
+		next.Empty(at).Empty(from).PlacePiece(at, attacker)
+	}
+	if len(gains) == 0 {
+		return 0
+	}
+	// each side stops capturing if continuing loses material, the first capture is made anyway
+	score := 0
+	for i := len(gains) - 1; i > 0; i-- {
+		if score = gains[i] - score; score < 0 {
+			score = 0
+		}
+	}
+	return gains[0] - score
+}
+
+// leastValuableAttacker returns the least valuable piece by values of colours attacking a cell at,
+// or nil if there is none
+func (b *Board) leastValuableAttacker(at base.ICoord, colours []Colour, values PieceValues) base.IPiece {
+	var res base.IPiece
+	for _, piece := range b.Attackers(at, colours...) {
+		if res == nil || values[piece.Name()] < values[res.Name()] {
+			res = piece
+		}
+	}
+	return res
+}
+
+// HangingPieces returns pieces of colour which opponents win material of values by capturing as for SEE,
+// kings are not included
+func (b *Board) HangingPieces(colour Colour, values PieceValues) base.Pieces {
+	res := base.Pieces{}
+	for _, piece := range b.FindPieces(base.PieceFilter{Colours: []Colour{colour}}) {
+		if piece.Name() == base.KingName {
+			continue
+		}
+		for _, opponent := range b.Settings().Players.Opponents(colour) {
+			if b.SEE(piece.Coord(), opponent, values) > 0 {
+				res = append(res, piece)
+				break
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,98 @@
+package rect_test
+
+import (
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	"github.com/mtfelian/mtfchess/rect"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("exchange", func() {
+	// names returns names of pieces
+	names := func(pieces base.Pieces) []string {
+		res := []string{}
+		for _, piece := range pieces {
+			res = append(res, piece.Name())
+		}
+		return res
+	}
+
+	It("returns attack maps", func() {
+		m := board(rect.NewStandardChessStartingPosition()).AttackMap()
+		Expect(m.Count(rect.Coord{4, 3}, White)).To(Equal(2))
+		Expect(names(m.Attackers(rect.Coord{6, 3}, White))).To(ConsistOf(base.PawnName, base.PawnName,
+			base.KnightName))
+		Expect(m.Count(rect.Coord{6, 3}, Black)).To(BeZero())
+		Expect(m.Attackers(rect.Coord{4, 4}, White)).To(BeEmpty())
+		Expect(m.Count(rect.Coord{6, 6}, Black)).To(Equal(3))
+	})
+
+	It("evaluates exchanges", func() {
+		By("an undefended pawn")
+		b := board("4k3/8/8/3p4/8/8/8/3RK3 w - - 0 1")
+		Expect(b.SEE(rect.Coord{4, 5}, White, nil)).To(Equal(100))
+		Expect(b.SEE(rect.Coord{4, 5}, Black, nil)).To(BeZero())
+		Expect(b.SEE(rect.Coord{4, 4}, White, nil)).To(BeZero())
+
+		By("a pawn defended by a pawn")
+		b = board("4k3/8/2p5/3p4/8/8/8/3RK3 w - - 0 1")
+		Expect(b.SEE(rect.Coord{4, 5}, White, nil)).To(Equal(-400))
+
+		By("rooks attacking through each other")
+		b = board("3rk3/8/8/3p4/8/3R4/3R4/3RK3 w - - 0 1")
+		Expect(b.SEE(rect.Coord{4, 5}, White, nil)).To(Equal(100))
+		b = board("3rk3/3r4/8/3p4/8/8/3R4/3RK3 w - - 0 1")
+		Expect(b.SEE(rect.Coord{4, 5}, White, nil)).To(Equal(-400))
+
+		By("an archbishop capturing as a knight")
+		b = board("4k3/8/4p3/3p4/8/2A5/8/4K3 w - - 0 1")
+		Expect(b.SEE(rect.Coord{4, 5}, White, nil)).To(Equal(-600))
+
+		By("a king capturing a defended pawn")
+		b = board("4k3/8/8/8/8/8/3p4/4K3 w - - 0 1")
+		Expect(b.SEE(rect.Coord{4, 2}, White, nil)).To(Equal(100))
+		b = board("4k3/8/8/8/8/2b5/3p4/4K3 w - - 0 1")
+		Expect(b.SEE(rect.Coord{4, 2}, White, nil)).To(BeNumerically("<", -90000))
+
+		By("pieces which aren't of opponents")
+		b = board("4k3/8/4b3/3p4/8/8/8/3RK3 w - - 0 1")
+		Expect(b.SEE(rect.Coord{4, 5}, Black, nil)).To(BeZero())
+		b = rect.NewEmptyBoard(14, 14, rect.FourPlayerChessSettings(true))
+		b.PlacePiece(rect.Coord{7, 7}, rect.NewRook(Red))
+		b.PlacePiece(rect.Coord{7, 9}, rect.NewPawn(Yellow))
+		b.PlacePiece(rect.Coord{9, 7}, rect.NewPawn(Dead))
+		b.PlacePiece(rect.Coord{5, 7}, rect.NewPawn(Blue))
+		Expect(b.SEE(rect.Coord{7, 9}, Red, nil)).To(BeZero())
+		Expect(b.SEE(rect.Coord{9, 7}, Red, nil)).To(BeZero())
+		Expect(b.SEE(rect.Coord{5, 7}, Red, nil)).To(Equal(100))
+	})
+
+	It("evaluates captures by given pieces", func() {
+		b := board("4k3/8/2p5/3p4/8/2N5/8/3QK3 w - - 0 1")
+		Expect(b.SEE(rect.Coord{4, 5}, White, nil)).To(Equal(-100))
+		Expect(b.CaptureSEE(b.Piece(rect.Coord{4, 1}), rect.Coord{4, 5}, nil)).To(Equal(-700))
+		Expect(b.CaptureSEE(b.Piece(rect.Coord{3, 3}), rect.Coord{4, 5}, nil)).To(Equal(-100))
+		Expect(b.CaptureSEE(b.Piece(rect.Coord{3, 3}), rect.Coord{3, 6}, nil)).To(BeZero())
+	})
+
+	It("finds hanging pieces", func() {
+		b := board("4k3/8/2p5/3p3n/8/2N5/8/3QK3 b - - 0 1")
+		Expect(names(b.HangingPieces(Black, nil))).To(Equal([]string{base.KnightName}))
+		Expect(b.HangingPieces(White, nil)).To(BeEmpty())
+
+		b = board("4k3/8/8/8/8/2b5/3p4/4K3 w - - 0 1")
+		Expect(b.HangingPieces(Black, nil)).To(BeEmpty())
+	})
+
+	It("evaluates exchanges by given piece values", func() {
+		b := board("4k3/8/2p5/3p4/8/8/8/3RK3 w - - 0 1")
+		values := rect.StandardPieceValues()
+		values[base.PawnName] = 600
+		Expect(b.SEE(rect.Coord{4, 5}, White, values)).To(Equal(100))
+		Expect(b.CaptureSEE(b.Piece(rect.Coord{4, 1}), rect.Coord{4, 5}, values)).To(Equal(100))
+		Expect(b.HangingPieces(Black, values)).To(HaveLen(1))
+		Expect(b.HangingPieces(Black, nil)).To(BeEmpty())
+		Expect(rect.StandardPieceValues()[base.PawnName]).To(Equal(100))
+	})
+})
@@ -0,0 +1,155 @@
+package rect
+
+import (
+	"fmt"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+// PieceValue is an immutable piece stored by value in a frozen position
+type PieceValue struct {
+	Name   string
+	Colour Colour
+	Moved  bool
+}
+
+// Empty returns true if there is no piece
+func (v PieceValue) Empty() bool { return v.Name == "" }
+
+// Frozen is an immutable board position. A move on it returns a new position sharing unchanged ranks
+// and the history of positions with the old one, so any position may be kept for undo, used in search
+// or read concurrently.
+type Frozen struct {
+	ranks   [][]PieceValue // ranks[y-1][x-1], a rank is never changed after it is created
+	state   *Board         // a board without pieces and positions counter keeping the rest of a position
+	history *history       // positions occurred in a game, it is never changed
+	pieces  constructors   // piece constructors shared by positions of a game
+}
+
+// history is a persistent positions counter. Each move adds a node linked to the history before it,
+// so frozen positions of a game share their common history instead of copying a counter on every move.
+type history struct {
+	prev     *history
+	position string         // a position occurred after a move, it's empty at the root
+	counter  map[string]int // a counter of a frozen board at the root, nil at other nodes
+}
+
+// positionsCounter returns a new positions counter, it takes time proportional to the number of moves since freezing
+func (h *history) positionsCounter() map[string]int {
+	res := make(map[string]int)
+	for ; h.prev != nil; h = h.prev {
+		res[h.position]++
+	}
+	for position, n := range h.counter {
+		res[position] += n
+	}
+	return res
+}
+
+// Freeze returns an immutable copy of a board position, pieces should contain all pieces on a board
+func (b *Board) Freeze(pieces PieceRegistry) (Frozen, error) {
+	return freeze(b, pieces.constructorsByName(), nil, &history{counter: b.copyPositionsCounter()})
+}
+
+// freeze returns an immutable copy of a board position with the history of positions h
+// reusing ranks of prev which are not changed, pieces are constructors by names
+func freeze(b *Board, pieces constructors, prev [][]PieceValue, h *history) (Frozen, error) {
+	ranks := make([][]PieceValue, b.height)
+	for y := 1; y <= b.height; y++ {
+		rank := make([]PieceValue, b.width)
+		for x := 1; x <= b.width; x++ {
+			piece := b.Piece(Coord{x, y})
+			if piece == nil {
+				continue
+			}
+			if _, exists := pieces[piece.Name()]; !exists {
+				return Frozen{}, fmt.Errorf("piece %s is not registered", piece.Name())
+			}
+			rank[x-1] = PieceValue{Name: piece.Name(), Colour: piece.Colour(), Moved: piece.WasMoved()}
+		}
+		if prev != nil && ranksEqual(prev[y-1], rank) {
+			rank = prev[y-1]
+		}
+		ranks[y-1] = rank
+	}
+
+	state := b.copyWith(nil)
+	state.SetSettings(b.Settings().Copy())
+	for y := range state.cells {
+		for x := range state.cells[y] {
+			state.cells[y][x].Empty()
+		}
+	}
+	state.king = nil // kings are set on placing them back
+	return Frozen{ranks: ranks, state: state, history: h, pieces: pieces}, nil
+}
+
+// ranksEqual returns true if ranks a and b contain the same pieces
+func ranksEqual(a, b []PieceValue) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Board returns a new mutable board with a frozen position, changing it doesn't affect the frozen position.
+// Its positions counter is rebuilt from the history, so it takes time proportional to the number of moves.
+func (f Frozen) Board() *Board {
+	b := f.state.copyWith(f.history.positionsCounter())
+	b.SetSettings(f.state.Settings().Copy())
+	for y := range f.ranks {
+		for x, v := range f.ranks[y] {
+			if v.Empty() {
+				continue
+			}
+			piece := f.pieces[v.Name](v.Colour)
+			if v.Moved {
+				piece.MarkMoved()
+			}
+			b.PlacePiece(Coord{x + 1, y + 1}, piece)
+		}
+	}
+	return b
+}
+
+// Dim returns board dimensions
+func (f Frozen) Dim() Coord { return Coord{X: f.state.width, Y: f.state.height} }
+
+// Piece returns a piece at c, it's empty if there is no piece or c is out of board
+func (f Frozen) Piece(c Coord) PieceValue {
+	if c.X < 1 || c.Y < 1 || c.X > f.state.width || c.Y > f.state.height {
+		return PieceValue{}
+	}
+	return f.ranks[c.Y-1][c.X-1]
+}
+
+// SideToMove returns a colour of the side to move
+func (f Frozen) SideToMove() Colour { return f.state.SideToMove() }
+
+// MoveNumber returns a move number
+func (f Frozen) MoveNumber() int { return f.state.MoveNumber() }
+
+// Outcome returns the game outcome
+func (f Frozen) Outcome() base.Outcome { return f.state.Outcome() }
+
+// LegalMoves returns legal moves encoded in notation
+func (f Frozen) LegalMoves(notation base.INotation) []string { return f.Board().LegalMoves(notation) }
+
+// Move decodes a move in notation and returns a new position after it, f is not changed
+func (f Frozen) Move(notation base.INotation, move string) (Frozen, error) {
+	b := f.Board()
+	makeMove, err := notation.DecodeMove(b, move)
+	if err != nil {
+		return Frozen{}, err
+	}
+	if !makeMove() {
+		return Frozen{}, fmt.Errorf("illegal move %s", move)
+	}
+	return freeze(b, f.pieces, f.ranks, &history{prev: f.history, position: b.Position()})
+}
+
+// Equals returns true if f and to are the same positions
+func (f Frozen) Equals(to Frozen) bool { return f.Board().Equals(to.Board()) }
@@ -0,0 +1,118 @@
+package rect
+
+import (
+	"sync"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Frozen test", func() {
+	var f Frozen
+
+	BeforeEach(func() {
+		b, err := NewStandardChessStartingPosition().Board()
+		Expect(err).NotTo(HaveOccurred())
+		f, err = b.(*Board).Freeze(StandardPieceRegistry())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("makes moves returning new positions", func() {
+		f1, err := f.Move(NewLongAlgebraicNotation(), "e2-e4")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(f.Piece(Coord{5, 2})).To(Equal(PieceValue{Name: base.PawnName, Colour: White}))
+		Expect(f.Piece(Coord{5, 4}).Empty()).To(BeTrue())
+		Expect(f.SideToMove()).To(Equal(White))
+		Expect(f1.Piece(Coord{5, 2}).Empty()).To(BeTrue())
+		Expect(f1.Piece(Coord{5, 4})).To(Equal(PieceValue{Name: base.PawnName, Colour: White, Moved: true}))
+		Expect(f1.SideToMove()).To(Equal(Black))
+		Expect(f1.Piece(Coord{0, 1}).Empty()).To(BeTrue())
+
+		By("sharing unchanged ranks")
+		for y := range f.ranks {
+			Expect(&f1.ranks[y][0] == &f.ranks[y][0]).To(Equal(y != 1 && y != 3), "rank %d", y+1)
+		}
+
+		By("converting to mutable boards")
+		b := f1.Board()
+		Expect(NewXFEN(b)).To(Equal(XFEN(`rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1`)))
+		Expect(b.King(White).Coord()).To(Equal(Coord{5, 1}))
+		b.Empty(Coord{5, 4})
+		Expect(f1.Piece(Coord{5, 4}).Empty()).To(BeFalse())
+		Expect(f1.Equals(f)).To(BeFalse())
+		Expect(f.Equals(f)).To(BeTrue())
+	})
+
+	It("rejects illegal moves and unregistered pieces", func() {
+		_, err := f.Move(NewLongAlgebraicNotation(), "e2-e5")
+		Expect(err).To(HaveOccurred())
+		_, err = f.Move(NewLongAlgebraicNotation(), "bad")
+		Expect(err).To(HaveOccurred())
+		_, err = f.Board().Freeze(PieceRegistry{"k": NewKing})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("doesn't share settings with boards it is frozen from or converted to", func() {
+		b := f.Board()
+		f1, err := b.Freeze(StandardPieceRegistry())
+		Expect(err).NotTo(HaveOccurred())
+		b.Settings().MoveOrder = false
+		f.Board().Settings().MoveOrder = false
+		for _, frozen := range []Frozen{f, f1} {
+			_, err = frozen.Move(NewLongAlgebraicNotation(), "e7-e5")
+			Expect(err).To(HaveOccurred(), "black doesn't move first")
+		}
+	})
+
+	It("plays a game until checkmate and keeps previous positions", func() {
+		positions := []Frozen{f}
+		for _, move := range []string{"f2-f3", "e7-e5", "g2-g4", "d8-h4"} {
+			next, err := positions[len(positions)-1].Move(NewLongAlgebraicNotation(), move)
+			Expect(err).NotTo(HaveOccurred())
+			positions = append(positions, next)
+		}
+		Expect(positions[4].Outcome()).To(Equal(base.NewCheckmate(Black)))
+		Expect(positions[4].LegalMoves(NewLongAlgebraicNotation())).To(BeEmpty())
+		Expect(positions[3].Outcome().IsFinished()).To(BeFalse())
+		Expect(positions[3].LegalMoves(NewLongAlgebraicNotation())).To(HaveLen(30))
+		Expect(positions[0].MoveNumber()).To(Equal(1))
+		Expect(positions[4].MoveNumber()).To(Equal(3))
+	})
+
+	It("shares the history of positions and counts repetitions", func() {
+		positions := []Frozen{f}
+		for i := 0; i < 9; i++ {
+			move := []string{"g1-f3", "g8-f6", "f3-g1", "f6-g8"}[i%4]
+			next, err := positions[len(positions)-1].Move(NewLongAlgebraicNotation(), move)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(next.history.prev).To(BeIdenticalTo(positions[len(positions)-1].history))
+			Expect(next.state.positionsCounter).To(BeNil())
+			positions = append(positions, next)
+		}
+		Expect(positions[1].Board().PositionOccurred()).To(Equal(1))
+		Expect(positions[5].Board().PositionOccurred()).To(Equal(2))
+		Expect(positions[8].Outcome().IsFinished()).To(BeFalse())
+		Expect(positions[9].Board().PositionOccurred()).To(Equal(3))
+		Expect(positions[9].Outcome()).To(Equal(base.NewDrawByXFoldRepetition()))
+		Expect(positions[5].Board().PositionOccurred()).To(Equal(2), "older positions keep their history")
+	})
+
+	It("can be read and moved from concurrently", func() {
+		var wg sync.WaitGroup
+		moves := f.LegalMoves(NewLongAlgebraicNotation())
+		for _, move := range moves {
+			wg.Add(1)
+			go func(move string) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				next, err := f.Move(NewLongAlgebraicNotation(), move)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(next.LegalMoves(NewLongAlgebraicNotation())).To(HaveLen(20))
+			}(move)
+		}
+		wg.Wait()
+	})
+})
@@ -1,6 +1,8 @@
 package rect
 
 import (
+	"sort"
+
 	"github.com/mtfelian/mtfchess/base"
 	. "github.com/mtfelian/mtfchess/colour"
 	. "github.com/mtfelian/utils"
@@ -21,13 +23,27 @@ const (
 	Standard3FoldRepetitionDraw = 3 // 3-fold repetition draw rule
 )
 
+// names of settings presets
+const (
+	StandardSettingsName        = "standard"
+	CylinderSettingsName        = "cylinder"
+	ToroidalSettingsName        = "toroidal"
+	FourPlayerSettingsName      = "fourplayer"
+	FourPlayerTeamsSettingsName = "fourplayer-teams"
+	testSettingsName            = "test"
+)
+
 // StandardChessBoardSettings returns a set of settings for standard chess
 func StandardChessBoardSettings() *base.Settings {
 	return &base.Settings{
+		Name:                   StandardSettingsName,
 		PawnLongMoveModifier:   StandardPawnLongMove,
+		PawnStartZoneFunc:      StandardPawnStartZoneFunc,
 		AllowedPromotions:      StandardAllowedPromotions(),
+		PromotionRules:         StandardPromotionRules(),
 		PromotionConditionFunc: StandardPromotionConditionFunc,
 		CastlingsFunc:          StandardCastlingFunc,
+		Castling:               StandardCastlingRule(),
 		EnPassantFunc:          StandardEnPassantFunc,
 		MoveOrder:              true,
 		MovesToDraw:            Standard50MovesToDraw,
@@ -35,13 +51,104 @@ func StandardChessBoardSettings() *base.Settings {
 	}
 }
 
+// CylinderChessBoardSettings returns a set of settings for cylinder chess: standard chess with a-file and h-file
+// adjacent to each other, castling is disabled
+func CylinderChessBoardSettings() *base.Settings {
+	s := StandardChessBoardSettings()
+	s.Name, s.CastlingsFunc, s.WrapFiles = CylinderSettingsName, NoCastlingFunc, true
+	return s
+}
+
+// ToroidalChessBoardSettings returns a set of settings for chess on a torus: both files and ranks are wrapped
+// around a board, castling is disabled
+func ToroidalChessBoardSettings() *base.Settings {
+	s := CylinderChessBoardSettings()
+	s.Name, s.WrapRanks = ToroidalSettingsName, true
+	return s
+}
+
+// FourPlayerChessSettings returns a set of settings for four-player chess on 14x14 board without 3x3 corners.
+// Red, Blue, Yellow and Green move in turn, pawns move from their sides to the opposite ones and are promoted
+// to queens on the 8th rank. Checkmated or stalemated sides are eliminated. Free-for-all game is scored by points
+// for captures and checkmates, the teams game of Red and Yellow against Blue and Green goes on
+// until one team remains. Castling and en passant are disabled.
+func FourPlayerChessSettings(teams bool) *base.Settings {
+	players := &base.Players{
+		TurnOrder: FourPlayerColours(),
+		Forward: map[Colour]base.ICoord{
+			Red: Coord{0, 1}, Blue: Coord{1, 0}, Yellow: Coord{0, -1}, Green: Coord{-1, 0},
+		},
+		Points: map[string]int{
+			base.PawnName: 1, base.KnightName: 3, base.BishopName: 5, base.RookName: 5, base.QueenName: 9,
+		},
+		CheckmatePoints: 20,
+	}
+	name := FourPlayerSettingsName
+	if teams {
+		name = FourPlayerTeamsSettingsName
+		players.Teams = [][]Colour{{Red, Yellow}, {Blue, Green}}
+		players.Points, players.CheckmatePoints = nil, 0
+	}
+	return &base.Settings{
+		Name:                 name,
+		PawnLongMoveModifier: StandardPawnLongMove,
+		PawnStartZoneFunc:    StandardPawnStartZoneFunc,
+		AllowedPromotions:    StandardAllowedPromotions(),
+		PromotionRules: base.PromotionRules{
+			base.PawnName: {ZoneFunc: FourPlayerPromotionZoneFunc, Targets: []string{base.QueenName}},
+		},
+		PromotionConditionFunc: StandardPromotionConditionFunc,
+		CastlingsFunc:          NoCastlingFunc,
+		Castling:               StandardCastlingRule(),
+		EnPassantFunc:          NoEnPassantFunc,
+		Holes:                  NewCornerHoles(14, 14, 3),
+		Players:                players,
+		MoveOrder:              true,
+		MovesToDraw:            NoMovesToDraw,
+		PositionsToDraw:        Standard3FoldRepetitionDraw,
+	}
+}
+
+// NewCornerHoles returns coords of holes cutting squares of size n out of each corner of w*h board,
+// like in four-player chess where 3x3 corners are cut out of 14x14 board
+func NewCornerHoles(w, h, n int) []base.ICoord {
+	res := []base.ICoord{}
+	for x := 1; x <= w; x++ {
+		for y := 1; y <= h; y++ {
+			if (x <= n || x > w-n) && (y <= n || y > h-n) {
+				res = append(res, Coord{x, y})
+			}
+		}
+	}
+	return res
+}
+
+// NewOmegaChessHoles returns coords of holes of 12x12 board for Omega chess: the board has 10x10 field
+// and four extra corner cells diagonally adjacent to the field corners, all other border cells are holes
+func NewOmegaChessHoles() []base.ICoord {
+	res := []base.ICoord{}
+	for x := 1; x <= 12; x++ {
+		for y := 1; y <= 12; y++ {
+			border, corner := x == 1 || x == 12 || y == 1 || y == 12, (x == 1 || x == 12) && (y == 1 || y == 12)
+			if border && !corner {
+				res = append(res, Coord{x, y})
+			}
+		}
+	}
+	return res
+}
+
 // testBoardSettings returns a set of settings for tests
 func testBoardSettings() *base.Settings {
 	return &base.Settings{
+		Name:                   testSettingsName,
 		PawnLongMoveModifier:   NoPawnLongMove,
+		PawnStartZoneFunc:      StandardPawnStartZoneFunc,
 		AllowedPromotions:      StandardAllowedPromotions(),
+		PromotionRules:         StandardPromotionRules(),
 		PromotionConditionFunc: StandardPromotionConditionFunc,
 		CastlingsFunc:          NoCastlingFunc,
+		Castling:               StandardCastlingRule(),
 		EnPassantFunc:          NoEnPassantFunc,
 		MoveOrder:              false,
 		MovesToDraw:            NoMovesToDraw,
@@ -49,168 +156,237 @@ func testBoardSettings() *base.Settings {
 	}
 }
 
+// StandardPawnStartZoneFunc allows pawn long move from the 2nd rank like in standard chess
+func StandardPawnStartZoneFunc(board base.IBoard, piece base.IPiece) bool {
+	return NewPawnStartRanksFunc(true, 2)(board, piece)
+}
+
+// NewPawnStartRanksFunc returns a pawn start zone func allowing pawn long move from the given ranks,
+// ranks are counted from the pawn's side of a board, so rank 2 is the 2nd rank for white
+// and the pre-last rank for black. Set unmovedOnly to true to allow long move only to unmoved pawns.
+func NewPawnStartRanksFunc(unmovedOnly bool, ranks ...int) func(base.IBoard, base.IPiece) bool {
+	return func(board base.IBoard, piece base.IPiece) bool {
+		if unmovedOnly && piece.WasMoved() {
+			return false
+		}
+		rank, _ := relativeRank(board, piece.Colour(), piece.Coord().(Coord))
+		return SliceContains(rank, ranks)
+	}
+}
+
+// NewPawnStartSquaresFunc returns a pawn start zone func allowing pawn long move from the given squares,
+// squares maps pawn colour to a slice of coords. Set unmovedOnly to true to allow long move only to unmoved pawns.
+func NewPawnStartSquaresFunc(unmovedOnly bool, squares map[Colour][]Coord) func(base.IBoard, base.IPiece) bool {
+	return func(board base.IBoard, piece base.IPiece) bool {
+		if unmovedOnly && piece.WasMoved() {
+			return false
+		}
+		for _, c := range squares[piece.Colour()] {
+			if c.Equals(piece.Coord()) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
 // NoEnPassantFunc always disables en passant capturing
-func NoEnPassantFunc(_ base.IBoard, _ base.IPiece) base.ICoord { return nil }
+func NoEnPassantFunc(_ base.IBoard, _ base.IPiece) base.ICoords { return NewCoords([]base.ICoord{}) }
 
 // StandardEnPassantFunc enables en passant capturing like in standard chess, returns coords to capture
-// piece is a capturing piece
-func StandardEnPassantFunc(board base.IBoard, piece base.IPiece) base.ICoord {
+// piece is a capturing piece. Pawn can capture en passant on any of cells passed over by an opponent's pawn
+// making a long move.
+func StandardEnPassantFunc(board base.IBoard, piece base.IPiece) base.ICoords {
+	res := NewCoords([]base.ICoord{})
 	if piece.Name() != base.PawnName {
-		return nil
+		return res
 	}
 
 	// pieceAt is a coord of a piece which can be captured en passant
 	pieceAt := board.CanCaptureEnPassantAt()
-	if pieceAt == nil {
-		return nil
-	}
-
-	pX, epAtX := piece.Coord().(Coord).X, pieceAt.(Coord).X
-	if epAtX != pX+1 && epAtX != pX-1 { // here we check X
-		return nil
+	if pieceAt == nil || board.Piece(pieceAt) == nil || board.Piece(pieceAt).Colour() == piece.Colour() {
+		return res
 	}
 
-	longMove := board.Settings().PawnLongMoveModifier // here is checked also piece Y coord
-	if longMove == 0 {
-		return nil
+	pC, targets := piece.Coord().(Coord), NewCoords(board.EnPassantTargets())
+	for _, o := range orient(board, piece.Colour(), []Coord{{-1, 1}, {1, 1}}) {
+		if tC, ok := normalize(board, Coord{pC.X + o.X, pC.Y + o.Y}); ok && targets.Contains(tC) {
+			res.Add(tC)
+		}
 	}
+	return res
+}
 
-	bh := board.Dim().(Coord).Y
-	// maps colour of a capturing piece to Y of the piece to capture
-	minYs := map[Colour]int{White: bh - 2 - longMove, Black: 3}
-	maxYs := map[Colour]int{White: bh - 3, Black: 2 + longMove}
+// StandardAllowedPromotions returns allowed pawn promotions pieces names list for standard chess
+func StandardAllowedPromotions() []string {
+	return []string{base.KnightName, base.BishopName, base.RookName, base.QueenName}
+}
 
-	step := 1
-	if piece.Colour() == Black {
-		step *= -1
+// StandardPromotionRules returns promotion rules for standard chess: pawn should be promoted
+// on the last rank to any of Settings.AllowedPromotions pieces
+func StandardPromotionRules() base.PromotionRules {
+	return base.PromotionRules{
+		base.PawnName: {ZoneFunc: StandardPromotionZoneFunc},
 	}
+}
 
-	minY, maxY := minYs[piece.Colour()], maxYs[piece.Colour()]
-	for y := minY; y >= minY && y <= maxY; y = y + step { // here we check Y
-		if y == piece.Coord().(Coord).Y+step {
-			return Coord{epAtX, y}
-		}
-	}
+// StandardPromotionZoneFunc makes promotion mandatory on the last rank like in standard chess
+func StandardPromotionZoneFunc(board base.IBoard, piece base.IPiece, dst base.ICoord) base.PromotionKind {
+	return NewPromotionRanksFunc(nil, []int{-1})(board, piece, dst)
+}
 
-	return nil
+// FourPlayerPromotionZoneFunc makes promotion mandatory on the 8th rank from the side like in four-player chess
+func FourPlayerPromotionZoneFunc(board base.IBoard, piece base.IPiece, dst base.ICoord) base.PromotionKind {
+	return NewPromotionRanksFunc(nil, []int{8})(board, piece, dst)
 }
 
-// StandardAllowedPromotions returns allowed pawn promotions pieces names list for standard chess
-func StandardAllowedPromotions() []string {
-	return []string{base.KnightName, base.BishopName, base.RookName, base.QueenName}
+// NewPromotionRanksFunc returns a promotion zone func for a piece going to the given ranks, where promotion is
+// optional or mandatory. Ranks are counted from the piece's side of a board, so rank 1 is the 1st rank for white
+// and the last rank for black. Negative ranks are counted from the opposite side, so -1 is the last rank for white.
+func NewPromotionRanksFunc(optional, mandatory []int) func(base.IBoard, base.IPiece, base.ICoord) base.PromotionKind {
+	return func(board base.IBoard, piece base.IPiece, dst base.ICoord) base.PromotionKind {
+		y, bh := relativeRank(board, piece.Colour(), dst.(Coord))
+		inRanks := func(ranks []int) bool {
+			for _, rank := range ranks {
+				if rank == y || rank < 0 && bh+rank+1 == y {
+					return true
+				}
+			}
+			return false
+		}
+		switch {
+		case inRanks(mandatory):
+			return base.MandatoryPromotion
+		case inRanks(optional):
+			return base.OptionalPromotion
+		}
+		return base.NoPromotion
+	}
 }
 
-// StandardPromotionConditionFunc is a pawn promotion condition for standard chess
+// StandardPromotionConditionFunc is a promotion condition according to board settings promotion rules
 func StandardPromotionConditionFunc(board base.IBoard, piece base.IPiece, dst base.ICoord, to base.IPiece) bool {
-	bh, fromY, dstY := board.(*Board).height, piece.Coord().(Coord).Y, dst.(Coord).Y
-	return piece.Name() == base.PawnName && // only pawn can be promoted
-		to.Colour() == piece.Colour() && // only to self-colored
-		SliceContains(to.Name(), board.(*Board).Settings().AllowedPromotions) && // to piece from list
-		(piece.Colour() == White && fromY == bh-1 && dstY == bh || // for white from pre-last horizontal to the last
-			piece.Colour() == Black && fromY == 2 && dstY == 1) // for black from 2nd horizontal to the 1st
+	return base.StandardPromotionConditionFunc(board, piece, dst, to)
 }
 
-// standardCastling returns castling data for standard chess for given colour on a given board
-// parameter x is a rook coord
-func standardCastling(board base.IBoard, colour Colour, rook base.IPiece) base.Castling {
-	res, bh := base.Castling{Enabled: false}, board.Dim().(Coord).Y
+// StandardCastlingRule returns a castling rule for standard chess
+func StandardCastlingRule() base.CastlingRule {
+	return base.CastlingRule{Partners: []string{base.RookName}, KingFiles: [2]int{3, 2}}
+}
 
-	king := board.King(colour)
-	if king == nil || king.WasMoved() || board.InCheck(colour) {
+// castlingKingDstX returns king's destination X coords for castling towards partner with coord pC
+// according to the given rule, dir is a direction from king to partner
+func castlingKingDstX(rule base.CastlingRule, width int, kC, pC Coord, dir int) []int {
+	switch {
+	case rule.Free:
+		res := []int{}
+		for x := kC.X + dir; x != pC.X+dir; x += dir {
+			res = append(res, x)
+		}
+		return res
+	case len(rule.KingSteps) > 0:
+		res := []int{}
+		for _, step := range rule.KingSteps {
+			if x := kC.X + dir*step; step > 0 && x >= 1 && x <= width {
+				res = append(res, x)
+			}
+		}
 		return res
+	case dir < 0:
+		return []int{rule.KingFiles[0]}
+	default:
+		return []int{width - rule.KingFiles[1] + 1}
 	}
+}
 
-	// detect, whether it aSide or zSide castling
-	rooksCoords, n := board.RookInitialCoords(colour), -1
-	if len(rooksCoords) != 2 {
-		panic("rookCoords should have len 2")
+// castlingPathsFree returns true if king can move from kC to kDst and partner can move from pC to pDst,
+// paths should be free of pieces except king and partner, king's path should not be attacked
+func castlingPathsFree(board base.IBoard, attacked base.ICoords, kC, kDst, pC, pDst Coord) bool {
+	isFree := func(c Coord) bool {
+		piece := board.Piece(c)
+		return piece == nil || piece.Coord().Equals(kC) || piece.Coord().Equals(pC)
 	}
-	rC := rook.Coord().(Coord)
-	for i := range rooksCoords {
-		if rooksCoords[i] == nil {
-			continue
+	for x, step := kC.X, sign(kDst.X-kC.X); x != kDst.X; {
+		x += step
+		if c := (Coord{x, kC.Y}); attacked.Contains(c) || !isFree(c) {
+			return false
 		}
-		if rooksCoords[i].(Coord).X == rC.X {
-			n = i
-			break
+	}
+	for x, step := pC.X, sign(pDst.X-pC.X); x != pDst.X; {
+		x += step
+		if !isFree(Coord{x, pC.Y}) {
+			return false
 		}
 	}
-	if n == -1 { // rook not found
+	return true
+}
+
+// partnerCastlings returns castlings of king with partner on a given board according to the castling rule
+func partnerCastlings(board base.IBoard, rule base.CastlingRule, king, partner base.IPiece) base.Castlings {
+	res := base.Castlings{}
+	kC, pC := king.Coord().(Coord), partner.Coord().(Coord)
+	if kC.Y != pC.Y || kC.X == pC.X {
 		return res
 	}
 
-	// kDstX and rDstX is a king and rook destination X after castling
-	kDstX, rDstX := 3, 4 // aSide castling
-	if n == 1 {
-		kDstX, rDstX = 7, 6 // zSide castling
+	// castling towards a-side has index 0 and towards z-side has index 1
+	dir, i := sign(pC.X-kC.X), 0
+	if dir > 0 {
+		i = 1
 	}
 
-	// checking that king's path from source cell to destination cell is not attacked and free of pieces
-	// except the same rook
-	kC, step := king.Coord().(Coord), 1 // go right if king now is to the left from the dst
-	if kDstX < kC.X {                   // go left if king now is to the right from the dst
-		step = -1
-	}
-	attacked := board.FindAttackedCellsBy(base.PieceFilter{Colours: []Colour{colour.Invert()}})
-	for i := step; kC.X+i != kDstX+step; i += step {
-		shouldBeFree := Coord{kC.X + i, kC.Y}
-		if attacked.Contains(shouldBeFree) ||
-			(board.Piece(shouldBeFree) != nil && !board.Piece(shouldBeFree).Coord().Equals(rook.Coord())) {
-			return res
+	attacked := board.FindAttackedCellsBy(base.PieceFilter{
+		Colours: board.Settings().Players.Opponents(king.Colour()),
+	})
+	for _, kDstX := range castlingKingDstX(rule, board.Dim().(Coord).X, kC, pC, dir) {
+		kDst, pDst := Coord{kDstX, kC.Y}, Coord{kDstX - dir, kC.Y}
+		if pDst.X < 1 || pDst.X > board.Dim().(Coord).X || !castlingPathsFree(board, attacked, kC, kDst, pC, pDst) {
+			continue
 		}
+		res = append(res, base.Castling{
+			Piece:   [2]base.IPiece{king, partner},
+			To:      [2]base.ICoord{kDst, pDst},
+			I:       i,
+			Enabled: true,
+		})
 	}
+	return res
+}
 
-	// checking that rook's path from source cell to destination cell is free of pieces except king
-	step = 1          // go right if rook now is to the left from the dst
-	if rDstX < rC.X { // go left if rook now is to the right from the dst
-		step = -1
-	}
-	for i := step; rC.X+i != rDstX+step; i += step {
-		shouldBeFree := Coord{rC.X + i, rC.Y}
-		if board.Piece(shouldBeFree) != nil && !board.Piece(shouldBeFree).Coord().Equals(king.Coord()) {
-			return res
-		}
+// StandardCastlingFunc is a castling func for standard chess and its variants,
+// it uses board castling partners and settings castling rule, the standard rule is used if no partners are set
+func StandardCastlingFunc(board base.IBoard, colour Colour) base.Castlings {
+	res, rule := base.Castlings{}, board.Settings().Castling
+	if len(rule.Partners) == 0 {
+		rule = StandardCastlingRule()
 	}
-
-	kingDstCoord := map[Colour]Coord{White: {kDstX, 1}, Black: {kDstX, bh}}
-	rookDstCoord := map[Colour]Coord{White: {rDstX, 1}, Black: {rDstX, bh}}
-	return base.Castling{
-		Piece:   [2]base.IPiece{king, rook},
-		To:      [2]base.ICoord{kingDstCoord[colour], rookDstCoord[colour]},
-		I:       n,
-		Enabled: true,
+	king := board.King(colour)
+	if king == nil || king.WasMoved() || board.InCheck(colour) {
+		return res
 	}
-}
 
-// StandardCastlingFunc is a castling func for standard chess
-func StandardCastlingFunc(board base.IBoard, colour Colour) base.Castlings {
-	bh := board.Dim().(Coord).Y
-	rooks := board.FindPieces(base.PieceFilter{
-		Names:   []string{base.RookName},
+	partners := board.FindPieces(base.PieceFilter{
+		Names:   rule.Partners,
 		Colours: []Colour{colour},
-		Condition: func(r base.IPiece) bool {
-			rC, y := r.Coord().(Coord), map[Colour]int{White: 1, Black: bh}
-			rCoords, boardAllowed := board.RookInitialCoords(colour), false
-			for i := range rCoords {
-				if rCoords[i] != nil && rCoords[i].Equals(r.Coord()) {
-					boardAllowed = true
-					break
+		Condition: func(p base.IPiece) bool {
+			for _, c := range board.CastlingPartners(colour) {
+				if c.Equals(p.Coord()) {
+					return !p.WasMoved()
 				}
 			}
-			return rC.Y == y[colour] && boardAllowed && !r.WasMoved()
+			return false
 		},
 	})
-
-	castlings := base.Castlings{}
-	for i := range rooks {
-		castlings = append(castlings, standardCastling(board, colour, rooks[i]))
+	for i := range partners {
+		res = append(res, partnerCastlings(board, rule, king, partners[i])...)
 	}
-	res := base.Castlings{}
-	for i := range castlings {
-		if castlings[i].Enabled {
-			res = append(res, castlings[i])
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].I != res[j].I {
+			return res[i].I < res[j].I
 		}
-	}
+		return res[i].To[0].(Coord).X < res[j].To[0].(Coord).X
+	})
 	return res
 }
 
@@ -0,0 +1,442 @@
+package rect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"runtime"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+// JSONVersion is a version of the board JSON schema, it's increased on incompatible schema changes
+const JSONVersion = 1
+
+// SettingsFuncs maps names to settings funcs, they are used to write funcs of settings to JSON and to restore them.
+// Funcs are told apart by their code, so only named funcs can be registered: closures made by one constructor
+// share their code. A closure should be wrapped in a named func to register it, like StandardPawnStartZoneFunc.
+type SettingsFuncs map[string]interface{}
+
+// StandardSettingsFuncs returns settings funcs of this package
+func StandardSettingsFuncs() SettingsFuncs {
+	return SettingsFuncs{
+		"standardPawnStartZone":      StandardPawnStartZoneFunc,
+		"standardPromotionZone":      StandardPromotionZoneFunc,
+		"fourPlayerPromotionZone":    FourPlayerPromotionZoneFunc,
+		"standardPromotionCondition": StandardPromotionConditionFunc,
+		"standardEnPassant":          StandardEnPassantFunc,
+		"noEnPassant":                NoEnPassantFunc,
+		"standardCastlings":          StandardCastlingFunc,
+		"noCastling":                 NoCastlingFunc,
+	}
+}
+
+// closureName matches names of closures and method values which can't be told apart by their code
+var closureName = regexp.MustCompile(`\.(func)?\d+(\.\d+)*$|-fm$`)
+
+// funcCode returns a code pointer of a named func f, it returns an error if f is a closure
+func funcCode(f interface{}) (uintptr, error) {
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return 0, fmt.Errorf("%T is not a func", f)
+	}
+	if fn := runtime.FuncForPC(v.Pointer()); fn == nil || closureName.MatchString(fn.Name()) {
+		return 0, errors.New("func is not a named func")
+	}
+	return v.Pointer(), nil
+}
+
+// names returns names of registered funcs by their code pointers
+func (r SettingsFuncs) names() (map[uintptr]string, error) {
+	res := make(map[uintptr]string, len(r))
+	for name, f := range r {
+		code, err := funcCode(f)
+		if err != nil {
+			return nil, fmt.Errorf("settings func %q can't be registered: %v", name, err)
+		}
+		res[code] = name
+	}
+	return res, nil
+}
+
+// funcName returns a name of a settings func f of a settings field, it's empty for a nil func
+func funcName(names map[uintptr]string, field string, f interface{}) (string, error) {
+	if reflect.ValueOf(f).IsNil() {
+		return "", nil
+	}
+	code, err := funcCode(f)
+	if err != nil {
+		return "", fmt.Errorf("settings func %s is not registered: %v", field, err)
+	}
+	res, exists := names[code]
+	if !exists {
+		return "", fmt.Errorf("settings func %s is not registered", field)
+	}
+	return res, nil
+}
+
+// lookup returns a registered func by name into a settings field func pointed by to, nothing is set for an empty name
+func (r SettingsFuncs) lookup(field, name string, to interface{}) error {
+	if name == "" {
+		return nil
+	}
+	f, exists := r[name]
+	if !exists {
+		return fmt.Errorf("settings func %q of %s is not registered", name, field)
+	}
+	dst := reflect.ValueOf(to).Elem()
+	if v := reflect.ValueOf(f); v.Type() != dst.Type() {
+		return fmt.Errorf("settings func %q of %s has type %s, expected %s", name, field, v.Type(), dst.Type())
+	}
+	dst.Set(reflect.ValueOf(f))
+	return nil
+}
+
+// JSONOptions are options of writing and reading a board in JSON
+type JSONOptions struct {
+	Pieces PieceRegistry // pieces which may be on a board or in hands
+	Funcs  SettingsFuncs // funcs which settings may refer to
+}
+
+// DefaultJSONOptions returns options to write and read boards with pieces and settings funcs of this package
+func DefaultJSONOptions() JSONOptions {
+	return JSONOptions{Pieces: StandardPieceRegistry(), Funcs: StandardSettingsFuncs()}
+}
+
+// pieceJSON is a JSON representation of a piece, coords are nil for a piece in hand
+type pieceJSON struct {
+	Name   string `json:"name"`
+	Colour Colour `json:"colour"`
+	At     *Coord `json:"at,omitempty"`
+	Moved  bool   `json:"moved,omitempty"`
+}
+
+// promotionRuleJSON is a JSON representation of base.PromotionRule, a zone func is referred by its name
+type promotionRuleJSON struct {
+	ZoneFunc string         `json:"zoneFunc,omitempty"`
+	Targets  []string       `json:"targets,omitempty"`
+	Limits   map[string]int `json:"limits,omitempty"`
+}
+
+// playersJSON is a JSON representation of base.Players
+type playersJSON struct {
+	TurnOrder       []Colour         `json:"turnOrder"`
+	Forward         map[Colour]Coord `json:"forward"`
+	Teams           [][]Colour       `json:"teams,omitempty"`
+	Points          map[string]int   `json:"points,omitempty"`
+	CheckmatePoints int              `json:"checkmatePoints,omitempty"`
+}
+
+// settingsJSON is a JSON representation of base.Settings, funcs are referred by their names in SettingsFuncs
+type settingsJSON struct {
+	Name                   string                       `json:"name,omitempty"`
+	PawnLongMoveModifier   int                          `json:"pawnLongMoveModifier"`
+	PawnStartZoneFunc      string                       `json:"pawnStartZoneFunc,omitempty"`
+	AllowedPromotions      []string                     `json:"allowedPromotions"`
+	PromotionRules         map[string]promotionRuleJSON `json:"promotionRules"`
+	PromotionConditionFunc string                       `json:"promotionConditionFunc,omitempty"`
+	EnPassantFunc          string                       `json:"enPassantFunc,omitempty"`
+	CastlingsFunc          string                       `json:"castlingsFunc,omitempty"`
+	Castling               base.CastlingRule            `json:"castling"`
+	WrapFiles              bool                         `json:"wrapFiles,omitempty"`
+	WrapRanks              bool                         `json:"wrapRanks,omitempty"`
+	Holes                  []Coord                      `json:"holes,omitempty"`
+	Players                *playersJSON                 `json:"players,omitempty"`
+	MoveOrder              bool                         `json:"moveOrder"`
+	MovesToDraw            int                          `json:"movesToDraw"`
+	PositionsToDraw        int                          `json:"positionsToDraw"`
+}
+
+// boardJSON is a JSON representation of a board
+type boardJSON struct {
+	Version          int                    `json:"version"`
+	Width            int                    `json:"width"`
+	Height           int                    `json:"height"`
+	Settings         settingsJSON           `json:"settings"`
+	Pieces           []pieceJSON            `json:"pieces"`
+	Hands            map[Colour][]pieceJSON `json:"hands,omitempty"`
+	SideToMove       *Colour                `json:"sideToMove"`
+	MoveNumber       int                    `json:"moveNumber"`
+	HalfMoveCount    int                    `json:"halfMoveCount"`
+	EnPassant        *Coord                 `json:"enPassant,omitempty"`
+	EnPassantTargets []Coord                `json:"enPassantTargets,omitempty"`
+	CastlingPartners map[Colour][]Coord     `json:"castlingPartners"`
+	PositionsCounter map[string]int         `json:"positionsCounter"`
+	ChecksGiven      map[Colour]int         `json:"checksGiven,omitempty"`
+	Eliminated       map[Colour]bool        `json:"eliminated,omitempty"`
+	Scores           map[Colour]int         `json:"scores,omitempty"`
+	Outcome          base.Outcome           `json:"outcome"`
+}
+
+// toCoords converts a slice of coords to a slice of rect coords
+func toCoords(coords []base.ICoord) []Coord {
+	res := make([]Coord, len(coords))
+	for i := range coords {
+		res[i] = coords[i].(Coord)
+	}
+	return res
+}
+
+// fromCoords converts a slice of rect coords to a slice of coords
+func fromCoords(coords []Coord) []base.ICoord {
+	res := make([]base.ICoord, len(coords))
+	for i := range coords {
+		res[i] = coords[i]
+	}
+	return res
+}
+
+// newSettingsJSON returns a JSON representation of settings s with funcs registered in funcs
+func newSettingsJSON(s *base.Settings, funcs SettingsFuncs) (settingsJSON, error) {
+	names, err := funcs.names()
+	if err != nil {
+		return settingsJSON{}, err
+	}
+	v := settingsJSON{
+		Name:                 s.Name,
+		PawnLongMoveModifier: s.PawnLongMoveModifier,
+		AllowedPromotions:    s.AllowedPromotions,
+		PromotionRules:       make(map[string]promotionRuleJSON, len(s.PromotionRules)),
+		Castling:             s.Castling,
+		WrapFiles:            s.WrapFiles,
+		WrapRanks:            s.WrapRanks,
+		Holes:                toCoords(s.Holes),
+		MoveOrder:            s.MoveOrder,
+		MovesToDraw:          s.MovesToDraw,
+		PositionsToDraw:      s.PositionsToDraw,
+	}
+	for _, f := range []struct {
+		field string
+		f     interface{}
+		to    *string
+	}{
+		{"PawnStartZoneFunc", s.PawnStartZoneFunc, &v.PawnStartZoneFunc},
+		{"PromotionConditionFunc", s.PromotionConditionFunc, &v.PromotionConditionFunc},
+		{"EnPassantFunc", s.EnPassantFunc, &v.EnPassantFunc},
+		{"CastlingsFunc", s.CastlingsFunc, &v.CastlingsFunc},
+	} {
+		if *f.to, err = funcName(names, f.field, f.f); err != nil {
+			return settingsJSON{}, err
+		}
+	}
+	for piece, rule := range s.PromotionRules {
+		zoneFunc, err := funcName(names, "ZoneFunc of "+piece+" promotion rule", rule.ZoneFunc)
+		if err != nil {
+			return settingsJSON{}, err
+		}
+		v.PromotionRules[piece] = promotionRuleJSON{ZoneFunc: zoneFunc, Targets: rule.Targets, Limits: rule.Limits}
+	}
+	if p := s.Players; p != nil {
+		v.Players = &playersJSON{TurnOrder: p.TurnOrder, Forward: make(map[Colour]Coord, len(p.Forward)),
+			Teams: p.Teams, Points: p.Points, CheckmatePoints: p.CheckmatePoints}
+		for colour, c := range p.Forward {
+			v.Players.Forward[colour] = c.(Coord)
+		}
+	}
+	return v, nil
+}
+
+// settings returns settings represented by v with funcs registered in funcs
+func (v settingsJSON) settings(funcs SettingsFuncs) (*base.Settings, error) {
+	s := &base.Settings{
+		Name:                 v.Name,
+		PawnLongMoveModifier: v.PawnLongMoveModifier,
+		AllowedPromotions:    v.AllowedPromotions,
+		PromotionRules:       make(base.PromotionRules, len(v.PromotionRules)),
+	}
+	for _, f := range []struct {
+		field, name string
+		to          interface{}
+	}{
+		{"PawnStartZoneFunc", v.PawnStartZoneFunc, &s.PawnStartZoneFunc},
+		{"PromotionConditionFunc", v.PromotionConditionFunc, &s.PromotionConditionFunc},
+		{"EnPassantFunc", v.EnPassantFunc, &s.EnPassantFunc},
+		{"CastlingsFunc", v.CastlingsFunc, &s.CastlingsFunc},
+	} {
+		if err := funcs.lookup(f.field, f.name, f.to); err != nil {
+			return nil, err
+		}
+	}
+	for piece, rule := range v.PromotionRules {
+		r := base.PromotionRule{Targets: rule.Targets, Limits: rule.Limits}
+		if err := funcs.lookup("ZoneFunc of "+piece+" promotion rule", rule.ZoneFunc, &r.ZoneFunc); err != nil {
+			return nil, err
+		}
+		s.PromotionRules[piece] = r
+	}
+	s.Castling, s.WrapFiles, s.WrapRanks = v.Castling, v.WrapFiles, v.WrapRanks
+	if v.Holes != nil {
+		s.Holes = fromCoords(v.Holes)
+	}
+	if p := v.Players; p != nil {
+		s.Players = &base.Players{TurnOrder: p.TurnOrder, Forward: make(map[Colour]base.ICoord, len(p.Forward)),
+			Teams: p.Teams, Points: p.Points, CheckmatePoints: p.CheckmatePoints}
+		for colour, c := range p.Forward {
+			s.Players.Forward[colour] = c
+		}
+	}
+	s.MoveOrder, s.MovesToDraw, s.PositionsToDraw = v.MoveOrder, v.MovesToDraw, v.PositionsToDraw
+	return s, nil
+}
+
+// MarshalJSON makes Board to implement json.Marshaler, it writes a board with DefaultJSONOptions
+func (b *Board) MarshalJSON() ([]byte, error) { return MarshalBoardJSON(b, DefaultJSONOptions()) }
+
+// MarshalBoardJSON writes a board with the given options, settings funcs are written by their names
+// registered in options, so all of them should be registered
+func MarshalBoardJSON(b *Board, opts JSONOptions) ([]byte, error) {
+	settings, err := newSettingsJSON(b.settings, opts.Funcs)
+	if err != nil {
+		return nil, err
+	}
+	v := boardJSON{
+		Version:          JSONVersion,
+		Width:            b.width,
+		Height:           b.height,
+		Settings:         settings,
+		Pieces:           []pieceJSON{},
+		SideToMove:       &b.sideToMove,
+		MoveNumber:       b.moveNumber,
+		HalfMoveCount:    b.halfMoveCounter,
+		EnPassantTargets: toCoords(b.enPassantTargets),
+		CastlingPartners: make(map[Colour][]Coord, len(b.castlingPartners)),
+		PositionsCounter: b.positionsCounter,
+		ChecksGiven:      b.checksGiven,
+		Eliminated:       b.eliminated,
+		Scores:           b.scores,
+		Outcome:          b.outcome,
+	}
+	for y := 1; y <= b.height; y++ {
+		for x := 1; x <= b.width; x++ {
+			if piece := b.Piece(Coord{x, y}); piece != nil {
+				at := Coord{x, y}
+				v.Pieces = append(v.Pieces,
+					pieceJSON{Name: piece.Name(), Colour: piece.Colour(), At: &at, Moved: piece.WasMoved()})
+			}
+		}
+	}
+	if b.hands != nil {
+		v.Hands = make(map[Colour][]pieceJSON, len(b.hands))
+		for colour, hand := range b.hands {
+			v.Hands[colour] = make([]pieceJSON, len(hand))
+			for i := range hand {
+				v.Hands[colour][i] = pieceJSON{Name: hand[i].Name(), Colour: hand[i].Colour()}
+			}
+		}
+	}
+	if b.canCaptureEnPassantAt != nil {
+		at := b.canCaptureEnPassantAt.(Coord)
+		v.EnPassant = &at
+	}
+	for colour, coords := range b.castlingPartners {
+		v.CastlingPartners[colour] = toCoords(coords)
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON makes Board to implement json.Unmarshaler, it reads a board with DefaultJSONOptions
+func (b *Board) UnmarshalJSON(data []byte) error {
+	board, err := UnmarshalBoardJSON(data, DefaultJSONOptions())
+	if err != nil {
+		return err
+	}
+	*b = *board
+	return nil
+}
+
+// UnmarshalBoardJSON reads a board written by Board.MarshalJSON with the given options
+func UnmarshalBoardJSON(data []byte, opts JSONOptions) (*Board, error) {
+	var v boardJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	if v.Version != JSONVersion {
+		return nil, fmt.Errorf("unsupported board JSON version %d", v.Version)
+	}
+	if v.Width < 1 || v.Height < 1 {
+		return nil, fmt.Errorf("invalid board dimensions %dx%d", v.Width, v.Height)
+	}
+	settings, err := v.Settings.settings(opts.Funcs)
+	if err != nil {
+		return nil, err
+	}
+
+	b := NewEmptyBoard(v.Width, v.Height, settings)
+	newPiece := func(p pieceJSON) (base.IPiece, error) {
+		piece := opts.Pieces.NewByName(p.Name, p.Colour)
+		if piece == nil {
+			return nil, fmt.Errorf("piece %s is not registered", p.Name)
+		}
+		if p.Moved {
+			piece.MarkMoved()
+		}
+		return piece, nil
+	}
+	for _, p := range v.Pieces {
+		if p.At == nil || p.At.OutOf(b) {
+			return nil, fmt.Errorf("invalid coords of piece %s", p.Name)
+		}
+		if b.Piece(*p.At) != nil {
+			return nil, fmt.Errorf("several pieces at %s", *p.At)
+		}
+		piece, err := newPiece(p)
+		if err != nil {
+			return nil, err
+		}
+		b.PlacePiece(*p.At, piece)
+	}
+	delete(b.king, Dead) // dead pieces are out of game like on eliminating a side
+	if v.Hands != nil {
+		b.hands = make(map[Colour]base.Pieces, len(v.Hands))
+		for colour, hand := range v.Hands {
+			b.hands[colour] = make(base.Pieces, len(hand))
+			for i := range hand {
+				if b.hands[colour][i], err = newPiece(hand[i]); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if v.SideToMove == nil {
+		return nil, errors.New("side to move is missing")
+	}
+	b.SetSideToMove(*v.SideToMove)
+	b.SetMoveNumber(v.MoveNumber)
+	b.SetHalfMoveCount(v.HalfMoveCount)
+	checkCoords := func(what string, coords ...Coord) error {
+		for _, c := range coords {
+			if c.OutOf(b) {
+				return fmt.Errorf("invalid %s coords %s", what, c)
+			}
+		}
+		return nil
+	}
+	if v.EnPassant != nil {
+		if err := checkCoords("en passant", *v.EnPassant); err != nil {
+			return nil, err
+		}
+		b.SetCanCaptureEnPassantAt(*v.EnPassant)
+	}
+	if v.EnPassantTargets != nil {
+		if err := checkCoords("en passant target", v.EnPassantTargets...); err != nil {
+			return nil, err
+		}
+		b.SetEnPassantTargets(fromCoords(v.EnPassantTargets))
+	}
+	for colour, coords := range v.CastlingPartners {
+		if err := checkCoords("castling partner", coords...); err != nil {
+			return nil, err
+		}
+		b.castlingPartners[colour] = fromCoords(coords)
+	}
+	if v.PositionsCounter != nil {
+		b.positionsCounter = v.PositionsCounter
+	}
+	b.checksGiven, b.eliminated, b.scores = v.ChecksGiven, v.Eliminated, v.Scores
+	b.setOutcome(v.Outcome)
+	return b, nil
+}
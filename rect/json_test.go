@@ -0,0 +1,217 @@
+package rect
+
+import (
+	"encoding/json"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JSON test", func() {
+	// roundTrip marshals b, reads it back with opts and checks that the result is marshaled the same way
+	roundTrip := func(b *Board, opts JSONOptions) *Board {
+		data, err := json.Marshal(b)
+		Expect(err).NotTo(HaveOccurred())
+		b1, err := UnmarshalBoardJSON(data, opts)
+		Expect(err).NotTo(HaveOccurred())
+		data1, err := json.Marshal(b1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data1).To(MatchJSON(data))
+		Expect(b1.Equals(b)).To(BeTrue())
+		Expect(b1.positionsCounter).To(Equal(b.positionsCounter))
+		return b1
+	}
+
+	// makeMoves makes moves in long algebraic notation on b
+	makeMoves := func(b *Board, moves ...string) {
+		for _, move := range moves {
+			makeMove, err := NewLongAlgebraicNotation().DecodeMove(b, move)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(makeMove()).To(BeTrue(), move)
+		}
+	}
+
+	It("restores a standard chess game exactly", func() {
+		board, err := NewStandardChessStartingPosition().Board()
+		Expect(err).NotTo(HaveOccurred())
+		b := board.(*Board)
+		makeMoves(b, "e2-e4", "c7-c5", "g1-f3", "b8-c6", "f3-g1", "c6-b8", "e4-e5", "d7-d5")
+
+		var b1 Board
+		Expect(json.Unmarshal(mustMarshal(b), &b1)).To(Succeed())
+		Expect(b1.Equals(b)).To(BeTrue())
+		Expect(NewXFEN(&b1)).To(Equal(XFEN(`rnbqkbnr/pp2pppp/8/2ppP3/8/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 5`)))
+		Expect(b1.Settings().Name).To(Equal(StandardSettingsName))
+		Expect(b1.Settings().PromotionRules.Kind(&b1, NewPawn(White), Coord{1, 8})).To(Equal(base.MandatoryPromotion))
+		Expect(b1.King(White).Coord()).To(Equal(Coord{5, 1}))
+		Expect(b1.LegalMoves(NewLongAlgebraicNotation())).To(ConsistOf(b.LegalMoves(NewLongAlgebraicNotation())))
+		roundTrip(b, DefaultJSONOptions())
+
+		By("continuing the restored game")
+		makeMoves(&b1, "e5-d6", "b8-c6", "g1-f3", "c6-b8", "f3-g1", "c8-g4", "g1-f3", "g4-c8", "f3-g1")
+		Expect(b1.Outcome()).To(Equal(base.NewDrawByXFoldRepetition()))
+		Expect(roundTrip(&b1, DefaultJSONOptions()).Outcome()).To(Equal(base.NewDrawByXFoldRepetition()))
+	})
+
+	It("restores pieces in hands, checks counters and changed settings", func() {
+		b := NewEmptyBoard(5, 6, CylinderChessBoardSettings())
+		b.PlacePiece(Coord{1, 1}, NewKing(White))
+		b.PlacePiece(Coord{5, 6}, NewKing(Black))
+		b.PlacePiece(Coord{3, 3}, NewPawn(Black))
+		b.Piece(Coord{3, 3}).MarkMoved()
+		b.SetHand(White, base.Pieces{NewQueen(White), NewPawn(White)})
+		b.SetHand(Black, base.Pieces{})
+		b.SetChecksGiven(Black, 2)
+		b.SetSideToMove(Black)
+		b.Settings().Holes = []base.ICoord{Coord{3, 4}}
+		b.Settings().PromotionRules[base.PawnName] = base.PromotionRule{
+			ZoneFunc: b.Settings().PromotionRules[base.PawnName].ZoneFunc,
+			Targets:  []string{base.KnightName},
+			Limits:   map[string]int{base.KnightName: 1},
+		}
+		b.Resign(Black)
+
+		b1 := roundTrip(b, DefaultJSONOptions())
+		Expect(b1.Hand(White)).To(HaveLen(2))
+		Expect(b1.Hand(White)[0].Name()).To(Equal(base.QueenName))
+		Expect(b1.Hand(Black)).To(BeEmpty())
+		Expect(b1.HasHands()).To(BeTrue())
+		Expect(b1.ChecksGiven(Black)).To(Equal(2))
+		Expect(b1.Piece(Coord{3, 3}).WasMoved()).To(BeTrue())
+		Expect(b1.Outcome()).To(Equal(base.NewResignation(Black)))
+		s := b1.Settings()
+		Expect(s.WrapFiles).To(BeTrue())
+		Expect(s.Holes).To(Equal([]base.ICoord{Coord{3, 4}}))
+		Expect(s.PromotionRules[base.PawnName].Targets).To(Equal([]string{base.KnightName}))
+		Expect(s.PromotionRules[base.PawnName].Limits).To(Equal(map[string]int{base.KnightName: 1}))
+		Expect(s.PromotionRules[base.PawnName].ZoneFunc).NotTo(BeNil())
+		Expect(s.CastlingsFunc).NotTo(BeNil())
+	})
+
+	It("restores a four-player game", func() {
+		b := NewFourPlayerChessBoard(false)
+		makeMoves(b, "h2-h3", "b7-c7", "g13-g12", "m8-l8")
+		b.SetScore(Red, 5)
+		b.eliminate(Blue)
+
+		b1 := roundTrip(b, DefaultJSONOptions())
+		Expect(b1.Eliminated(Blue)).To(BeTrue())
+		Expect(b1.Score(Red)).To(Equal(5))
+		Expect(b1.Piece(Coord{1, 8}).Colour()).To(Equal(Dead))
+		Expect(b1.King(Dead)).To(BeNil())
+		Expect(b1.SideToMove()).To(Equal(Red))
+		Expect(b1.Settings().Players.Forward[Blue]).To(Equal(Coord{1, 0}))
+		Expect(b1.Settings().Holes).To(HaveLen(36))
+		Expect(b1.LegalMoves(NewLongAlgebraicNotation())).To(ConsistOf(b.LegalMoves(NewLongAlgebraicNotation())))
+	})
+
+	It("reads boards with custom settings and pieces", func() {
+		b := NewEmptyBoard(3, 3, testBoardSettings())
+		b.Settings().Name = ""
+		b.PlacePiece(Coord{1, 1}, NewKing(White))
+		b.PlacePiece(Coord{3, 3}, NewKing(Black))
+		b.PlacePiece(Coord{2, 2}, NewChancellor(White))
+		data := mustMarshal(b)
+
+		opts := JSONOptions{Pieces: PieceRegistry{"k": NewKing, "c": NewChancellor}, Funcs: StandardSettingsFuncs()}
+		Expect(roundTrip(b, opts).Settings().PawnStartZoneFunc).NotTo(BeNil())
+		_, err := UnmarshalBoardJSON(data, JSONOptions{Pieces: PieceRegistry{"k": NewKing}, Funcs: opts.Funcs})
+		Expect(err).To(HaveOccurred())
+		_, err = UnmarshalBoardJSON(data, JSONOptions{Pieces: opts.Pieces, Funcs: SettingsFuncs{}})
+		Expect(err).To(HaveOccurred(), "funcs are not registered")
+	})
+
+	It("writes settings funcs by their registered names", func() {
+		b := NewEmptyBoard(8, 8, StandardChessBoardSettings())
+		b.PlacePiece(Coord{5, 1}, NewKing(White))
+		b.PlacePiece(Coord{5, 8}, NewKing(Black))
+		var v struct {
+			Settings settingsJSON `json:"settings"`
+		}
+		Expect(json.Unmarshal(mustMarshal(b), &v)).To(Succeed())
+		Expect(v.Settings.PawnStartZoneFunc).To(Equal("standardPawnStartZone"))
+		Expect(v.Settings.CastlingsFunc).To(Equal("standardCastlings"))
+		Expect(v.Settings.PromotionRules[base.PawnName].ZoneFunc).To(Equal("standardPromotionZone"))
+
+		By("rejecting unregistered funcs and closures")
+		b.Settings().PawnStartZoneFunc = NewPawnStartRanksFunc(true, 3)
+		_, err := json.Marshal(b)
+		Expect(err).To(HaveOccurred())
+		funcs := StandardSettingsFuncs()
+		funcs["thirdRank"] = NewPawnStartRanksFunc(true, 3)
+		_, err = MarshalBoardJSON(b, JSONOptions{Pieces: StandardPieceRegistry(), Funcs: funcs})
+		Expect(err).To(HaveOccurred())
+
+		By("writing named funcs registered in options")
+		b.Settings().PawnStartZoneFunc = thirdRankPawnStartZoneFunc
+		funcs = StandardSettingsFuncs()
+		funcs["thirdRank"] = thirdRankPawnStartZoneFunc
+		opts := JSONOptions{Pieces: StandardPieceRegistry(), Funcs: funcs}
+		data, err := MarshalBoardJSON(b, opts)
+		Expect(err).NotTo(HaveOccurred())
+		b1, err := UnmarshalBoardJSON(data, opts)
+		Expect(err).NotTo(HaveOccurred())
+		b1.PlacePiece(Coord{1, 3}, NewPawn(White))
+		Expect(b1.Piece(Coord{1, 3}).Destinations(b1).Contains(Coord{1, 5})).To(BeTrue())
+
+		By("rejecting funcs of wrong types")
+		funcs["thirdRank"] = NoCastlingFunc
+		_, err = UnmarshalBoardJSON(data, opts)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects invalid coords, several pieces at the same coords and a missing side to move", func() {
+		settings := StandardChessBoardSettings()
+		settings.Holes = []base.ICoord{Coord{4, 4}}
+		b := NewEmptyBoard(8, 8, settings)
+		b.PlacePiece(Coord{5, 1}, NewKing(White))
+		b.PlacePiece(Coord{5, 8}, NewKing(Black))
+		for i, change := range []func(v map[string]interface{}){
+			func(v map[string]interface{}) { v["enPassant"] = Coord{9, 3} },
+			func(v map[string]interface{}) { v["enPassant"] = Coord{4, 4} },
+			func(v map[string]interface{}) { v["enPassantTargets"] = []Coord{{0, 3}} },
+			func(v map[string]interface{}) { v["castlingPartners"] = map[Colour][]Coord{White: {{1, 9}}} },
+			func(v map[string]interface{}) { v["castlingPartners"] = map[Colour][]Coord{Black: {{4, 4}}} },
+			func(v map[string]interface{}) {
+				v["pieces"] = append(v["pieces"].([]interface{}), v["pieces"].([]interface{})[0])
+			},
+			func(v map[string]interface{}) { delete(v, "sideToMove") },
+		} {
+			var v map[string]interface{}
+			Expect(json.Unmarshal(mustMarshal(b), &v)).To(Succeed())
+			change(v)
+			data, err := json.Marshal(v)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = UnmarshalBoardJSON(data, DefaultJSONOptions())
+			Expect(err).To(HaveOccurred(), "change at index %d", i)
+		}
+		_, err := UnmarshalBoardJSON(mustMarshal(b), DefaultJSONOptions())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("rejects unsupported versions", func() {
+		board, err := NewStandardChessStartingPosition().Board()
+		Expect(err).NotTo(HaveOccurred())
+		var v map[string]interface{}
+		Expect(json.Unmarshal(mustMarshal(board.(*Board)), &v)).To(Succeed())
+		v["version"] = JSONVersion + 1
+		data, err := json.Marshal(v)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = UnmarshalBoardJSON(data, DefaultJSONOptions())
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+// thirdRankPawnStartZoneFunc allows pawn long move from the 3rd rank
+func thirdRankPawnStartZoneFunc(board base.IBoard, piece base.IPiece) bool {
+	return NewPawnStartRanksFunc(true, 3)(board, piece)
+}
+
+// mustMarshal returns b marshaled to JSON
+func mustMarshal(b *Board) []byte {
+	data, err := json.Marshal(b)
+	Expect(err).NotTo(HaveOccurred())
+	return data
+}
@@ -16,7 +16,7 @@ func NewArchbishop(colour Colour) base.IPiece {
 // dst returns a slice of destination cells coords, making it's legal moves
 // if moving is false then pairs leading to check-exposing moves also included
 func (p *Archbishop) dst(b *Board, moving bool) base.ICoords {
-	return NewCoords(append(reader(1, 1, p, b, moving, 0, 0, moveAny), leaper(1, 2, p, b, moving, 0, moveAny)...))
+	return NewCoords(append(reader(1, 1, p, b, moving, 0, 0, base.MoveAny), leaper(1, 2, p, b, moving, 0, base.MoveAny)...))
 }
 
 // Attacks returns a slice of coords pairs of cells attacked by a piece
@@ -29,7 +29,7 @@ func (p *Archbishop) Destinations(b base.IBoard) base.ICoords { return p.dst(b.(
 func (p *Archbishop) Copy() base.IPiece { return &Archbishop{Piece: p.Piece.Copy()} }
 
 // Promote returns a promoted piece
-func (p *Archbishop) Promote() base.IPiece { return p }
+func (p *Archbishop) Promote() base.IPiece { return base.Promoted(p) }
 
 // Set sets a piece to p1
 func (p *Archbishop) Set(p1 base.IPiece) { *p = *(p1.(*Archbishop)) }
@@ -16,7 +16,7 @@ func NewBishop(colour Colour) base.IPiece {
 // dst returns a slice of destination cells coords, making it's legal moves
 // if moving is false then pairs leading to check-exposing moves also included
 func (p *Bishop) dst(b *Board, moving bool) base.ICoords {
-	return NewCoords(reader(1, 1, p, b, moving, 0, 0, moveAny))
+	return NewCoords(reader(1, 1, p, b, moving, 0, 0, base.MoveAny))
 }
 
 // Attacks returns a slice of coords pairs of cells attacked by a piece
@@ -29,7 +29,7 @@ func (p *Bishop) Destinations(b base.IBoard) base.ICoords { return p.dst(b.(*Boa
 func (p *Bishop) Copy() base.IPiece { return &Bishop{Piece: p.Piece.Copy()} }
 
 // Promote returns a promoted piece
-func (p *Bishop) Promote() base.IPiece { return p }
+func (p *Bishop) Promote() base.IPiece { return base.Promoted(p) }
 
 // Set sets a piece to p1
 func (p *Bishop) Set(p1 base.IPiece) { *p = *(p1.(*Bishop)) }
@@ -3,56 +3,61 @@ package rect
 import (
 	"github.com/mtfelian/mtfchess/base"
 	. "github.com/mtfelian/mtfchess/colour"
-	. "github.com/mtfelian/utils"
 )
 
 // inOneStep returns legal moves for pieces which move in one step, like knight and king
 func inOneStep(piece base.IPiece, board base.IBoard, moving bool, o []base.ICoord, moveType int) []base.ICoord {
 	result := []base.ICoord{}
 	for i := range o {
-		to := piece.Coord().Add(o[i])
-		if to.OutOf(board) {
+		to, ok := normalize(board, piece.Coord().Add(o[i]).(Coord))
+		if !ok || to.Equals(piece.Coord()) {
 			continue
 		}
 		if moving && board.Project(piece, to).InCheck(piece.Colour()) {
 			continue
 		}
-		stroke(to, moving, board, piece, &result, moveType) // here should not break even if true!
+		base.Stroke(to, moving, board, piece, &result, moveType) // here should not break even if true!
 	}
-	return result
+	return uniqueCoords(board, result)
 }
 
-// stroke returns true if mine imaginary beam strokes some piece on coords on board, memorizing it's path
-// it returns false if an imaginary beam is still going meating no barrier
-// to is a destination cell coords
-// moving - set it to true if the func should return possible legal moves, set it to false to return attacked cells
-// on is a board on which piece is moving
-// mine is a moving piece
-// path is a pointer to a slice of coords to add
-// moveType is a type of move: only capturing, only non-capturing, or any
-func stroke(to base.ICoord, moving bool, on base.IBoard, mine base.IPiece, path *[]base.ICoord, moveType int) bool {
-	dstPiece := on.Cell(to).Piece()
-	// destination cell contains another piece
-	if dstPiece != nil {
-		// if we are only calculating attacking cells, or if can capture
-		if SliceContains(moveType, []int{moveAny, moveCapture}) && (!moving || dstPiece.Colour() != mine.Colour()) {
-			*path = append(*path, to)
+// forward returns a forward direction of pawns of the given colour: to the top for white, to the bottom for black,
+// and as set by Settings.Players for sides of a multi-player game
+func forward(board base.IBoard, colour Colour) Coord {
+	if players := board.Settings().Players; players != nil {
+		if f, exists := players.Forward[colour]; exists {
+			return f.(Coord)
 		}
-		return true
 	}
+	if colour == Black {
+		return Coord{0, -1}
+	}
+	return Coord{0, 1}
+}
 
-	// dstPiece == nil, empty cell
-	if moveType == moveAny || (moving && moveType == moveNonCapture) || (!moving && moveType == moveCapture) {
-		*path = append(*path, to)
+// orient returns offsets o given for white turned to the forward direction of the given colour
+func orient(board base.IBoard, colour Colour, o []Coord) []Coord {
+	f := forward(board, colour)
+	side, res := Coord{abs(f.Y), abs(f.X)}, make([]Coord, len(o))
+	for i := range o {
+		res[i] = Coord{o[i].X*side.X + o[i].Y*f.X, o[i].X*side.Y + o[i].Y*f.Y}
 	}
-	return false
+	return res
 }
 
-const (
-	moveAny        = iota // capture / non-capturing move
-	moveCapture           // only capture
-	moveNonCapture        // only non-capturing move
-)
+// uniqueCoords returns coords c without duplicates which can appear on a board with wrap-around
+func uniqueCoords(board base.IBoard, c []base.ICoord) []base.ICoord {
+	if !board.Settings().WrapFiles && !board.Settings().WrapRanks {
+		return c
+	}
+	res := NewCoords([]base.ICoord{})
+	for i := range c {
+		if !res.Contains(c[i]) {
+			res.Add(c[i])
+		}
+	}
+	return res.Coords.Slice()
+}
 
 // leaper launches piece's beam like knight (+/- m/n, rot90, +/- n,m) on a board.
 // Set moving to true to exclude check exposing path and defending own piece path.
@@ -61,9 +66,6 @@ const (
 // Set f to 0 to allow both forward and backward piece movement.
 // Returns a slice of destination coords.
 func leaper(m, n int, piece base.IPiece, board *Board, moving bool, f int, moveType int) []base.ICoord {
-	if piece.Colour() == Black {
-		f *= -1
-	}
 	if m == 0 { // only n can be 0
 		if n == 0 {
 			panic("reader can't be (0,0)")
@@ -97,6 +99,9 @@ func leaper(m, n int, piece base.IPiece, board *Board, moving bool, f int, moveT
 		panic("wrong front value")
 	}
 
+	if f != 0 {
+		offsets = orient(board, piece.Colour(), offsets)
+	}
 	iOffsets := make([]base.ICoord, len(offsets))
 	for i := range offsets {
 		iOffsets[i] = offsets[i]
@@ -105,28 +110,29 @@ func leaper(m, n int, piece base.IPiece, board *Board, moving bool, f int, moveT
 	return inOneStep(piece, board, moving, iOffsets, moveType)
 }
 
-// inManySteps returns legal moves for pieces which move in many steps, like rook and bishop
+// inManySteps returns legal moves for pieces which move in many steps, like rook and bishop.
+// Moving around a board with wrap-around stops on reaching the piece's own cell.
 func inManySteps(piece base.IPiece, board *Board, moving bool, o []Coord, max int, moveType int) []base.ICoord {
-	bW, pX := board.Dim().(Coord).X, piece.Coord().(Coord).X
-	bH, pY := board.Dim().(Coord).Y, piece.Coord().(Coord).Y
-	// oX, oY - offsets, step - current step of a reader
-	notOut := func(oX, oY, step int) bool {
-		return oX >= 1-pX && oY >= 1-pY && oX <= bW-pX && oY <= bH-pY && (step < max || max == 0)
-	}
 	result := []base.ICoord{}
 directions:
 	for i := range o {
-		for oX, oY, step := o[i].X, o[i].Y, 0; notOut(oX, oY, step); oX, oY, step = oX+o[i].X, oY+o[i].Y, step+1 {
-			to := piece.Coord().Add(Coord{oX, oY})
+		for to, step := piece.Coord().(Coord), 0; step < max || max == 0; step++ {
+			var ok bool
+			if to, ok = normalize(board, Coord{to.X + o[i].X, to.Y + o[i].Y}); !ok || to.Equals(piece.Coord()) {
+				continue directions
+			}
 			if moving && board.Project(piece, to).InCheck(piece.Colour()) {
+				if board.Piece(to) != nil {
+					continue directions // a piece blocks the way even if capturing it doesn't release check
+				}
 				continue // should continue in same direction (may be further capture releases check?)
 			}
-			if stroke(to, moving, board, piece, &result, moveType) {
+			if base.Stroke(to, moving, board, piece, &result, moveType) {
 				continue directions // capture occurred, don't go further in that direction
 			}
 		}
 	}
-	return result
+	return uniqueCoords(board, result)
 }
 
 // reader launches (m,n)-reader piece's beam on a board.
@@ -138,9 +144,6 @@ directions:
 // Set f to 0 to allow both forward and backward piece movement.
 // Returns a slice of destination coords.
 func reader(m, n int, piece base.IPiece, board *Board, moving bool, max int, f int, moveType int) []base.ICoord {
-	if piece.Colour() == Black {
-		f *= -1
-	}
 	if m == 0 { // only n can be 0
 		if n == 0 {
 			panic("reader can't be (0,0)")
@@ -175,5 +178,8 @@ func reader(m, n int, piece base.IPiece, board *Board, moving bool, max int, f i
 		panic("wrong front value")
 	}
 
+	if f != 0 {
+		offsets = orient(board, piece.Colour(), offsets)
+	}
 	return inManySteps(piece, board, moving, offsets, max, moveType)
 }
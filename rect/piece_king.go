@@ -16,7 +16,7 @@ func NewKing(colour Colour) base.IPiece {
 // dst returns a slice of destination cells coords, making it's legal moves
 // if moving is false then pairs leading to check-exposing moves also included
 func (p *King) dst(b *Board, moving bool) base.ICoords {
-	return NewCoords(append(leaper(1, 0, p, b, moving, 0, moveAny), leaper(1, 1, p, b, moving, 0, moveAny)...))
+	return NewCoords(append(leaper(1, 0, p, b, moving, 0, base.MoveAny), leaper(1, 1, p, b, moving, 0, base.MoveAny)...))
 }
 
 // Attacks returns a slice of coords pairs of cells attacked by a piece
@@ -35,7 +35,7 @@ func (p *King) SetCoords(board base.IBoard, to base.ICoord) {
 func (p *King) Copy() base.IPiece { return &King{Piece: p.Piece.Copy()} }
 
 // Promote returns a promoted piece
-func (p *King) Promote() base.IPiece { return p }
+func (p *King) Promote() base.IPiece { return base.Promoted(p) }
 
 // Set sets a piece to p1
 func (p *King) Set(p1 base.IPiece) { *p = *(p1.(*King)) }
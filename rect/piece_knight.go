@@ -16,7 +16,7 @@ func NewKnight(colour Colour) base.IPiece {
 // dst returns a slice of destination cells coords, making it's legal moves
 // if moving is false then pairs leading to check-exposing moves also included
 func (p *Knight) dst(b *Board, moving bool) base.ICoords {
-	return NewCoords(leaper(1, 2, p, b, moving, 0, moveAny))
+	return NewCoords(leaper(1, 2, p, b, moving, 0, base.MoveAny))
 }
 
 // Attacks returns a slice of coords pairs of cells attacked by a piece
@@ -29,7 +29,7 @@ func (p *Knight) Destinations(b base.IBoard) base.ICoords { return p.dst(b.(*Boa
 func (p *Knight) Copy() base.IPiece { return &Knight{Piece: p.Piece.Copy()} }
 
 // Promote returns a promoted piece
-func (p *Knight) Promote() base.IPiece { return p }
+func (p *Knight) Promote() base.IPiece { return base.Promoted(p) }
 
 // Set sets a piece to p1
 func (p *Knight) Set(p1 base.IPiece) { *p = *(p1.(*Knight)) }
@@ -16,19 +16,21 @@ func NewPawn(colour Colour) base.IPiece {
 // dst returns a slice of destination cells coords, making it's legal moves
 // if moving is false then pairs leading to check-exposing moves also included
 func (p *Pawn) dst(b *Board, moving bool) base.ICoords {
-	long, pY := 0, p.Coord().(Coord).Y
-	if p.Colour() == White && pY == 2 || p.Colour() == Black && pY == b.Dim().(Coord).Y-1 {
+	long := 0
+	if b.Settings().PawnStartZoneFunc(b, p) {
 		long = b.Settings().PawnLongMoveModifier
 	}
 
-	d := NewCoords(append(reader(1, 0, p, b, moving, 1+long, 1, moveNonCapture),
-		leaper(1, 1, p, b, moving, 1, moveCapture)...))
+	d := NewCoords(append(reader(1, 0, p, b, moving, 1+long, 1, base.MoveNonCapture),
+		leaper(1, 1, p, b, moving, 1, base.MoveCapture)...))
 
 	if moving {
 		// search through the possible en passant capturing coords and add if appropriate coords is found
-		epCoord := b.Settings().EnPassantFunc(b, p)
-		if epCoord != nil {
-			d.Add(epCoord)
+		epCoords := b.Settings().EnPassantFunc(b, p)
+		for epCoords.HasNext() {
+			if to := epCoords.Next().(base.ICoord); !base.PromotionBlocked(b, p, to) {
+				d.Add(to)
+			}
 		}
 	}
 
@@ -45,13 +47,7 @@ func (p *Pawn) Destinations(b base.IBoard) base.ICoords { return p.dst(b.(*Board
 func (p *Pawn) Copy() base.IPiece { return &Pawn{Piece: p.Piece.Copy()} }
 
 // Promote returns a promoted piece
-func (p *Pawn) Promote() base.IPiece {
-	promotion := p.Promotion()
-	if promotion == nil {
-		return p
-	}
-	return promotion.Copy()
-}
+func (p *Pawn) Promote() base.IPiece { return base.Promoted(p) }
 
 // Set sets a piece to p1
 func (p *Pawn) Set(p1 base.IPiece) { *p = *(p1.(*Pawn)) }
@@ -2,6 +2,7 @@ package rect
 
 import (
 	"sort"
+	"strings"
 
 	"github.com/mtfelian/mtfchess/base"
 	. "github.com/mtfelian/mtfchess/colour"
@@ -546,3 +547,232 @@ var _ = Describe("En passant capturing test", func() {
 	})
 
 })
+
+var _ = Describe("Pawn start zones and multi-step en passant test", func() {
+	var b *Board
+	resetBoard := func(w, h int) {
+		b = NewEmptyBoard(w, h, StandardChessBoardSettings())
+		b.Settings().MoveOrder = false
+	}
+
+	It("allows long move from the 3rd rank like in Grand Chess", func() {
+		resetBoard(10, 10)
+		b.Settings().PawnStartZoneFunc = NewPawnStartRanksFunc(true, 3)
+		wp, bp, wp2 := NewPawn(White), NewPawn(Black), NewPawn(White)
+		b.PlacePiece(Coord{2, 3}, wp)
+		b.PlacePiece(Coord{3, 8}, bp)
+		b.PlacePiece(Coord{5, 2}, wp2)
+
+		d := wp.Destinations(b)
+		sort.Sort(d)
+		Expect(d.Equals(NewCoords([]base.ICoord{Coord{2, 4}, Coord{2, 5}}))).To(BeTrue())
+
+		d = bp.Destinations(b)
+		sort.Sort(d)
+		Expect(d.Equals(NewCoords([]base.ICoord{Coord{3, 6}, Coord{3, 7}}))).To(BeTrue())
+
+		d = wp2.Destinations(b)
+		Expect(d.Equals(NewCoords([]base.ICoord{Coord{5, 3}}))).To(BeTrue(), "2nd rank is not a start zone")
+
+		wp.MarkMoved()
+		d = wp.Destinations(b)
+		Expect(d.Equals(NewCoords([]base.ICoord{Coord{2, 4}}))).To(BeTrue(), "moved pawn can't make a long move")
+	})
+
+	It("allows long move from any rank within the zone for moved pawns", func() {
+		resetBoard(8, 8)
+		b.Settings().PawnStartZoneFunc = NewPawnStartRanksFunc(false, 2, 3)
+		wp := NewPawn(White)
+		b.PlacePiece(Coord{1, 2}, wp)
+		Expect(b.MakeMove(Coord{1, 3}, wp)).To(BeTrue())
+		Expect(b.MakeMove(Coord{1, 5}, wp)).To(BeTrue())
+		Expect(b.EnPassantTargets()).To(Equal([]base.ICoord{Coord{1, 4}}))
+	})
+
+	It("allows long move from the given squares", func() {
+		resetBoard(8, 8)
+		b.Settings().PawnStartZoneFunc = NewPawnStartSquaresFunc(true, map[Colour][]Coord{
+			White: {{1, 2}},
+			Black: {{2, 6}},
+		})
+		wp1, wp2, bp := NewPawn(White), NewPawn(White), NewPawn(Black)
+		b.PlacePiece(Coord{1, 2}, wp1)
+		b.PlacePiece(Coord{3, 2}, wp2)
+		b.PlacePiece(Coord{2, 6}, bp)
+		Expect(wp1.Destinations(b).Len()).To(Equal(2))
+		Expect(wp2.Destinations(b).Len()).To(Equal(1))
+		Expect(bp.Destinations(b).Len()).To(Equal(2))
+	})
+
+	It("captures en passant on all passed over cells after a triple step", func() {
+		resetBoard(12, 12)
+		b.Settings().PawnLongMoveModifier = 2
+		wp, bp1, bp2 := NewPawn(White), NewPawn(Black), NewPawn(Black)
+		b.PlacePiece(Coord{3, 2}, wp)
+		b.PlacePiece(Coord{4, 4}, bp1)
+		b.PlacePiece(Coord{2, 5}, bp2)
+
+		d := wp.Destinations(b)
+		sort.Sort(d)
+		Expect(d.Equals(NewCoords([]base.ICoord{Coord{3, 3}, Coord{3, 4}, Coord{3, 5}}))).To(BeTrue())
+
+		Expect(b.MakeMove(Coord{3, 5}, wp)).To(BeTrue())
+		Expect(b.CanCaptureEnPassantAt()).To(Equal(Coord{3, 5}))
+		Expect(b.EnPassantTargets()).To(Equal([]base.ICoord{Coord{3, 3}, Coord{3, 4}}))
+		Expect(NewXFEN(b).PositionPart()).To(Equal(`12/12/12/12/12/12/12/1pP9/3p8/12/12/12 b - c3`))
+
+		boardCopy := b.Copy()
+		Expect(bp1.Destinations(b).Contains(Coord{3, 3})).To(BeTrue())
+		Expect(b.MakeMove(Coord{3, 3}, bp1)).To(BeTrue())
+		Expect(b.Piece(Coord{3, 5})).To(BeNil())
+		Expect(wp.Coord()).To(BeNil())
+		Expect(b.CanCaptureEnPassantAt()).To(BeNil())
+		Expect(b.EnPassantTargets()).To(BeNil())
+
+		b.Set(boardCopy)
+		wp, bp2 = b.Piece(Coord{3, 5}), b.Piece(Coord{2, 5})
+		Expect(b.MakeMove(Coord{3, 4}, bp2)).To(BeTrue())
+		Expect(b.Piece(Coord{3, 5})).To(BeNil())
+		Expect(wp.Coord()).To(BeNil())
+	})
+
+	It("parses en passant targets from X-FEN", func() {
+		opts := DefaultXFENOptions()
+		b, err := XFEN(`12/12/12/12/12/12/12/1pP9/3p8/12/12/12 b - c3 0 1`).BoardWithOptions(opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b.CanCaptureEnPassantAt()).To(Equal(Coord{3, 5}))
+		Expect(b.EnPassantTargets()).To(Equal([]base.ICoord{Coord{3, 3}, Coord{3, 4}}))
+	})
+})
+
+var _ = Describe("Promotion rules test", func() {
+	var b *Board
+	resetBoard := func(w, h int) {
+		b = NewEmptyBoard(w, h, StandardChessBoardSettings())
+		b.Settings().MoveOrder = false
+	}
+
+	It("requires mandatory promotion on the last rank", func() {
+		resetBoard(8, 8)
+		wp, wk, bk := NewPawn(White), NewKing(White), NewKing(Black)
+		b.PlacePiece(Coord{5, 7}, wp)
+		b.PlacePiece(Coord{1, 1}, wk)
+		b.PlacePiece(Coord{1, 8}, bk)
+
+		Expect(b.MakeMove(Coord{5, 8}, wp)).To(BeFalse(), "pawn moved to the last rank without promotion")
+
+		moves := b.LegalMoves(NewLongAlgebraicNotation())
+		sort.Strings(moves)
+		Expect(moves).To(Equal([]string{"Ka1-a2", "Ka1-b1", "Ka1-b2", "e7-e8=B", "e7-e8=N", "e7-e8=Q+", "e7-e8=R+"}))
+
+		makeMove, err := NewLongAlgebraicNotation().DecodeMove(b, "e7-e8q")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(makeMove()).To(BeTrue())
+		Expect(b.Piece(Coord{5, 8}).Name()).To(Equal(base.QueenName))
+		Expect(b.Piece(Coord{5, 8}).Colour()).To(Equal(White))
+	})
+
+	It("fails to decode a promotion to unknown piece", func() {
+		resetBoard(8, 8)
+		_, err := NewLongAlgebraicNotation().DecodeMove(b, "e7-e8=X")
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("Grand Chess like rules", func() {
+		BeforeEach(func() {
+			resetBoard(10, 10)
+			b.Settings().PromotionRules = base.PromotionRules{
+				base.PawnName: {
+					ZoneFunc: NewPromotionRanksFunc([]int{8, 9}, []int{10}),
+					Targets: []string{base.KnightName, base.BishopName, base.RookName, base.QueenName,
+						base.ArchbishopName, base.ChancellorName},
+					Limits: map[string]int{
+						base.KnightName: 2, base.BishopName: 2, base.RookName: 2,
+						base.QueenName: 1, base.ArchbishopName: 1, base.ChancellorName: 1,
+					},
+				},
+			}
+		})
+
+		It("allows optional promotion only to captured pieces", func() {
+			wp, bp := NewPawn(White), NewPawn(Black)
+			b.PlacePiece(Coord{1, 7}, wp)
+			b.PlacePiece(Coord{10, 4}, bp)
+			b.PlacePiece(Coord{2, 1}, NewQueen(White))
+			b.PlacePiece(Coord{3, 1}, NewRook(White))
+			b.PlacePiece(Coord{4, 1}, NewRook(White))
+			b.PlacePiece(Coord{5, 1}, NewKnight(White))
+
+			boardCopy := b.Copy()
+			wp.SetPromote(NewQueen(White))
+			Expect(b.MakeMove(Coord{1, 8}, wp)).To(BeFalse(), "queen was not captured")
+			wp.SetPromote(NewRook(White))
+			Expect(b.MakeMove(Coord{1, 8}, wp)).To(BeFalse(), "no rooks were captured")
+			Expect(b.Equals(boardCopy)).To(BeTrue())
+			wp.SetPromote(nil)
+			Expect(b.MakeMove(Coord{1, 8}, wp)).To(BeTrue(), "promotion is optional on the 8th rank")
+
+			b.SetSideToMove(White)
+			moves := []string{}
+			for _, move := range b.LegalMoves(NewLongAlgebraicNotation()) {
+				if strings.HasPrefix(move, "a8") {
+					moves = append(moves, move)
+				}
+			}
+			sort.Strings(moves)
+			Expect(moves).To(Equal([]string{"a8-a9", "a8-a9=A", "a8-a9=B", "a8-a9=C", "a8-a9=N"}))
+
+			bp.SetPromote(NewChancellor(Black))
+			Expect(b.MakeMove(Coord{10, 3}, bp)).To(BeTrue(), "black promotes on it's 8th rank")
+			Expect(b.Piece(Coord{10, 3}).Name()).To(Equal(base.ChancellorName))
+		})
+
+		It("forbids moving to the last rank if no pieces were captured", func() {
+			wp := NewPawn(White)
+			b.PlacePiece(Coord{1, 9}, wp)
+			b.PlacePiece(Coord{2, 1}, NewQueen(White))
+			for i, p := range []base.IPiece{NewRook(White), NewRook(White), NewKnight(White), NewKnight(White),
+				NewBishop(White), NewBishop(White), NewArchbishop(White), NewChancellor(White)} {
+				b.PlacePiece(Coord{3 + i, 1}, p)
+			}
+			Expect(b.MakeMove(Coord{1, 10}, wp)).To(BeFalse())
+			for _, move := range b.LegalMoves(NewLongAlgebraicNotation()) {
+				Expect(move).NotTo(HavePrefix("a9"))
+			}
+		})
+
+		It("detects stalemate when the only pawn moves are to the last rank without pieces to promote to", func() {
+			rule := b.Settings().PromotionRules[base.PawnName]
+			rule.Limits = map[string]int{base.QueenName: 0}
+			rule.Targets = []string{base.QueenName}
+			b.Settings().PromotionRules[base.PawnName] = rule
+			wk, wp := NewKing(White), NewPawn(White)
+			b.PlacePiece(Coord{1, 1}, wk)
+			b.PlacePiece(Coord{5, 9}, wp)
+			b.PlacePiece(Coord{6, 10}, NewRook(Black))
+			b.PlacePiece(Coord{3, 2}, NewQueen(Black))
+			b.PlacePiece(Coord{10, 10}, NewKing(Black))
+
+			Expect(wp.Destinations(b).Len()).To(BeZero())
+			Expect(b.HasMoves(White)).To(BeFalse())
+			Expect(b.InStalemate(White)).To(BeTrue())
+			Expect(b.LegalMoves(NewLongAlgebraicNotation())).To(BeEmpty())
+		})
+	})
+
+	It("promotes non-pawn pieces", func() {
+		resetBoard(8, 8)
+		b.Settings().PromotionRules[base.KnightName] = base.PromotionRule{
+			ZoneFunc: NewPromotionRanksFunc([]int{-1}, nil),
+			Targets:  []string{base.ChancellorName},
+		}
+		wn := NewKnight(White)
+		b.PlacePiece(Coord{2, 6}, wn)
+		wn.SetPromote(NewQueen(White))
+		Expect(b.MakeMove(Coord{3, 8}, wn)).To(BeFalse(), "knight can't promote to queen")
+		wn.SetPromote(NewChancellor(White))
+		Expect(b.MakeMove(Coord{4, 7}, wn)).To(BeFalse(), "knight can't promote outside the zone")
+		Expect(b.MakeMove(Coord{3, 8}, wn)).To(BeTrue())
+		Expect(b.Piece(Coord{3, 8}).Name()).To(Equal(base.ChancellorName))
+	})
+})
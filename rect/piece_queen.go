@@ -16,7 +16,7 @@ func NewQueen(colour Colour) base.IPiece {
 // dst returns a slice of destination cells coords, making it's legal moves
 // if moving is false then pairs leading to check-exposing moves also included
 func (p *Queen) dst(b *Board, moving bool) base.ICoords {
-	return NewCoords(append(reader(1, 0, p, b, moving, 0, 0, moveAny), reader(1, 1, p, b, moving, 0, 0, moveAny)...))
+	return NewCoords(append(reader(1, 0, p, b, moving, 0, 0, base.MoveAny), reader(1, 1, p, b, moving, 0, 0, base.MoveAny)...))
 }
 
 // Attacks returns a slice of coords pairs of cells attacked by a piece
@@ -29,7 +29,7 @@ func (p *Queen) Destinations(b base.IBoard) base.ICoords { return p.dst(b.(*Boar
 func (p *Queen) Copy() base.IPiece { return &Queen{Piece: p.Piece.Copy()} }
 
 // Promote returns a promoted piece
-func (p *Queen) Promote() base.IPiece { return p }
+func (p *Queen) Promote() base.IPiece { return base.Promoted(p) }
 
 // Set sets a piece to p1
 func (p *Queen) Set(p1 base.IPiece) { *p = *(p1.(*Queen)) }
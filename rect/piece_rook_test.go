@@ -51,6 +51,17 @@ var _ = Describe("Rook test", func() {
 		Expect(b.MakeMove(Coord{1, 2}, wr)).To(BeTrue(), "can't capture")
 	})
 
+	It("doesn't jump over pieces to release check", func() {
+		wk, wr, wn, br := NewKing(White), NewRook(White), NewKnight(White), NewRook(Black)
+		b.PlacePiece(Coord{1, 1}, wk)
+		b.PlacePiece(Coord{5, 2}, wr)
+		b.PlacePiece(Coord{3, 2}, wn)
+		b.PlacePiece(Coord{1, 6}, br)
+
+		Expect(wr.Destinations(b).Len()).To(Equal(0))
+		Expect(b.MakeMove(Coord{1, 2}, wr)).To(BeFalse(), "jumped over own piece")
+	})
+
 	It("makes legal moves", func() {
 		var wr, br base.IPiece
 		testReset := func() {
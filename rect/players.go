@@ -0,0 +1,263 @@
+package rect
+
+import (
+	"fmt"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+// Eliminated returns true if a side of colour is eliminated from a multi-player game
+func (b *Board) Eliminated(colour Colour) bool { return b.eliminated[colour] }
+
+// Score returns points of a side of colour in a multi-player game with scoring
+func (b *Board) Score(of Colour) int { return b.scores[of] }
+
+// SetScore sets points of a side of colour to n
+func (b *Board) SetScore(of Colour, n int) {
+	if b.scores == nil {
+		b.scores = make(map[Colour]int)
+	}
+	b.scores[of] = n
+}
+
+// copyEliminated returns a copy of eliminated sides
+func (b *Board) copyEliminated() map[Colour]bool {
+	if b.eliminated == nil {
+		return nil
+	}
+	c := make(map[Colour]bool)
+	for key, value := range b.eliminated {
+		c[key] = value
+	}
+	return c
+}
+
+// copyScores returns a copy of sides scores
+func (b *Board) copyScores() map[Colour]int {
+	if b.scores == nil {
+		return nil
+	}
+	c := make(map[Colour]int)
+	for key, value := range b.scores {
+		c[key] = value
+	}
+	return c
+}
+
+// playersEqual returns true if eliminated sides and scores of boards b and b1 are equal
+func (b *Board) playersEqual(b1 *Board) bool {
+	for _, colour := range b.Settings().Players.Colours() {
+		if b.Eliminated(colour) != b1.Eliminated(colour) || b.Score(colour) != b1.Score(colour) {
+			return false
+		}
+	}
+	return true
+}
+
+// eliminate eliminates a side of colour from a multi-player game. Its pieces become dead: they stay on a board,
+// can't move and don't give check, but any side can capture them. Pieces unknown to the standard piece registry
+// are removed from a board.
+func (b *Board) eliminate(colour Colour) {
+	if b.eliminated == nil {
+		b.eliminated = make(map[Colour]bool)
+	}
+	b.eliminated[colour] = true
+	pieces := b.FindPieces(base.PieceFilter{Colours: []Colour{colour}})
+	for i := range pieces {
+		at := pieces[i].Coord()
+		b.Empty(at)
+		if dead := StandardPieceRegistry().NewByName(pieces[i].Name(), Dead); dead != nil {
+			dead.MarkMoved()
+			b.PlacePiece(at, dead)
+		}
+	}
+	delete(b.king, colour)
+	delete(b.king, Dead)
+}
+
+// addPoints adds points for capturing a piece to a side of colour if scoring is enabled
+func (b *Board) addPoints(to Colour, captured base.IPiece) {
+	players := b.Settings().Players
+	if players == nil || players.Points == nil || captured.Colour() == Dead {
+		return
+	}
+	b.SetScore(to, b.Score(to)+players.Points[captured.Name()])
+}
+
+// captureKing eliminates a side of colour which king is captured by a side of by in a multi-player game,
+// the king can be captured if its side had no turn to escape the check. It gives checkmate points.
+func (b *Board) captureKing(by Colour, colour Colour) {
+	if players := b.Settings().Players; players.Points != nil {
+		b.SetScore(by, b.Score(by)+players.CheckmatePoints)
+	}
+	b.eliminate(colour)
+}
+
+// passTurn passes the turn to the next side after a move of mover and increases a move number
+// when all sides made their moves
+func (b *Board) passTurn(mover Colour) {
+	players := b.Settings().Players
+	if players == nil {
+		b.SetSideToMove(b.SideToMove().Invert())
+		if mover == Black {
+			b.SetMoveNumber(b.MoveNumber() + 1)
+		}
+		return
+	}
+
+	next := players.Next(mover, b.Eliminated)
+	if turnIndex(players, next) <= turnIndex(players, mover) {
+		b.SetMoveNumber(b.MoveNumber() + 1)
+	}
+	b.SetSideToMove(next)
+}
+
+// turnIndex returns an index of colour in order of turns, or -1 if there is no such side
+func turnIndex(players *base.Players, colour Colour) int {
+	for i, c := range players.TurnOrder {
+		if c == colour {
+			return i
+		}
+	}
+	return -1
+}
+
+// remaining returns colours of sides which are not eliminated from a multi-player game
+func (b *Board) remaining() []Colour {
+	res := []Colour{}
+	for _, c := range b.Settings().Players.Colours() {
+		if !b.Eliminated(c) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// finishMultiPlayer finishes a multi-player game if only one side or allied sides remain.
+// It returns true if the game is finished.
+func (b *Board) finishMultiPlayer() bool {
+	players, remaining := b.Settings().Players, b.remaining()
+	for i := range remaining {
+		if !players.Allied(remaining[0], remaining[i]) {
+			return false
+		}
+	}
+	switch {
+	case len(remaining) == 0:
+		b.setOutcome(base.NewStalemate())
+	case players.Points != nil && len(players.Teams) == 0:
+		scores := make(map[Colour]int)
+		for _, c := range players.Colours() {
+			scores[c] = b.Score(c)
+		}
+		b.setOutcome(base.NewPointsVictory(scores))
+	default:
+		b.setOutcome(base.NewLastStanding(remaining))
+	}
+	return true
+}
+
+// checkingSide returns colour of a side giving check to the king of colour, mover is preferred
+// if several sides give check. It returns Transparent if the king is not in check.
+func (b *Board) checkingSide(colour Colour, mover Colour) Colour {
+	king, res := b.King(colour), Transparent
+	if king == nil || king.Coord() == nil {
+		return res
+	}
+	for _, c := range b.Settings().Players.Opponents(colour) {
+		if !b.FindAttackedCellsBy(base.PieceFilter{Colours: []Colour{c}}).Contains(king.Coord()) {
+			continue
+		}
+		if c == mover {
+			return c
+		}
+		if res == Transparent {
+			res = c
+		}
+	}
+	return res
+}
+
+// computeMultiPlayerOutcome computes outcome of a multi-player game after a move of mover and sets it.
+// The game is finished if a king capture left only allied sides. Sides which are checkmated or stalemated
+// on their turn are eliminated, checkmate gives points to the checking side.
+func (b *Board) computeMultiPlayerOutcome(mover Colour) {
+	if b.finishMultiPlayer() {
+		return
+	}
+	players := b.Settings().Players
+	for side := b.SideToMove(); !b.HasMoves(side); side = b.SideToMove() {
+		if by := b.checkingSide(side, mover); by != Transparent && players.Points != nil {
+			b.SetScore(by, b.Score(by)+players.CheckmatePoints)
+		}
+		b.eliminate(side)
+		if b.finishMultiPlayer() {
+			return
+		}
+		b.passTurn(side)
+	}
+
+	if settings := b.Settings(); settings.PositionsToDraw > 0 && b.PositionOccurred() >= settings.PositionsToDraw {
+		b.setOutcome(base.NewDrawByXFoldRepetition())
+	}
+}
+
+// resignMultiPlayer eliminates a resigned side of colour from a multi-player game
+func (b *Board) resignMultiPlayer(colour Colour) {
+	if b.Eliminated(colour) {
+		return
+	}
+	b.eliminate(colour)
+	if b.finishMultiPlayer() || b.SideToMove() != colour {
+		return
+	}
+	b.passTurn(colour)
+	b.computeMultiPlayerOutcome(colour)
+}
+
+// multiPlayerPosition returns a string position description of a multi-player game,
+// X-FEN can't describe it because X-FEN has only white and black pieces
+func (b *Board) multiPlayerPosition() string {
+	s := ""
+	for i := range b.cells {
+		for j := range b.cells[i] {
+			if piece := b.cells[i][j].Piece(); piece != nil {
+				s += fmt.Sprintf("%c%c", []rune(piece.Colour().Name())[0], piece.Capital())
+				continue
+			}
+			s += "-"
+		}
+		s += "/"
+	}
+	return s + " " + b.SideToMove().Name()
+}
+
+// NewFourPlayerChessBoard creates new board with starting position of four-player chess.
+// Red, Blue, Yellow and Green sides are placed at the bottom, left, top and right board edges,
+// and each one has the king to the right of the queen.
+func NewFourPlayerChessBoard(teams bool) *Board {
+	b := NewEmptyBoard(14, 14, FourPlayerChessSettings(teams))
+	pieces := []func(Colour) base.IPiece{NewRook, NewKnight, NewBishop, NewQueen, NewKing, NewBishop, NewKnight, NewRook}
+	for _, colour := range FourPlayerColours() {
+		f := forward(b, colour)
+		// first is a coord of the leftmost piece from the side's view, right is a direction to the right
+		first, right := Coord{4, 1}, Coord{f.Y, -f.X}
+		switch colour {
+		case Blue:
+			first = Coord{1, 11}
+		case Yellow:
+			first = Coord{11, 14}
+		case Green:
+			first = Coord{14, 4}
+		}
+		for i := range pieces {
+			at := Coord{first.X + i*right.X, first.Y + i*right.Y}
+			b.PlacePiece(at, pieces[i](colour))
+			b.PlacePiece(Coord{at.X + f.X, at.Y + f.Y}, NewPawn(colour))
+		}
+	}
+	b.SetSideToMove(Red)
+	b.increasePositionCounter()
+	return b
+}
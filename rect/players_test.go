@@ -0,0 +1,209 @@
+package rect
+
+import (
+	"fmt"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Multi-player test", func() {
+	It("checks four-player chess starting position", func() {
+		b := NewFourPlayerChessBoard(false)
+		testCases := []struct {
+			colour Colour
+			king   Coord
+			queen  Coord
+		}{
+			{Red, Coord{8, 1}, Coord{7, 1}},
+			{Blue, Coord{1, 7}, Coord{1, 8}},
+			{Yellow, Coord{7, 14}, Coord{8, 14}},
+			{Green, Coord{14, 8}, Coord{14, 7}},
+		}
+		for i, testCase := range testCases {
+			By(fmt.Sprintf("Checking testCase %v at index %d...", testCase.colour.Name(), i))
+			Expect(b.FindPieces(base.PieceFilter{Colours: []Colour{testCase.colour}})).To(HaveLen(16))
+			Expect(b.King(testCase.colour).Coord()).To(Equal(testCase.king))
+			Expect(b.Piece(testCase.queen).Name()).To(Equal(base.QueenName))
+		}
+		Expect(b.SideToMove()).To(Equal(Red))
+		Expect(b.LegalMoves(NewLongAlgebraicNotation())).To(HaveLen(20))
+		Expect(Coord{2, 2}.OutOf(b)).To(BeTrue())
+	})
+
+	It("marks pieces of multi-player sides in their string representation", func() {
+		Expect(NewKing(White).String()).To(Equal("K"))
+		Expect(NewKing(Black).String()).To(Equal("k"))
+		Expect(NewKing(Red).String()).To(Equal("RK"))
+		Expect(NewKing(Blue).String()).To(Equal("BK"))
+		Expect(NewQueen(Yellow).String()).To(Equal("YQ"))
+		Expect(NewPawn(Green).String()).To(Equal("GP"))
+		Expect(NewRook(Dead).String()).To(Equal("DR"))
+	})
+
+	It("checks turn order and pawn directions", func() {
+		b := NewFourPlayerChessBoard(false)
+		testCases := []struct {
+			from, to Coord
+			side     Colour
+		}{
+			{Coord{5, 2}, Coord{5, 4}, Red},
+			{Coord{2, 5}, Coord{4, 5}, Blue},
+			{Coord{10, 13}, Coord{10, 11}, Yellow},
+			{Coord{13, 10}, Coord{12, 10}, Green},
+			{Coord{5, 4}, Coord{5, 5}, Red},
+		}
+		for i, testCase := range testCases {
+			By(fmt.Sprintf("Checking testCase %v at index %d...", testCase, i))
+			Expect(b.SideToMove()).To(Equal(testCase.side))
+			Expect(b.MakeMove(testCase.to, b.Piece(testCase.from))).To(BeTrue())
+		}
+		Expect(b.MoveNumber()).To(Equal(2))
+		Expect(b.SideToMove()).To(Equal(Blue))
+		Expect(b.Piece(Coord{4, 5}).Destinations(b).Contains(Coord{5, 5})).To(BeFalse())
+		Expect(b.Piece(Coord{4, 5}).Destinations(b).Contains(Coord{5, 6})).To(BeFalse())
+		Expect(b.Piece(Coord{4, 5}).Attacks(b).Contains(Coord{5, 6})).To(BeTrue())
+		Expect(b.Piece(Coord{4, 5}).Attacks(b).Contains(Coord{5, 4})).To(BeTrue())
+	})
+
+	It("checks pawn promotion on the 8th rank from the side", func() {
+		b := NewEmptyBoard(14, 14, FourPlayerChessSettings(false))
+		b.Settings().MoveOrder = false
+		bp := NewPawn(Blue)
+		b.PlacePiece(Coord{7, 5}, bp)
+		Expect(b.Settings().PromotionRules.Kind(b, bp, Coord{8, 5})).To(Equal(base.MandatoryPromotion))
+		Expect(b.MakeMove(Coord{8, 5}, bp)).To(BeFalse())
+		bp.SetPromote(NewQueen(Blue))
+		Expect(b.MakeMove(Coord{8, 5}, bp)).To(BeTrue())
+		Expect(b.Piece(Coord{8, 5}).Name()).To(Equal(base.QueenName))
+	})
+
+	Context("free for all", func() {
+		var b *Board
+		BeforeEach(func() {
+			b = NewEmptyBoard(14, 14, FourPlayerChessSettings(false))
+			b.PlacePiece(Coord{8, 1}, NewKing(Red))
+			b.PlacePiece(Coord{1, 7}, NewKing(Blue))
+			b.PlacePiece(Coord{7, 14}, NewKing(Yellow))
+			b.PlacePiece(Coord{14, 8}, NewKing(Green))
+			b.SetSideToMove(Red)
+		})
+
+		It("eliminates checkmated side and gives points", func() {
+			b.PlacePiece(Coord{4, 13}, NewRook(Red))
+			b.PlacePiece(Coord{10, 4}, NewRook(Red))
+			b.PlacePiece(Coord{5, 9}, NewKnight(Green))
+
+			Expect(b.MakeMove(Coord{10, 14}, b.Piece(Coord{10, 4}))).To(BeTrue())
+			Expect(b.InCheck(Yellow)).To(BeTrue())
+			Expect(b.Eliminated(Yellow)).To(BeFalse(), "side is eliminated on its turn")
+			Expect(b.MakeMove(Coord{1, 6}, b.King(Blue))).To(BeTrue())
+
+			Expect(b.Eliminated(Yellow)).To(BeTrue())
+			Expect(b.SideToMove()).To(Equal(Green))
+			Expect(b.Score(Red)).To(Equal(20))
+			Expect(b.King(Yellow)).To(BeNil())
+			Expect(b.Piece(Coord{7, 14}).Colour()).To(Equal(Dead))
+			Expect(b.Outcome().IsFinished()).To(BeFalse())
+
+			By("Checking dead pieces don't move and give no points...")
+			Expect(b.MakeMove(Coord{7, 13}, b.Piece(Coord{7, 14}))).To(BeFalse())
+			Expect(b.MakeMove(Coord{14, 9}, b.King(Green))).To(BeTrue())
+			Expect(b.MoveNumber()).To(Equal(2))
+			Expect(b.MakeMove(Coord{7, 14}, b.Piece(Coord{10, 14}))).To(BeTrue())
+			Expect(b.Score(Red)).To(Equal(20))
+
+			By("Checking captures give points...")
+			Expect(b.MakeMove(Coord{1, 5}, b.King(Blue))).To(BeTrue())
+			Expect(b.MakeMove(Coord{4, 10}, b.Piece(Coord{5, 9}))).To(BeFalse())
+			Expect(b.MakeMove(Coord{4, 11}, b.Piece(Coord{5, 9}))).To(BeTrue())
+			Expect(b.MakeMove(Coord{4, 11}, b.Piece(Coord{4, 13}))).To(BeTrue())
+			Expect(b.Score(Red)).To(Equal(23))
+		})
+
+		It("finishes the game by points when one side remains", func() {
+			b.SetScore(Blue, 5)
+			b.Resign(Blue)
+			Expect(b.Eliminated(Blue)).To(BeTrue())
+			Expect(b.Outcome().IsFinished()).To(BeFalse())
+			b.Resign(Red)
+			Expect(b.SideToMove()).To(Equal(Yellow))
+			b.Resign(Yellow)
+			Expect(b.Outcome().IsFinished()).To(BeTrue())
+			Expect(b.Outcome().Winner).To(Equal(Blue))
+			Expect(b.Outcome().Scores).To(Equal(map[Colour]int{Red: 0, Blue: 5, Yellow: 0, Green: 0}))
+			Expect(b.Outcome().String()).To(Equal("Blue won by points"))
+		})
+
+		It("gives points victory by the most points below zero and compares outcomes by scores", func() {
+			o := base.NewPointsVictory(map[Colour]int{Red: -3, Blue: -1, Yellow: -2})
+			Expect(o.Winner).To(Equal(Blue))
+			Expect(o.Winners).To(Equal([]Colour{Blue}))
+			Expect(base.NewPointsVictory(map[Colour]int{Red: -1, Blue: -1}).Winner).To(Equal(Transparent))
+			Expect(base.NewPointsVictory(nil).Winner).To(Equal(Transparent))
+
+			Expect(o.Equals(base.NewPointsVictory(map[Colour]int{Red: -3, Blue: -1, Yellow: -2}))).To(BeTrue())
+			Expect(o.Equals(base.NewPointsVictory(map[Colour]int{Red: -3, Blue: -1, Yellow: -4}))).To(BeFalse())
+			Expect(o.Equals(base.NewPointsVictory(map[Colour]int{Red: -3, Blue: -1}))).To(BeFalse())
+			Expect(base.NewLastStanding([]Colour{Red, Yellow}).Equals(base.NewLastStanding([]Colour{Red, Blue}))).
+				To(BeFalse())
+		})
+
+		It("allows king capture when it is left in check by another side's move", func() {
+			b.PlacePiece(Coord{7, 4}, NewRook(Red))
+			b.PlacePiece(Coord{6, 13}, NewBishop(Blue))
+			Expect(b.MakeMove(Coord{7, 12}, b.Piece(Coord{7, 4}))).To(BeTrue())
+			Expect(b.InCheck(Yellow)).To(BeTrue())
+			Expect(b.MakeMove(Coord{7, 14}, b.Piece(Coord{6, 13}))).To(BeTrue())
+			Expect(b.Eliminated(Yellow)).To(BeTrue())
+			Expect(b.Score(Blue)).To(Equal(20))
+			Expect(b.SideToMove()).To(Equal(Green))
+		})
+	})
+
+	It("checks teams", func() {
+		b := NewEmptyBoard(14, 14, FourPlayerChessSettings(true))
+		for _, c := range FourPlayerColours() {
+			Expect(b.Settings().Players.Opponents(c)).To(HaveLen(2))
+		}
+		b.Settings().MoveOrder = false
+		wr := NewRook(Red)
+		b.PlacePiece(Coord{7, 7}, wr)
+		b.PlacePiece(Coord{7, 9}, NewPawn(Yellow))
+		b.PlacePiece(Coord{9, 7}, NewPawn(Blue))
+		Expect(wr.Destinations(b).Contains(Coord{7, 9})).To(BeFalse(), "can't capture an ally")
+		Expect(wr.Destinations(b).Contains(Coord{9, 7})).To(BeTrue())
+
+		b.Settings().MoveOrder = true
+		b.SetSideToMove(Red)
+		b.Resign(Blue)
+		Expect(b.Outcome().IsFinished()).To(BeFalse())
+		b.Resign(Green)
+		Expect(b.Outcome()).To(Equal(base.NewLastStanding([]Colour{Red, Yellow})))
+		Expect(b.Outcome().String()).To(Equal("Red and Yellow won as the last standing"))
+	})
+
+	It("finishes the game when a king capture leaves only allies", func() {
+		b := NewEmptyBoard(14, 14, FourPlayerChessSettings(true))
+		b.PlacePiece(Coord{8, 1}, NewKing(Red))
+		b.PlacePiece(Coord{1, 7}, NewKing(Blue))
+		b.PlacePiece(Coord{7, 14}, NewKing(Yellow))
+		b.PlacePiece(Coord{14, 8}, NewKing(Green))
+		b.PlacePiece(Coord{8, 4}, NewRook(Blue))
+		b.PlacePiece(Coord{8, 10}, NewKnight(Green))
+		b.SetSideToMove(Red)
+
+		b.Resign(Red)
+		Expect(b.MakeMove(Coord{1, 6}, b.King(Blue))).To(BeTrue())
+		Expect(b.MakeMove(Coord{8, 14}, b.King(Yellow))).To(BeTrue())
+		Expect(b.MakeMove(Coord{6, 9}, b.Piece(Coord{8, 10}))).To(BeTrue())
+		Expect(b.InCheck(Yellow)).To(BeTrue())
+		Expect(b.SideToMove()).To(Equal(Blue))
+
+		Expect(b.MakeMove(Coord{8, 14}, b.Piece(Coord{8, 4}))).To(BeTrue())
+		Expect(b.Eliminated(Yellow)).To(BeTrue())
+		Expect(b.Outcome()).To(Equal(base.NewLastStanding([]Colour{Blue, Green})))
+	})
+})
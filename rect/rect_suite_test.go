@@ -3,6 +3,7 @@ package rect_test
 import (
 	"testing"
 
+	"github.com/mtfelian/mtfchess/rect"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -11,3 +12,10 @@ func TestRect(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Rectangular Board Suite")
 }
+
+// board returns a board of X-FEN
+func board(xfen rect.XFEN) *rect.Board {
+	b, err := xfen.Board()
+	Expect(err).NotTo(HaveOccurred())
+	return b.(*rect.Board)
+}
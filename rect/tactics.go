@@ -0,0 +1,127 @@
+package rect
+
+import (
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+// XRay is an attack of a piece on a cell through another piece: the attack appears when that piece moves away
+type XRay struct {
+	Attacker base.IPiece
+	Through  base.IPiece
+	Target   base.ICoord
+}
+
+// Pin is a piece which can't leave a line between its king and an opponent piece without exposing the king
+type Pin struct {
+	Piece  base.IPiece
+	Pinner base.IPiece
+	// Ray is cells where the pinned piece still shields the king: cells between the king and the pinner,
+	// the pinned piece cell and the pinner cell
+	Ray []base.ICoord
+}
+
+// Attackers returns pieces of colours attacking a cell at
+func (b *Board) Attackers(at base.ICoord, colours ...Colour) base.Pieces {
+	res := base.Pieces{}
+	for _, piece := range b.FindPieces(base.PieceFilter{Colours: colours}) {
+		if piece.Attacks(b).Contains(at) {
+			res = append(res, piece)
+		}
+	}
+	return res
+}
+
+// Checkers returns opponent pieces giving check to the king of colour
+func (b *Board) Checkers(colour Colour) base.Pieces {
+	king := b.King(colour)
+	if king == nil || king.Coord() == nil {
+		return base.Pieces{}
+	}
+	return b.Attackers(king.Coord(), b.Settings().Players.Opponents(colour)...)
+}
+
+// InDoubleCheck returns true if the king of colour is checked by two or more pieces at once,
+// so only a king move can release the check
+func (b *Board) InDoubleCheck(colour Colour) bool { return len(b.Checkers(colour)) > 1 }
+
+// XRays returns attacks of pieces of colours on a cell at through any other single piece.
+// Attacks of all kinds of pieces are found by removing pieces one by one, so hoppers and wrap-around
+// boards are taken into account.
+func (b *Board) XRays(at base.ICoord, colours ...Colour) []XRay {
+	direct := NewCoords([]base.ICoord{})
+	for _, piece := range b.Attackers(at, colours...) {
+		direct.Add(piece.Coord())
+	}
+	res := []XRay{}
+	for _, through := range b.FindPieces(base.PieceFilter{}) {
+		if through.Coord().Equals(at) {
+			continue
+		}
+		next := b.Copy().Empty(through.Coord()).(*Board)
+		for _, attacker := range next.Attackers(at, colours...) {
+			if !direct.Contains(attacker.Coord()) {
+				res = append(res, XRay{Attacker: b.Piece(attacker.Coord()), Through: through, Target: at})
+			}
+		}
+	}
+	return res
+}
+
+// Pins returns pieces of colour pinned to its king
+func (b *Board) Pins(colour Colour) []Pin {
+	king := b.King(colour)
+	if king == nil || king.Coord() == nil {
+		return []Pin{}
+	}
+	res := []Pin{}
+	for _, x := range b.XRays(king.Coord(), b.Settings().Players.Opponents(colour)...) {
+		if x.Through.Colour() != colour {
+			continue
+		}
+		next := b.Copy().Empty(x.Through.Coord()).(*Board)
+		ray := append(next.blockingCells(next.Piece(x.Attacker.Coord()), king.Coord(), colour), x.Attacker.Coord())
+		res = append(res, Pin{Piece: x.Through, Pinner: x.Attacker, Ray: ray})
+	}
+	return res
+}
+
+// DiscoveredAttacks returns attacks of pieces of colour on opponent pieces through pieces of colour,
+// moving such a piece away discovers an attack, or a check if a target is a king
+func (b *Board) DiscoveredAttacks(colour Colour) []XRay {
+	res := []XRay{}
+	for _, target := range b.FindPieces(base.PieceFilter{Colours: b.Settings().Players.Opponents(colour)}) {
+		for _, x := range b.XRays(target.Coord(), colour) {
+			if x.Through.Colour() == colour {
+				res = append(res, x)
+			}
+		}
+	}
+	return res
+}
+
+// CheckBlockingCells returns empty cells where a piece of colour blocks a check to its king.
+// It's empty if there is no check, if a check is double or if it can't be blocked, like a check of a knight.
+func (b *Board) CheckBlockingCells(colour Colour) []base.ICoord {
+	checkers := b.Checkers(colour)
+	if len(checkers) != 1 {
+		return []base.ICoord{}
+	}
+	return b.blockingCells(checkers[0], b.King(colour).Coord(), colour)
+}
+
+// blockingCells returns empty cells where a piece of colour stops an attack of attacker on a cell at
+func (b *Board) blockingCells(attacker base.IPiece, at base.ICoord, colour Colour) []base.ICoord {
+	res, attacked := []base.ICoord{}, attacker.Attacks(b)
+	for i := 0; i < attacked.Len(); i++ {
+		c := attacked.Get(i)
+		if b.Piece(c) != nil || c.Equals(at) {
+			continue
+		}
+		next := b.Copy().PlacePiece(c, NewKnight(colour))
+		if !next.Piece(attacker.Coord()).Attacks(next).Contains(at) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
@@ -0,0 +1,82 @@
+package rect_test
+
+import (
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	"github.com/mtfelian/mtfchess/rect"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("tactics", func() {
+	// coords returns coords of pieces
+	coords := func(pieces base.Pieces) []base.ICoord {
+		res := []base.ICoord{}
+		for _, piece := range pieces {
+			res = append(res, piece.Coord())
+		}
+		return res
+	}
+
+	It("finds checkers and double checks", func() {
+		b := board("4r1k1/8/8/8/8/3n4/8/4K3 w - - 0 1")
+		Expect(coords(b.Checkers(White))).To(ConsistOf(rect.Coord{5, 8}, rect.Coord{4, 3}))
+		Expect(b.InDoubleCheck(White)).To(BeTrue())
+		Expect(b.CheckBlockingCells(White)).To(BeEmpty())
+		Expect(b.Checkers(Black)).To(BeEmpty())
+		Expect(b.InDoubleCheck(Black)).To(BeFalse())
+	})
+
+	It("finds cells blocking a check", func() {
+		b := board("4r1k1/8/8/8/8/8/8/4K3 w - - 0 1")
+		Expect(b.InDoubleCheck(White)).To(BeFalse())
+		Expect(b.CheckBlockingCells(White)).To(ConsistOf(rect.Coord{5, 2}, rect.Coord{5, 3}, rect.Coord{5, 4},
+			rect.Coord{5, 5}, rect.Coord{5, 6}, rect.Coord{5, 7}))
+
+		b = board("6k1/8/8/8/8/3n4/8/4K3 w - - 0 1")
+		Expect(coords(b.Checkers(White))).To(Equal([]base.ICoord{rect.Coord{4, 3}}))
+		Expect(b.CheckBlockingCells(White)).To(BeEmpty())
+
+		b = board("6k1/8/8/8/8/8/8/4K3 w - - 0 1")
+		Expect(b.CheckBlockingCells(White)).To(BeEmpty())
+	})
+
+	It("finds pins with their rays", func() {
+		b := board("4r1k1/8/8/b7/8/8/3BN3/4K3 w - - 0 1")
+		pins := b.Pins(White)
+		Expect(pins).To(HaveLen(2))
+		rays := map[string][]base.ICoord{}
+		for _, pin := range pins {
+			rays[pin.Piece.Name()+">"+pin.Pinner.Name()] = pin.Ray
+		}
+		Expect(rays["knight>rook"]).To(ConsistOf(rect.Coord{5, 2}, rect.Coord{5, 3}, rect.Coord{5, 4},
+			rect.Coord{5, 5}, rect.Coord{5, 6}, rect.Coord{5, 7}, rect.Coord{5, 8}))
+		Expect(rays["bishop>bishop"]).To(ConsistOf(rect.Coord{4, 2}, rect.Coord{3, 3}, rect.Coord{2, 4},
+			rect.Coord{1, 5}))
+		Expect(b.Pins(Black)).To(BeEmpty())
+
+		By("a piece behind another piece is not pinned")
+		Expect(board("4r1k1/8/8/8/8/4N3/4N3/4K3 w - - 0 1").Pins(White)).To(BeEmpty())
+	})
+
+	It("finds x-ray attacks", func() {
+		b := board("1k6/8/8/8/8/8/R7/R6K w - - 0 1")
+		xrays := b.XRays(rect.Coord{1, 7}, White)
+		Expect(xrays).To(HaveLen(1))
+		Expect(xrays[0].Attacker.Coord()).To(Equal(rect.Coord{1, 1}))
+		Expect(xrays[0].Through.Coord()).To(Equal(rect.Coord{1, 2}))
+		Expect(xrays[0].Target).To(Equal(rect.Coord{1, 7}))
+		Expect(coords(b.Attackers(rect.Coord{1, 7}, White))).To(Equal([]base.ICoord{rect.Coord{1, 2}}))
+		Expect(b.XRays(rect.Coord{1, 7}, Black)).To(BeEmpty())
+	})
+
+	It("finds discovered attacks", func() {
+		b := board("4k3/8/8/8/1q6/8/4N3/4R1K1 w - - 0 1")
+		attacks := b.DiscoveredAttacks(White)
+		Expect(attacks).To(HaveLen(1))
+		Expect(attacks[0].Attacker.Name()).To(Equal(base.RookName))
+		Expect(attacks[0].Through.Name()).To(Equal(base.KnightName))
+		Expect(attacks[0].Target).To(Equal(rect.Coord{5, 8}))
+		Expect(b.DiscoveredAttacks(Black)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,143 @@
+package rect
+
+import (
+	"fmt"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Board topology test", func() {
+	var b *Board
+	newBoard := func(settings *base.Settings) {
+		b = NewEmptyBoard(8, 8, settings)
+		b.Settings().MoveOrder = false
+	}
+
+	Context("cylinder", func() {
+		BeforeEach(func() { newBoard(CylinderChessBoardSettings()) })
+
+		It("generates moves around the board", func() {
+			testCases := []struct {
+				piece base.IPiece
+				at    Coord
+				n     int
+			}{
+				{NewRook(White), Coord{1, 1}, 14},
+				{NewBishop(White), Coord{1, 1}, 13}, // both diagonals pass e5
+				{NewKnight(White), Coord{1, 1}, 4},
+				{NewKing(White), Coord{1, 1}, 5},
+				{NewQueen(White), Coord{4, 4}, 14 + 13}, // both diagonals pass h8
+			}
+			for i, testCase := range testCases {
+				By(fmt.Sprintf("Checking testCase %v at index %d...", testCase, i))
+				newBoard(CylinderChessBoardSettings())
+				b.PlacePiece(testCase.at, testCase.piece)
+				Expect(testCase.piece.Destinations(b).Len()).To(Equal(testCase.n))
+			}
+		})
+
+		It("blocks riders around the board", func() {
+			wr := NewRook(White)
+			b.PlacePiece(Coord{1, 1}, wr)
+			b.PlacePiece(Coord{3, 1}, NewKnight(White))
+			b.PlacePiece(Coord{6, 1}, NewKnight(Black))
+
+			d := wr.Destinations(b)
+			Expect(d.Len()).To(Equal(7 + 1 + 3))
+			Expect(d.Contains(Coord{6, 1})).To(BeTrue())
+			Expect(d.Contains(Coord{5, 1})).To(BeFalse())
+			Expect(b.LegalMoves(NewLongAlgebraicNotation())).To(HaveLen(d.Len() + 4))
+		})
+
+		It("captures by pawn and en passant around the board", func() {
+			wp, bp := NewPawn(White), NewPawn(Black)
+			b.PlacePiece(Coord{8, 5}, wp)
+			b.PlacePiece(Coord{1, 7}, bp)
+			Expect(b.MakeMove(Coord{1, 5}, bp)).To(BeTrue())
+			Expect(b.EnPassantTargets()).To(Equal([]base.ICoord{Coord{1, 6}}))
+
+			Expect(wp.Destinations(b).Contains(Coord{1, 6})).To(BeTrue())
+			Expect(b.MakeMove(Coord{1, 6}, wp)).To(BeTrue())
+			Expect(b.Piece(Coord{1, 5})).To(BeNil())
+
+			b.PlacePiece(Coord{8, 7}, NewRook(Black))
+			Expect(wp.Attacks(b).Contains(Coord{8, 7})).To(BeTrue())
+		})
+	})
+
+	Context("torus", func() {
+		BeforeEach(func() { newBoard(ToroidalChessBoardSettings()) })
+
+		It("generates moves around the board", func() {
+			wk, wr := NewKing(White), NewRook(White)
+			b.PlacePiece(Coord{1, 1}, wk)
+			Expect(wk.Destinations(b).Len()).To(Equal(8))
+			Expect(wk.Destinations(b).Contains(Coord{8, 8})).To(BeTrue())
+
+			b.PlacePiece(Coord{5, 5}, wr)
+			Expect(wr.Destinations(b).Len()).To(Equal(14))
+		})
+
+		It("detects check around the board", func() {
+			b.PlacePiece(Coord{1, 1}, NewKing(White))
+			b.PlacePiece(Coord{8, 8}, NewKnight(Black))
+			Expect(b.InCheck(White)).To(BeFalse())
+			b.PlacePiece(Coord{7, 8}, NewKnight(Black))
+			Expect(b.InCheck(White)).To(BeTrue())
+		})
+	})
+
+	Context("holes", func() {
+		BeforeEach(func() {
+			settings := StandardChessBoardSettings()
+			settings.Holes = []base.ICoord{Coord{2, 3}, Coord{3, 3}}
+			newBoard(settings)
+		})
+
+		It("doesn't allow pieces to stand on or pass through holes", func() {
+			wr, wn := NewRook(White), NewKnight(White)
+			b.PlacePiece(Coord{1, 3}, wr)
+			Expect(wr.Destinations(b).Len()).To(Equal(7))
+
+			b.PlacePiece(Coord{1, 1}, wn)
+			Expect(wn.Destinations(b).Equals(NewCoords([]base.ICoord{Coord{3, 2}}))).To(BeTrue())
+			Expect(func() { b.PlacePiece(Coord{2, 3}, NewQueen(White)) }).To(Panic())
+		})
+
+		It("follows holes of settings set to a board", func() {
+			Expect(Coord{2, 3}.OutOf(b)).To(BeTrue())
+			Expect(Coord{4, 4}.OutOf(b.Copy())).To(BeFalse())
+
+			settings := b.Settings()
+			settings.Holes = []base.ICoord{Coord{4, 4}}
+			Expect(Coord{4, 4}.OutOf(b)).To(BeFalse(), "holes are built when settings are set")
+			b.SetSettings(settings)
+			Expect(Coord{2, 3}.OutOf(b)).To(BeFalse())
+			Expect(Coord{4, 4}.OutOf(b)).To(BeTrue())
+			Expect(Coord{4, 4}.OutOf(b.Copy())).To(BeTrue())
+		})
+
+		It("checks X-FEN with holes", func() {
+			opts := DefaultXFENOptions()
+			opts.Settings = func() *base.Settings {
+				s := StandardChessBoardSettings()
+				s.Holes = NewOmegaChessHoles()
+				return s
+			}
+			_, err := XFEN(`12/12/12/12/12/12/12/12/12/12/12/k10K w - - 0 1`).BoardWithOptions(opts)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = XFEN(`12/12/12/12/12/12/12/12/12/12/12/1k9K w - - 0 1`).BoardWithOptions(opts)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("checks holes constructors", func() {
+			Expect(NewOmegaChessHoles()).To(HaveLen(40))
+			Expect(NewCornerHoles(14, 14, 3)).To(HaveLen(36))
+			Expect(NewCornerHoles(14, 14, 3)).To(ContainElement(Coord{12, 3}))
+			Expect(NewCornerHoles(14, 14, 3)).NotTo(ContainElement(Coord{4, 3}))
+		})
+	})
+})
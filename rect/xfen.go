@@ -2,6 +2,8 @@ package rect
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"unicode"
@@ -10,40 +12,64 @@ import (
 	. "github.com/mtfelian/mtfchess/colour"
 )
 
+var (
+	longAlgebraicCoordsRegexp = regexp.MustCompile(`[a-z]\d{1,2}`)
+	virginsRegexp             = regexp.MustCompile(`^(?:[a-z]\d{1,2})+$`)
+)
+
 // XFEN is an X-FEN string
 type XFEN string
 
 // getPosLineTokens parses line as runes into string tokens
 // it should be done especially for board with at least one of rect dimensions
 // greater then 9 (in this case token may consist of one or two runes)
-func getPosLineTokens(line string) []string {
-	tokens := []string{}
-	firstDigit := true
-	for _, rune := range line {
-		if !unicode.IsDigit(rune) {
-			tokens = append(tokens, string(rune))
-			firstDigit = true
+func getPosLineTokens(line string) []string { return getPosLineTokensWith(line, nil) }
+
+// getPosLineTokensWith parses line as runes into string tokens like getPosLineTokens does,
+// but also detects multi-letter piece tokens registered in pieces.
+// A promotedPrefix is joined to the piece token following it.
+func getPosLineTokensWith(line string, pieces PieceRegistry) []string {
+	tokens, registered := []string{}, pieces.sortedTokens()
+	runes, firstDigit, prefix := []rune(line), true, ""
+	for i := 0; i < len(runes); i++ {
+		if string(runes[i]) == promotedPrefix {
+			prefix += promotedPrefix
+			continue
+		}
+
+		if !unicode.IsDigit(runes[i]) {
+			// searching the longest registered token, by default token is a single rune
+			token, rest := string(runes[i]), strings.ToLower(prefix+string(runes[i:]))
+			for _, t := range registered {
+				if strings.HasPrefix(rest, t) && strings.HasPrefix(t, prefix) && len(t) > len(prefix) {
+					n := len([]rune(t)) - len([]rune(prefix))
+					token, i = string(runes[i:i+n]), i+n-1
+					break
+				}
+			}
+			tokens = append(tokens, prefix+token)
+			firstDigit, prefix = true, ""
 			continue
 		}
 
 		// if unicode.IsDigit(rune)
 		if firstDigit {
-			tokens = append(tokens, string(rune))
+			tokens = append(tokens, string(runes[i]))
 			firstDigit = false
 			continue
 		}
 
 		// if unicode.IsDigit(rune) && !firstDigit
-		tokens[len(tokens)-1] += string(rune)
+		tokens[len(tokens)-1] += string(runes[i])
 	}
 	return tokens
 }
 
 // parseBoardWidth parses one line of posLines and returns a board width, it can be any of line
 // (they should tokens in same int value) due to all horizontals (rows) have the same length == board width
-func parseBoardWidth(line string) int {
+func parseBoardWidth(line string, pieces PieceRegistry) int {
 	w := 0
-	for _, token := range getPosLineTokens(line) {
+	for _, token := range getPosLineTokensWith(line, pieces) {
 		i, err := strconv.Atoi(token)
 		if err == nil { // token is a number
 			w += i
@@ -55,51 +81,116 @@ func parseBoardWidth(line string) int {
 }
 
 // parsePosLines parses lines containing FEN position parts (between '/' splitters) into pieces on a board
+// set guessMoved to true to mark pieces moved if they are not on their starting ranks,
+// or pawns out of the pawn start zone of the board settings
 // this func changes board parameter
-func parsePosLines(lines []string, board *Board) error {
+func parsePosLines(lines []string, pieces PieceRegistry, guessMoved bool, board *Board) error {
 	for y, line := range lines {
 		x := 1
-		for _, token := range getPosLineTokens(line) {
+		for _, token := range getPosLineTokensWith(line, pieces) {
 			i, err := strconv.Atoi(token)
 			if err == nil { // token is a number
 				x += i
 				continue
 			}
 
-			coord, runeToken := Coord{x, board.Dim().(Coord).Y - y}, []rune(token)[0]
-
-			colour := White
-			if unicode.IsLower(runeToken) {
-				colour = Black
-			}
-
-			f, exists := map[rune]func(Colour) base.IPiece{
-				'p': NewPawn, 'n': NewKnight, 'b': NewBishop, 'r': NewRook,
-				'q': NewQueen, 'a': NewArchbishop, 'c': NewChancellor, 'k': NewKing,
-			}[unicode.ToLower(runeToken)]
-			if !exists {
+			coord := Coord{x, board.Dim().(Coord).Y - y}
+			piece := pieces.New(token)
+			if piece == nil {
 				return fmt.Errorf("invalid piece token: %s", token)
 			}
-			board.PlacePiece(coord, f(colour))
+			if isHole(board, coord) {
+				return fmt.Errorf("piece %s is placed on a hole %s", token, coord)
+			}
+			if coord.OutOf(board) {
+				return fmt.Errorf("position line %d is too long", y+1)
+			}
+			board.PlacePiece(coord, piece)
+			x++
+			if !guessMoved {
+				continue
+			}
 
-			// marking pieces moved as long as possible to detect it
+			// marking pieces moved as long as possible to detect it,
+			// pawns are unmoved within the start zone of the board settings
 			bh := board.Dim().(Coord).Y
 			switch {
-			case runeToken == 'p' && coord.Y != bh-1:
-				board.Piece(coord).MarkMoved()
-			case runeToken == 'P' && coord.Y != 2:
-				board.Piece(coord).MarkMoved()
-			default:
-				if unicode.IsUpper(runeToken) && coord.Y != 1 || unicode.IsLower(runeToken) && coord.Y != bh {
-					board.Piece(coord).MarkMoved()
+			case piece.Name() == base.PawnName:
+				if !board.Settings().PawnStartZoneFunc(board, piece) {
+					piece.MarkMoved()
 				}
+			case piece.Colour() == White && coord.Y != 1 || piece.Colour() == Black && coord.Y != bh:
+				piece.MarkMoved()
 			}
-			x++
 		}
 	}
 	return nil
 }
 
+// parseVirgins parses line containing coords of unmoved (virgin) pieces, like "a1e1h1a8e8h8",
+// all other pieces on a board are marked moved
+// this func changes board parameter
+func parseVirgins(line string, board *Board) error {
+	virgins := NewCoords([]base.ICoord{})
+	if line != "-" {
+		if !virginsRegexp.MatchString(line) {
+			return fmt.Errorf("invalid virgin pieces: %s", line)
+		}
+		notation := NewLongAlgebraicNotation()
+		for _, coord := range longAlgebraicCoordsRegexp.FindAllString(line, -1) {
+			if err := notation.DecodeCoord(coord); err != nil {
+				return err
+			}
+			if notation.Coord.OutOf(board) || board.Piece(notation.Coord) == nil {
+				return fmt.Errorf("virgin piece not found at %s", coord)
+			}
+			virgins.Add(notation.Coord)
+		}
+	}
+
+	pieces := board.FindPieces(base.PieceFilter{})
+	for i := range pieces {
+		if !virgins.Contains(pieces[i].Coord()) {
+			pieces[i].MarkMoved()
+		}
+	}
+	return nil
+}
+
+// parseHands parses line containing pieces in hand (without brackets) into board hands
+// this func changes board parameter
+func parseHands(line string, pieces PieceRegistry, board *Board) error {
+	hands := map[Colour]base.Pieces{White: {}, Black: {}}
+	for _, token := range getPosLineTokensWith(line, pieces) {
+		piece := pieces.New(token)
+		if piece == nil {
+			return fmt.Errorf("invalid piece token in hand: %s", token)
+		}
+		hands[piece.Colour()] = append(hands[piece.Colour()], piece)
+	}
+	for _, colour := range AllColours() {
+		board.SetHand(colour, hands[colour])
+	}
+	return nil
+}
+
+// parseCheckCounters parses line like "+2+1" into amounts of checks given by white and black
+// this func changes board parameter
+func parseCheckCounters(line string, board *Board) error {
+	parts := strings.Split(line, "+")
+	if len(parts) != 3 || parts[0] != "" {
+		return fmt.Errorf("invalid check counters: %s", line)
+	}
+	for i, colour := range AllColours() {
+		n, err := strconv.Atoi(parts[i+1])
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid check counters: %s", line)
+		}
+		board.SetChecksGiven(colour, n)
+	}
+	return nil
+}
+
 // parseSideToMove parses line into side to move colour
 // this func changes board parameter
 func parseSideToMove(line string, board *Board) error {
@@ -137,12 +228,13 @@ func parseEP(line string, sideToMove Colour, board *Board) error {
 
 	// FEN has 'EP capture dst cell' coords while the board keeps 'piece to capture' coords
 
-	epCoordX := ep.Coord.(Coord).X
-	for y := ep.Coord.(Coord).Y + step; y != limY; y = y + step {
-		coord := Coord{epCoordX, y}
+	epCoord := ep.Coord.(Coord)
+	for y := epCoord.Y + step; y != limY; y = y + step {
+		coord := Coord{epCoord.X, y}
 		p := board.Piece(coord)
 		if p != nil && p.Name() == base.PawnName {
 			board.SetCanCaptureEnPassantAt(coord)
+			board.SetEnPassantTargets(append([]base.ICoord{epCoord}, passedCoords(epCoord, coord)...))
 			return nil
 		}
 	}
@@ -150,54 +242,34 @@ func parseEP(line string, sideToMove Colour, board *Board) error {
 	return fmt.Errorf("piece which can be EP-captured not found on board")
 }
 
-// parseCastling parses line about allowed castlings
-// this func changes board parameter
-func parseCastling(line string, board *Board) error {
-	if line == "-" {
-		return nil
+// castlingPartnerNames returns names of pieces which king can castle with according to board settings
+func castlingPartnerNames(board base.IBoard) []string {
+	if partners := board.Settings().Castling.Partners; len(partners) > 0 {
+		return partners
 	}
+	return StandardCastlingRule().Partners
+}
 
-	bC := board.Dim().(Coord)
-	// findRook finds rook of colour.
-	// Set i to 0 for aSide rook finding, set i to 1 for zSide rook finding
-	// Set outer to true to find outer rook (closer to board border), otherwise inner rook (closer to king)
-	findRook := func(colour Colour, i int, outer bool) *Rook {
-		rooks := board.FindPieces(base.PieceFilter{
-			Names:   []string{base.RookName},
-			Colours: []Colour{colour},
-			Condition: func(r base.IPiece) bool {
-				rC, y := r.Coord().(Coord), map[Colour]int{White: 1, Black: bC.Y}
-				return rC.Y == y[colour]
-			},
-		})
-		if rooks == nil || len(rooks) == 0 {
-			return nil
-		}
-
-		if len(rooks) == 1 {
-			return rooks[0].(*Rook)
-		}
-
-		if len(rooks) > 2 {
-			panic("found more then two rooks on starting horizontal")
-		}
+// findCastlingPartners returns pieces of colour which king can castle with, placed on king's rank,
+// sorted by X descending
+func findCastlingPartners(board base.IBoard, colour Colour) base.Pieces {
+	kC := board.King(colour).Coord().(Coord)
+	partners := board.FindPieces(PieceFilter{
+		PieceFilter: base.PieceFilter{Names: castlingPartnerNames(board), Colours: []Colour{colour}},
+		Y:           []int{kC.Y},
+	})
+	sort.Slice(partners, func(i, j int) bool {
+		return partners[i].Coord().(Coord).X > partners[j].Coord().(Coord).X
+	})
+	return partners
+}
 
-		minRookX, maxRookX := bC.X+1, 0
-		var aRook, zRook base.IPiece
-		for j := range rooks {
-			c := rooks[j].Coord().(Coord)
-			if c.X < minRookX {
-				minRookX, aRook = c.X, rooks[j]
-			}
-			if c.X > maxRookX {
-				maxRookX, zRook = c.X, rooks[j]
-			}
-		}
-		if outer && i == 1 || !outer && i == 0 {
-			return zRook.(*Rook)
-		}
-		// if (outer && i == 0) || (!outer && i == 1)
-		return aRook.(*Rook)
+// parseCastling parses line about allowed castlings.
+// K and Q mean the outermost partner on z-side and a-side, a file letter means a partner on that file (Shredder-FEN).
+// This func changes board parameter.
+func parseCastling(line string, board *Board) error {
+	if line == "-" {
+		return nil
 	}
 
 	for _, token := range []rune(line) {
@@ -209,15 +281,30 @@ func parseCastling(line string, board *Board) error {
 		if king == nil {
 			return fmt.Errorf("king is not set while parseCastling() in XFEN")
 		}
-		outer, i, kC := strings.Contains("KkQq", string(token)), 0, king.Coord().(Coord)
-		if strings.Contains("Kk", string(token)) || (!outer && FromLetter(token) > kC.X) {
-			i = 1
+
+		kC, partners := king.Coord().(Coord), findCastlingPartners(board, colour)
+		var partner base.IPiece
+		for i := range partners {
+			pX := partners[i].Coord().(Coord).X
+			switch {
+			case strings.ContainsRune("Kk", token):
+				if pX > kC.X && partner == nil {
+					partner = partners[i]
+				}
+			case strings.ContainsRune("Qq", token):
+				if pX < kC.X {
+					partner = partners[i]
+				}
+			default:
+				if pX == FromLetter(token) {
+					partner = partners[i]
+				}
+			}
 		}
-		r := findRook(colour, i, outer)
-		if r == nil {
-			return fmt.Errorf("wrong FEN, %s-castling specified, but rook not found", string(token))
+		if partner == nil {
+			return fmt.Errorf("wrong FEN, %s-castling specified, but castling partner not found", string(token))
 		}
-		board.SetRookInitialCoords(colour, i, r.Coord())
+		board.AddCastlingPartner(colour, partner.Coord())
 	}
 	return nil
 }
@@ -228,31 +315,74 @@ func (s XFEN) PositionPart() string {
 }
 
 // Board returns a new rectangular chess board position from standard X-FEN
-func (s XFEN) Board() (base.IBoard, error) {
-	xfenParts := strings.Split(string(s), " ")
-	if len(xfenParts) != 6 {
+func (s XFEN) Board() (base.IBoard, error) { return s.BoardWithOptions(DefaultXFENOptions()) }
+
+// BoardWithOptions returns a new rectangular chess board position from X-FEN parsed with the given options
+func (s XFEN) BoardWithOptions(opts XFENOptions) (base.IBoard, error) {
+	xfenParts, maxParts := strings.Split(string(s), " "), 6
+	if opts.CheckCounters {
+		maxParts++
+	}
+	if opts.VirginFlags {
+		maxParts++
+	}
+	if len(xfenParts) < 6 || len(xfenParts) > maxParts {
 		return nil, fmt.Errorf("invalid X-FEN length")
 	}
 
 	// xfenParts slice indexes:
 	// 0 - position, 1 - side to move, 2 - castling rights, 3 - EP dst cell, 4 - half-moves counter, 5 - move number
+	// then optional fields if enabled: check counters, virgin pieces
+
+	checkCounters, virgins := "+0+0", ""
+	for _, field := range xfenParts[6:] {
+		switch {
+		case opts.CheckCounters && strings.HasPrefix(field, "+"):
+			checkCounters = field
+		case opts.VirginFlags && virgins == "":
+			virgins = field
+		default:
+			return nil, fmt.Errorf("invalid X-FEN field: %s", field)
+		}
+	}
+
+	position, hands := xfenParts[0], ""
+	if opts.Hands && strings.HasSuffix(position, "]") {
+		i := strings.LastIndex(position, "[")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid hands in X-FEN")
+		}
+		position, hands = position[:i], position[i+1:len(position)-1]
+	}
 
-	posLines := strings.Split(xfenParts[0], "/")
+	posLines := strings.Split(position, "/")
 	bh := len(posLines)
 	if bh < 3 {
 		return nil, fmt.Errorf("board height is too small")
 	}
-	bw := parseBoardWidth(posLines[0])
+	bw := parseBoardWidth(posLines[0], opts.Pieces)
 	if bw < 3 {
 		return nil, fmt.Errorf("board width is too small")
 	}
 
-	b := NewEmptyBoard(bw, bh, StandardChessBoardSettings())
+	b := NewEmptyBoard(bw, bh, opts.Settings())
 
-	if err := parsePosLines(posLines, b); err != nil {
+	if err := parsePosLines(posLines, opts.Pieces, virgins == "", b); err != nil {
 		return nil, err
 	}
 
+	if virgins != "" {
+		if err := parseVirgins(virgins, b); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Hands {
+		if err := parseHands(hands, opts.Pieces, b); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := parseSideToMove(xfenParts[1], b); err != nil {
 		return nil, err
 	}
@@ -275,18 +405,38 @@ func (s XFEN) Board() (base.IBoard, error) {
 		return nil, err
 	}
 	b.SetMoveNumber(moveNumber)
-	b.computeOutcome()
+
+	if opts.CheckCounters {
+		if err := parseCheckCounters(checkCounters, b); err != nil {
+			return nil, err
+		}
+	}
+
+	b.computeOutcome(b.SideToMove().Invert())
 
 	return b, nil
 }
 
 // NewXFEN converts rectangular board position to X-FEN
-func NewXFEN(board *Board) XFEN {
+func NewXFEN(board *Board) XFEN { return NewXFENWithOptions(board, DefaultXFENOptions()) }
+
+// NewXFENWithOptions converts rectangular board position to X-FEN with the given options
+func NewXFENWithOptions(board *Board, opts XFENOptions) XFEN {
 	xfen := ""
 
 	// converting position
-	cells := board.Cells().(Cells)
+	cells, tokens := board.Cells().(Cells), opts.Pieces.tokensByName()
 	setCase := map[Colour]func(rune) rune{White: unicode.ToUpper, Black: unicode.ToLower}
+	pieceToken := func(piece base.IPiece) string {
+		token, exists := tokens[piece.Name()]
+		if !exists {
+			token = string(piece.Capital())
+		}
+		if piece.Colour() == White {
+			return strings.ToUpper(token)
+		}
+		return strings.ToLower(token)
+	}
 	for y := range cells {
 		empty := 0
 		for x := range cells[y] {
@@ -299,7 +449,7 @@ func NewXFEN(board *Board) XFEN {
 				xfen += strconv.Itoa(empty)
 				empty = 0
 			}
-			xfen += string(setCase[piece.Colour()](piece.Capital()))
+			xfen += pieceToken(piece)
 		}
 		if empty != 0 {
 			xfen += strconv.Itoa(empty)
@@ -308,41 +458,43 @@ func NewXFEN(board *Board) XFEN {
 	}
 	xfen = xfen[:len(xfen)-1]
 
+	// converting pieces in hand
+	if opts.Hands {
+		xfen += "["
+		for _, colour := range AllColours() {
+			for _, piece := range board.Hand(colour) {
+				xfen += pieceToken(piece)
+			}
+		}
+		xfen += "]"
+	}
+
 	sideToMove := board.SideToMove()
 
 	// converting side to move
 	xfen += " " + string(unicode.ToLower([]rune(sideToMove.Name())[0]))
 
 	// converting castling flags
-	bh, castlingFlags := board.Dim().(Coord).Y, ""
+	castlingFlags := ""
 	for _, colour := range AllColours() {
 		king := board.King(colour)
 		if king == nil || king.WasMoved() {
 			continue
 		}
-		rookInitialCoords := board.RookInitialCoords(colour)
-		for i := len(rookInitialCoords) - 1; i >= 0; i-- {
-			if rookInitialCoords[i] == nil {
+		kX, partners := king.Coord().(Coord).X, findCastlingPartners(board, colour)
+		for i := range partners {
+			if partners[i].WasMoved() || !board.castlingPartners.Contains(colour, partners[i].Coord()) {
 				continue
 			}
-			r := board.Piece(rookInitialCoords[i])
-			if r == nil || r.WasMoved() {
-				continue
-			}
-
-			rC := r.Coord().(Coord)
-			rooks := board.FindPieces(
-				base.PieceFilter{
-					Names:   []string{base.RookName},
-					Colours: []Colour{colour},
-					Condition: func(p base.IPiece) bool {
-						pC, y := p.Coord().(Coord), map[Colour]int{White: 1, Black: bh}
-						return pC.Y == y[colour] && (pC.X < rC.X && i == 0 || pC.X > rC.X && i == 1)
-					},
-				})
-			castlingFlag := setCase[colour]([]rune{'q', 'k'}[i])
-			if len(rooks) > 0 {
-				castlingFlag = setCase[colour](ToLetter(rC.X))
+			pX := partners[i].Coord().(Coord).X
+			castlingFlag := setCase[colour](ToLetter(pX))
+			if !opts.ShredderCastling {
+				if i == 0 && pX > kX {
+					castlingFlag = setCase[colour]('k')
+				}
+				if i == len(partners)-1 && pX < kX {
+					castlingFlag = setCase[colour]('q')
+				}
 			}
 			castlingFlags += string(castlingFlag)
 		}
@@ -353,21 +505,37 @@ func NewXFEN(board *Board) XFEN {
 	xfen += " " + castlingFlags
 
 	// converting en-passant capture coord
-	canCaptureEP, epCaptureFEN := board.CanCaptureEnPassantAt(), "-"
-	if canCaptureEP != nil {
-		step := 1
-		if sideToMove == Black {
-			step = -1
-		}
-		epCoord, notation := canCaptureEP.(Coord), NewLongAlgebraicNotation()
-		notation.SetCoord(Coord{epCoord.X, epCoord.Y + step})
-		epCaptureFEN = notation.EncodeCoord()
+	canCaptureEP, epTargets, epCaptureFEN := board.CanCaptureEnPassantAt(), board.EnPassantTargets(), "-"
+	if canCaptureEP != nil && len(epTargets) > 0 {
+		// the farthest from the piece to capture passed over cell
+		epCaptureFEN = NewLongAlgebraicNotation().SetCoord(epTargets[0]).EncodeCoord()
 	}
 	xfen += " " + epCaptureFEN
 
 	// converting counters
 	xfen += fmt.Sprintf(" %d %d", board.HalfMoveCount(), board.MoveNumber())
 
+	if opts.CheckCounters {
+		xfen += fmt.Sprintf(" +%d+%d", board.ChecksGiven(White), board.ChecksGiven(Black))
+	}
+
+	// converting virgin pieces
+	if opts.VirginFlags {
+		virgins, bC := "", board.Dim().(Coord)
+		for y := 1; y <= bC.Y; y++ {
+			for x := 1; x <= bC.X; x++ {
+				piece := board.Piece(Coord{x, y})
+				if piece != nil && !piece.WasMoved() {
+					virgins += NewLongAlgebraicNotation().SetCoord(piece.Coord()).EncodeCoord()
+				}
+			}
+		}
+		if virgins == "" {
+			virgins = "-"
+		}
+		xfen += " " + virgins
+	}
+
 	return XFEN(xfen)
 }
 
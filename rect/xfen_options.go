@@ -0,0 +1,134 @@
+package rect
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+// promotedPrefix is a prefix of a piece token denoting a promoted piece
+const promotedPrefix = "+"
+
+// PieceRegistry maps lower case X-FEN piece tokens to piece constructors.
+// Tokens may consist of several letters and may have a promotedPrefix, like "+p".
+type PieceRegistry map[string]func(Colour) base.IPiece
+
+// StandardPieceRegistry returns a piece registry with all pieces of this package
+func StandardPieceRegistry() PieceRegistry {
+	return PieceRegistry{
+		"p": NewPawn, "n": NewKnight, "b": NewBishop, "r": NewRook,
+		"q": NewQueen, "a": NewArchbishop, "c": NewChancellor, "k": NewKing,
+	}
+}
+
+// Copy returns a copy of r
+func (r PieceRegistry) Copy() PieceRegistry {
+	c := make(PieceRegistry, len(r))
+	for token, f := range r {
+		c[token] = f
+	}
+	return c
+}
+
+// Register adds a piece constructor f with the given token to r and returns r
+func (r PieceRegistry) Register(token string, f func(Colour) base.IPiece) PieceRegistry {
+	r[strings.ToLower(token)] = f
+	return r
+}
+
+// New returns a new piece by the given token, colour is detected by the token case:
+// upper case is for white pieces and lower case is for black ones.
+// It returns nil if the token is not registered.
+func (r PieceRegistry) New(token string) base.IPiece {
+	f, exists := r[strings.ToLower(token)]
+	if !exists {
+		return nil
+	}
+	colour := White
+	if unicode.IsLower([]rune(strings.TrimPrefix(token, promotedPrefix))[0]) {
+		colour = Black
+	}
+	return f(colour)
+}
+
+// NewByName returns a new piece of colour by the given piece name, or nil if it is not registered
+func (r PieceRegistry) NewByName(name string, colour Colour) base.IPiece {
+	token, exists := r.tokensByName()[name]
+	if !exists {
+		return nil
+	}
+	return r[token](colour)
+}
+
+// tokensByName maps piece names to lower case tokens
+func (r PieceRegistry) tokensByName() map[string]string {
+	res := make(map[string]string, len(r))
+	tokens := r.sortedTokens()
+	for _, token := range tokens { // shorter tokens go last, so they are preferred
+		res[r[token](Transparent).Name()] = token
+	}
+	return res
+}
+
+// constructors maps piece names to piece constructors
+type constructors map[string]func(Colour) base.IPiece
+
+// constructorsByName maps piece names to constructors
+func (r PieceRegistry) constructorsByName() constructors {
+	res := make(constructors, len(r))
+	for name, token := range r.tokensByName() {
+		res[name] = r[token]
+	}
+	return res
+}
+
+// sortedTokens returns registered tokens sorted by length descending, then lexicographically
+func (r PieceRegistry) sortedTokens() []string {
+	tokens := make([]string, 0, len(r))
+	for token := range r {
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		if len(tokens[i]) != len(tokens[j]) {
+			return len(tokens[i]) > len(tokens[j])
+		}
+		return tokens[i] < tokens[j]
+	})
+	return tokens
+}
+
+// XFENOptions is a set of options to parse a board from X-FEN and to convert a board to X-FEN
+type XFENOptions struct {
+	// Pieces is a registry of piece tokens
+	Pieces PieceRegistry
+
+	// Settings returns settings to apply to a parsed board
+	Settings func() *base.Settings
+
+	// Hands enables pieces in hand placed in brackets after the position, like "RNBQKBNR[Qp]" in crazyhouse
+	Hands bool
+
+	// CheckCounters enables an extra field "+W+B" with amounts of checks given by white and black
+	// like in three-check chess
+	CheckCounters bool
+
+	// VirginFlags enables an extra field with coords of unmoved (virgin) pieces like "a1e1h1a2b2e8",
+	// if the field is present, pieces are not marked moved by their position, if it's "-" all pieces are moved.
+	// Without the field pieces out of their back ranks and pawns out of the pawn start zone of Settings are moved.
+	VirginFlags bool
+
+	// ShredderCastling enables Shredder-FEN castling flags: files of castling partners like "HAha"
+	// instead of "KQkq", otherwise file letters are used only for inner partners
+	ShredderCastling bool
+}
+
+// DefaultXFENOptions returns X-FEN options for standard chess with pieces of this package
+func DefaultXFENOptions() XFENOptions {
+	return XFENOptions{
+		Pieces:   StandardPieceRegistry(),
+		Settings: StandardChessBoardSettings,
+	}
+}
@@ -45,10 +45,10 @@ var _ = Describe("XFEN to rectangular board tests", func() {
 		resetBoard = func() {
 			b = NewEmptyStandardChessBoard()
 			// set rook initial coords to enable castling
-			//b.SetRookInitialCoords(White, 0, Coord{1, 1}) // should not set it, rook moved
-			b.SetRookInitialCoords(White, 1, Coord{7, 1})
-			b.SetRookInitialCoords(Black, 0, Coord{1, 8})
-			b.SetRookInitialCoords(Black, 1, Coord{7, 8})
+			//b.AddCastlingPartner(White, Coord{1, 1}) // should not set it, rook moved
+			b.AddCastlingPartner(White, Coord{7, 1})
+			b.AddCastlingPartner(Black, Coord{1, 8})
+			b.AddCastlingPartner(Black, Coord{7, 8})
 		}
 		var wr1, wr2, wk, br1, br2, bk base.IPiece
 		setupPosition := func() {
@@ -110,10 +110,10 @@ var _ = Describe("Rectangular board to XFEN tests", func() {
 	resetBoard = func() {
 		b = NewEmptyStandardChessBoard()
 		// set rook initial coords to enable castling
-		//b.SetRookInitialCoords(White, 0, Coord{1, 1}) // should not set it, rook moved
-		b.SetRookInitialCoords(White, 1, Coord{7, 1})
-		b.SetRookInitialCoords(Black, 0, Coord{1, 8})
-		b.SetRookInitialCoords(Black, 1, Coord{7, 8})
+		//b.AddCastlingPartner(White, Coord{1, 1}) // should not set it, rook moved
+		b.AddCastlingPartner(White, Coord{7, 1})
+		b.AddCastlingPartner(Black, Coord{1, 8})
+		b.AddCastlingPartner(Black, Coord{7, 8})
 	}
 	var wr1, wr2, wk, br1, br2, bk base.IPiece
 	setupPosition := func() {
@@ -162,3 +162,228 @@ var _ = Describe("Rectangular board to XFEN tests", func() {
 		Expect(xfen).To(Equal(XFEN(`rn2k1r1/ppp1pp1p/3p2p1/5bn1/P7/2N2B2/1PPPPP2/2BNK1RR w Gkq - 4 11`)))
 	})
 })
+
+var _ = Describe("XFEN with options tests", func() {
+	It("checks getting multi-letter and promoted tokens from one position line", func() {
+		pieces := StandardPieceRegistry().Register("ca", NewChancellor).Register("+p", NewQueen)
+		testCases := []struct {
+			line   string
+			tokens []string
+		}{
+			{"4CAr5", []string{"4", "CA", "r", "5"}},
+			{"ca10+PC", []string{"ca", "10", "+P", "C"}},
+			{"+p+r2", []string{"+p", "+r", "2"}},
+			{"QRqr5", []string{"Q", "R", "q", "r", "5"}},
+		}
+
+		for i, testCase := range testCases {
+			By(fmt.Sprintf("Checking testCase %v at index %d...", testCase, i))
+			Expect(getPosLineTokensWith(testCase.line, pieces)).To(Equal(testCase.tokens))
+		}
+	})
+
+	It("parses custom piece tokens and applies settings", func() {
+		opts := DefaultXFENOptions()
+		opts.Pieces = StandardPieceRegistry().Register("ca", NewChancellor)
+		opts.Settings = testBoardSettings
+		b, err := XFEN(`k4/5/5/1ca3/5/2CA1K w - - 0 1`).BoardWithOptions(opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b.Piece(Coord{2, 3}).Name()).To(Equal(base.ChancellorName))
+		Expect(b.Piece(Coord{2, 3}).Colour()).To(Equal(Black))
+		Expect(b.Piece(Coord{3, 1}).Name()).To(Equal(base.ChancellorName))
+		Expect(b.Piece(Coord{3, 1}).Colour()).To(Equal(White))
+		Expect(b.Settings().MoveOrder).To(BeFalse())
+		Expect(b.Settings().MovesToDraw).To(Equal(NoMovesToDraw))
+
+		_, err = XFEN(`k4/5/5/1ca3/5/2CA1K w - - 0 1`).Board()
+		Expect(err).To(HaveOccurred(), "token is not registered in the standard registry")
+	})
+
+	It("parses and converts back hands and check counters", func() {
+		opts := DefaultXFENOptions()
+		opts.Hands, opts.CheckCounters = true, true
+		input := XFEN(`r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R[Pn] w KQkq - 2 3 +2+1`)
+		b, err := input.BoardWithOptions(opts)
+		Expect(err).NotTo(HaveOccurred())
+		rb := b.(*Board)
+		Expect(rb.HasHands()).To(BeTrue())
+		Expect(rb.Hand(White)).To(HaveLen(1))
+		Expect(rb.Hand(White)[0].Name()).To(Equal(base.PawnName))
+		Expect(rb.Hand(Black)).To(HaveLen(1))
+		Expect(rb.Hand(Black)[0].Name()).To(Equal(base.KnightName))
+		Expect(rb.ChecksGiven(White)).To(Equal(2))
+		Expect(rb.ChecksGiven(Black)).To(Equal(1))
+		Expect(NewXFENWithOptions(rb, opts)).To(Equal(input))
+		Expect(rb.Equals(rb.Copy())).To(BeTrue())
+		Expect(NewXFEN(rb)).To(Equal(XFEN(`r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3`)))
+
+		n := NewLongAlgebraicNotation()
+		for _, move := range []string{"f1-b5", "a7-a6", "b5xc6", "d7xc6", "f3xe5", "d8-d4", "e5xf7", "d4xf2+"} {
+			makeMove, err := n.DecodeMove(b, move)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(makeMove()).To(BeTrue())
+			if move == "b5xc6" {
+				Expect(rb.ChecksGiven(White)).To(Equal(2), "b5xc6 is not a check")
+			}
+		}
+		Expect(rb.ChecksGiven(Black)).To(Equal(2))
+	})
+
+	It("checks errors on invalid hands and check counters", func() {
+		opts := DefaultXFENOptions()
+		opts.Hands, opts.CheckCounters = true, true
+		for _, input := range []XFEN{
+			`rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR[X] w KQkq - 0 1`,
+			`rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR] w KQkq - 0 1`,
+			`rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR[] w KQkq - 0 1 3+3`,
+			`rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR[] w KQkq - 0 1 +1+x`,
+		} {
+			By(fmt.Sprintf("Checking %s...", input))
+			b, err := input.BoardWithOptions(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(b).To(BeNil())
+		}
+	})
+})
+
+var _ = Describe("XFEN virgin flags tests", func() {
+	var opts XFENOptions
+	BeforeEach(func() {
+		opts = DefaultXFENOptions()
+		opts.VirginFlags = true
+	})
+
+	It("marks pieces moved according to the virgin flags field", func() {
+		input := XFEN(`r3k2r/8/8/8/8/8/1P6/R3K2R w KQkq - 0 20 a1e1e8h8`)
+		b, err := input.BoardWithOptions(opts)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(b.Piece(Coord{1, 1}).WasMoved()).To(BeFalse())
+		Expect(b.Piece(Coord{8, 1}).WasMoved()).To(BeTrue(), "rook left and came back")
+		Expect(b.Piece(Coord{2, 2}).WasMoved()).To(BeTrue(), "pawn went back by some fairy rule")
+		Expect(b.Piece(Coord{1, 8}).WasMoved()).To(BeTrue())
+
+		castlings := b.Castlings(White)
+		Expect(castlings).To(HaveLen(1))
+		Expect(castlings[0].I).To(Equal(0))
+		Expect(b.Castlings(Black)).To(HaveLen(1))
+
+		d := b.Piece(Coord{2, 2}).Destinations(b)
+		Expect(d.Len()).To(Equal(1), "moved pawn can't do a long move")
+
+		Expect(NewXFENWithOptions(b.(*Board), opts)).To(Equal(XFEN(`r3k2r/8/8/8/8/8/1P6/R3K2R w Qk - 0 20 a1e1e8h8`)))
+	})
+
+	It("keeps pieces out of their starting ranks unmoved", func() {
+		input := XFEN(`r8r/1nbqkcabn1/pppppppppp/10/10/10/10/PPPPPPPPPP/1NBQKCABN1/R8R w - - 0 1 ` +
+			`a1j1b2c2d2e2f2g2h2i2a3b3c3d3e3f3g3h3i3j3a8b8c8d8e8f8g8h8i8j8b9c9d9e9f9g9h9i9a10j10`)
+		b, err := input.BoardWithOptions(opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b.FindPieces(base.PieceFilter{Condition: func(p base.IPiece) bool { return p.WasMoved() }})).To(BeEmpty())
+		Expect(NewXFENWithOptions(b.(*Board), opts)).To(Equal(input))
+	})
+
+	It("guesses unmoved pawns by the pawn start zone without the virgin flags field", func() {
+		opts.Settings = func() *base.Settings {
+			s := StandardChessBoardSettings()
+			s.PawnStartZoneFunc = NewPawnStartRanksFunc(true, 3)
+			return s
+		}
+		b, err := XFEN(`r8r/1nbqkcabn1/ppppp1pppp/10/5p4/10/P9/1PPPPPPPPP/1NBQKCABN1/R8R w - - 0 1`).
+			BoardWithOptions(opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b.Piece(Coord{2, 3}).WasMoved()).To(BeFalse())
+		Expect(b.Piece(Coord{2, 3}).Destinations(b).Contains(Coord{2, 5})).To(BeTrue())
+		Expect(b.Piece(Coord{5, 8}).WasMoved()).To(BeFalse())
+		Expect(b.Piece(Coord{1, 4}).WasMoved()).To(BeTrue())
+		Expect(b.Piece(Coord{6, 6}).WasMoved()).To(BeTrue())
+		Expect(b.Piece(Coord{1, 1}).WasMoved()).To(BeFalse())
+	})
+
+	It("guesses unmoved pawns by start squares on a non-standard board without the virgin flags field", func() {
+		opts.Settings = func() *base.Settings {
+			s := StandardChessBoardSettings()
+			s.PawnStartZoneFunc = NewPawnStartSquaresFunc(true, map[Colour][]Coord{
+				White: {{3, 1}}, Black: {{3, 4}},
+			})
+			return s
+		}
+		b, err := XFEN(`k4/1p3/2p2/5/1P3/K1P2 w - - 0 1`).BoardWithOptions(opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b.Piece(Coord{3, 1}).WasMoved()).To(BeFalse())
+		Expect(b.Piece(Coord{3, 1}).Destinations(b).Contains(Coord{3, 3})).To(BeTrue())
+		Expect(b.Piece(Coord{3, 4}).WasMoved()).To(BeFalse())
+		Expect(b.Piece(Coord{2, 2}).WasMoved()).To(BeTrue(), "the 2nd rank is not a start zone here")
+		Expect(b.Piece(Coord{2, 5}).WasMoved()).To(BeTrue(), "the pre-last rank is not a start zone here")
+	})
+
+	It("marks all pieces moved with the empty virgin flags field", func() {
+		b, err := NewStandardChessStartingPosition().BoardWithOptions(opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b.FindPieces(base.PieceFilter{Condition: func(p base.IPiece) bool { return p.WasMoved() }})).To(BeEmpty())
+
+		b, err = XFEN(NewStandardChessStartingPosition() + " -").BoardWithOptions(opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b.FindPieces(base.PieceFilter{Condition: func(p base.IPiece) bool { return !p.WasMoved() }})).To(BeEmpty())
+		Expect(b.Castlings(White)).To(BeEmpty())
+		Expect(NewXFENWithOptions(b.(*Board), opts)).To(Equal(XFEN(`rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w - - 0 1 -`)))
+	})
+
+	It("checks errors on invalid virgin flags", func() {
+		for _, field := range []string{"a1e1x", "a1e3", "z9", "+1+1"} {
+			By(fmt.Sprintf("Checking %s...", field))
+			b, err := XFEN(NewStandardChessStartingPosition() + XFEN(" "+field)).BoardWithOptions(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(b).To(BeNil())
+		}
+	})
+})
+
+var _ = Describe("XFEN castling flags tests", func() {
+	It("checks castling with inner rooks", func() {
+		input := XFEN(`4k3/8/8/8/8/8/8/R1R1K2R w CK - 0 1`)
+		b, err := input.Board()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b.CastlingPartners(White)).To(ConsistOf(Coord{3, 1}, Coord{8, 1}))
+
+		castlings := b.Castlings(White)
+		Expect(castlings).To(HaveLen(2))
+		Expect(castlings[0].Piece[1].Coord()).To(Equal(Coord{3, 1}))
+		Expect(castlings[0].To).To(Equal([2]base.ICoord{Coord{3, 1}, Coord{4, 1}}))
+		Expect(NewXFEN(b.(*Board))).To(Equal(XFEN(`4k3/8/8/8/8/8/8/R1R1K2R w KC - 0 1`)))
+	})
+
+	It("checks Shredder-FEN castling flags", func() {
+		opts := DefaultXFENOptions()
+		opts.ShredderCastling = true
+
+		b, err := NewStandardChessStartingPosition().Board()
+		Expect(err).NotTo(HaveOccurred())
+		xfen := NewXFENWithOptions(b.(*Board), opts)
+		Expect(xfen).To(Equal(XFEN(`rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAha - 0 1`)))
+
+		b1, err := xfen.BoardWithOptions(opts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b1.Equals(b)).To(BeTrue())
+	})
+
+	It("checks castling with non-rook partners", func() {
+		opts := DefaultXFENOptions()
+		opts.Settings = func() *base.Settings {
+			s := StandardChessBoardSettings()
+			s.Castling.Partners = []string{base.RookName, base.ArchbishopName}
+			return s
+		}
+
+		input := XFEN(`a3k2r/8/8/8/8/8/8/A3K2R b KQkq - 0 1`)
+		b, err := input.BoardWithOptions(opts)
+		Expect(err).NotTo(HaveOccurred())
+		castlings := b.Castlings(Black)
+		Expect(castlings).To(HaveLen(2))
+		Expect(castlings[0].Piece[1].Name()).To(Equal(base.ArchbishopName))
+		Expect(NewXFENWithOptions(b.(*Board), opts)).To(Equal(input))
+
+		_, err = input.Board()
+		Expect(err).To(HaveOccurred(), "archbishop is not a castling partner in standard chess")
+	})
+})
@@ -0,0 +1,100 @@
+package render
+
+import (
+	"github.com/mtfelian/mtfchess/base"
+)
+
+// Point is a point of a glyph in a unit square, the origin is at the top left corner
+type Point struct {
+	X, Y float64
+}
+
+// Shape is a part of a glyph: a polygon if Polygon is not empty, otherwise a circle
+type Shape struct {
+	Polygon []Point
+	Center  Point
+	Radius  float64
+}
+
+// Glyph is a piece image made of shapes, each shape is filled with the piece colour
+// and outlined with a contrast colour in order
+type Glyph []Shape
+
+// PieceSet maps piece names to their glyphs, pieces without a glyph are drawn as circles with a capital letter
+type PieceSet map[string]Glyph
+
+// transform returns a copy of g scaled by k relatively to the unit square center and shifted by dx, dy
+func (g Glyph) transform(k, dx, dy float64) Glyph {
+	f := func(p Point) Point { return Point{0.5 + (p.X-0.5)*k + dx, 0.5 + (p.Y-0.5)*k + dy} }
+	res := make(Glyph, len(g))
+	for i := range g {
+		res[i] = Shape{Center: f(g[i].Center), Radius: g[i].Radius * k}
+		for _, p := range g[i].Polygon {
+			res[i].Polygon = append(res[i].Polygon, f(p))
+		}
+	}
+	return res
+}
+
+// combine returns a glyph of a compound piece: a is drawn on the left side and b overlaps it on the right side
+func combine(a, b Glyph) Glyph {
+	return append(a.transform(0.8, -0.14, 0.1), b.transform(0.8, 0.14, 0.1)...)
+}
+
+// polygon returns a polygon shape made of pairs of coordinates
+func polygon(c ...float64) Shape {
+	s := Shape{Polygon: make([]Point, len(c)/2)}
+	for i := range s.Polygon {
+		s.Polygon[i] = Point{c[2*i], c[2*i+1]}
+	}
+	return s
+}
+
+// circle returns a circle shape
+func circle(x, y, r float64) Shape { return Shape{Center: Point{x, y}, Radius: r} }
+
+var (
+	pawnGlyph = Glyph{
+		polygon(0.38, 0.42, 0.62, 0.42, 0.7, 0.8, 0.3, 0.8),
+		circle(0.5, 0.32, 0.13),
+		polygon(0.25, 0.8, 0.75, 0.8, 0.75, 0.88, 0.25, 0.88),
+	}
+	knightGlyph = Glyph{polygon(
+		0.3, 0.88, 0.76, 0.88, 0.74, 0.55, 0.68, 0.32, 0.54, 0.18, 0.47, 0.1, 0.42, 0.2,
+		0.3, 0.3, 0.18, 0.5, 0.26, 0.58, 0.38, 0.5, 0.46, 0.5, 0.32, 0.68,
+	)}
+	bishopGlyph = Glyph{
+		circle(0.5, 0.15, 0.06),
+		polygon(0.5, 0.2, 0.65, 0.4, 0.6, 0.62, 0.66, 0.8, 0.34, 0.8, 0.4, 0.62, 0.35, 0.4),
+		polygon(0.25, 0.8, 0.75, 0.8, 0.75, 0.88, 0.25, 0.88),
+	}
+	rookGlyph = Glyph{polygon(
+		0.25, 0.88, 0.75, 0.88, 0.75, 0.8, 0.68, 0.8, 0.64, 0.4, 0.72, 0.4, 0.72, 0.18, 0.64, 0.18,
+		0.64, 0.26, 0.56, 0.26, 0.56, 0.18, 0.44, 0.18, 0.44, 0.26, 0.36, 0.26, 0.36, 0.18, 0.28, 0.18,
+		0.28, 0.4, 0.36, 0.4, 0.32, 0.8, 0.25, 0.8,
+	)}
+	queenGlyph = Glyph{
+		polygon(0.28, 0.88, 0.72, 0.88, 0.7, 0.78, 0.82, 0.3, 0.66, 0.56, 0.6, 0.24, 0.5, 0.54,
+			0.4, 0.24, 0.34, 0.56, 0.18, 0.3, 0.3, 0.78),
+		circle(0.18, 0.27, 0.05), circle(0.4, 0.21, 0.05), circle(0.6, 0.21, 0.05), circle(0.82, 0.27, 0.05),
+	}
+	kingGlyph = Glyph{
+		polygon(0.46, 0.06, 0.54, 0.06, 0.54, 0.13, 0.61, 0.13, 0.61, 0.2, 0.54, 0.2, 0.54, 0.3,
+			0.46, 0.3, 0.46, 0.2, 0.39, 0.2, 0.39, 0.13, 0.46, 0.13),
+		polygon(0.5, 0.3, 0.76, 0.42, 0.68, 0.78, 0.72, 0.88, 0.28, 0.88, 0.32, 0.78, 0.24, 0.42),
+	}
+)
+
+// StandardPieceSet returns a piece set with glyphs of standard chess pieces, archbishop and chancellor
+func StandardPieceSet() PieceSet {
+	return PieceSet{
+		base.PawnName:       pawnGlyph,
+		base.KnightName:     knightGlyph,
+		base.BishopName:     bishopGlyph,
+		base.RookName:       rookGlyph,
+		base.QueenName:      queenGlyph,
+		base.KingName:       kingGlyph,
+		base.ArchbishopName: combine(bishopGlyph, knightGlyph),
+		base.ChancellorName: combine(rookGlyph, knightGlyph),
+	}
+}
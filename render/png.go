@@ -0,0 +1,270 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"unicode"
+
+	"github.com/mtfelian/mtfchess/base"
+	"github.com/mtfelian/mtfchess/rect"
+)
+
+// samples is a number of samples per pixel side for anti-aliasing
+const samples = 4
+
+// PNG writes a PNG image of a board position to w
+func PNG(w io.Writer, b *rect.Board, opts Options) error { return png.Encode(w, Image(b, opts)) }
+
+// Image returns an image of a board position
+func Image(b *rect.Board, opts Options) *image.RGBA {
+	l := newLayout(b, opts)
+	w, h := l.size()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	for _, c := range l.cells() {
+		draw.Draw(img, cellRect(l, c), image.NewUniform(cellColour(b, c, opts)), image.Point{}, draw.Src)
+	}
+
+	cells, colours := highlights(b, opts)
+	for i := range cells {
+		draw.Draw(img, cellRect(l, cells[i]), image.NewUniform(colours[i]), image.Point{}, draw.Over)
+	}
+
+	if opts.Coordinates {
+		imageCoordinates(img, l, opts)
+	}
+
+	for _, c := range l.cells() {
+		if c.OutOf(b) {
+			continue
+		}
+		if piece := b.Piece(c); piece != nil {
+			imagePiece(img, piece, l, c, opts)
+		}
+	}
+
+	for _, arrow := range opts.Arrows {
+		colour := arrow.Colour
+		if colour == nil {
+			colour = opts.ArrowColour
+		}
+		polygon := arrowPolygon(l.center(arrow.From), l.center(arrow.To), float64(l.cell))
+		fill(img, bounds(polygon, 0), colour, func(x, y float64) bool { return insidePolygon(polygon, x, y) })
+	}
+	return img
+}
+
+// cellRect returns a rectangle of a cell at c
+func cellRect(l layout, c rect.Coord) image.Rectangle {
+	x, y := l.origin(c)
+	return image.Rect(x, y, x+l.cell, y+l.cell)
+}
+
+// imageCoordinates draws file letters on the bottom edge cells and rank numbers on the left edge cells
+func imageCoordinates(img *image.RGBA, l layout, opts Options) {
+	scale := l.cell / 22
+	if scale < 1 {
+		scale = 1
+	}
+	for _, c := range l.cells() {
+		bottom, left := l.edges(c)
+		x, y := l.origin(c)
+		colour := labelColour(c, opts)
+		if bottom {
+			text := string(rect.ToLetter(c.X))
+			drawText(img, text, x+l.cell-textWidth(text, scale)-scale, y+l.cell-6*scale, scale, colour)
+		}
+		if left {
+			drawText(img, itoa(c.Y), x+scale, y+scale, scale, colour)
+		}
+	}
+}
+
+// imagePiece draws a piece at cell c
+func imagePiece(img *image.RGBA, piece base.IPiece, l layout, c rect.Coord, opts Options) {
+	x, y := l.origin(c)
+	k := float64(l.cell)
+	colours, stroke := pieceColours[piece.Colour()], k/30
+	glyph, exists := opts.Pieces[piece.Name()]
+	if !exists {
+		glyph = Glyph{circle(0.5, 0.5, 0.35)}
+	}
+
+	for _, shape := range glyph.transform(1, 0, 0) {
+		shape = scaleShape(shape, float64(x), float64(y), k)
+		fill(img, shapeBounds(shape, stroke), colours[0], func(px, py float64) bool {
+			return insideShape(shape, px, py)
+		})
+		fill(img, shapeBounds(shape, stroke), colours[1], func(px, py float64) bool {
+			return onShapeOutline(shape, px, py, stroke/2)
+		})
+	}
+
+	if !exists {
+		scale := l.cell / 15
+		if scale < 1 {
+			scale = 1
+		}
+		letter := string(unicode.ToLower(piece.Capital()))
+		drawText(img, letter, x+(l.cell-textWidth(letter, scale))/2, y+(l.cell-5*scale)/2, scale, colours[1])
+	}
+}
+
+// scaleShape returns a shape scaled by k and shifted by ox, oy
+func scaleShape(s Shape, ox, oy, k float64) Shape {
+	res := Shape{Center: Point{ox + s.Center.X*k, oy + s.Center.Y*k}, Radius: s.Radius * k}
+	for _, p := range s.Polygon {
+		res.Polygon = append(res.Polygon, Point{ox + p.X*k, oy + p.Y*k})
+	}
+	return res
+}
+
+// shapeBounds returns pixel bounds of a shape extended by margin
+func shapeBounds(s Shape, margin float64) image.Rectangle {
+	if len(s.Polygon) > 0 {
+		return bounds(s.Polygon, margin)
+	}
+	r := s.Radius + margin
+	return bounds([]Point{{s.Center.X - r, s.Center.Y - r}, {s.Center.X + r, s.Center.Y + r}}, 0)
+}
+
+// bounds returns pixel bounds of points extended by margin
+func bounds(points []Point, margin float64) image.Rectangle {
+	if len(points) == 0 {
+		return image.Rectangle{}
+	}
+	minX, minY, maxX, maxY := points[0].X, points[0].Y, points[0].X, points[0].Y
+	for _, p := range points {
+		minX, minY = math.Min(minX, p.X), math.Min(minY, p.Y)
+		maxX, maxY = math.Max(maxX, p.X), math.Max(maxY, p.Y)
+	}
+	return image.Rect(int(math.Floor(minX-margin)), int(math.Floor(minY-margin)),
+		int(math.Ceil(maxX+margin))+1, int(math.Ceil(maxY+margin))+1)
+}
+
+// insideShape returns true if a point is inside a shape
+func insideShape(s Shape, x, y float64) bool {
+	if len(s.Polygon) > 0 {
+		return insidePolygon(s.Polygon, x, y)
+	}
+	return math.Hypot(x-s.Center.X, y-s.Center.Y) <= s.Radius
+}
+
+// onShapeOutline returns true if a point is not further than w from a shape outline
+func onShapeOutline(s Shape, x, y, w float64) bool {
+	if len(s.Polygon) == 0 {
+		return math.Abs(math.Hypot(x-s.Center.X, y-s.Center.Y)-s.Radius) <= w
+	}
+	for i := range s.Polygon {
+		if segmentDistance(s.Polygon[i], s.Polygon[(i+1)%len(s.Polygon)], x, y) <= w {
+			return true
+		}
+	}
+	return false
+}
+
+// insidePolygon returns true if a point is inside a polygon by the even-odd rule
+func insidePolygon(polygon []Point, x, y float64) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Y > y) != (b.Y > y) && x < (b.X-a.X)*(y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// segmentDistance returns a distance from a point to a segment ab
+func segmentDistance(a, b Point, x, y float64) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = math.Max(0, math.Min(1, ((x-a.X)*dx+(y-a.Y)*dy)/l))
+	}
+	return math.Hypot(x-a.X-t*dx, y-a.Y-t*dy)
+}
+
+// fill blends colour c into pixels of img within r covered by a figure, inside returns true for points
+// of the figure. Each pixel is sampled several times for anti-aliasing.
+func fill(img *image.RGBA, r image.Rectangle, c color.Color, inside func(x, y float64) bool) {
+	src := nrgba(c)
+	r = r.Intersect(img.Bounds())
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		for px := r.Min.X; px < r.Max.X; px++ {
+			n := 0
+			for sy := 0; sy < samples; sy++ {
+				for sx := 0; sx < samples; sx++ {
+					if inside(float64(px)+(float64(sx)+0.5)/samples, float64(py)+(float64(sy)+0.5)/samples) {
+						n++
+					}
+				}
+			}
+			if n > 0 {
+				blend(img, px, py, src, float64(src.A)/0xff*float64(n)/(samples*samples))
+			}
+		}
+	}
+}
+
+// blend blends src colour with alpha into a pixel of img
+func blend(img *image.RGBA, x, y int, src color.NRGBA, alpha float64) {
+	dst := img.RGBAAt(x, y)
+	mix := func(s, d uint8) uint8 { return uint8(math.Round(float64(s)*alpha + float64(d)*(1-alpha))) }
+	img.SetRGBA(x, y, color.RGBA{
+		R: mix(src.R, dst.R), G: mix(src.G, dst.G), B: mix(src.B, dst.B), A: mix(0xff, dst.A),
+	})
+}
+
+// font is a 3x5 pixels font for coordinates labels
+var font = map[rune][5]string{
+	'a': {".#.", "#.#", "###", "#.#", "#.#"}, 'b': {"##.", "#.#", "##.", "#.#", "##."},
+	'c': {".##", "#..", "#..", "#..", ".##"}, 'd': {"##.", "#.#", "#.#", "#.#", "##."},
+	'e': {"###", "#..", "##.", "#..", "###"}, 'f': {"###", "#..", "##.", "#..", "#.."},
+	'g': {".##", "#..", "#.#", "#.#", ".##"}, 'h': {"#.#", "#.#", "###", "#.#", "#.#"},
+	'i': {"###", ".#.", ".#.", ".#.", "###"}, 'j': {"..#", "..#", "..#", "#.#", ".#."},
+	'k': {"#.#", "#.#", "##.", "#.#", "#.#"}, 'l': {"#..", "#..", "#..", "#..", "###"},
+	'm': {"#.#", "###", "###", "#.#", "#.#"}, 'n': {"##.", "#.#", "#.#", "#.#", "#.#"},
+	'o': {".#.", "#.#", "#.#", "#.#", ".#."}, 'p': {"##.", "#.#", "##.", "#..", "#.."},
+	'q': {".#.", "#.#", "#.#", "##.", ".##"}, 'r': {"##.", "#.#", "##.", "#.#", "#.#"},
+	's': {".##", "#..", ".#.", "..#", "##."}, 't': {"###", ".#.", ".#.", ".#.", ".#."},
+	'u': {"#.#", "#.#", "#.#", "#.#", "###"}, 'v': {"#.#", "#.#", "#.#", "#.#", ".#."},
+	'w': {"#.#", "#.#", "###", "###", "#.#"}, 'x': {"#.#", "#.#", ".#.", "#.#", "#.#"},
+	'y': {"#.#", "#.#", ".#.", ".#.", ".#."}, 'z': {"###", "..#", ".#.", "#..", "###"},
+	'0': {"###", "#.#", "#.#", "#.#", "###"}, '1': {".#.", "##.", ".#.", ".#.", "###"},
+	'2': {"##.", "..#", ".#.", "#..", "###"}, '3': {"##.", "..#", ".#.", "..#", "##."},
+	'4': {"#.#", "#.#", "###", "..#", "..#"}, '5': {"###", "#..", "##.", "..#", "##."},
+	'6': {".##", "#..", "###", "#.#", "###"}, '7': {"###", "..#", ".#.", ".#.", ".#."},
+	'8': {"###", "#.#", "###", "#.#", "###"}, '9': {"###", "#.#", "###", "..#", "##."},
+}
+
+// textWidth returns a width of text drawn with the given scale
+func textWidth(text string, scale int) int { return (4*len([]rune(text)) - 1) * scale }
+
+// drawText draws text with its top left corner at x, y, each font pixel is a square of scale side
+func drawText(img *image.RGBA, text string, x, y, scale int, c color.Color) {
+	u := image.NewUniform(c)
+	for i, r := range text {
+		for row, line := range font[r] {
+			for col, p := range line {
+				if p != '#' {
+					continue
+				}
+				px, py := x+(4*i+col)*scale, y+row*scale
+				draw.Draw(img, image.Rect(px, py, px+scale, py+scale), u, image.Point{}, draw.Over)
+			}
+		}
+	}
+}
+
+// itoa converts n to a string
+func itoa(n int) string {
+	if n < 10 {
+		return string(rune('0' + n))
+	}
+	return itoa(n/10) + string(rune('0'+n%10))
+}
@@ -0,0 +1,87 @@
+package render_test
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	"github.com/mtfelian/mtfchess/rect"
+	"github.com/mtfelian/mtfchess/render"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PNG rendering", func() {
+	rgba := func(c color.Color) color.RGBA { return color.RGBAModel.Convert(c).(color.RGBA) }
+
+	It("encodes a decodable image of a board size", func() {
+		testCases := []struct {
+			b             *rect.Board
+			width, height int
+		}{
+			{rect.NewEmptyStandardChessBoard(), 360, 360},
+			{rect.NewEmptyTestBoard(), 225, 270},
+			{rect.NewFourPlayerChessBoard(true), 630, 630},
+		}
+		for i, testCase := range testCases {
+			By(fmt.Sprintf("Checking testCase at index %d...", i))
+			buf := &bytes.Buffer{}
+			Expect(render.PNG(buf, testCase.b, render.DefaultOptions())).To(Succeed())
+			img, err := png.Decode(buf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(img.Bounds()).To(Equal(image.Rect(0, 0, testCase.width, testCase.height)))
+		}
+	})
+
+	It("draws cells, holes and flipped boards", func() {
+		opts := render.DefaultOptions()
+		img := render.Image(rect.NewEmptyStandardChessBoard(), opts)
+		Expect(img.RGBAAt(22, 337)).To(Equal(rgba(opts.Dark)))
+		Expect(img.RGBAAt(337, 337)).To(Equal(rgba(opts.Light)))
+
+		opts.Flipped = true
+		img = render.Image(rect.NewEmptyStandardChessBoard(), opts)
+		Expect(img.RGBAAt(337, 22)).To(Equal(rgba(opts.Dark)))
+		Expect(img.RGBAAt(22, 22)).To(Equal(rgba(opts.Light)))
+
+		img = render.Image(rect.NewFourPlayerChessBoard(false), render.DefaultOptions())
+		Expect(img.RGBAAt(22, 22)).To(Equal(rgba(opts.Hole)))
+	})
+
+	It("draws pieces, highlights and arrows", func() {
+		b := rect.NewEmptyStandardChessBoard()
+		b.PlacePiece(rect.Coord{X: 1, Y: 1}, rect.StandardPieceRegistry().NewByName(base.PawnName, White))
+		b.PlacePiece(rect.Coord{X: 2, Y: 1}, rect.StandardPieceRegistry().NewByName(base.PawnName, Black))
+		b.PlacePiece(rect.Coord{X: 3, Y: 1}, rect.StandardPieceRegistry().NewByName(base.PawnName, Red))
+		opts := render.DefaultOptions()
+		opts.Coordinates = false
+		opts.LastMove = []rect.Coord{{X: 8, Y: 8}}
+		opts.Arrows = []render.Arrow{{From: rect.Coord{X: 1, Y: 4}, To: rect.Coord{X: 8, Y: 4}}}
+		img := render.Image(b, opts)
+
+		Expect(img.RGBAAt(22, 342)).To(Equal(color.RGBA{0xff, 0xff, 0xff, 0xff}))
+		Expect(img.RGBAAt(67, 342)).To(Equal(color.RGBA{0x00, 0x00, 0x00, 0xff}))
+		Expect(img.RGBAAt(112, 342)).To(Equal(color.RGBA{0xbf, 0x3b, 0x43, 0xff}))
+		Expect(img.RGBAAt(337, 22)).NotTo(Equal(rgba(opts.Dark)))
+		Expect(img.RGBAAt(180, 202)).NotTo(Equal(rgba(opts.Light)))
+		Expect(img.RGBAAt(180, 202)).NotTo(Equal(rgba(opts.Dark)))
+		Expect(img.RGBAAt(180, 100)).To(Equal(rgba(opts.Light)))
+	})
+
+	It("draws coordinates and unknown pieces", func() {
+		b := rect.NewEmptyStandardChessBoard()
+		b.PlacePiece(rect.Coord{X: 4, Y: 4}, rect.StandardPieceRegistry().NewByName(base.KingName, White))
+		opts := render.DefaultOptions()
+		plain := render.Image(b, opts)
+		opts.Coordinates, opts.Pieces = false, render.PieceSet{}
+		img := render.Image(b, opts)
+		Expect(img.Bounds()).To(Equal(plain.Bounds()))
+		Expect(img.Pix).NotTo(Equal(plain.Pix))
+		Expect(img.RGBAAt(146, 202)).To(Equal(color.RGBA{0xff, 0xff, 0xff, 0xff}))
+		Expect(img.RGBAAt(154, 196)).To(Equal(color.RGBA{0x00, 0x00, 0x00, 0xff}))
+	})
+})
@@ -0,0 +1,184 @@
+// Package render draws rectangular board positions as SVG and PNG images
+package render
+
+import (
+	"image/color"
+	"math"
+
+	. "github.com/mtfelian/mtfchess/colour"
+	"github.com/mtfelian/mtfchess/rect"
+)
+
+// Arrow is an arrow drawn from one cell to another
+type Arrow struct {
+	From, To rect.Coord
+	Colour   color.Color // if nil then Options.ArrowColour is used
+}
+
+// Options are rendering options
+type Options struct {
+	CellSize    int          // cell size in pixels
+	Flipped     bool         // draw a board from the black side: h8 is at the bottom left corner
+	Coordinates bool         // draw file letters and rank numbers on the edge cells
+	LastMove    []rect.Coord // cells to highlight as a last move, usually source and destination
+	Check       bool         // highlight cells of kings in check
+	Arrows      []Arrow
+	Pieces      PieceSet
+
+	Light, Dark, Hole color.Color // colours of light cells, dark cells and holes
+	LastMoveColour    color.Color // colour of last move highlighting, it should be semi-transparent
+	CheckColour       color.Color // colour of check highlighting, it should be semi-transparent
+	ArrowColour       color.Color // default arrows colour, it should be semi-transparent
+}
+
+// DefaultOptions returns default rendering options
+func DefaultOptions() Options {
+	return Options{
+		CellSize:       45,
+		Coordinates:    true,
+		Check:          true,
+		Pieces:         StandardPieceSet(),
+		Light:          color.RGBA{0xf0, 0xd9, 0xb5, 0xff},
+		Dark:           color.RGBA{0xb5, 0x88, 0x63, 0xff},
+		Hole:           color.RGBA{0xff, 0xff, 0xff, 0xff},
+		LastMoveColour: color.NRGBA{0x9b, 0xc7, 0x00, 0x69},
+		CheckColour:    color.NRGBA{0xe0, 0x00, 0x00, 0x80},
+		ArrowColour:    color.NRGBA{0x15, 0x78, 0x1b, 0xa0},
+	}
+}
+
+// pieceColours maps piece colours to fill and outline colours
+var pieceColours = map[Colour][2]color.RGBA{
+	White:  {{0xff, 0xff, 0xff, 0xff}, {0x00, 0x00, 0x00, 0xff}},
+	Black:  {{0x00, 0x00, 0x00, 0xff}, {0xff, 0xff, 0xff, 0xff}},
+	Red:    {{0xbf, 0x3b, 0x43, 0xff}, {0x00, 0x00, 0x00, 0xff}},
+	Blue:   {{0x41, 0x85, 0xbf, 0xff}, {0x00, 0x00, 0x00, 0xff}},
+	Yellow: {{0xc0, 0x95, 0x26, 0xff}, {0x00, 0x00, 0x00, 0xff}},
+	Green:  {{0x4e, 0x91, 0x61, 0xff}, {0x00, 0x00, 0x00, 0xff}},
+	Dead:   {{0x99, 0x99, 0x99, 0xff}, {0x44, 0x44, 0x44, 0xff}},
+}
+
+// layout is a board geometry in pixels
+type layout struct {
+	w, h    int // board width and height in cells
+	cell    int
+	flipped bool
+}
+
+// newLayout returns a layout of board according to options
+func newLayout(b *rect.Board, opts Options) layout {
+	dim := b.Dim().(rect.Coord)
+	return layout{w: dim.X, h: dim.Y, cell: opts.CellSize, flipped: opts.Flipped}
+}
+
+// size returns image width and height in pixels
+func (l layout) size() (int, int) { return l.w * l.cell, l.h * l.cell }
+
+// origin returns a pixel position of the top left corner of a cell at c
+func (l layout) origin(c rect.Coord) (int, int) {
+	if l.flipped {
+		return (l.w - c.X) * l.cell, (c.Y - 1) * l.cell
+	}
+	return (c.X - 1) * l.cell, (l.h - c.Y) * l.cell
+}
+
+// center returns a pixel position of a cell center at c
+func (l layout) center(c rect.Coord) Point {
+	x, y := l.origin(c)
+	return Point{float64(x) + float64(l.cell)/2, float64(y) + float64(l.cell)/2}
+}
+
+// edges returns true if a cell at c is at the bottom edge and at the left edge of an image
+func (l layout) edges(c rect.Coord) (bottom, left bool) {
+	if l.flipped {
+		return c.Y == l.h, c.X == l.w
+	}
+	return c.Y == 1, c.X == 1
+}
+
+// cells returns all coords of a board
+func (l layout) cells() []rect.Coord {
+	res := []rect.Coord{}
+	for y := 1; y <= l.h; y++ {
+		for x := 1; x <= l.w; x++ {
+			res = append(res, rect.Coord{X: x, Y: y})
+		}
+	}
+	return res
+}
+
+// cellColour returns a colour of a cell at c, a1 is dark
+func cellColour(b *rect.Board, c rect.Coord, opts Options) color.Color {
+	switch {
+	case c.OutOf(b):
+		return opts.Hole
+	case (c.X+c.Y)%2 == 0:
+		return opts.Dark
+	}
+	return opts.Light
+}
+
+// labelColour returns a colour of coordinates labels on a cell at c, it contrasts with the cell colour
+func labelColour(c rect.Coord, opts Options) color.Color {
+	if (c.X+c.Y)%2 == 0 {
+		return opts.Light
+	}
+	return opts.Dark
+}
+
+// checkedKings returns coords of kings in check
+func checkedKings(b *rect.Board) []rect.Coord {
+	res := []rect.Coord{}
+	for _, colour := range b.Settings().Players.Colours() {
+		if king := b.King(colour); king != nil && king.Coord() != nil && b.InCheck(colour) {
+			res = append(res, king.Coord().(rect.Coord))
+		}
+	}
+	return res
+}
+
+// highlights returns cells highlighted according to options and their colours
+func highlights(b *rect.Board, opts Options) ([]rect.Coord, []color.Color) {
+	cells, colours := []rect.Coord{}, []color.Color{}
+	for _, c := range opts.LastMove {
+		cells, colours = append(cells, c), append(colours, opts.LastMoveColour)
+	}
+	if opts.Check {
+		for _, c := range checkedKings(b) {
+			cells, colours = append(cells, c), append(colours, opts.CheckColour)
+		}
+	}
+	return cells, colours
+}
+
+// arrowPolygon returns a polygon of an arrow from one pixel position to another,
+// the arrow starts at a distance from the source cell center and ends with a head at the destination
+func arrowPolygon(from, to Point, cell float64) []Point {
+	dx, dy := to.X-from.X, to.Y-from.Y
+	length := math.Sqrt(dx*dx + dy*dy)
+	if length == 0 {
+		return nil
+	}
+	ux, uy := dx/length, dy/length // direction
+	nx, ny := -uy, ux              // normal
+	shaft, head, headLength := cell*0.08, cell*0.2, cell*0.4
+	start := Point{from.X + ux*cell*0.25, from.Y + uy*cell*0.25}
+	neck := Point{to.X - ux*headLength, to.Y - uy*headLength}
+	return []Point{
+		{start.X + nx*shaft, start.Y + ny*shaft},
+		{neck.X + nx*shaft, neck.Y + ny*shaft},
+		{neck.X + nx*head, neck.Y + ny*head},
+		to,
+		{neck.X - nx*head, neck.Y - ny*head},
+		{neck.X - nx*shaft, neck.Y - ny*shaft},
+		{start.X - nx*shaft, start.Y - ny*shaft},
+	}
+}
+
+// nrgba converts c to a non-premultiplied colour, nil is converted to a transparent colour
+func nrgba(c color.Color) color.NRGBA {
+	if c == nil {
+		return color.NRGBA{}
+	}
+	return color.NRGBAModel.Convert(c).(color.NRGBA)
+}
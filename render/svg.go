@@ -0,0 +1,132 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mtfelian/mtfchess/base"
+	"github.com/mtfelian/mtfchess/rect"
+)
+
+// SVG returns an SVG image of a board position
+func SVG(b *rect.Board, opts Options) string {
+	l := newLayout(b, opts)
+	w, h := l.size()
+	s := &strings.Builder{}
+	fmt.Fprintf(s, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		w, h, w, h)
+
+	for _, c := range l.cells() {
+		x, y := l.origin(c)
+		fmt.Fprintf(s, `<rect x="%d" y="%d" width="%d" height="%d" %s/>`+"\n",
+			x, y, l.cell, l.cell, svgFill(cellColour(b, c, opts)))
+	}
+
+	cells, colours := highlights(b, opts)
+	for i := range cells {
+		x, y := l.origin(cells[i])
+		fmt.Fprintf(s, `<rect x="%d" y="%d" width="%d" height="%d" %s/>`+"\n",
+			x, y, l.cell, l.cell, svgFill(colours[i]))
+	}
+
+	if opts.Coordinates {
+		svgCoordinates(s, l, opts)
+	}
+
+	for _, c := range l.cells() {
+		if c.OutOf(b) {
+			continue
+		}
+		if piece := b.Piece(c); piece != nil {
+			svgPiece(s, piece, l, c, opts)
+		}
+	}
+
+	for _, arrow := range opts.Arrows {
+		colour := arrow.Colour
+		if colour == nil {
+			colour = opts.ArrowColour
+		}
+		polygon := arrowPolygon(l.center(arrow.From), l.center(arrow.To), float64(l.cell))
+		fmt.Fprintf(s, `<polygon points="%s" %s/>`+"\n", svgPoints(polygon, 0, 0, 1), svgFill(colour))
+	}
+
+	s.WriteString("</svg>\n")
+	return s.String()
+}
+
+// svgCoordinates writes file letters on the bottom edge cells and rank numbers on the left edge cells
+func svgCoordinates(s *strings.Builder, l layout, opts Options) {
+	size := float64(l.cell) / 4
+	for _, c := range l.cells() {
+		bottom, left := l.edges(c)
+		x, y := l.origin(c)
+		fill := svgFill(labelColour(c, opts))
+		if bottom {
+			fmt.Fprintf(s, `<text x="%s" y="%s" font-family="sans-serif" font-size="%s" text-anchor="end" %s>%c</text>`+"\n",
+				num(float64(x+l.cell)-size/4), num(float64(y+l.cell)-size/4), num(size), fill, rect.ToLetter(c.X))
+		}
+		if left {
+			fmt.Fprintf(s, `<text x="%s" y="%s" font-family="sans-serif" font-size="%s" %s>%d</text>`+"\n",
+				num(float64(x)+size/4), num(float64(y)+size), num(size), fill, c.Y)
+		}
+	}
+}
+
+// svgPiece writes a piece at cell c
+func svgPiece(s *strings.Builder, piece base.IPiece, l layout, c rect.Coord, opts Options) {
+	x, y := l.origin(c)
+	ox, oy, k := float64(x), float64(y), float64(l.cell)
+	colours := pieceColours[piece.Colour()]
+	style := fmt.Sprintf(`%s stroke="%s" stroke-width="%s" stroke-linejoin="round"`,
+		svgFill(colours[0]), svgHex(colours[1]), num(k/30))
+
+	glyph, exists := opts.Pieces[piece.Name()]
+	if !exists {
+		fmt.Fprintf(s, `<circle cx="%s" cy="%s" r="%s" %s/>`+"\n", num(ox+k/2), num(oy+k/2), num(k*0.35), style)
+		fmt.Fprintf(s, `<text x="%s" y="%s" font-family="sans-serif" font-size="%s" text-anchor="middle" %s>%c</text>`+"\n",
+			num(ox+k/2), num(oy+k*0.65), num(k*0.4), svgFill(colours[1]), piece.Capital())
+		return
+	}
+
+	fmt.Fprintf(s, `<g %s>`+"\n", style)
+	for _, shape := range glyph {
+		if len(shape.Polygon) > 0 {
+			fmt.Fprintf(s, `<polygon points="%s"/>`+"\n", svgPoints(shape.Polygon, ox, oy, k))
+			continue
+		}
+		fmt.Fprintf(s, `<circle cx="%s" cy="%s" r="%s"/>`+"\n",
+			num(ox+shape.Center.X*k), num(oy+shape.Center.Y*k), num(shape.Radius*k))
+	}
+	s.WriteString("</g>\n")
+}
+
+// svgPoints returns points of a polygon scaled by k and shifted by ox, oy
+func svgPoints(polygon []Point, ox, oy, k float64) string {
+	points := make([]string, len(polygon))
+	for i, p := range polygon {
+		points[i] = num(ox+p.X*k) + "," + num(oy+p.Y*k)
+	}
+	return strings.Join(points, " ")
+}
+
+// svgFill returns fill attributes for a colour
+func svgFill(c color.Color) string {
+	n := nrgba(c)
+	if n.A == 0xff {
+		return fmt.Sprintf(`fill="%s"`, svgHex(c))
+	}
+	return fmt.Sprintf(`fill="%s" fill-opacity="%s"`, svgHex(c), num(float64(n.A)/0xff))
+}
+
+// svgHex returns a hex colour code
+func svgHex(c color.Color) string {
+	n := nrgba(c)
+	return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B)
+}
+
+// num formats a number with at most 2 decimal places
+func num(v float64) string { return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64) }
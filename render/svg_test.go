@@ -0,0 +1,82 @@
+package render_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	"github.com/mtfelian/mtfchess/rect"
+	"github.com/mtfelian/mtfchess/render"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SVG rendering", func() {
+	board := func(xfen rect.XFEN) *rect.Board {
+		b, err := xfen.Board()
+		Expect(err).NotTo(HaveOccurred())
+		return b.(*rect.Board)
+	}
+
+	It("draws cells, coordinates and pieces", func() {
+		s := render.SVG(board(rect.NewStandardChessStartingPosition()), render.DefaultOptions())
+		Expect(s).To(HavePrefix(`<svg xmlns="http://www.w3.org/2000/svg" width="360" height="360"`))
+		Expect(s).To(HaveSuffix("</svg>\n"))
+		Expect(strings.Count(s, "<rect ")).To(Equal(64))
+		Expect(strings.Count(s, "<text ")).To(Equal(16))
+		Expect(s).To(ContainSubstring(">a</text>"))
+		Expect(s).To(ContainSubstring(">8</text>"))
+		Expect(strings.Count(s, "<g ")).To(Equal(32))
+	})
+
+	It("draws boards of any size", func() {
+		testCases := []struct {
+			b    *rect.Board
+			size string
+		}{
+			{rect.NewEmptyTestBoard(), `width="225" height="270"`},
+			{board(`rnabqkbcnr/pppppppppp/10/10/10/10/PPPPPPPPPP/RNABQKBCNR w KQkq - 0 1`), `width="450" height="360"`},
+			{rect.NewFourPlayerChessBoard(false), `width="630" height="630"`},
+		}
+		for i, testCase := range testCases {
+			By(fmt.Sprintf("Checking testCase at index %d...", i))
+			Expect(render.SVG(testCase.b, render.DefaultOptions())).To(ContainSubstring(testCase.size))
+		}
+	})
+
+	It("highlights last move and check, draws arrows", func() {
+		b := board(`rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3`)
+		opts := render.DefaultOptions()
+		opts.LastMove = []rect.Coord{{X: 4, Y: 8}, {X: 8, Y: 4}}
+		opts.Arrows = []render.Arrow{{From: rect.Coord{X: 8, Y: 4}, To: rect.Coord{X: 5, Y: 1}}}
+		s := render.SVG(b, opts)
+		Expect(strings.Count(s, "<rect ")).To(Equal(64 + 3))
+		Expect(s).To(ContainSubstring(`<rect x="315" y="180" width="45" height="45" fill="#9bc700" fill-opacity="0.41"/>`))
+		Expect(s).To(ContainSubstring(`<rect x="180" y="315" width="45" height="45" fill="#e00000" fill-opacity="0.5"/>`))
+		Expect(strings.Count(s, "<polygon points=")).To(BeNumerically(">", 0))
+
+		opts.Check, opts.LastMove, opts.Coordinates = false, nil, false
+		s = render.SVG(b, opts)
+		Expect(strings.Count(s, "<rect ")).To(Equal(64))
+		Expect(s).NotTo(ContainSubstring("<text "))
+	})
+
+	It("draws flipped boards", func() {
+		b := rect.NewEmptyStandardChessBoard()
+		b.PlacePiece(rect.Coord{X: 1, Y: 1}, rect.StandardPieceRegistry().NewByName(base.KingName, White))
+		opts := render.DefaultOptions()
+		opts.LastMove = []rect.Coord{{X: 1, Y: 1}}
+		Expect(render.SVG(b, opts)).To(ContainSubstring(`<rect x="0" y="315" width="45" height="45" fill="#9bc700"`))
+		opts.Flipped = true
+		Expect(render.SVG(b, opts)).To(ContainSubstring(`<rect x="315" y="0" width="45" height="45" fill="#9bc700"`))
+	})
+
+	It("draws unknown pieces as letters", func() {
+		opts := render.DefaultOptions()
+		opts.Pieces = render.PieceSet{}
+		s := render.SVG(board(rect.NewStandardChessStartingPosition()), opts)
+		Expect(s).To(ContainSubstring(">K</text>"))
+		Expect(s).To(ContainSubstring(">N</text>"))
+	})
+})
@@ -0,0 +1,212 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+// ErrFinished is returned on an action in a finished game
+var ErrFinished = errors.New("game is finished")
+
+// IllegalMoveError is returned when a move can't be decoded or made
+type IllegalMoveError struct {
+	Move   string
+	Reason string
+}
+
+// Error makes IllegalMoveError to implement error
+func (e IllegalMoveError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("illegal move %s", e.Move)
+	}
+	return fmt.Sprintf("illegal move %s: %s", e.Move, e.Reason)
+}
+
+// Outcome is a game outcome in a state
+type Outcome struct {
+	Finished bool           `json:"finished"`
+	Result   string         `json:"result"`
+	Winners  []string       `json:"winners,omitempty"`
+	Scores   map[string]int `json:"scores,omitempty"`
+}
+
+// State is a game state returned to clients
+type State struct {
+	ID         string   `json:"id"`
+	Variant    string   `json:"variant"`
+	Position   string   `json:"position"`
+	SideToMove string   `json:"sideToMove"`
+	MoveNumber int      `json:"moveNumber"`
+	InCheck    bool     `json:"inCheck"`
+	Moves      []string `json:"moves"`
+	LegalMoves []string `json:"legalMoves"`
+	DrawOffers []string `json:"drawOffers"`
+	Outcome    Outcome  `json:"outcome"`
+}
+
+// game is a live game restored from a record
+type game struct {
+	sync.Mutex
+	record     Record
+	variant    Variant
+	board      base.IBoard
+	drawOffers map[Colour]bool
+}
+
+// newGame returns a game restored by replaying actions of a record
+func newGame(r Record, variant Variant) (*game, error) {
+	board, err := variant.New(r.Position)
+	if err != nil {
+		return nil, err
+	}
+	g := &game{record: r, variant: variant, board: board, drawOffers: make(map[Colour]bool)}
+	for i, a := range r.Actions {
+		if err := g.play(a); err != nil {
+			return nil, fmt.Errorf("action %d: %v", i, err)
+		}
+	}
+	return g, nil
+}
+
+// copy returns a copy of a game to try an action on
+func (g *game) copy() *game {
+	drawOffers := make(map[Colour]bool, len(g.drawOffers))
+	for colour, offered := range g.drawOffers {
+		drawOffers[colour] = offered
+	}
+	return &game{record: g.record.Copy(), variant: g.variant, board: g.board.Copy(), drawOffers: drawOffers}
+}
+
+// set replaces a state of a game by a state of next, the game's lock is kept
+func (g *game) set(next *game) {
+	g.record, g.board, g.drawOffers = next.record, next.board, next.drawOffers
+}
+
+// apply plays an action and appends it to a game record
+func (g *game) apply(a Action) error {
+	if err := g.play(a); err != nil {
+		return err
+	}
+	g.record.Actions = append(g.record.Actions, a)
+	return nil
+}
+
+// play plays an action on a board
+func (g *game) play(a Action) error {
+	if g.board.Outcome().IsFinished() {
+		return ErrFinished
+	}
+	if a.Type == MoveAction {
+		newNotation, exists := g.variant.Notations[a.Notation]
+		if !exists {
+			return fmt.Errorf("unknown notation %q", a.Notation)
+		}
+		makeMove, err := newNotation(g.board).DecodeMove(g.board, a.Move)
+		if err != nil {
+			return IllegalMoveError{Move: a.Move, Reason: err.Error()}
+		}
+		if !makeMove() {
+			return IllegalMoveError{Move: a.Move}
+		}
+		g.drawOffers = make(map[Colour]bool)
+		return nil
+	}
+
+	colour, err := g.side(a.Colour)
+	if err != nil {
+		return err
+	}
+	switch a.Type {
+	case ResignAction:
+		g.board.Resign(colour)
+	case DrawAction:
+		g.drawOffers[colour] = true
+		for _, side := range g.active() {
+			if !g.drawOffers[side] {
+				return nil
+			}
+		}
+		g.board.AgreeDraw()
+	default:
+		return fmt.Errorf("unknown action %q", a.Type)
+	}
+	return nil
+}
+
+// side returns an active side of a game by its name
+func (g *game) side(name string) (Colour, error) {
+	for _, colour := range g.active() {
+		if strings.EqualFold(colour.Name(), name) {
+			return colour, nil
+		}
+	}
+	return Transparent, fmt.Errorf("no active side %q", name)
+}
+
+// active returns sides still playing a game
+func (g *game) active() []Colour {
+	eliminated, multiPlayer := g.board.(interface{ Eliminated(Colour) bool })
+	res := []Colour{}
+	for _, colour := range g.board.Settings().Players.Colours() {
+		if !multiPlayer || !eliminated.Eliminated(colour) {
+			res = append(res, colour)
+		}
+	}
+	return res
+}
+
+// moves returns moves made in a game
+func (g *game) moves() []string {
+	res := []string{}
+	for _, a := range g.record.Actions {
+		if a.Type == MoveAction {
+			res = append(res, a.Move)
+		}
+	}
+	return res
+}
+
+// state returns a game state
+func (g *game) state() State {
+	sideToMove, outcome := g.board.SideToMove(), g.board.Outcome()
+	s := State{
+		ID:         g.record.ID,
+		Variant:    g.record.Variant,
+		Position:   g.variant.Position(g.board),
+		SideToMove: sideToMove.Name(),
+		MoveNumber: g.board.MoveNumber(),
+		InCheck:    g.board.InCheck(sideToMove),
+		Moves:      g.moves(),
+		LegalMoves: []string{},
+		DrawOffers: []string{},
+		Outcome:    Outcome{Finished: outcome.IsFinished(), Result: outcome.String()},
+	}
+	if !outcome.IsFinished() {
+		s.LegalMoves = g.board.LegalMoves(g.variant.Notations[LongAlgebraic](g.board))
+	}
+	for _, colour := range g.board.Settings().Players.Colours() {
+		if g.drawOffers[colour] {
+			s.DrawOffers = append(s.DrawOffers, colour.Name())
+		}
+	}
+
+	winners := outcome.Winners
+	if len(winners) == 0 && outcome.Winner != Transparent {
+		winners = []Colour{outcome.Winner}
+	}
+	for _, colour := range winners {
+		s.Outcome.Winners = append(s.Outcome.Winners, colour.Name())
+	}
+	if len(outcome.Scores) > 0 {
+		s.Outcome.Scores = make(map[string]int, len(outcome.Scores))
+		for colour, points := range outcome.Scores {
+			s.Outcome.Scores[colour.Name()] = points
+		}
+	}
+	return s
+}
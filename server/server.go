@@ -0,0 +1,286 @@
+// Package server hosts games over HTTP: a JSON REST API to create games, fetch their states,
+// make moves, offer draws and resign, and a WebSocket feed of game events.
+//
+// Endpoints:
+//
+//	GET  /variants              names of supported variants
+//	POST /games                 create a game: {"variant": "standard", "position": "<FEN or X-FEN>"}
+//	GET  /games/{id}            game state
+//	POST /games/{id}/moves      make a move: {"move": "e2-e4", "notation": "long"}
+//	POST /games/{id}/draw       offer a draw: {"colour": "white"}, the draw is agreed when all sides offered it
+//	POST /games/{id}/resign     resign: {"colour": "white"}
+//	GET  /games/{id}/ws         WebSocket feed of events
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// event types besides action types
+const (
+	StateEvent   = "state"
+	OutcomeEvent = "outcome"
+)
+
+// Event is a message sent to WebSocket subscribers of a game
+type Event struct {
+	Type   string `json:"type"`
+	Seq    int    `json:"seq"` // number of game actions made, events are sent in order of it
+	Action Action `json:"action"`
+	State  State  `json:"state"`
+}
+
+// Server is an HTTP handler hosting games
+type Server struct {
+	store    Store
+	variants Variants
+
+	sync.Mutex
+	games       map[string]*game
+	subscribers map[string]map[*wsConn]bool
+}
+
+// New returns a new server keeping games in store
+func New(store Store, variants Variants) *Server {
+	return &Server{
+		store:       store,
+		variants:    variants,
+		games:       make(map[string]*game),
+		subscribers: make(map[string]map[*wsConn]bool),
+	}
+}
+
+// ServeHTTP makes Server to implement http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "variants" && r.Method == http.MethodGet:
+		s.listVariants(w)
+	case len(parts) == 1 && parts[0] == "games" && r.Method == http.MethodPost:
+		s.createGame(w, r)
+	case len(parts) == 2 && parts[0] == "games" && r.Method == http.MethodGet:
+		s.getGame(w, parts[1])
+	case len(parts) == 3 && parts[0] == "games" && r.Method == http.MethodPost && parts[2] == "moves":
+		s.act(w, r, parts[1], MoveAction)
+	case len(parts) == 3 && parts[0] == "games" && r.Method == http.MethodPost && parts[2] == "draw":
+		s.act(w, r, parts[1], DrawAction)
+	case len(parts) == 3 && parts[0] == "games" && r.Method == http.MethodPost && parts[2] == "resign":
+		s.act(w, r, parts[1], ResignAction)
+	case len(parts) == 3 && parts[0] == "games" && r.Method == http.MethodGet && parts[2] == "ws":
+		s.subscribe(w, r, parts[1])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// listVariants writes sorted names of variants
+func (s *Server) listVariants(w http.ResponseWriter) {
+	names := make([]string, 0, len(s.variants))
+	for name := range s.variants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	writeJSON(w, http.StatusOK, names)
+}
+
+// createGame creates a game by a variant name and an optional position
+func (s *Server) createGame(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Variant  string `json:"variant"`
+		Position string `json:"position"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Variant == "" {
+		req.Variant = "standard"
+	}
+	variant, exists := s.variants[req.Variant]
+	if !exists {
+		writeError(w, http.StatusBadRequest, "unknown variant "+req.Variant)
+		return
+	}
+
+	record := Record{ID: newID(), Variant: req.Variant, Position: req.Position, Actions: []Action{}}
+	g, err := newGame(record, variant)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.store.Save(record); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.Lock()
+	s.games[record.ID] = g
+	s.Unlock()
+	writeJSON(w, http.StatusCreated, g.state())
+}
+
+// getGame writes a game state
+func (s *Server) getGame(w http.ResponseWriter, id string) {
+	g, err := s.game(id)
+	if err != nil {
+		writeError(w, errorStatus(err), err.Error())
+		return
+	}
+	g.Lock()
+	defer g.Unlock()
+	writeJSON(w, http.StatusOK, g.state())
+}
+
+// act applies an action of type t from a request body to a game and broadcasts it
+func (s *Server) act(w http.ResponseWriter, r *http.Request, id, t string) {
+	var a Action
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	a.Type = t
+	if a.Type == MoveAction && a.Notation == "" {
+		a.Notation = LongAlgebraic
+	}
+
+	g, err := s.game(id)
+	if err != nil {
+		writeError(w, errorStatus(err), err.Error())
+		return
+	}
+	// the action is tried on a copy, a live game changes only after its record is saved
+	g.Lock()
+	next := g.copy()
+	if err := next.apply(a); err != nil {
+		g.Unlock()
+		writeError(w, errorStatus(err), err.Error())
+		return
+	}
+	if err := s.store.Save(next.record); err != nil {
+		g.Unlock()
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	g.set(next)
+	state, seq := g.state(), len(g.record.Actions)
+	g.Unlock()
+
+	s.broadcast(id, Event{Type: a.Type, Seq: seq, Action: a, State: state})
+	if state.Outcome.Finished {
+		s.broadcast(id, Event{Type: OutcomeEvent, Seq: seq, State: state})
+	}
+	writeJSON(w, http.StatusOK, state)
+}
+
+// game returns a live game by id, restoring it from the store if needed
+func (s *Server) game(id string) (*game, error) {
+	s.Lock()
+	defer s.Unlock()
+	if g, exists := s.games[id]; exists {
+		return g, nil
+	}
+	record, err := s.store.Load(id)
+	if err != nil {
+		return nil, err
+	}
+	variant, exists := s.variants[record.Variant]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	g, err := newGame(record, variant)
+	if err != nil {
+		return nil, err
+	}
+	s.games[id] = g
+	return g, nil
+}
+
+// subscribe upgrades a request to WebSocket and sends game events to it until it's closed
+func (s *Server) subscribe(w http.ResponseWriter, r *http.Request, id string) {
+	g, err := s.game(id)
+	if err != nil {
+		writeError(w, errorStatus(err), err.Error())
+		return
+	}
+	conn, err := upgrade(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	g.Lock()
+	s.Lock()
+	if s.subscribers[id] == nil {
+		s.subscribers[id] = make(map[*wsConn]bool)
+	}
+	s.subscribers[id][conn] = true
+	s.Unlock()
+	event := Event{Type: StateEvent, Seq: len(g.record.Actions), State: g.state()}
+	g.Unlock()
+	if payload, err := json.Marshal(event); err == nil {
+		conn.writeEvent(event.Seq, payload)
+	}
+
+	conn.serve()
+
+	s.Lock()
+	delete(s.subscribers[id], conn)
+	s.Unlock()
+}
+
+// broadcast sends an event to all subscribers of a game
+func (s *Server) broadcast(id string, event Event) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	s.Lock()
+	conns := make([]*wsConn, 0, len(s.subscribers[id]))
+	for conn := range s.subscribers[id] {
+		conns = append(conns, conn)
+	}
+	s.Unlock()
+	for _, conn := range conns {
+		if conn.writeEvent(event.Seq, payload) != nil {
+			conn.conn.Close()
+		}
+	}
+}
+
+// errorStatus returns an HTTP status code for an error
+func errorStatus(err error) int {
+	if _, illegal := err.(IllegalMoveError); illegal {
+		return http.StatusUnprocessableEntity
+	}
+	switch err {
+	case ErrNotFound:
+		return http.StatusNotFound
+	case ErrFinished:
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+// newID returns a new random game id
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// writeJSON writes v as a JSON response with status code
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a JSON error response with status code
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, map[string]string{"error": message})
+}
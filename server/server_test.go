@@ -0,0 +1,293 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// failingStore is a store which fails to save records when fail is set
+type failingStore struct {
+	*MemoryStore
+	fail bool
+}
+
+// Save creates or replaces a record unless the store fails
+func (s *failingStore) Save(r Record) error {
+	if s.fail {
+		return errors.New("store is unavailable")
+	}
+	return s.MemoryStore.Save(r)
+}
+
+var _ = Describe("Server", func() {
+	var (
+		store *MemoryStore
+		ts    *httptest.Server
+	)
+
+	BeforeEach(func() {
+		store = NewMemoryStore()
+		ts = httptest.NewServer(New(store, StandardVariants()))
+	})
+
+	AfterEach(func() { ts.Close() })
+
+	request := func(method, path string, body interface{}, state interface{}) int {
+		b, err := json.Marshal(body)
+		Expect(err).NotTo(HaveOccurred())
+		req, err := http.NewRequest(method, ts.URL+path, bytes.NewReader(b))
+		Expect(err).NotTo(HaveOccurred())
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		if state != nil {
+			Expect(json.NewDecoder(resp.Body).Decode(state)).To(Succeed())
+		}
+		return resp.StatusCode
+	}
+
+	create := func(variant, position string) State {
+		var state State
+		Expect(request(http.MethodPost, "/games", map[string]string{"variant": variant, "position": position},
+			&state)).To(Equal(http.StatusCreated))
+		return state
+	}
+
+	It("lists variants", func() {
+		var names []string
+		Expect(request(http.MethodGet, "/variants", nil, &names)).To(Equal(http.StatusOK))
+		Expect(names).To(ContainElement("standard"))
+		Expect(names).To(ContainElement("glinski"))
+		Expect(names).To(ContainElement("raumschach"))
+	})
+
+	It("creates games of all variants", func() {
+		testCases := []struct {
+			variant string
+			moves   int
+		}{
+			{"standard", 20}, {"cylinder", 20}, {"fourplayer", 20}, {"glinski", 51}, {"shafran", 33}, {"raumschach", 61},
+		}
+		for i, testCase := range testCases {
+			By(fmt.Sprintf("Checking testCase %s at index %d...", testCase.variant, i))
+			state := create(testCase.variant, "")
+			Expect(state.ID).NotTo(BeEmpty())
+			Expect(state.Variant).To(Equal(testCase.variant))
+			Expect(state.LegalMoves).To(HaveLen(testCase.moves))
+			Expect(state.Outcome.Finished).To(BeFalse())
+		}
+	})
+
+	It("rejects bad requests", func() {
+		Expect(request(http.MethodPost, "/games", map[string]string{"variant": "nope"}, nil)).
+			To(Equal(http.StatusBadRequest))
+		Expect(request(http.MethodPost, "/games", map[string]string{"position": "bad"}, nil)).
+			To(Equal(http.StatusBadRequest))
+		Expect(request(http.MethodPost, "/games", map[string]string{"variant": "fourplayer", "position": "8/8"}, nil)).
+			To(Equal(http.StatusBadRequest))
+		Expect(request(http.MethodGet, "/games/unknown", nil, nil)).To(Equal(http.StatusNotFound))
+		Expect(request(http.MethodDelete, "/games", nil, nil)).To(Equal(http.StatusNotFound))
+
+		id := create("standard", "").ID
+		Expect(request(http.MethodPost, "/games/"+id+"/moves", map[string]string{"move": "e2-e5"}, nil)).
+			To(Equal(http.StatusUnprocessableEntity))
+		Expect(request(http.MethodPost, "/games/"+id+"/moves", map[string]string{"move": "zz"}, nil)).
+			To(Equal(http.StatusUnprocessableEntity))
+		Expect(request(http.MethodPost, "/games/"+id+"/moves", map[string]string{"move": "e2-e4", "notation": "x"},
+			nil)).To(Equal(http.StatusBadRequest))
+		Expect(request(http.MethodPost, "/games/"+id+"/resign", map[string]string{"colour": "red"}, nil)).
+			To(Equal(http.StatusBadRequest))
+	})
+
+	It("plays a game from a position until checkmate", func() {
+		id := create("standard", `3qk3/8/8/8/8/8/5PPP/6K1 b - - 0 1`).ID
+		var state State
+		Expect(request(http.MethodPost, "/games/"+id+"/moves", map[string]string{"move": "d8-d1"}, &state)).
+			To(Equal(http.StatusOK))
+		Expect(state.Moves).To(Equal([]string{"d8-d1"}))
+		Expect(state.InCheck).To(BeTrue())
+		Expect(state.Outcome).To(Equal(Outcome{Finished: true, Result: "Black won by checkmate",
+			Winners: []string{"Black"}}))
+		Expect(state.LegalMoves).To(BeEmpty())
+
+		Expect(request(http.MethodPost, "/games/"+id+"/resign", map[string]string{"colour": "white"}, nil)).
+			To(Equal(http.StatusConflict))
+	})
+
+	It("agrees a draw when all sides offered it, a move cancels offers", func() {
+		id := create("standard", "").ID
+		var state State
+		Expect(request(http.MethodPost, "/games/"+id+"/draw", map[string]string{"colour": "white"}, &state)).
+			To(Equal(http.StatusOK))
+		Expect(state.DrawOffers).To(Equal([]string{"White"}))
+		Expect(request(http.MethodPost, "/games/"+id+"/moves", map[string]string{"move": "e2-e4"}, &state)).
+			To(Equal(http.StatusOK))
+		Expect(state.DrawOffers).To(BeEmpty())
+
+		Expect(request(http.MethodPost, "/games/"+id+"/draw", map[string]string{"colour": "Black"}, nil)).
+			To(Equal(http.StatusOK))
+		Expect(request(http.MethodPost, "/games/"+id+"/draw", map[string]string{"colour": "white"}, &state)).
+			To(Equal(http.StatusOK))
+		Expect(state.Outcome).To(Equal(Outcome{Finished: true, Result: "Draw by agreement"}))
+	})
+
+	It("restores games from the store", func() {
+		id := create("standard", "").ID
+		Expect(request(http.MethodPost, "/games/"+id+"/moves", map[string]string{"move": "e2-e4"}, nil)).
+			To(Equal(http.StatusOK))
+		Expect(request(http.MethodPost, "/games/"+id+"/resign", map[string]string{"colour": "black"}, nil)).
+			To(Equal(http.StatusOK))
+
+		record, err := store.Load(id)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(record.Actions).To(Equal([]Action{
+			{Type: MoveAction, Move: "e2-e4", Notation: LongAlgebraic},
+			{Type: ResignAction, Colour: "black"},
+		}))
+
+		restored := httptest.NewServer(New(store, StandardVariants()))
+		defer restored.Close()
+		resp, err := http.Get(restored.URL + "/games/" + id)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		var state State
+		Expect(json.NewDecoder(resp.Body).Decode(&state)).To(Succeed())
+		Expect(state.Moves).To(Equal([]string{"e2-e4"}))
+		Expect(state.Outcome.Result).To(Equal("White won by resignation"))
+	})
+
+	It("streams events over WebSocket", func() {
+		id := create("standard", `3qk3/8/8/8/8/8/5PPP/6K1 b - - 0 1`).ID
+
+		conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+		fmt.Fprintf(conn, "GET /games/%s/ws HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+			"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n", id)
+		r := bufio.NewReader(conn)
+		resp, err := http.ReadResponse(r, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusSwitchingProtocols))
+		Expect(resp.Header.Get("Sec-WebSocket-Accept")).To(Equal("s3pPLMBiTxaQ9kYGzzhZRbK+xOo="))
+
+		Expect(conn.SetReadDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+		next := func() Event {
+			opcode, payload, err := readFrame(r, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(opcode).To(Equal(byte(opText)))
+			var event Event
+			Expect(json.Unmarshal(payload, &event)).To(Succeed())
+			return event
+		}
+		Expect(next().Type).To(Equal(StateEvent))
+
+		_, err = conn.Write(appendFrame(nil, opPing, []byte("hi"), []byte{1, 2, 3, 4}))
+		Expect(err).NotTo(HaveOccurred())
+		opcode, payload, err := readFrame(r, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(opcode).To(Equal(byte(opPong)))
+		Expect(string(payload)).To(Equal("hi"))
+
+		Expect(request(http.MethodPost, "/games/"+id+"/moves", map[string]string{"move": "d8-d1"}, nil)).
+			To(Equal(http.StatusOK))
+		event := next()
+		Expect(event.Type).To(Equal(MoveAction))
+		Expect(event.Action.Move).To(Equal("d8-d1"))
+		event = next()
+		Expect(event.Type).To(Equal(OutcomeEvent))
+		Expect(event.State.Outcome.Winners).To(Equal([]string{"Black"}))
+
+		_, err = conn.Write(appendFrame(nil, opClose, nil, []byte{1, 2, 3, 4}))
+		Expect(err).NotTo(HaveOccurred())
+		opcode, _, err = readFrame(r, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(opcode).To(Equal(byte(opClose)))
+	})
+
+	It("keeps a game unchanged when its record can't be saved", func() {
+		fs := &failingStore{MemoryStore: NewMemoryStore()}
+		failing := httptest.NewServer(New(fs, StandardVariants()))
+		defer failing.Close()
+
+		var state State
+		body, _ := json.Marshal(map[string]string{"variant": "standard"})
+		resp, err := http.Post(failing.URL+"/games", "application/json", bytes.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(json.NewDecoder(resp.Body).Decode(&state)).To(Succeed())
+		resp.Body.Close()
+
+		fs.fail = true
+		body, _ = json.Marshal(map[string]string{"move": "e2-e4"})
+		resp, err = http.Post(failing.URL+"/games/"+state.ID+"/moves", "application/json", bytes.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusInternalServerError))
+
+		resp, err = http.Get(failing.URL + "/games/" + state.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(json.NewDecoder(resp.Body).Decode(&state)).To(Succeed())
+		resp.Body.Close()
+		Expect(state.Moves).To(BeEmpty())
+		Expect(state.SideToMove).To(Equal("White"))
+
+		fs.fail = false
+		resp, err = http.Post(failing.URL+"/games/"+state.ID+"/moves", "application/json", bytes.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(json.NewDecoder(resp.Body).Decode(&state)).To(Succeed())
+		resp.Body.Close()
+		Expect(state.Moves).To(Equal([]string{"e2-e4"}))
+	})
+
+	It("fails WebSocket connections on unmasked and fragmented client frames", func() {
+		id := create("standard", "").ID
+		testCases := []struct {
+			name  string
+			frame []byte
+		}{
+			{"unmasked", appendFrame(nil, opPing, []byte("hi"), nil)},
+			{"fragmented", func() []byte {
+				frame := appendFrame(nil, opText, []byte("hi"), []byte{1, 2, 3, 4})
+				frame[0] &^= 0x80 // clear FIN bit
+				return frame
+			}()},
+		}
+		for i, testCase := range testCases {
+			By(fmt.Sprintf("Checking testCase %s at index %d...", testCase.name, i))
+			conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+			Expect(err).NotTo(HaveOccurred())
+			fmt.Fprintf(conn, "GET /games/%s/ws HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\n"+
+				"Connection: Upgrade\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n"+
+				"Sec-WebSocket-Version: 13\r\n\r\n", id)
+			r := bufio.NewReader(conn)
+			resp, err := http.ReadResponse(r, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusSwitchingProtocols))
+			Expect(conn.SetReadDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+			opcode, _, err := readFrame(r, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(opcode).To(Equal(byte(opText)))
+
+			_, err = conn.Write(testCase.frame)
+			Expect(err).NotTo(HaveOccurred())
+			opcode, payload, err := readFrame(r, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(opcode).To(Equal(byte(opClose)))
+			Expect(binary.BigEndian.Uint16(payload)).To(Equal(uint16(closeProtocolError)))
+			_, _, err = readFrame(r, false)
+			Expect(err).To(HaveOccurred(), "connection is closed")
+			conn.Close()
+		}
+	})
+})
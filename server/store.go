@@ -0,0 +1,74 @@
+package server
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrNotFound is returned when a game is not found
+var ErrNotFound = errors.New("game not found")
+
+// action types
+const (
+	MoveAction   = "move"
+	ResignAction = "resign"
+	DrawAction   = "draw"
+)
+
+// Action is a recorded game action: a move, a resignation or a draw offer
+type Action struct {
+	Type     string `json:"type"`
+	Colour   string `json:"colour,omitempty"`
+	Move     string `json:"move,omitempty"`
+	Notation string `json:"notation,omitempty"`
+}
+
+// Record is a persistent game record, a game is restored by replaying actions from its initial position
+type Record struct {
+	ID       string   `json:"id"`
+	Variant  string   `json:"variant"`
+	Position string   `json:"position"`
+	Actions  []Action `json:"actions"`
+}
+
+// Copy returns a deep copy of a record
+func (r Record) Copy() Record {
+	r.Actions = append([]Action{}, r.Actions...)
+	return r
+}
+
+// Store persists game records
+type Store interface {
+	// Save creates or replaces a record
+	Save(r Record) error
+	// Load returns a record by id or ErrNotFound
+	Load(id string) (Record, error)
+}
+
+// MemoryStore is an in-memory Store
+type MemoryStore struct {
+	sync.RWMutex
+	records map[string]Record
+}
+
+// NewMemoryStore returns a new empty in-memory store
+func NewMemoryStore() *MemoryStore { return &MemoryStore{records: make(map[string]Record)} }
+
+// Save creates or replaces a record
+func (s *MemoryStore) Save(r Record) error {
+	s.Lock()
+	defer s.Unlock()
+	s.records[r.ID] = r.Copy()
+	return nil
+}
+
+// Load returns a record by id or ErrNotFound
+func (s *MemoryStore) Load(id string) (Record, error) {
+	s.RLock()
+	defer s.RUnlock()
+	r, exists := s.records[id]
+	if !exists {
+		return Record{}, ErrNotFound
+	}
+	return r.Copy(), nil
+}
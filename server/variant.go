@@ -0,0 +1,120 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/mtfelian/mtfchess/base"
+	"github.com/mtfelian/mtfchess/cube"
+	"github.com/mtfelian/mtfchess/hex"
+	"github.com/mtfelian/mtfchess/rect"
+)
+
+// LongAlgebraic is a name of long algebraic notation, like "e2-e4"
+const LongAlgebraic = "long"
+
+// ErrPositionNotSupported is returned when a variant can't be started from a custom position
+var ErrPositionNotSupported = errors.New("variant does not support custom positions")
+
+// Variant describes a chess variant a game can be created with
+type Variant struct {
+	// New returns a board with the given position, empty position means the starting one
+	New func(position string) (base.IBoard, error)
+
+	// Position returns a position of a board in the format accepted by New
+	Position func(b base.IBoard) string
+
+	// Notations maps names of notations accepted for moves to notation constructors
+	Notations map[string]func(b base.IBoard) base.INotation
+}
+
+// Variants maps variant names to variants
+type Variants map[string]Variant
+
+// StandardVariants returns all variants of packages rect, hex and cube
+func StandardVariants() Variants {
+	rectNotations := map[string]func(base.IBoard) base.INotation{
+		LongAlgebraic: func(base.IBoard) base.INotation { return rect.NewLongAlgebraicNotation() },
+	}
+	hexNotations := map[string]func(base.IBoard) base.INotation{
+		LongAlgebraic: func(b base.IBoard) base.INotation { return hex.NewLongAlgebraicNotation(b.Dim()) },
+	}
+	cubeNotations := map[string]func(base.IBoard) base.INotation{
+		LongAlgebraic: func(b base.IBoard) base.INotation { return cube.NewLongAlgebraicNotation(b.Dim()) },
+	}
+
+	return Variants{
+		"standard":         rectVariant(rect.StandardChessBoardSettings, rect.NewStandardChessStartingPosition(), rectNotations),
+		"cylinder":         rectVariant(rect.CylinderChessBoardSettings, noCastling, rectNotations),
+		"toroidal":         rectVariant(rect.ToroidalChessBoardSettings, noCastling, rectNotations),
+		"fourplayer":       fourPlayerVariant(false, rectNotations),
+		"fourplayer-teams": fourPlayerVariant(true, rectNotations),
+		"glinski":          hexVariant(hex.GlinskiSettings, hex.NewGlinskiStartingPosition(), hexNotations),
+		"mccooey":          hexVariant(hex.McCooeySettings, hex.NewMcCooeyStartingPosition(), hexNotations),
+		"shafran":          hexVariant(hex.ShafranSettings, hex.NewShafranStartingPosition(), hexNotations),
+		"raumschach":       cubeVariant(cube.RaumschachSettings, cube.NewRaumschachStartingPosition(), cubeNotations),
+	}
+}
+
+// noCastling is a starting position of standard chess without castling flags
+const noCastling = rect.XFEN(`rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w - - 0 1`)
+
+// rectVariant returns a variant on rectangular board with X-FEN positions
+func rectVariant(settings func() *base.Settings, start rect.XFEN,
+	notations map[string]func(base.IBoard) base.INotation) Variant {
+	opts := rect.DefaultXFENOptions()
+	opts.Settings = settings
+	return Variant{
+		New: func(position string) (base.IBoard, error) {
+			if position == "" {
+				position = string(start)
+			}
+			return rect.XFEN(position).BoardWithOptions(opts)
+		},
+		Position:  func(b base.IBoard) string { return string(rect.NewXFENWithOptions(b.(*rect.Board), opts)) },
+		Notations: notations,
+	}
+}
+
+// fourPlayerVariant returns a variant of four-player chess, it starts only from the starting position
+func fourPlayerVariant(teams bool, notations map[string]func(base.IBoard) base.INotation) Variant {
+	return Variant{
+		New: func(position string) (base.IBoard, error) {
+			if position != "" {
+				return nil, ErrPositionNotSupported
+			}
+			return rect.NewFourPlayerChessBoard(teams), nil
+		},
+		Position:  func(b base.IBoard) string { return b.Position() },
+		Notations: notations,
+	}
+}
+
+// hexVariant returns a variant on hexagonal board with FEN positions
+func hexVariant(settings func() *base.Settings, start hex.FEN,
+	notations map[string]func(base.IBoard) base.INotation) Variant {
+	return Variant{
+		New: func(position string) (base.IBoard, error) {
+			if position == "" {
+				position = string(start)
+			}
+			return hex.FEN(position).BoardWithSettings(settings())
+		},
+		Position:  func(b base.IBoard) string { return string(hex.NewFEN(b.(*hex.Board))) },
+		Notations: notations,
+	}
+}
+
+// cubeVariant returns a variant on three-dimensional board with FEN positions
+func cubeVariant(settings func() *base.Settings, start cube.FEN,
+	notations map[string]func(base.IBoard) base.INotation) Variant {
+	return Variant{
+		New: func(position string) (base.IBoard, error) {
+			if position == "" {
+				position = string(start)
+			}
+			return cube.FEN(position).BoardWithSettings(settings())
+		},
+		Position:  func(b base.IBoard) string { return string(cube.NewFEN(b.(*cube.Board))) },
+		Notations: notations,
+	}
+}
@@ -0,0 +1,234 @@
+package server
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebSocket opcodes, see RFC 6455
+const (
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xa
+)
+
+const (
+	wsGUID         = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	wsMaxPayload   = 1 << 16
+	wsWriteTimeout = 10 * time.Second
+)
+
+// WebSocket close status codes, see RFC 6455
+const (
+	closeProtocolError = 1002
+	closeTooLarge      = 1009
+)
+
+var (
+	// errFrameTooLarge is returned when a received frame exceeds wsMaxPayload
+	errFrameTooLarge = errors.New("websocket frame is too large")
+	// errUnmaskedFrame is returned when a client frame is not masked
+	errUnmaskedFrame = errors.New("websocket client frame is not masked")
+	// errFragmentedFrame is returned on a fragmented message, fragments are not supported
+	errFragmentedFrame = errors.New("websocket fragmented frames are not supported")
+	// errReservedBits is returned when a frame has reserved bits set without a negotiated extension
+	errReservedBits = errors.New("websocket frame has reserved bits set")
+)
+
+// wsConn is a server side WebSocket connection
+type wsConn struct {
+	sync.Mutex // serializes writes
+	conn       net.Conn
+	r          *bufio.Reader
+	seq        int // sequence number of the last written event
+}
+
+// upgrade switches an HTTP connection to the WebSocket protocol
+func upgrade(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") ||
+		key == "" {
+		return nil, errors.New("not a websocket handshake")
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("connection can't be hijacked")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha1.Sum([]byte(key + wsGUID))
+	response := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(hash[:]) + "\r\n\r\n"
+	if _, err := conn.Write([]byte(response)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{conn: conn, r: rw.Reader, seq: -1}, nil
+}
+
+// headerContains returns true if a comma separated header contains a token, case insensitive
+func headerContains(h http.Header, name, token string) bool {
+	for _, value := range h[http.CanonicalHeaderKey(name)] {
+		for _, s := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(s), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeFrame writes a single unmasked frame
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.Lock()
+	defer c.Unlock()
+	if err := c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout)); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(appendFrame(nil, opcode, payload, nil))
+	return err
+}
+
+// writeEvent writes an event payload of sequence number seq in a text frame. Events are broadcast
+// after a game is unlocked, so an event older than the last written one is dropped: its state is outdated.
+func (c *wsConn) writeEvent(seq int, payload []byte) error {
+	c.Lock()
+	defer c.Unlock()
+	if seq < c.seq {
+		return nil
+	}
+	c.seq = seq
+	if err := c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout)); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(appendFrame(nil, opText, payload, nil))
+	return err
+}
+
+// appendFrame appends a final frame to buf, payload is masked if mask is not nil
+func appendFrame(buf []byte, opcode byte, payload []byte, mask []byte) []byte {
+	buf = append(buf, 0x80|opcode)
+	var maskBit byte
+	if mask != nil {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n < 126:
+		buf = append(buf, maskBit|byte(n))
+	case n <= 0xffff:
+		buf = append(buf, maskBit|126, 0, 0)
+		binary.BigEndian.PutUint16(buf[len(buf)-2:], uint16(n))
+	default:
+		buf = append(buf, maskBit|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(buf[len(buf)-8:], uint64(n))
+	}
+	if mask == nil {
+		return append(buf, payload...)
+	}
+	buf = append(buf, mask...)
+	for i, b := range payload {
+		buf = append(buf, b^mask[i%4])
+	}
+	return buf
+}
+
+// readFrame reads a single final frame and returns its opcode and unmasked payload.
+// Set mustMask to true to read client frames, which must be masked.
+func readFrame(r io.Reader, mustMask bool) (byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+	fin, opcode, masked, n := header[0]&0x80 != 0, header[0]&0x0f, header[1]&0x80 != 0, uint64(header[1]&0x7f)
+	switch {
+	case header[0]&0x70 != 0:
+		return 0, nil, errReservedBits
+	case !fin || opcode == 0:
+		return 0, nil, errFragmentedFrame
+	case mustMask && !masked:
+		return 0, nil, errUnmaskedFrame
+	}
+	switch n {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err := io.ReadFull(r, ext); err != nil {
+			return 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		if _, err := io.ReadFull(r, ext); err != nil {
+			return 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext)
+	}
+	if n > wsMaxPayload {
+		return 0, nil, errFrameTooLarge
+	}
+
+	mask := make([]byte, 4)
+	if masked {
+		if _, err := io.ReadFull(r, mask); err != nil {
+			return 0, nil, err
+		}
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return opcode, payload, nil
+}
+
+// serve reads client frames until the connection is closed, answering pings and close frames.
+// Messages from clients are ignored: they act through the REST API. The connection fails
+// with a close frame on a protocol error.
+func (c *wsConn) serve() {
+	defer c.conn.Close()
+	for {
+		opcode, payload, err := readFrame(c.r, true)
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		switch opcode {
+		case opPing:
+			if c.writeFrame(opPong, payload) != nil {
+				return
+			}
+		case opClose:
+			c.writeFrame(opClose, nil)
+			return
+		}
+	}
+}
+
+// fail sends a close frame with a status code for a read error err if it's a protocol error
+func (c *wsConn) fail(err error) {
+	code := closeProtocolError
+	switch err {
+	case errFrameTooLarge:
+		code = closeTooLarge
+	case errUnmaskedFrame, errFragmentedFrame, errReservedBits:
+	default:
+		return
+	}
+	payload := make([]byte, 2)
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	c.writeFrame(opClose, payload)
+}
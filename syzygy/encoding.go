@@ -0,0 +1,134 @@
+package syzygy
+
+// Squares of Syzygy tables are numbered from a1 = 0 to h8 = 63 by ranks, pieces are coded
+// from a white pawn = 1 to a white king = 6, black pieces are coded as white ones plus 8.
+const (
+	pawnCode   = 1
+	kingCode   = 6
+	blackFlag  = 8
+	squaresNum = 64
+	fileD      = 3
+)
+
+// tables encoding squares of pieces into indexes of positions, they are filled by init
+var (
+	mapPawns      [squaresNum]int     // squares a2-h7 to 0..47, the leading pawn has the maximum value
+	mapB1H1H7     [squaresNum]int     // squares below the a1-h8 diagonal to 0..27
+	mapA1D1D4     [squaresNum]int     // squares of the a1-d1-d4 triangle to 0..9, diagonal squares are last
+	mapKK         [10][squaresNum]int // legal positions of two kings, the first one is in the a1-d1-d4 triangle
+	binomial      [6][squaresNum]uint64
+	leadPawnIdx   [6][squaresNum]uint64 // by a number of leading pawns and a square of the leading pawn
+	leadPawnsSize [6][4]uint64          // by a number of leading pawns and a file a..d
+)
+
+// file returns a file of square s from 0 to 7
+func file(s int) int { return s & 7 }
+
+// rank returns a rank of square s from 0 to 7
+func rank(s int) int { return s >> 3 }
+
+// offA1H8 returns a distance of square s from the a1-h8 diagonal, it's negative below the diagonal
+func offA1H8(s int) int { return rank(s) - file(s) }
+
+// flipFile mirrors square s from the h-file to the a-file
+func flipFile(s int) int { return s ^ 7 }
+
+// flipRank mirrors square s from the 8th rank to the 1st one
+func flipRank(s int) int { return s ^ 56 }
+
+// queenside returns file f mirrored to files a..d
+func queenside(f int) int {
+	if f > fileD {
+		return 7 - f
+	}
+	return f
+}
+
+// adjacent returns true if squares s1 and s2 are equal or kings on them would attack each other
+func adjacent(s1, s2 int) bool {
+	df, dr := file(s1)-file(s2), rank(s1)-rank(s2)
+	return df >= -1 && df <= 1 && dr >= -1 && dr <= 1
+}
+
+func init() {
+	code := 0
+	for s := 0; s < squaresNum; s++ {
+		if offA1H8(s) < 0 {
+			mapB1H1H7[s] = code
+			code++
+		}
+	}
+
+	diagonal := []int{}
+	code = 0
+	for s := 0; s <= 27; s++ {
+		switch {
+		case offA1H8(s) < 0 && file(s) <= fileD:
+			mapA1D1D4[s] = code
+			code++
+		case offA1H8(s) == 0 && file(s) <= fileD:
+			diagonal = append(diagonal, s)
+		}
+	}
+	for _, s := range diagonal {
+		mapA1D1D4[s] = code
+		code++
+	}
+
+	// kings both on the diagonal are encoded last, the second king isn't above the diagonal
+	// if the first one is on it
+	type kings struct{ idx, s int }
+	bothOnDiagonal := []kings{}
+	code = 0
+	for idx := 0; idx < 10; idx++ {
+		for s1 := 0; s1 <= 27; s1++ {
+			if mapA1D1D4[s1] != idx || idx == 0 && s1 != 1 { // b1 is mapped to 0
+				continue
+			}
+			for s2 := 0; s2 < squaresNum; s2++ {
+				switch {
+				case adjacent(s1, s2), offA1H8(s1) == 0 && offA1H8(s2) > 0:
+				case offA1H8(s1) == 0 && offA1H8(s2) == 0:
+					bothOnDiagonal = append(bothOnDiagonal, kings{idx, s2})
+				default:
+					mapKK[idx][s2] = code
+					code++
+				}
+			}
+		}
+	}
+	for _, k := range bothOnDiagonal {
+		mapKK[k.idx][k.s] = code
+		code++
+	}
+
+	binomial[0][0] = 1
+	for n := 1; n < squaresNum; n++ {
+		for k := 0; k < 6 && k <= n; k++ {
+			if k > 0 {
+				binomial[k][n] += binomial[k-1][n-1]
+			}
+			if k < n {
+				binomial[k][n] += binomial[k][n-1]
+			}
+		}
+	}
+
+	available := 47 // squares available for other pawns when the leading pawn is on a2
+	for n := 1; n <= 5; n++ {
+		for f := 0; f <= fileD; f++ {
+			idx := uint64(0)
+			for r := 1; r <= 6; r++ {
+				s := r*8 + f
+				if n == 1 {
+					mapPawns[s] = available
+					mapPawns[flipFile(s)] = available - 1
+					available -= 2
+				}
+				leadPawnIdx[n][s] = idx
+				idx += binomial[n-1][mapPawns[s]]
+			}
+			leadPawnsSize[n][f] = idx
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package syzygy
+
+import (
+	"fmt"
+
+	"github.com/mtfelian/mtfchess/base"
+	"github.com/mtfelian/mtfchess/rect"
+)
+
+// move is a legal move with a board after it
+type move struct {
+	board   *rect.Board
+	capture bool
+	zeroing bool // a capture or a pawn move
+}
+
+// moves returns legal moves of the side to move on b
+func moves(b *rect.Board) []move {
+	notation, n := rect.NewLongAlgebraicNotation(), len(b.FindPieces(base.PieceFilter{}))
+	res := []move{}
+	for _, m := range b.LegalMoves(notation) {
+		next := b.Copy().(*rect.Board)
+		next.SetHalfMoveCount(0)
+		if makeMove, err := notation.DecodeMove(next, m); err == nil && makeMove() {
+			res = append(res, move{
+				board:   next,
+				capture: len(next.FindPieces(base.PieceFilter{})) < n,
+				zeroing: next.HalfMoveCount() == 0,
+			})
+		}
+	}
+	return res
+}
+
+// flip returns position p with colours of pieces and the side to move inverted and ranks mirrored
+func (p position) flip() position {
+	res := position{stm: p.stm ^ 1}
+	for s, code := range p.squares {
+		if code != 0 {
+			res.squares[flipRank(s)] = code ^ blackFlag
+		}
+	}
+	return res
+}
+
+// probeTable returns a value stored in a WDL or a DTZ table for a position on b, a DTZ value is
+// in plies for WDL wdl. It returns false if a DTZ table stores positions of the other side to move.
+func (tb *Tablebase) probeTable(b *rect.Board, dtz bool, wdl WDL) (int, bool, error) {
+	p, whiteFirst, blackFirst, err := material(b)
+	if err != nil {
+		return 0, false, err
+	}
+	if whiteFirst == "KvK" {
+		return int(Draw), true, nil
+	}
+
+	// tables are stored with the stronger side as white, and with white to move for equal sides
+	tables := tb.wdl
+	if dtz {
+		tables = tb.dtz
+	}
+	t, flip := tables[whiteFirst], false
+	if t == nil {
+		t, flip = tables[blackFirst], true
+	}
+	if t == nil {
+		return 0, false, fmt.Errorf("%s: %v", whiteFirst, ErrMissingTable)
+	}
+	if flip || t.symmetric && p.stm == 1 {
+		p = p.flip()
+	}
+	value, ok, err := t.probe(p, wdl)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %v", t.material, err)
+	}
+	return value, ok, nil
+}
+
+// search returns a WDL of a position on b searching captures, and pawn moves if zeroingMoves is true,
+// as tables store arbitrary values for positions with winning captures, or with a drawing capture
+// if they are a draw. It returns true if the best move is a capture or a pawn move, or if all moves
+// are searched, so a DTZ table can't be probed.
+func (tb *Tablebase) search(b *rect.Board, zeroingMoves bool) (WDL, bool, error) {
+	best, n := Loss, 0
+	all := moves(b)
+	for _, m := range all {
+		if !m.capture && (!zeroingMoves || !m.zeroing) {
+			continue
+		}
+		n++
+		v, _, err := tb.search(m.board, false)
+		if err != nil {
+			return Draw, false, err
+		}
+		if v = -v; v > best {
+			best = v
+			if v >= Win {
+				return v, true, nil
+			}
+		}
+	}
+
+	// tables don't store values of positions with en passant captures, they are searched
+	searchedAll := n > 0 && n == len(all)
+	value := best
+	if !searchedAll {
+		stored, _, err := tb.probeTable(b, false, Draw)
+		if err != nil {
+			return Draw, false, err
+		}
+		value = WDL(stored)
+	}
+	if best >= value {
+		return best, best > Draw || searchedAll, nil
+	}
+	return value, false, nil
+}
+
+// dtzBeforeZeroing returns a DTZ of a position with WDL wdl where the best move is a capture or a pawn move
+func dtzBeforeZeroing(wdl WDL) int {
+	return [...]int{-1, -101, 0, 101, 1}[wdl+2]
+}
+
+// sign returns -1, 0 or 1 by a sign of n
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	}
+	return 0
+}
+
+// probeDTZ returns a distance to zeroing the half-move counter of a position on b in plies: it's 0 for
+// a draw and -1 for a checkmated side, values over 100 by an absolute value are cursed wins and blessed losses.
+// It can be one ply more than the actual distance, as tables store moves for some positions.
+func (tb *Tablebase) probeDTZ(b *rect.Board) (int, error) {
+	wdl, zeroing, err := tb.search(b, true)
+	switch {
+	case err != nil:
+		return 0, err
+	case wdl == Draw:
+		return 0, nil
+	case zeroing:
+		return dtzBeforeZeroing(wdl), nil
+	}
+
+	dtz, stored, err := tb.probeTable(b, true, wdl)
+	if err != nil {
+		return 0, err
+	}
+	if stored {
+		if wdl == BlessedLoss || wdl == CursedWin {
+			dtz += 100
+		}
+		return dtz * sign(int(wdl)), nil
+	}
+
+	// a table stores positions of the other side to move, so the best move minimizing DTZ is searched
+	minDTZ := 0xffff
+	for _, m := range moves(b) {
+		if m.zeroing {
+			v, _, err := tb.search(m.board, false)
+			if err != nil {
+				return 0, err
+			}
+			dtz = -dtzBeforeZeroing(v)
+		} else {
+			if dtz, err = tb.probeDTZ(m.board); err != nil {
+				return 0, err
+			}
+			dtz = -dtz
+		}
+		if dtz == 1 && m.board.InCheckmate(m.board.SideToMove()) {
+			minDTZ = 1
+		}
+		if !m.zeroing {
+			dtz += sign(dtz)
+		}
+		if dtz < minDTZ && sign(dtz) == sign(int(wdl)) {
+			minDTZ = dtz
+		}
+	}
+	if minDTZ == 0xffff {
+		return -1, nil
+	}
+	return minDTZ, nil
+}
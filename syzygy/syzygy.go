@@ -0,0 +1,250 @@
+// Package syzygy probes Syzygy endgame tablebases for standard 8x8 chess positions with up to 7 pieces.
+// Tables are read from local WDL (.rtbw) and DTZ (.rtbz) files at first probe of their material.
+//
+// Tables don't store values of positions which are decided by captures or pawn moves,
+// so probing searches them first like Stockfish does, and tables of materials left
+// after captures are needed too.
+package syzygy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	"github.com/mtfelian/mtfchess/rect"
+)
+
+// MaxPieces is the maximum number of pieces of positions of Syzygy tables
+const MaxPieces = 7
+
+// extensions of table files
+const (
+	WDLExt = ".rtbw"
+	DTZExt = ".rtbz"
+)
+
+// magics start table files
+var (
+	wdlMagic = []byte{0x71, 0xe8, 0x23, 0x5d}
+	dtzMagic = []byte{0xd7, 0x66, 0x0c, 0xa5}
+)
+
+var (
+	// ErrNotStandard is returned for positions which are not standard 8x8 chess positions
+	ErrNotStandard = errors.New("not a standard 8x8 chess position")
+	// ErrTooManyPieces is returned for positions with more than MaxPieces pieces
+	ErrTooManyPieces = fmt.Errorf("more than %d pieces", MaxPieces)
+	// ErrCastling is returned for positions with castling rights, they are not in tables
+	ErrCastling = errors.New("position has castling rights")
+	// ErrMissingTable is returned if there is no table file for material of a position
+	ErrMissingTable = errors.New("missing table")
+)
+
+// materialRegexp matches materials of table file names like "KRPvKR"
+var materialRegexp = regexp.MustCompile(`^K[QRBNP]*vK[QRBNP]*$`)
+
+// pieceLetters are letters and codes of pieces in materials by piece names in the order of Syzygy file names
+var pieceLetters = []struct {
+	name, letter string
+	code         byte
+}{
+	{base.KingName, "K", 6}, {base.QueenName, "Q", 5}, {base.RookName, "R", 4},
+	{base.BishopName, "B", 3}, {base.KnightName, "N", 2}, {base.PawnName, "P", 1},
+}
+
+// WDL is a value of a position for the side to move taking the 50 moves rule into account
+type WDL int
+
+const (
+	Loss        WDL = iota - 2 // the side to move loses
+	BlessedLoss                // the side to move loses without the 50 moves rule, but it's a draw with it
+	Draw                       // neither side can win
+	CursedWin                  // the side to move wins without the 50 moves rule, but it's a draw with it
+	Win                        // the side to move wins
+)
+
+// String makes WDL to implement fmt.Stringer
+func (v WDL) String() string {
+	return [...]string{"loss", "blessed loss", "draw", "cursed win", "win"}[v+2]
+}
+
+// Result is a result of probing a position
+type Result struct {
+	WDL WDL
+	// DTZ is a distance to zeroing the half-move counter by a capture or a pawn move in plies,
+	// it's negative for losses and 0 for draws
+	DTZ int
+}
+
+// Tablebase is a set of Syzygy table files in a directory
+type Tablebase struct {
+	wdl, dtz map[string]*table // by materials
+}
+
+// Open opens table files in dir, it checks that files are Syzygy tables of at most MaxPieces pieces
+func Open(dir string) (*Tablebase, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	tb := &Tablebase{wdl: map[string]*table{}, dtz: map[string]*table{}}
+	for _, f := range files {
+		ext := filepath.Ext(f.Name())
+		material := strings.TrimSuffix(f.Name(), ext)
+		if f.IsDir() || ext != WDLExt && ext != DTZExt || !materialRegexp.MatchString(material) {
+			continue
+		}
+		if len(material)-1 > MaxPieces {
+			return nil, fmt.Errorf("%s: %v", f.Name(), ErrTooManyPieces)
+		}
+		tables, magic := tb.wdl, wdlMagic
+		if ext == DTZExt {
+			tables, magic = tb.dtz, dtzMagic
+		}
+		path := filepath.Join(dir, f.Name())
+		if err := checkMagic(path, magic); err != nil {
+			return nil, fmt.Errorf("%s: %v", f.Name(), err)
+		}
+		tables[material] = newTable(path, material, ext == DTZExt)
+	}
+	return tb, nil
+}
+
+// Close closes files of tables probed
+func (tb *Tablebase) Close() error {
+	var res error
+	for _, tables := range []map[string]*table{tb.wdl, tb.dtz} {
+		for _, t := range tables {
+			if err := t.close(); err != nil && res == nil {
+				res = err
+			}
+		}
+	}
+	return res
+}
+
+// checkMagic returns an error if a file at path doesn't start with magic
+func checkMagic(path string, magic []byte) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data := make([]byte, len(magic))
+	if _, err := io.ReadFull(f, data); err != nil || !bytes.Equal(data, magic) {
+		return errors.New("not a Syzygy table")
+	}
+	return nil
+}
+
+// Materials returns sorted materials of tables having both WDL and DTZ files
+func (tb *Tablebase) Materials() []string {
+	res := []string{}
+	for material := range tb.wdl {
+		if _, exists := tb.dtz[material]; exists {
+			res = append(res, material)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// material returns a position on board and materials of its pieces: with white pieces first and
+// with black pieces first, as a table is named by the stronger side first
+func material(b *rect.Board) (p position, whiteFirst, blackFirst string, err error) {
+	if b.Dim() != (rect.Coord{X: 8, Y: 8}) || b.Settings().Name != rect.StandardSettingsName {
+		return p, "", "", ErrNotStandard
+	}
+	pieces := b.FindPieces(base.PieceFilter{})
+	if len(pieces) > MaxPieces {
+		return p, "", "", ErrTooManyPieces
+	}
+	if strings.Split(string(rect.NewXFEN(b)), " ")[2] != "-" {
+		return p, "", "", ErrCastling
+	}
+
+	sides, n := map[Colour]string{}, 0
+	for _, letter := range pieceLetters {
+		for _, piece := range pieces {
+			if piece.Name() != letter.name {
+				continue
+			}
+			code, c := letter.code, piece.Coord().(rect.Coord)
+			if piece.Colour() == Black {
+				code |= blackFlag
+			}
+			p.squares[(c.Y-1)*8+c.X-1] = code
+			sides[piece.Colour()] += letter.letter
+			n++
+		}
+	}
+	if n != len(pieces) {
+		return p, "", "", ErrNotStandard
+	}
+	if b.SideToMove() == Black {
+		p.stm = 1
+	}
+	return p, sides[White] + "v" + sides[Black], sides[Black] + "v" + sides[White], nil
+}
+
+// Probe returns a value of a position on board and its distance to zeroing the half-move counter.
+// The distance doesn't take the half-move counter of board into account.
+func (tb *Tablebase) Probe(b *rect.Board) (Result, error) {
+	_, whiteFirst, blackFirst, err := material(b)
+	if err != nil {
+		return Result{}, err
+	}
+	if whiteFirst == "KvK" {
+		return Result{WDL: Draw}, nil
+	}
+	if tb.wdl[whiteFirst] == nil && tb.wdl[blackFirst] == nil || tb.dtz[whiteFirst] == nil && tb.dtz[blackFirst] == nil {
+		return Result{}, fmt.Errorf("%s: %v", whiteFirst, ErrMissingTable)
+	}
+
+	// moves are searched on a board with a reset half-move counter, so they don't finish the game
+	xfen := strings.Split(string(rect.NewXFEN(b)), " ")
+	xfen[4] = "0"
+	board, err := rect.XFEN(strings.Join(xfen, " ")).Board()
+	if err != nil {
+		return Result{}, err
+	}
+	b = board.(*rect.Board)
+
+	wdl, _, err := tb.search(b, false)
+	if err != nil {
+		return Result{}, err
+	}
+	dtz, err := tb.probeDTZ(b)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{WDL: wdl, DTZ: dtz}, nil
+}
+
+// Adjudicate finishes a game on board by a result of probing its position: a win or a loss of the side
+// to move finishes it by its win or loss, all other values finish it by a draw.
+// It returns an error and leaves the game unfinished if the position can't be probed.
+func (tb *Tablebase) Adjudicate(b *rect.Board) error {
+	res, err := tb.Probe(b)
+	if err != nil {
+		return err
+	}
+	switch res.WDL {
+	case Win:
+		b.Adjudicate(b.SideToMove())
+	case Loss:
+		b.Adjudicate(b.SideToMove().Invert())
+	default:
+		b.Adjudicate(Transparent)
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package syzygy_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestSyzygy(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Syzygy Suite")
+}
@@ -0,0 +1,199 @@
+package syzygy_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	"github.com/mtfelian/mtfchess/rect"
+	"github.com/mtfelian/mtfchess/syzygy"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// board returns a board of X-FEN
+func board(xfen rect.XFEN) *rect.Board {
+	b, err := xfen.Board()
+	Expect(err).NotTo(HaveOccurred())
+	return b.(*rect.Board)
+}
+
+var _ = Describe("syzygy", func() {
+	var dir string
+
+	// writeTable writes a table file name starting with data
+	writeTable := func(name string, data ...byte) {
+		Expect(ioutil.WriteFile(filepath.Join(dir, name), data, 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "syzygy")
+		Expect(err).NotTo(HaveOccurred())
+		writeTable("KRvK.rtbw", 0x71, 0xe8, 0x23, 0x5d, 0)
+		writeTable("KRvK.rtbz", 0xd7, 0x66, 0x0c, 0xa5, 0)
+		writeTable("KQvK.rtbw", 0x71, 0xe8, 0x23, 0x5d, 0)
+		writeTable("README.txt")
+	})
+
+	AfterEach(func() { os.RemoveAll(dir) })
+
+	It("opens tables", func() {
+		tb, err := syzygy.Open(dir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tb.Materials()).To(Equal([]string{"KRvK"}))
+	})
+
+	It("rejects invalid table files", func() {
+		writeTable("KBvK.rtbz", 0x71, 0xe8, 0x23, 0x5d)
+		_, err := syzygy.Open(dir)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects tables of too many pieces", func() {
+		writeTable("KQRBNvKQR.rtbw", 0x71, 0xe8, 0x23, 0x5d)
+		_, err := syzygy.Open(dir)
+		Expect(err).To(MatchError(ContainSubstring(syzygy.ErrTooManyPieces.Error())))
+	})
+
+	Context("probing", func() {
+		var tb *syzygy.Tablebase
+		BeforeEach(func() {
+			var err error
+			tb, err = syzygy.Open(dir)
+			Expect(err).NotTo(HaveOccurred())
+		})
+		AfterEach(func() { Expect(tb.Close()).To(Succeed()) })
+
+		It("probes two kings as a draw", func() {
+			res, err := tb.Probe(board("8/8/3k4/8/8/4K3/8/8 w - - 0 1"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(Equal(syzygy.Result{WDL: syzygy.Draw}))
+		})
+
+		It("rejects positions not in tables", func() {
+			for xfen, expected := range map[rect.XFEN]error{
+				rect.NewStandardChessStartingPosition(): syzygy.ErrTooManyPieces,
+				"r3k3/8/8/8/8/8/8/4K3 b q - 0 1":        syzygy.ErrCastling,
+				"4k3/8/8/8/8/8/8/4KA2 w - - 0 1":        syzygy.ErrNotStandard,
+				"4k3/8/8/8/8/8/8/4K3 w - - 0 1":         nil,
+				"4k3/8/8/8/8/8/8/4KQ2 w - - 0 1":        syzygy.ErrMissingTable,
+				"4k3/8/8/8/8/8/8/3rK3 w - - 0 1":        syzygy.ErrCorrupted,
+				"4k3/8/8/8/8/8/8/3RK3 w - - 0 1":        syzygy.ErrCorrupted,
+				"k1K5/8/8/8/8/8/8/8/8/8 w - - 0 1":      syzygy.ErrNotStandard,
+			} {
+				_, err := tb.Probe(board(xfen))
+				if expected == nil {
+					Expect(err).NotTo(HaveOccurred(), string(xfen))
+					continue
+				}
+				Expect(err).To(MatchError(ContainSubstring(expected.Error())), string(xfen))
+			}
+		})
+
+		It("adjudicates games", func() {
+			b := board("8/8/3k4/8/8/4K3/8/8 w - - 0 1")
+			Expect(tb.Adjudicate(b)).To(Succeed())
+			Expect(b.Outcome()).To(Equal(base.NewAdjudication(Transparent)))
+			Expect(b.Outcome().String()).To(Equal("Draw by adjudication"))
+
+			b = board("4k3/8/8/8/8/8/8/3RK3 w - - 0 1")
+			Expect(tb.Adjudicate(b)).NotTo(Succeed())
+			Expect(b.Outcome().IsFinished()).To(BeFalse())
+		})
+	})
+
+	It("probes tables of single values searching captures and moves of the other side", func() {
+		// a win for white and a loss for black to move, wins are 7 moves to zeroing for white to move
+		writeTable("KRvK.rtbw", 0x71, 0xe8, 0x23, 0x5d, 1, 0, 0x66, 0x44, 0xee, 0, 128, 4, 128, 0)
+		writeTable("KRvK.rtbz", 0xd7, 0x66, 0x0c, 0xa5, 1, 0, 0x66, 0x44, 0xee, 0, 130, 1, 3, 5, 7, 9, 0, 0, 0, 0)
+		tb, err := syzygy.Open(dir)
+		Expect(err).NotTo(HaveOccurred())
+		defer tb.Close()
+
+		for xfen, expected := range map[rect.XFEN]syzygy.Result{
+			"4k3/8/8/8/8/8/8/R3K3 w - - 0 1": {WDL: syzygy.Win, DTZ: 15},
+			"4k3/8/8/8/8/8/8/R3K3 b - - 0 1": {WDL: syzygy.Loss, DTZ: -16},
+			"r3k3/8/8/8/8/8/8/4K3 b - - 0 1": {WDL: syzygy.Win, DTZ: 15},
+			"8/8/8/8/8/8/6kR/K7 b - - 0 1":   {WDL: syzygy.Draw},
+		} {
+			res, err := tb.Probe(board(xfen))
+			Expect(err).NotTo(HaveOccurred(), string(xfen))
+			Expect(res).To(Equal(expected), string(xfen))
+		}
+	})
+
+	Context("with real tables", func() {
+		var tb *syzygy.Tablebase
+		BeforeEach(func() {
+			path := os.Getenv("SYZYGY_PATH")
+			for _, material := range []string{"KQvK", "KRvK", "KBvK", "KNvK", "KPvK", "KQvKR"} {
+				for _, ext := range []string{syzygy.WDLExt, syzygy.DTZExt} {
+					if _, err := os.Stat(filepath.Join(path, material+ext)); path == "" || err != nil {
+						Skip("SYZYGY_PATH has no 3-piece tables and the KQvKR table")
+					}
+				}
+			}
+			var err error
+			tb, err = syzygy.Open(path)
+			Expect(err).NotTo(HaveOccurred())
+		})
+		AfterEach(func() { Expect(tb.Close()).To(Succeed()) })
+
+		// probe returns a result of probing a position
+		probe := func(xfen rect.XFEN) syzygy.Result {
+			res, err := tb.Probe(board(xfen))
+			Expect(err).NotTo(HaveOccurred(), string(xfen))
+			return res
+		}
+
+		// won returns 1 for a win of the side to move without the 50 moves rule, -1 for a loss and 0 for a draw
+		won := func(v syzygy.WDL) int { return map[syzygy.WDL]int{-2: -1, -1: -1, 1: 1, 2: 1}[v] }
+
+		It("probes KRvK", func() {
+			Expect(probe("4k3/8/4K3/8/8/8/8/R7 w - - 0 1")).To(Equal(syzygy.Result{WDL: syzygy.Win, DTZ: 1}))
+			Expect(probe("8/8/8/8/8/5k2/6R1/K7 b - - 0 1")).To(Equal(syzygy.Result{WDL: syzygy.Draw}))
+			res := probe("8/8/8/8/8/5k2/8/K5R1 w - - 0 1")
+			Expect(res.WDL).To(Equal(syzygy.Win))
+			Expect(res.DTZ).To(BeNumerically(">", 1))
+			res = probe("8/8/8/8/8/5k2/8/K5R1 b - - 0 1")
+			Expect(res.WDL).To(Equal(syzygy.Loss))
+			Expect(res.DTZ).To(BeNumerically("<", 0))
+		})
+
+		It("probes KQvKR", func() {
+			Expect(probe("k7/8/8/8/8/8/8/K2r3Q w - - 0 1")).To(Equal(syzygy.Result{WDL: syzygy.Win, DTZ: 1}))
+			Expect(probe("4k3/8/8/8/8/8/3r4/3QK3 b - - 0 1")).To(Equal(syzygy.Result{WDL: syzygy.Draw}))
+			Expect(probe("8/7k/8/8/8/2Q5/6r1/1K6 w - - 0 1").WDL).To(Equal(syzygy.Win))
+		})
+
+		It("probes KPvK", func() {
+			Expect(probe("k7/8/8/8/8/8/P7/K7 w - - 0 1")).To(Equal(syzygy.Result{WDL: syzygy.Draw}))
+			Expect(probe("7k/8/8/4K3/4P3/8/8/8 w - - 0 1").WDL).To(Equal(syzygy.Win))
+			Expect(probe("7k/8/8/4K3/4P3/8/8/8 b - - 0 1").WDL).To(Equal(syzygy.Loss))
+		})
+
+		It("agrees with values of positions after moves", func() {
+			for _, xfen := range []rect.XFEN{
+				"8/8/8/8/8/5k2/8/K5R1 b - - 0 1",
+				"8/7k/8/8/8/2Q5/6r1/1K6 b - - 0 1",
+				"8/8/8/3k4/8/8/3P4/3K4 w - - 0 1",
+				"8/8/8/3k4/8/8/3P4/3K4 b - - 0 1",
+			} {
+				b, best := board(xfen), -1
+				for _, m := range b.LegalMoves(rect.NewLongAlgebraicNotation()) {
+					next := b.Copy().(*rect.Board)
+					makeMove, err := rect.NewLongAlgebraicNotation().DecodeMove(next, m)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(makeMove()).To(BeTrue())
+					if v := -won(probe(rect.NewXFEN(next)).WDL); v > best {
+						best = v
+					}
+				}
+				Expect(won(probe(xfen).WDL)).To(Equal(best), string(xfen))
+			}
+		})
+	})
+})
@@ -0,0 +1,690 @@
+package syzygy
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrCorrupted is returned for table files which can't be decoded
+var ErrCorrupted = errors.New("corrupted table")
+
+// flags of table data
+const (
+	flagSTM         = 1
+	flagMapped      = 2
+	flagWinPlies    = 4
+	flagLossPlies   = 8
+	flagWide        = 16
+	flagSingleValue = 128
+)
+
+// fileHasPawns is a flag of a table file with pawns
+const fileHasPawns = 2
+
+// pairs are data of a table for a side to move and a file of the leading pawn.
+// Values are compressed by Recursive Pairing and canonical Huffman code into blocks,
+// each block stores a variable number of values.
+type pairs struct {
+	flags      byte
+	minSymLen  int
+	numBlocks  uint64
+	blockSize  uint64   // a size of a block in bytes
+	span       uint64   // about every span values there is a sparse index entry
+	lowestSym  []uint16 // a lowest symbol of each length starting with minSymLen
+	base64     []uint64 // a lowest symbol of each length starting with minSymLen right-padded to 64 bits
+	symLen     []int    // a number of values minus one represented by a symbol
+	tree       []byte   // left and right 12-bit symbols expanding each symbol, 3 bytes by symbol
+	sparse     []byte   // a number of a block and an offset in it of values at span intervals, 6 bytes each
+	sparseSize uint64
+	lengths    []byte // a number of values minus one of each block, 2 bytes each
+	lengthsNum uint64
+	data       int64 // an offset of blocks in a table file
+
+	pieces   [MaxPieces]byte       // codes of pieces in the order of encoding
+	groupIdx [MaxPieces + 1]uint64 // a factor of an index of each group of pieces, the last one is a table size
+	groupLen [MaxPieces + 1]int    // a number of pieces in each group, zero terminated
+	mapIdx   [4]int                // offsets of DTZ values maps by WDL
+}
+
+// left returns a left child of symbol sym, a leaf symbol stores its value there
+func (d *pairs) left(sym int) int { return int(d.tree[3*sym+1]&0xf)<<8 | int(d.tree[3*sym]) }
+
+// right returns a right child of symbol sym, it's 0xfff for a leaf symbol
+func (d *pairs) right(sym int) int { return int(d.tree[3*sym+2])<<4 | int(d.tree[3*sym+1]>>4) }
+
+// table is a WDL or a DTZ table of material read from a file at first probe
+type table struct {
+	path     string
+	material string // with the stronger side first, it's white in table positions
+	dtz      bool
+
+	once   sync.Once
+	err    error
+	f      *os.File
+	items  [2][4]pairs // by a side to move and a file of the leading pawn
+	dtzMap []byte
+
+	pieceCount      int
+	symmetric       bool // both sides have the same pieces
+	hasPawns        bool
+	hasUniquePieces bool // some side has a single piece of a kind except the king
+	pawnCount       [2]int
+}
+
+// newTable returns a table of material with a file at path
+func newTable(path, material string, dtz bool) *table {
+	t := &table{path: path, material: material, dtz: dtz, pieceCount: len(material) - 1}
+	sides := strings.Split(material, "v")
+	t.symmetric = sides[0] == sides[1]
+	t.hasPawns = strings.Contains(material, "P")
+	for _, side := range sides {
+		for _, letter := range "QRBNP" {
+			if strings.Count(side, string(letter)) == 1 {
+				t.hasUniquePieces = true
+			}
+		}
+	}
+
+	// the leading side has less pawns, if both sides have them, for better compression
+	w, b := strings.Count(sides[0], "P"), strings.Count(sides[1], "P")
+	if b == 0 || w > 0 && b >= w {
+		t.pawnCount = [2]int{w, b}
+	} else {
+		t.pawnCount = [2]int{b, w}
+	}
+	return t
+}
+
+// get returns data of a table for side to move stm and file f of the leading pawn
+func (t *table) get(stm, f int) *pairs {
+	if t.dtz || t.symmetric {
+		stm = 0
+	}
+	if !t.hasPawns {
+		f = 0
+	}
+	return &t.items[stm][f]
+}
+
+// open reads data of a table from its file once
+func (t *table) open() error {
+	t.once.Do(func() {
+		if t.f, t.err = os.Open(t.path); t.err != nil {
+			return
+		}
+		if t.err = t.read(&reader{r: t.f}); t.err != nil {
+			t.f.Close()
+			t.f = nil
+		}
+	})
+	return t.err
+}
+
+// close closes a file of a table if it was opened
+func (t *table) close() error {
+	if t.f == nil {
+		return nil
+	}
+	return t.f.Close()
+}
+
+// maxRead is a maximum size of data read at once from a table file
+const maxRead = 1 << 30
+
+// reader reads sequential data of a table file, it keeps the first error
+type reader struct {
+	r   io.ReaderAt
+	off int64
+	err error
+}
+
+// bytes returns next n bytes
+func (r *reader) bytes(n uint64) []byte {
+	if n > maxRead {
+		r.err, n = ErrCorrupted, 0
+	}
+	data := make([]byte, n)
+	if r.err == nil && n > 0 {
+		_, r.err = r.r.ReadAt(data, r.off)
+	}
+	r.off += int64(n)
+	return data
+}
+
+// byte returns a next byte
+func (r *reader) byte() byte { return r.bytes(1)[0] }
+
+// uint16 returns a next little-endian 16-bit number
+func (r *reader) uint16() uint16 { return binary.LittleEndian.Uint16(r.bytes(2)) }
+
+// uint32 returns a next little-endian 32-bit number
+func (r *reader) uint32() uint32 { return binary.LittleEndian.Uint32(r.bytes(4)) }
+
+// align skips bytes up to an offset multiple of n
+func (r *reader) align(n int64) { r.off = (r.off + n - 1) / n * n }
+
+// read reads data of a table, offsets of blocks of values are kept to read them on probes
+func (t *table) read(r *reader) error {
+	magic := r.bytes(4)
+	if string(magic) != string(wdlMagic) && !t.dtz || string(magic) != string(dtzMagic) && t.dtz {
+		return ErrCorrupted
+	}
+	if flags := r.byte(); r.err == nil && (flags&fileHasPawns != 0) != t.hasPawns {
+		return ErrCorrupted
+	}
+
+	sides, files := 2, 1
+	if t.dtz || t.symmetric {
+		sides = 1
+	}
+	if t.hasPawns {
+		files = 4
+	}
+	pp := t.hasPawns && t.pawnCount[1] > 0 // pawns on both sides
+
+	for f := 0; f < files; f++ {
+		order := [2][2]int{{0xf, 0xf}, {0xf, 0xf}}
+		b := r.byte()
+		order[0][0], order[1][0] = int(b&0xf), int(b>>4)
+		if pp {
+			b = r.byte()
+			order[0][1], order[1][1] = int(b&0xf), int(b>>4)
+		}
+		for k := 0; k < t.pieceCount; k++ {
+			b := r.byte()
+			t.items[0][f].pieces[k], t.items[1][f].pieces[k] = b&0xf, b>>4
+		}
+		for i := 0; i < sides; i++ {
+			if err := t.setGroups(&t.items[i][f], order[i], f); err != nil {
+				return err
+			}
+		}
+	}
+	r.align(2)
+
+	for f := 0; f < files; f++ {
+		for i := 0; i < sides; i++ {
+			if err := t.items[i][f].setSizes(r); err != nil {
+				return err
+			}
+		}
+	}
+	if t.dtz {
+		t.readDTZMap(r, files)
+	}
+	for f := 0; f < files; f++ {
+		for i := 0; i < sides; i++ {
+			d := &t.items[i][f]
+			d.sparse = r.bytes(6 * d.sparseSize)
+		}
+	}
+	for f := 0; f < files; f++ {
+		for i := 0; i < sides; i++ {
+			d := &t.items[i][f]
+			d.lengths = r.bytes(2 * d.lengthsNum)
+		}
+	}
+	for f := 0; f < files; f++ {
+		for i := 0; i < sides; i++ {
+			d := &t.items[i][f]
+			r.align(64)
+			d.data = r.off
+			r.off += int64(d.numBlocks * d.blockSize)
+		}
+	}
+	if r.err != nil {
+		return ErrCorrupted
+	}
+	return nil
+}
+
+// setGroups groups pieces encoded together and computes factors of indexes of groups in order.
+// A group is pieces of the same kind and colour except the leading group: it's the leading pawns,
+// or three unique pieces, or two kings.
+func (t *table) setGroups(d *pairs, order [2]int, f int) error {
+	firstLen := 2
+	switch {
+	case t.hasPawns:
+		firstLen = 0
+	case t.hasUniquePieces:
+		firstLen = 3
+	}
+	n := 0
+	d.groupLen[n] = 1
+	for i := 1; i < t.pieceCount; i++ {
+		if firstLen--; firstLen > 0 || d.pieces[i] == d.pieces[i-1] {
+			d.groupLen[n]++
+		} else {
+			n++
+			d.groupLen[n] = 1
+		}
+	}
+	n++
+	d.groupLen[n] = 0
+
+	pp := t.hasPawns && t.pawnCount[1] > 0
+	next, free := 1, squaresNum-d.groupLen[0]
+	if pp {
+		next, free = 2, free-d.groupLen[1]
+	}
+	if t.hasPawns && (d.groupLen[0] > 5 || d.pieces[0]&7 != pawnCode) {
+		return ErrCorrupted
+	}
+	idx := uint64(1)
+	for k := 0; next < n || k == order[0] || k == order[1]; k++ {
+		switch {
+		case k > MaxPieces:
+			return ErrCorrupted
+		case k == order[0]: // leading pawns or pieces
+			d.groupIdx[0] = idx
+			switch {
+			case t.hasPawns:
+				idx *= leadPawnsSize[d.groupLen[0]][f]
+			case t.hasUniquePieces:
+				idx *= 31332
+			default:
+				idx *= 462
+			}
+		case k == order[1]: // remaining pawns
+			d.groupIdx[1] = idx
+			idx *= binomial[d.groupLen[1]][48-d.groupLen[0]]
+		default: // remaining pieces
+			d.groupIdx[next] = idx
+			idx *= binomial[d.groupLen[next]][free]
+			free -= d.groupLen[next]
+			next++
+		}
+	}
+	d.groupIdx[n] = idx
+	return nil
+}
+
+// setSizes reads sizes of blocks and a Huffman code of symbols
+func (d *pairs) setSizes(r *reader) error {
+	if d.flags = r.byte(); d.flags&flagSingleValue != 0 {
+		d.minSymLen = int(r.byte()) // the single value
+		return r.err
+	}
+
+	n := 0
+	for d.groupLen[n] != 0 {
+		n++
+	}
+	tbSize := d.groupIdx[n]
+
+	d.blockSize = 1 << r.byte()
+	d.span = 1 << r.byte()
+	d.sparseSize = (tbSize + d.span - 1) / d.span
+	padding := uint64(r.byte())
+	d.numBlocks = uint64(r.uint32())
+	d.lengthsNum = d.numBlocks + padding
+	maxSymLen, minSymLen := int(r.byte()), int(r.byte())
+	if r.err != nil || minSymLen == 0 || maxSymLen < minSymLen || maxSymLen-minSymLen >= 64-minSymLen {
+		return ErrCorrupted
+	}
+	d.minSymLen = minSymLen
+
+	// longer symbols have lower values in the canonical code, so base64 values of lengths decrease
+	d.lowestSym = make([]uint16, maxSymLen-minSymLen+1)
+	for i := range d.lowestSym {
+		d.lowestSym[i] = r.uint16()
+	}
+	d.base64 = make([]uint64, len(d.lowestSym))
+	for i := len(d.base64) - 2; i >= 0; i-- {
+		d.base64[i] = (d.base64[i+1] + uint64(d.lowestSym[i]) - uint64(d.lowestSym[i+1])) / 2
+	}
+	for i := range d.base64 {
+		d.base64[i] <<= uint(64 - i - minSymLen)
+	}
+
+	symbols := int(r.uint16())
+	d.tree = r.bytes(uint64(3 * symbols))
+	if symbols&1 != 0 {
+		r.bytes(1)
+	}
+	if r.err != nil {
+		return ErrCorrupted
+	}
+	d.symLen = make([]int, symbols)
+	visited := make([]bool, symbols)
+	for sym := range d.symLen {
+		if !visited[sym] {
+			if err := d.setSymLen(sym, visited); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setSymLen computes a number of values represented by symbol sym expanding it recursively
+func (d *pairs) setSymLen(sym int, visited []bool) error {
+	visited[sym] = true
+	right := d.right(sym)
+	if right == 0xfff {
+		return nil
+	}
+	left := d.left(sym)
+	for _, child := range []int{left, right} {
+		if child >= len(d.symLen) {
+			return ErrCorrupted
+		}
+		if !visited[child] {
+			if err := d.setSymLen(child, visited); err != nil {
+				return err
+			}
+		}
+	}
+	d.symLen[sym] = d.symLen[left] + d.symLen[right] + 1
+	return nil
+}
+
+// readDTZMap reads maps of DTZ values sorted by frequency to their values by WDL
+func (t *table) readDTZMap(r *reader, files int) {
+	start := r.off
+	for f := 0; f < files; f++ {
+		d := t.get(0, f)
+		if d.flags&flagMapped == 0 {
+			continue
+		}
+		for i := range d.mapIdx {
+			if d.flags&flagWide != 0 {
+				r.align(2)
+				d.mapIdx[i] = int(r.off-start)/2 + 1
+				r.bytes(2 * uint64(r.uint16()))
+				continue
+			}
+			d.mapIdx[i] = int(r.off-start) + 1
+			r.bytes(uint64(r.byte()))
+		}
+	}
+	r.align(2)
+	end := r.off
+	r.off = start
+	t.dtzMap = r.bytes(uint64(end - start))
+}
+
+// decompress returns a value at index idx
+func (t *table) decompress(d *pairs, idx uint64) (int, error) {
+	if d.flags&flagSingleValue != 0 {
+		return d.minSymLen, nil
+	}
+	if d.span == 0 || idx/d.span >= d.sparseSize {
+		return 0, ErrCorrupted
+	}
+
+	// a sparse index entry k points to a block and an offset in it of a value at k*span + span/2
+	k := idx / d.span
+	block := int64(binary.LittleEndian.Uint32(d.sparse[6*k:]))
+	offset := int64(binary.LittleEndian.Uint16(d.sparse[6*k+4:]))
+	offset += int64(idx%d.span) - int64(d.span/2)
+
+	length := func(block int64) (int64, error) {
+		if block < 0 || uint64(block) >= d.lengthsNum {
+			return 0, ErrCorrupted
+		}
+		return int64(binary.LittleEndian.Uint16(d.lengths[2*block:])), nil
+	}
+	for offset < 0 {
+		block--
+		l, err := length(block)
+		if err != nil {
+			return 0, err
+		}
+		offset += l + 1
+	}
+	for {
+		l, err := length(block)
+		if err != nil {
+			return 0, err
+		}
+		if offset <= l {
+			break
+		}
+		offset -= l + 1
+		block++
+	}
+
+	// symbols are read as big-endian bits, a buffer may be refilled with bytes after a block
+	data := make([]byte, d.blockSize+8)
+	if n, err := t.f.ReadAt(data, d.data+block*int64(d.blockSize)); err != nil && (err != io.EOF || n == 0) {
+		return 0, err
+	}
+	buf, bufSize, pos := binary.BigEndian.Uint64(data), 64, 8
+	var sym int
+	for {
+		l := 0
+		for l < len(d.base64)-1 && buf < d.base64[l] {
+			l++
+		}
+		sym = int((buf-d.base64[l])>>uint(64-l-d.minSymLen)) + int(d.lowestSym[l])
+		if sym >= len(d.symLen) {
+			return 0, ErrCorrupted
+		}
+		if offset < int64(d.symLen[sym])+1 {
+			break
+		}
+		offset -= int64(d.symLen[sym]) + 1
+		l += d.minSymLen
+		buf <<= uint(l)
+		bufSize -= l
+		if bufSize <= 32 {
+			if pos+4 > len(data) {
+				return 0, ErrCorrupted
+			}
+			bufSize += 32
+			buf |= uint64(binary.BigEndian.Uint32(data[pos:])) << uint(64-bufSize)
+			pos += 4
+		}
+	}
+
+	// a symbol expands into pairs of adjacent symbols up to a leaf storing a value
+	for d.symLen[sym] != 0 {
+		left := d.left(sym)
+		if offset < int64(d.symLen[left])+1 {
+			sym = left
+			continue
+		}
+		offset -= int64(d.symLen[left]) + 1
+		sym = d.right(sym)
+	}
+	return d.left(sym), nil
+}
+
+// position is a position of pieces coded by squares and a side to move, 0 for white and 1 for black
+type position struct {
+	squares [squaresNum]byte
+	stm     int
+}
+
+// index returns an index of position p in data of table t and the data, p is with white as the stronger side.
+// It returns false if p has a side to move not stored in a DTZ table.
+func (t *table) index(p position) (*pairs, uint64, int, bool) {
+	var squares, pieces [MaxPieces]int
+	size, leadPawns, tbFile, leadPc := 0, 0, 0, -1
+	if t.hasPawns {
+		leadPc = int(t.get(0, 0).pieces[0])
+		for s := 0; s < squaresNum; s++ {
+			if int(p.squares[s]) == leadPc {
+				squares[size] = s
+				size++
+			}
+		}
+		leadPawns = size
+		lead := 0
+		for i := 1; i < leadPawns; i++ {
+			if mapPawns[squares[i]] > mapPawns[squares[lead]] {
+				lead = i
+			}
+		}
+		squares[0], squares[lead] = squares[lead], squares[0]
+		tbFile = queenside(file(squares[0]))
+	}
+
+	d := t.get(p.stm, tbFile)
+	if t.dtz && int(d.flags&flagSTM) != p.stm && !(t.symmetric && !t.hasPawns) {
+		return nil, 0, 0, false
+	}
+
+	for s := 0; s < squaresNum; s++ {
+		pc := int(p.squares[s])
+		if pc == 0 || pc == leadPc {
+			continue
+		}
+		squares[size], pieces[size] = s, pc
+		size++
+	}
+
+	// pieces are reordered to the sequence of a table
+	for i := leadPawns; i < size-1; i++ {
+		for j := i; j < size; j++ {
+			if pieces[j] == int(d.pieces[i]) {
+				pieces[i], pieces[j] = pieces[j], pieces[i]
+				squares[i], squares[j] = squares[j], squares[i]
+				break
+			}
+		}
+	}
+
+	// the leading piece is mapped to the a1-d1-d4 triangle
+	if file(squares[0]) > fileD {
+		for i := 0; i < size; i++ {
+			squares[i] = flipFile(squares[i])
+		}
+	}
+
+	var idx uint64
+	if t.hasPawns {
+		idx = leadPawnIdx[leadPawns][squares[0]]
+		rest := squares[1:leadPawns]
+		sort.SliceStable(rest, func(i, j int) bool { return mapPawns[rest[i]] < mapPawns[rest[j]] })
+		for i := 1; i < leadPawns; i++ {
+			idx += binomial[i][mapPawns[squares[i]]]
+		}
+	} else {
+		idx = t.leadingPiecesIndex(d, squares[:size])
+	}
+	return d, t.remainingIndex(d, idx, squares[:size]), tbFile, true
+}
+
+// leadingPiecesIndex returns an index of the leading group of a table without pawns, squares are mirrored
+// so that the leading piece is below the 5th rank and the first piece of the group off the a1-h8 diagonal
+// is below it
+func (t *table) leadingPiecesIndex(d *pairs, squares []int) uint64 {
+	if rank(squares[0]) > 3 {
+		for i := range squares {
+			squares[i] = flipRank(squares[i])
+		}
+	}
+	for i := 0; i < d.groupLen[0]; i++ {
+		if offA1H8(squares[i]) == 0 {
+			continue
+		}
+		if offA1H8(squares[i]) > 0 {
+			for j := i; j < len(squares); j++ {
+				squares[j] = (squares[j]>>3 | squares[j]<<3) & 63
+			}
+		}
+		break
+	}
+
+	if !t.hasUniquePieces {
+		return uint64(mapKK[mapA1D1D4[squares[0]]][squares[1]])
+	}
+	s0, s1, s2 := squares[0], squares[1], squares[2]
+	adjust1, adjust2 := 0, 0
+	if s1 > s0 {
+		adjust1++
+	}
+	if s2 > s0 {
+		adjust2++
+	}
+	if s2 > s1 {
+		adjust2++
+	}
+	switch {
+	case offA1H8(s0) != 0:
+		return uint64((mapA1D1D4[s0]*63+s1-adjust1)*62 + s2 - adjust2)
+	case offA1H8(s1) != 0:
+		return uint64((6*63+rank(s0)*28+mapB1H1H7[s1])*62 + s2 - adjust2)
+	case offA1H8(s2) != 0:
+		return uint64(6*63*62 + 4*28*62 + rank(s0)*7*28 + (rank(s1)-adjust1)*28 + mapB1H1H7[s2])
+	}
+	return uint64(6*63*62 + 4*28*62 + 4*7*28 + rank(s0)*7*6 + (rank(s1)-adjust1)*6 + rank(s2) - adjust2)
+}
+
+// remainingIndex adds indexes of groups of pieces after the leading one to its index idx.
+// Pieces of a group are encoded by their squares in ascending order skipping squares of previous groups.
+func (t *table) remainingIndex(d *pairs, idx uint64, squares []int) uint64 {
+	idx *= d.groupIdx[0]
+	start := d.groupLen[0]
+	remainingPawns := t.hasPawns && t.pawnCount[1] > 0
+	for next := 1; d.groupLen[next] != 0; next++ {
+		group := squares[start : start+d.groupLen[next]]
+		sort.Ints(group)
+		n := uint64(0)
+		for i, s := range group {
+			adjust := 0
+			for _, prev := range squares[:start] {
+				if s > prev {
+					adjust++
+				}
+			}
+			if remainingPawns {
+				adjust += 8
+			}
+			n += binomial[i+1][s-adjust]
+		}
+		remainingPawns = false
+		idx += n * d.groupIdx[next]
+		start += d.groupLen[next]
+	}
+	return idx
+}
+
+// dtzValue maps value of a DTZ table with WDL wdl and file f of the leading pawn to plies
+func (t *table) dtzValue(f, value int, wdl WDL) (int, error) {
+	d := t.get(0, f)
+	if d.flags&flagMapped != 0 {
+		i := d.mapIdx[[...]int{1, 3, 0, 2, 0}[wdl+2]] + value
+		switch {
+		case d.flags&flagWide != 0 && 2*i+2 <= len(t.dtzMap):
+			value = int(binary.LittleEndian.Uint16(t.dtzMap[2*i:]))
+		case d.flags&flagWide == 0 && i < len(t.dtzMap):
+			value = int(t.dtzMap[i])
+		default:
+			return 0, ErrCorrupted
+		}
+	}
+	if wdl == Win && d.flags&flagWinPlies == 0 || wdl == Loss && d.flags&flagLossPlies == 0 ||
+		wdl == CursedWin || wdl == BlessedLoss {
+		value *= 2
+	}
+	return value + 1, nil
+}
+
+// probe returns a value stored in table t for position p, for a DTZ table it's in plies for WDL wdl.
+// It returns false if p has a side to move not stored in a DTZ table.
+func (t *table) probe(p position, wdl WDL) (int, bool, error) {
+	if err := t.open(); err != nil {
+		return 0, false, err
+	}
+	d, idx, f, ok := t.index(p)
+	if !ok {
+		return 0, false, nil
+	}
+	value, err := t.decompress(d, idx)
+	if err != nil {
+		return 0, false, err
+	}
+	if !t.dtz {
+		return value - 2, true, nil
+	}
+	value, err = t.dtzValue(f, value, wdl)
+	return value, err == nil, err
+}
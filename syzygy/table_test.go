@@ -0,0 +1,272 @@
+package syzygy
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("table", func() {
+	// transforms are mirrors of squares keeping values of positions without pawns, the first one is identity
+	transforms := []func(s int) int{
+		func(s int) int { return s },
+		flipFile,
+		flipRank,
+		func(s int) int { return flipFile(flipRank(s)) },
+	}
+	for _, t := range transforms[:4] {
+		t := t
+		transforms = append(transforms, func(s int) int { s = t(s); return (s>>3 | s<<3) & 63 })
+	}
+
+	// newPosition returns a position of pieces coded by squares
+	newPosition := func(stm int, pieces map[int]byte) position {
+		p := position{stm: stm}
+		for s, code := range pieces {
+			p.squares[s] = code
+		}
+		return p
+	}
+
+	// class returns a position mirrored by transforms to the least one
+	class := func(p position, transforms []func(s int) int) string {
+		res := ""
+		for _, t := range transforms {
+			var squares [squaresNum]byte
+			for s, code := range p.squares {
+				squares[t(s)] = code
+			}
+			if key := string(squares[:]); res == "" || key < res {
+				res = key
+			}
+		}
+		return res
+	}
+
+	// newIndexTable returns a table of material with pieces in order of encoding for all files
+	newIndexTable := func(material string, pieces ...byte) *table {
+		t := newTable("", material, false)
+		order := [2]int{0, 0xf}
+		if t.hasPawns && t.pawnCount[1] > 0 {
+			order[1] = 1
+		}
+		for f := 0; f < 4; f++ {
+			d := &t.items[0][f]
+			copy(d.pieces[:], pieces)
+			Expect(t.setGroups(d, order, f)).To(Succeed())
+		}
+		return t
+	}
+
+	// checkIndexes checks that positions are encoded to indexes less than a table size,
+	// and that only mirrored positions have equal indexes
+	checkIndexes := func(t *table, positions []position) {
+		transforms := transforms
+		if t.hasPawns {
+			transforms = transforms[:2]
+		}
+		classes := map[[2]uint64]string{}
+		for _, p := range positions {
+			d, idx, f, ok := t.index(p)
+			Expect(ok).To(BeTrue())
+			n := 0
+			for d.groupLen[n] != 0 {
+				n++
+			}
+			Expect(idx).To(BeNumerically("<", d.groupIdx[n]))
+			key, c := [2]uint64{uint64(f), idx}, class(p, transforms)
+			if prev, exists := classes[key]; exists {
+				Expect(prev).To(Equal(c), "positions of different classes have index %d", idx)
+			}
+			classes[key] = c
+		}
+	}
+
+	// randomPositions returns n random positions of kings on non-adjacent squares and pieces,
+	// pawns are on ranks 2..7
+	randomPositions := func(n int, pieces ...byte) []position {
+		r, res := rand.New(rand.NewSource(1)), []position{}
+		for len(res) < n {
+			squares := map[int]byte{}
+			s := []int{r.Intn(squaresNum), r.Intn(squaresNum)}
+			if adjacent(s[0], s[1]) {
+				continue
+			}
+			squares[s[0]], squares[s[1]] = kingCode, kingCode|blackFlag
+			for _, code := range pieces {
+				for {
+					sq := r.Intn(squaresNum)
+					if _, taken := squares[sq]; !taken && (code&7 != pawnCode || rank(sq) > 0 && rank(sq) < 7) {
+						squares[sq] = code
+						break
+					}
+				}
+			}
+			res = append(res, newPosition(0, squares))
+		}
+		return res
+	}
+
+	It("fills encoding tables", func() {
+		Expect(mapKK[mapA1D1D4[27]][squaresNum-1]).To(Equal(461))
+		Expect(mapA1D1D4[27]).To(Equal(9))
+		Expect(mapB1H1H7[55]).To(Equal(27))
+		Expect(mapPawns[8]).To(Equal(47))
+		Expect(mapPawns[15]).To(Equal(46))
+		Expect(binomial[2][62]).To(Equal(uint64(62 * 61 / 2)))
+		Expect(leadPawnsSize[1]).To(Equal([4]uint64{6, 6, 6, 6}))
+	})
+
+	It("encodes three unique pieces", func() {
+		t, positions := newIndexTable("KRvK", 6, 4, 14), []position{}
+		for wk := 0; wk < squaresNum; wk++ {
+			for bk := 0; bk < squaresNum; bk++ {
+				for wr := 0; wr < squaresNum; wr++ {
+					if !adjacent(wk, bk) && wr != wk && wr != bk {
+						positions = append(positions, newPosition(0, map[int]byte{wk: 6, wr: 4, bk: 14}))
+					}
+				}
+			}
+		}
+		checkIndexes(t, positions)
+	})
+
+	It("encodes kings and groups of pieces", func() {
+		checkIndexes(newIndexTable("KRRvK", 6, 14, 4, 4), randomPositions(50000, 4, 4))
+		checkIndexes(newIndexTable("KNNvKB", 6, 14, 11, 2, 2), randomPositions(50000, 2, 2, 11))
+	})
+
+	It("encodes pawns", func() {
+		checkIndexes(newIndexTable("KPvK", 1, 6, 14), randomPositions(50000, 1))
+		checkIndexes(newIndexTable("KPPvK", 1, 1, 6, 14), randomPositions(50000, 1, 1))
+		checkIndexes(newIndexTable("KPvKP", 1, 9, 6, 14), randomPositions(50000, 1, 9))
+	})
+
+	Context("decompressing", func() {
+		var dir string
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "syzygy")
+			Expect(err).NotTo(HaveOccurred())
+		})
+		AfterEach(func() { os.RemoveAll(dir) })
+
+		It("decodes values coded by Huffman code and pairs of symbols", func() {
+			const size, blockSize, span = 31332, 32, 64
+			value := func(idx int) int { // runs of wins and draws to code them by pairs
+				if idx%7 < 4 {
+					return []int{4, 2}[idx%2]
+				}
+				return idx % 5
+			}
+
+			// symbols 0..3 are coded as 0xx, the leaf symbol 4 as 10, the symbol 5 is a win and a draw coded as 11
+			bits, blocks, lengths := []bool{}, [][]bool{}, []int{}
+			starts := []int{}
+			flush := func(n int) {
+				blocks, lengths, bits = append(blocks, bits), append(lengths, n-1), []bool{}
+			}
+			n := 0
+			for idx := 0; idx < size; {
+				code, values := []bool{false, value(idx)&2 != 0, value(idx)&1 != 0}, 1
+				switch {
+				case value(idx) == 4 && idx+1 < size && value(idx+1) == 2:
+					code, values = []bool{true, true}, 2
+				case value(idx) == 4:
+					code = []bool{true, false}
+				}
+				if len(bits)+len(code) > 8*blockSize {
+					flush(n)
+					n = 0
+				}
+				if n == 0 {
+					starts = append(starts, idx)
+				}
+				bits, n, idx = append(bits, code...), n+values, idx+values
+			}
+			flush(n)
+
+			data := []byte{0x71, 0xe8, 0x23, 0x5d, 1, 0, 6 | 6<<4, 4 | 4<<4, 14 | 14<<4, 0}
+			data = append(data, 0, 5, 6, 0)
+			data = append(data, le32(uint32(len(blocks)))...)
+			data = append(data, 3, 2, 4, 0, 0, 0, 6, 0)
+			for sym := 0; sym < 5; sym++ {
+				data = append(data, byte(sym), 0xf0, 0xff)
+			}
+			data = append(data, 4, 0x20, 0)
+			data = append(data, 128, 0) // a single loss for black to move
+			for k := 0; k < (size+span-1)/span; k++ {
+				i, block := k*span+span/2, 0
+				for block+1 < len(starts) && starts[block+1] <= i {
+					block++
+				}
+				data = append(data, le32(uint32(block))...)
+				data = append(data, le16(uint16(i-starts[block]))...)
+			}
+			for _, l := range lengths {
+				data = append(data, le16(uint16(l))...)
+			}
+			for len(data)%64 != 0 {
+				data = append(data, 0)
+			}
+			for _, block := range blocks {
+				b := make([]byte, blockSize)
+				for i, bit := range block {
+					if bit {
+						b[i/8] |= 0x80 >> uint(i%8)
+					}
+				}
+				data = append(data, b...)
+			}
+			path := filepath.Join(dir, "KRvK.rtbw")
+			Expect(ioutil.WriteFile(path, data, 0644)).To(Succeed())
+			Expect(len(blocks)).To(BeNumerically(">", 10))
+
+			t := newTable(path, "KRvK", false)
+			defer t.close()
+			r := rand.New(rand.NewSource(1))
+			for i := 0; i < 20000; i++ {
+				wk, wr, bk := r.Intn(squaresNum), r.Intn(squaresNum), r.Intn(squaresNum)
+				if adjacent(wk, bk) || wr == wk || wr == bk {
+					continue
+				}
+				p := newPosition(i%2, map[int]byte{wk: 6, wr: 4, bk: 14})
+				v, ok, err := t.probe(p, Draw)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ok).To(BeTrue())
+				if p.stm == 1 {
+					Expect(v).To(Equal(int(Loss)))
+					continue
+				}
+				_, idx, _, _ := t.index(p)
+				Expect(v).To(Equal(value(int(idx))-2), "index %d", idx)
+			}
+		})
+
+		It("rejects truncated tables", func() {
+			path := filepath.Join(dir, "KRvK.rtbw")
+			Expect(ioutil.WriteFile(path, []byte{0x71, 0xe8, 0x23, 0x5d, 1, 0, 6, 4, 14}, 0644)).To(Succeed())
+			_, _, err := newTable(path, "KRvK", false).probe(position{}, Draw)
+			Expect(err).To(Equal(ErrCorrupted))
+		})
+	})
+})
+
+// le32 returns n as little-endian bytes
+func le32(n uint32) []byte {
+	res := make([]byte, 4)
+	binary.LittleEndian.PutUint32(res, n)
+	return res
+}
+
+// le16 returns n as little-endian bytes
+func le16(n uint16) []byte {
+	res := make([]byte, 2)
+	binary.LittleEndian.PutUint16(res, n)
+	return res
+}
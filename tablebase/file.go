@@ -0,0 +1,132 @@
+package tablebase
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// FileExt is an extension of table files
+const FileExt = ".mtb"
+
+// fileMagic starts table files
+const fileMagic = "MTFTB\x01"
+
+// fileHeader is a header of a table file following the magic
+type fileHeader struct {
+	Width, Height uint16
+	Size          uint32 // a number of positions
+}
+
+// fileName returns a name of a file of a table of material
+func fileName(dir string, material Material) string {
+	return filepath.Join(dir, material.String()+FileExt)
+}
+
+// Save writes the table and tables of materials left after captures and promotions to files named like "KAvK.mtb"
+// in dir.
+// A file has a header with board dimensions and material, and values of positions: a win in n plies is n,
+// a loss in n plies is -(n+1), a draw is 0, illegal positions are 32767.
+func (t *Table) Save(dir string) error {
+	for _, table := range t.tables {
+		if err := table.save(fileName(dir, table.material)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// save writes a table to file name
+func (t *Table) save(name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := t.write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// write writes a table to w
+func (t *Table) write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(fileMagic + t.material.String() + "\n"); err != nil {
+		return err
+	}
+	header := fileHeader{Width: uint16(t.opts.Width), Height: uint16(t.opts.Height), Size: uint32(len(t.values))}
+	if err := binary.Write(bw, binary.BigEndian, header); err != nil {
+		return err
+	}
+	if err := binary.Write(bw, binary.BigEndian, t.values); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Load reads a table of material written like "KAvK" and tables of materials left after captures and promotions
+// from files in dir.
+// Options should be the same as the ones tables were generated with.
+func Load(dir, material string, opts Options) (*Table, error) {
+	m, err := ParseMaterial(material, opts.Pieces)
+	if err != nil {
+		return nil, err
+	}
+	return load(dir, m, opts, map[string]*Table{})
+}
+
+// load reads a table of material m and tables left after captures and promotions which are not in tables yet
+func load(dir string, m Material, opts Options, tables map[string]*Table) (*Table, error) {
+	if t, exists := tables[m.String()]; exists {
+		return t, nil
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	dependencies, err := m.dependencies(opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range dependencies {
+		if _, err := load(dir, sub, opts, tables); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.Open(fileName(dir, m))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	t := newTable(m, opts, tables)
+	if err := t.read(f); err != nil {
+		return nil, fmt.Errorf("%s: %v", f.Name(), err)
+	}
+	return t, nil
+}
+
+// read reads values of a table from r
+func (t *Table) read(r io.Reader) error {
+	br := bufio.NewReader(r)
+	line, err := br.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if expected := fileMagic + t.material.String() + "\n"; line != expected {
+		return fmt.Errorf("not a table of %s", t.material)
+	}
+	var header fileHeader
+	if err := binary.Read(br, binary.BigEndian, &header); err != nil {
+		return err
+	}
+	if int(header.Width) != t.opts.Width || int(header.Height) != t.opts.Height || int(header.Size) != t.size() {
+		return fmt.Errorf("table of %dx%d board with %d positions doesn't match options",
+			header.Width, header.Height, header.Size)
+	}
+	t.values = make([]int16, header.Size)
+	return binary.Read(br, binary.BigEndian, t.values)
+}
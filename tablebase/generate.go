@@ -0,0 +1,328 @@
+package tablebase
+
+import (
+	"sort"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	"github.com/mtfelian/mtfchess/rect"
+)
+
+// Generate generates a table of material written like "KAvK" and tables of all materials left after captures
+// and promotions
+func Generate(material string, opts Options) (*Table, error) {
+	m, err := ParseMaterial(material, opts.Pieces)
+	if err != nil {
+		return nil, err
+	}
+	return generate(m, opts, map[string]*Table{})
+}
+
+// generate generates a table of material m and tables left after captures and promotions which are not in tables yet
+func generate(m Material, opts Options, tables map[string]*Table) (*Table, error) {
+	if t, exists := tables[m.String()]; exists {
+		return t, nil
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	dependencies, err := m.dependencies(opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range dependencies {
+		if _, err := generate(sub, opts, tables); err != nil {
+			return nil, err
+		}
+	}
+	t := newTable(m, opts, tables)
+	t.resolve(newGenerator(t).successors())
+	return t, nil
+}
+
+// successorValue returns a successor entry for a constant value of a position of another table
+func successorValue(v int16) int32 { return -1 - (int32(v) - int32(unknown)) }
+
+// successorConst returns a constant value of a successor entry e < 0
+func successorConst(e int32) int16 { return int16(-1 - e + int32(unknown)) }
+
+// epPosition is a position of a table after a long pawn move from one cell to another,
+// the pawn can be captured en passant
+type epPosition struct {
+	idx      int
+	from, to rect.Coord
+}
+
+// generator lists successors of positions of a table
+type generator struct {
+	t        *Table
+	b        *rect.Board       // a reused board to set positions up
+	pieces   base.Pieces       // pieces of the table material by indexes of the material
+	epBoard  *rect.Board       // a reused board to check en passant captures
+	epPieces base.Pieces       // pieces on epBoard
+	tokens   map[string]string // registry tokens of pieces to promote to by names
+
+	// positions with en passant captures get indexes after indexes of the table
+	epIndexes   map[epPosition]int
+	epPositions []epPosition
+}
+
+// newGenerator returns a generator of successors of positions of table t
+func newGenerator(t *Table) *generator {
+	g := &generator{t: t, b: t.newBoard(), epBoard: t.newBoard(), tokens: tokensByName(t.opts.Pieces),
+		epIndexes: map[epPosition]int{}}
+	for _, m := range t.material {
+		g.pieces = append(g.pieces, t.opts.Pieces[m.Token](m.Colour))
+		g.epPieces = append(g.epPieces, t.opts.Pieces[m.Token](m.Colour))
+	}
+	return g
+}
+
+// successors returns successors of positions: entries of positions of index i are in list[offsets[i]:offsets[i+1]].
+// An entry is an index of a position of the table or a constant value of a position after capture or promotion
+// encoded by successorValue(). It sets values of illegal positions, checkmates and stalemates, other values
+// are unknown. Positions with en passant captures are appended after positions of the table.
+func (g *generator) successors() (list []int32, offsets []int) {
+	t, n := g.t, g.t.size()
+	t.values, offsets = make([]int16, n), make([]int, 0, n+1)
+	cells := make([]int, len(t.material))
+	for idx := 0; idx < n; idx++ {
+		offsets = append(offsets, len(list))
+		side := t.position(idx, cells)
+		if !t.setUp(g.b, g.pieces, cells, side) || g.b.InCheck(side.Invert()) || g.promotionMissed() {
+			t.values[idx] = illegal
+			continue
+		}
+
+		list = g.appendMoves(list, cells, side)
+		switch {
+		case len(list) > offsets[idx]:
+			t.values[idx] = unknown
+		case g.b.InCheck(side):
+			t.values[idx] = -1 // checkmated
+		default:
+			t.values[idx] = 0 // stalemate
+		}
+	}
+
+	// positions are appended while moves of previous ones are listed, they always have en passant captures
+	for i := 0; i < len(g.epPositions); i++ {
+		offsets = append(offsets, len(list))
+		p := g.epPositions[i]
+		side := t.position(p.idx, cells)
+		t.setUp(g.b, g.pieces, cells, side)
+		g.b.SetCanCaptureEnPassantAt(p.to)
+		g.b.SetEnPassantTargets(passedCells(p.from, p.to))
+		list = g.appendMoves(list, cells, side)
+		g.b.SetCanCaptureEnPassantAt(nil)
+		g.b.SetEnPassantTargets(nil)
+		t.values = append(t.values, unknown)
+	}
+	offsets = append(offsets, len(list))
+	return
+}
+
+// promotionMissed returns true if a piece on the board stands where it should have been promoted
+func (g *generator) promotionMissed() bool {
+	rules := g.b.Settings().PromotionRules
+	for _, piece := range g.pieces {
+		if rules.Kind(g.b, piece, piece.Coord()) == base.MandatoryPromotion {
+			return true
+		}
+	}
+	return false
+}
+
+// appendMoves appends successor entries of moves of side on the board with pieces standing on cells to list
+func (g *generator) appendMoves(list []int32, cells []int, side Colour) []int32 {
+	t, b, next := g.t, g.b, make([]int, len(cells))
+	for i, piece := range g.pieces {
+		if piece.Colour() != side {
+			continue
+		}
+		from := piece.Coord().(rect.Coord)
+		for dst := piece.Destinations(b); dst.HasNext(); {
+			to := dst.Next().(rect.Coord)
+			copy(next, cells)
+			next[i] = t.cellsAt[to]
+			captured := g.captured(piece, to)
+
+			kind := b.Settings().PromotionRules.Kind(b, piece, to)
+			for _, name := range base.PromotionTargets(b, piece, to) {
+				promoted := t.material.promoted(i, Piece{Token: g.tokens[name], Name: name, Colour: side})
+				list = append(list, successorValue(t.otherValue(promoted, next, captured, side.Invert())))
+			}
+			switch {
+			case kind == base.MandatoryPromotion:
+			case captured >= 0:
+				list = append(list, successorValue(t.otherValue(t.material, next, captured, side.Invert())))
+			case g.capturableEnPassant(i, from, to, next):
+				list = append(list, int32(g.epIndex(epPosition{idx: t.index(next, side.Invert()), from: from, to: to})))
+			default:
+				list = append(list, int32(t.index(next, side.Invert())))
+			}
+		}
+	}
+	return list
+}
+
+// captured returns an index of a piece captured by piece going to coords to, it returns -1 if there is no capture
+func (g *generator) captured(piece base.IPiece, to rect.Coord) int {
+	capturedPiece := g.b.Piece(to)
+	if at := g.b.CanCaptureEnPassantAt(); capturedPiece == nil && at != nil && piece.Name() == base.PawnName &&
+		piece.Coord().(rect.Coord).X != to.X && rect.NewCoords(g.b.EnPassantTargets()).Contains(to) {
+		capturedPiece = g.b.Piece(at)
+	}
+	for j := range g.pieces {
+		if capturedPiece != nil && g.pieces[j] == capturedPiece {
+			return j
+		}
+	}
+	return -1
+}
+
+// capturableEnPassant returns true if a piece at index i going from one coords to another is a pawn
+// which can be captured en passant, pieces stand on cells after the move
+func (g *generator) capturableEnPassant(i int, from, to rect.Coord, cells []int) bool {
+	passed := passedCells(from, to)
+	if g.pieces[i].Name() != base.PawnName || from.X != to.X || len(passed) == 0 {
+		return false
+	}
+	side := g.pieces[i].Colour().Invert()
+	g.t.setUp(g.epBoard, g.epPieces, cells, side)
+	g.epBoard.SetCanCaptureEnPassantAt(to)
+	g.epBoard.SetEnPassantTargets(passed)
+	defer func() {
+		g.epBoard.SetCanCaptureEnPassantAt(nil)
+		g.epBoard.SetEnPassantTargets(nil)
+	}()
+	for _, piece := range g.epPieces {
+		if piece.Colour() != side || piece.Name() != base.PawnName {
+			continue
+		}
+		dst := piece.Destinations(g.epBoard)
+		for _, c := range passed {
+			if dst.Contains(c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// epIndex returns an index of position p with en passant captures, it's added if it is a new one
+func (g *generator) epIndex(p epPosition) int {
+	idx, exists := g.epIndexes[p]
+	if !exists {
+		idx = g.t.size() + len(g.epPositions)
+		g.epIndexes[p], g.epPositions = idx, append(g.epPositions, p)
+	}
+	return idx
+}
+
+// passedCells returns cells passed over by a pawn going along a file from one coords to another
+func passedCells(from, to rect.Coord) []base.ICoord {
+	res, step := []base.ICoord{}, 1
+	if to.Y < from.Y {
+		step = -1
+	}
+	for y := from.Y + step; from.X == to.X && y != to.Y; y += step {
+		res = append(res, rect.Coord{X: from.X, Y: y})
+	}
+	return res
+}
+
+// setUp places pieces at cells of a reused board b, it returns false if some pieces share a cell
+func (t *Table) setUp(b *rect.Board, pieces base.Pieces, cells []int, side Colour) bool {
+	for _, piece := range pieces {
+		if piece.Coord() != nil {
+			b.Empty(piece.Coord())
+		}
+	}
+	for i, piece := range pieces {
+		if b.Piece(t.cells[cells[i]]) != nil {
+			return false
+		}
+		b.PlacePiece(t.cells[cells[i]], piece)
+	}
+	b.SetSideToMove(side)
+	return true
+}
+
+// otherValue returns a value of a position of a table of material m, which may be not sorted, without a piece
+// at index captured if it is not negative, cells are cells of pieces of m
+func (t *Table) otherValue(m Material, cells []int, captured int, sideToMove Colour) int16 {
+	if captured >= 0 {
+		m, cells = m.without(captured), append(append([]int{}, cells[:captured]...), cells[captured+1:]...)
+	}
+	order := make([]int, len(m))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return less(m[order[i]], m[order[j]]) })
+	sorted, sortedCells := make(Material, len(m)), make([]int, len(m))
+	for i, j := range order {
+		sorted[i], sortedCells[i] = m[j], cells[j]
+	}
+	sub := t.tables[sorted.String()]
+	return sub.values[sub.index(sortedCells, sideToMove)]
+}
+
+// resolve sets values of unknown positions by retrograde analysis. On each iteration d a position becomes
+// a win in d plies if some move leads to a loss in less than d plies, and it becomes a loss in d plies if
+// all moves lead to wins in less than d plies. Positions left unknown are draws. Values of positions
+// after the table size are dropped.
+func (t *Table) resolve(list []int32, offsets []int) {
+	maxConst := 0 // the longest distance to mate of positions after captures and promotions
+	for _, e := range list {
+		if e < 0 {
+			if _, dtm := decode(successorConst(e)); dtm > maxConst {
+				maxConst = dtm
+			}
+		}
+	}
+
+	for d := 1; ; d++ {
+		changed := false
+		for idx, v := range t.values {
+			if v != unknown {
+				continue
+			}
+			allWins := true
+			for _, e := range list[offsets[idx]:offsets[idx+1]] {
+				nextV := t.successor(e)
+				if nextV == unknown {
+					allWins = false
+					continue
+				}
+				wdl, dtm := decode(nextV)
+				if wdl == Loss && dtm < d {
+					t.values[idx], changed = int16(d), true
+					break
+				}
+				allWins = allWins && wdl == Win && dtm < d
+			}
+			if t.values[idx] == unknown && allWins {
+				t.values[idx], changed = int16(-d-1), true
+			}
+		}
+		if !changed && d > maxConst {
+			break
+		}
+	}
+
+	for idx, v := range t.values {
+		if v == unknown {
+			t.values[idx] = 0
+		}
+	}
+	t.values = t.values[:t.size()]
+}
+
+// successor returns a value of a position of successor entry e
+func (t *Table) successor(e int32) int16 {
+	if e < 0 {
+		return successorConst(e)
+	}
+	return t.values[e]
+}
@@ -0,0 +1,172 @@
+// Package tablebase generates and probes endgame tablebases for rectangular boards by retrograde analysis.
+// Tables are generated for small sets of pieces on boards of any dimensions with any pieces of a piece registry,
+// like a king and an archbishop against a king on 10x8 board. Pawns are promoted by board settings promotion rules,
+// tables of materials after promotions are generated too. Castling rights and move counters are not stored,
+// pawns on start cells are taken as unmoved, en passant captures are taken into account.
+package tablebase
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	"github.com/mtfelian/mtfchess/rect"
+)
+
+// sidesDelimiter separates pieces of white and black sides in material
+const sidesDelimiter = "v"
+
+// Piece is a piece of material
+type Piece struct {
+	Token  string // a lower case token of a piece registry
+	Name   string
+	Colour Colour
+}
+
+// Material is a set of pieces sorted by colours, white pieces go first, then kings go first and then by tokens
+type Material []Piece
+
+// tokensByName returns lower case registry tokens by piece names, shorter tokens are preferred
+func tokensByName(pieces rect.PieceRegistry) map[string]string {
+	res := make(map[string]string, len(pieces))
+	for token, f := range pieces {
+		name := f(White).Name()
+		if t, exists := res[name]; !exists || len(token) < len(t) || len(token) == len(t) && token < t {
+			res[name] = token
+		}
+	}
+	return res
+}
+
+// ParseMaterial parses material written like "KAvK": single letter tokens of pieces of a registry of the white side,
+// "v" and tokens of the black side. Each side should have a single king.
+func ParseMaterial(s string, pieces rect.PieceRegistry) (Material, error) {
+	sides := strings.Split(strings.ToLower(s), sidesDelimiter)
+	if len(sides) != 2 {
+		return nil, fmt.Errorf("material %s should have two sides separated by %s", s, sidesDelimiter)
+	}
+	m := Material{}
+	for i, colour := range []Colour{White, Black} {
+		for _, token := range sides[i] {
+			f, exists := pieces[string(token)]
+			if !exists {
+				return nil, fmt.Errorf("piece %c is not registered", token)
+			}
+			m = append(m, Piece{Token: string(token), Name: f(colour).Name(), Colour: colour})
+		}
+	}
+	return m.sorted(), m.validate()
+}
+
+// NewMaterial returns material of pieces on board
+func NewMaterial(b *rect.Board, pieces rect.PieceRegistry) (Material, error) {
+	tokens, m := tokensByName(pieces), Material{}
+	for _, piece := range b.FindPieces(base.PieceFilter{}) {
+		token, exists := tokens[piece.Name()]
+		if !exists {
+			return nil, fmt.Errorf("piece %s is not registered", piece.Name())
+		}
+		m = append(m, Piece{Token: token, Name: piece.Name(), Colour: piece.Colour()})
+	}
+	return m.sorted(), m.validate()
+}
+
+// less returns true if piece a goes before piece b in material: white pieces go first, then kings and then by tokens
+func less(a, b Piece) bool {
+	switch {
+	case a.Colour != b.Colour:
+		return a.Colour == White
+	case a.Name == base.KingName || b.Name == base.KingName:
+		return a.Name == base.KingName && b.Name != base.KingName
+	}
+	return a.Token < b.Token
+}
+
+// sorted returns m sorted by colours, kings and tokens
+func (m Material) sorted() Material {
+	sort.SliceStable(m, func(i, j int) bool { return less(m[i], m[j]) })
+	return m
+}
+
+// validate returns an error if m is not supported by tables
+func (m Material) validate() error {
+	kings := map[Colour]int{}
+	for _, piece := range m {
+		switch {
+		case piece.Colour != White && piece.Colour != Black:
+			return fmt.Errorf("piece %s of %s side is not supported", piece.Name, piece.Colour)
+		case piece.Name == base.KingName:
+			kings[piece.Colour]++
+		}
+	}
+	if kings[White] != 1 || kings[Black] != 1 {
+		return fmt.Errorf("each side should have a single king")
+	}
+	return nil
+}
+
+// String returns material written like "KAvK"
+func (m Material) String() string {
+	sides := []string{"", ""}
+	for _, piece := range m {
+		if piece.Colour == White {
+			sides[0] += strings.ToUpper(piece.Token)
+		} else {
+			sides[1] += strings.ToUpper(piece.Token)
+		}
+	}
+	return sides[0] + sidesDelimiter + sides[1]
+}
+
+// without returns a copy of m without a piece at index i
+func (m Material) without(i int) Material {
+	return append(append(Material{}, m[:i]...), m[i+1:]...)
+}
+
+// promoted returns a copy of m with a piece at index i replaced by piece, the result may be not sorted
+func (m Material) promoted(i int, piece Piece) Material {
+	res := append(Material{}, m...)
+	res[i] = piece
+	return res
+}
+
+// promotions returns pieces to which a piece at index i of m can be promoted according to settings
+func (m Material) promotions(i int, settings *base.Settings, pieces rect.PieceRegistry) ([]Piece, error) {
+	rule, exists := settings.PromotionRules[m[i].Name]
+	if !exists || rule.ZoneFunc == nil {
+		return nil, nil
+	}
+	names := rule.Targets
+	if len(names) == 0 {
+		names = settings.AllowedPromotions
+	}
+	tokens, res := tokensByName(pieces), []Piece{}
+	for _, name := range names {
+		token, exists := tokens[name]
+		if !exists {
+			return nil, fmt.Errorf("piece %s to promote to is not registered", name)
+		}
+		res = append(res, Piece{Token: token, Name: name, Colour: m[i].Colour})
+	}
+	return res, nil
+}
+
+// dependencies returns materials left after captures and promotions of pieces of m
+func (m Material) dependencies(opts Options) ([]Material, error) {
+	settings, res := opts.Settings(), []Material{}
+	for i := range m {
+		if m[i].Name != base.KingName {
+			res = append(res, m.without(i))
+		}
+		promotions, err := m.promotions(i, settings, opts.Pieces)
+		if err != nil {
+			return nil, err
+		}
+		for _, piece := range promotions {
+			res = append(res, m.promoted(i, piece).sorted())
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,336 @@
+package tablebase
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	"github.com/mtfelian/mtfchess/rect"
+)
+
+// WDL is a game-theoretical value of a position for the side to move
+type WDL int
+
+const (
+	Loss WDL = iota - 1 // the side to move is checkmated with the best play of the opponent
+	Draw                // neither side can force checkmate
+	Win                 // the side to move checkmates with the best play
+)
+
+// String makes WDL to implement fmt.Stringer
+func (v WDL) String() string { return [...]string{"loss", "draw", "win"}[v+1] }
+
+// values of positions: a win in n plies is n, a loss in n plies is -(n+1), a draw is 0
+const (
+	illegal int16 = math.MaxInt16 // the side not to move is in check
+	unknown int16 = math.MinInt16 // a position is not resolved yet
+)
+
+// decode returns a WDL and a distance to mate in plies of a position value
+func decode(v int16) (WDL, int) {
+	switch {
+	case v > 0:
+		return Win, int(v)
+	case v < 0:
+		return Loss, int(-v - 1)
+	}
+	return Draw, 0
+}
+
+// Options are options of generating and loading tables
+type Options struct {
+	Width, Height int
+
+	// Settings returns settings of a board, holes and wrapping are taken into account
+	Settings func() *base.Settings
+
+	// Pieces is a registry of pieces of materials
+	Pieces rect.PieceRegistry
+}
+
+// Result is a result of probing a position
+type Result struct {
+	WDL  WDL
+	DTM  int    // distance to mate in plies, it's 0 for a draw and for a checkmated side
+	Move string // a best move in long algebraic notation, it's empty if there are no moves
+}
+
+// Table is an endgame table of all positions of material with a side to move.
+// It refers to tables of materials left after captures and promotions.
+type Table struct {
+	material Material
+	opts     Options
+	cells    []rect.Coord       // cells which pieces can stand on
+	values   []int16            // values of positions by their indexes
+	tables   map[string]*Table  // this table and tables of materials it refers to by materials
+	cellsAt  map[rect.Coord]int // indexes of cells by coords
+}
+
+// newTable returns a table of material without values
+func newTable(material Material, opts Options, tables map[string]*Table) *Table {
+	t := &Table{material: material, opts: opts, tables: tables, cellsAt: map[rect.Coord]int{}}
+	b := t.newBoard()
+	for y := 1; y <= opts.Height; y++ {
+		for x := 1; x <= opts.Width; x++ {
+			if c := (rect.Coord{X: x, Y: y}); !c.OutOf(b) {
+				t.cellsAt[c] = len(t.cells)
+				t.cells = append(t.cells, c)
+			}
+		}
+	}
+	tables[material.String()] = t
+	return t
+}
+
+// newBoard returns an empty board of the table dimensions and settings
+func (t *Table) newBoard() *rect.Board {
+	return rect.NewEmptyBoard(t.opts.Width, t.opts.Height, t.opts.Settings())
+}
+
+// Material returns the table material
+func (t *Table) Material() Material { return t.material }
+
+// size returns a number of positions
+func (t *Table) size() int {
+	n := 2
+	for range t.material {
+		n *= len(t.cells)
+	}
+	return n
+}
+
+// index returns an index of a position of pieces standing on cells with a side to move
+func (t *Table) index(cells []int, sideToMove Colour) int {
+	idx := 0
+	for i := len(cells) - 1; i >= 0; i-- {
+		idx = idx*len(t.cells) + cells[i]
+	}
+	if sideToMove == Black {
+		return 2*idx + 1
+	}
+	return 2 * idx
+}
+
+// position returns cells of pieces and a side to move of a position by index
+func (t *Table) position(idx int, cells []int) Colour {
+	side := White
+	if idx%2 == 1 {
+		side = Black
+	}
+	idx /= 2
+	for i := range cells {
+		cells[i], idx = idx%len(t.cells), idx/len(t.cells)
+	}
+	return side
+}
+
+// table returns a table of material
+func (t *Table) table(material Material) (*Table, error) {
+	table, exists := t.tables[material.String()]
+	if !exists {
+		return nil, fmt.Errorf("no table for %s", material)
+	}
+	return table, nil
+}
+
+// value returns a value of a position on board, a position with en passant captures is searched one ply deep
+// as tables don't store en passant rights
+func (t *Table) value(b *rect.Board) (int16, error) {
+	material, err := NewMaterial(b, t.opts.Pieces)
+	if err != nil {
+		return 0, err
+	}
+	table, err := t.table(material)
+	if err != nil {
+		return 0, err
+	}
+	if b.Dim() != (rect.Coord{X: t.opts.Width, Y: t.opts.Height}) {
+		return 0, fmt.Errorf("board dimensions differ from table dimensions")
+	}
+
+	cells, used := make([]int, len(material)), map[base.IPiece]bool{}
+	pieces := b.FindPieces(base.PieceFilter{})
+	for i, m := range table.material {
+		for _, piece := range pieces {
+			if !used[piece] && piece.Name() == m.Name && piece.Colour() == m.Colour {
+				used[piece], cells[i] = true, table.cellsAt[piece.Coord().(rect.Coord)]
+				break
+			}
+		}
+	}
+	v := table.values[table.index(cells, b.SideToMove())]
+	if v == illegal || !canCaptureEnPassant(b) {
+		return v, nil
+	}
+	values, _, err := t.moves(b)
+	if err != nil {
+		return 0, err
+	}
+	return valueBefore(values), nil
+}
+
+// canCaptureEnPassant returns true if the side to move on board can capture en passant
+func canCaptureEnPassant(b *rect.Board) bool {
+	if b.CanCaptureEnPassantAt() == nil {
+		return false
+	}
+	for _, piece := range b.FindPieces(base.PieceFilter{Names: []string{base.PawnName},
+		Colours: []Colour{b.SideToMove()}}) {
+		dst := piece.Destinations(b)
+		for _, c := range b.EnPassantTargets() {
+			if dst.Contains(c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// valueBefore returns a value of a position by values of positions after all its moves
+func valueBefore(values []int16) int16 {
+	minLoss, maxWin, allWins := -1, 0, true
+	for _, v := range values {
+		wdl, dtm := decode(v)
+		switch {
+		case wdl == Loss && (minLoss < 0 || dtm < minLoss):
+			minLoss = dtm
+		case wdl == Win && dtm > maxWin:
+			maxWin = dtm
+		}
+		allWins = allWins && wdl == Win
+	}
+	switch {
+	case minLoss >= 0:
+		return int16(minLoss + 1)
+	case allWins:
+		return int16(-maxWin - 2)
+	}
+	return 0
+}
+
+// moves returns values of positions after moves on board and the moves in long algebraic notation,
+// promotions to each piece are separate moves
+func (t *Table) moves(b *rect.Board) ([]int16, []string, error) {
+	values, moves, notation := []int16{}, []string{}, rect.NewLongAlgebraicNotation()
+	for _, piece := range b.FindPieces(base.PieceFilter{Colours: []Colour{b.SideToMove()}}) {
+		for dst := piece.Destinations(b); dst.HasNext(); {
+			to := dst.Next().(rect.Coord)
+			targets := base.PromotionTargets(b, piece, to)
+			if b.Settings().PromotionRules.Kind(b, piece, to) != base.MandatoryPromotion {
+				targets = append(targets, "") // a move without promotion
+			}
+			for _, name := range targets {
+				var promoted base.IPiece
+				if name != "" {
+					promoted = t.opts.Pieces.NewByName(name, piece.Colour())
+				}
+				v, err := t.value(after(b, piece, to, promoted))
+				if err != nil {
+					return nil, nil, err
+				}
+				piece.SetPromote(promoted)
+				values, moves = append(values, v), append(moves, notation.EncodeMove(b, piece, to))
+				piece.SetPromote(nil)
+			}
+		}
+	}
+	return values, moves, nil
+}
+
+// after returns a copy of board b after piece goes to coords to and is promoted to piece promoted if it's not nil.
+// Counters of moves and an outcome are not changed as tables don't store them.
+func after(b *rect.Board, piece base.IPiece, to rect.Coord, promoted base.IPiece) *rect.Board {
+	from := piece.Coord().(rect.Coord)
+	next := b.Project(piece, to).(*rect.Board)
+	if promoted != nil {
+		next.PlacePiece(to, promoted)
+	}
+	if at := b.CanCaptureEnPassantAt(); at != nil && piece.Name() == base.PawnName && b.Piece(to) == nil &&
+		from.X != to.X && rect.NewCoords(b.EnPassantTargets()).Contains(to) {
+		next.Empty(at)
+	}
+	next.SetCanCaptureEnPassantAt(nil)
+	next.SetEnPassantTargets(nil)
+	if passed := passedCells(from, to); piece.Name() == base.PawnName && len(passed) > 0 {
+		next.SetCanCaptureEnPassantAt(to)
+		next.SetEnPassantTargets(passed)
+	}
+	next.SetSideToMove(b.SideToMove().Invert())
+	return next
+}
+
+// Probe returns a value of a position on board and a best move: the fastest mate for a winning side,
+// the longest resistance for a losing side and any drawing move for a draw
+func (t *Table) Probe(b *rect.Board) (Result, error) {
+	v, err := t.value(b)
+	if err != nil {
+		return Result{}, err
+	}
+	if v == illegal {
+		return Result{}, fmt.Errorf("illegal position: side not to move is in check")
+	}
+	wdl, dtm := decode(v)
+	res := Result{WDL: wdl, DTM: dtm}
+
+	values, moves, err := t.moves(b)
+	if err != nil {
+		return Result{}, err
+	}
+	best := int16(0)
+	for i := range values {
+		if res.Move == "" || better(values[i], best) {
+			best, res.Move = values[i], moves[i]
+		}
+	}
+	return res, nil
+}
+
+// better returns true if a position of value a is better than a position of value b for the side
+// which moves to them
+func better(a, b int16) bool {
+	wdlA, dtmA := decode(a)
+	wdlB, dtmB := decode(b)
+	switch {
+	case wdlA != wdlB:
+		return wdlA < wdlB // a loss of an opponent is the best
+	case wdlA == Loss:
+		return dtmA < dtmB // the fastest mate
+	case wdlA == Win:
+		return dtmA > dtmB // the longest resistance
+	}
+	return false
+}
+
+// Stats are numbers of legal positions of a table by their values and sides to move
+type Stats struct {
+	Wins, Draws, Losses map[Colour]int
+	MaxDTM              int // the longest distance to mate in plies
+}
+
+// Stats returns numbers of positions of the table by values
+func (t *Table) Stats() Stats {
+	s := Stats{Wins: map[Colour]int{}, Draws: map[Colour]int{}, Losses: map[Colour]int{}}
+	for idx, v := range t.values {
+		if v == illegal {
+			continue
+		}
+		side := White
+		if idx%2 == 1 {
+			side = Black
+		}
+		wdl, dtm := decode(v)
+		switch wdl {
+		case Win:
+			s.Wins[side]++
+		case Draw:
+			s.Draws[side]++
+		case Loss:
+			s.Losses[side]++
+		}
+		if dtm > s.MaxDTM {
+			s.MaxDTM = dtm
+		}
+	}
+	return s
+}
@@ -0,0 +1,13 @@
+package tablebase_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestTablebase(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Tablebase Suite")
+}
@@ -0,0 +1,231 @@
+package tablebase_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	"github.com/mtfelian/mtfchess/rect"
+	"github.com/mtfelian/mtfchess/tablebase"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// board returns a board of X-FEN
+func board(xfen rect.XFEN) *rect.Board {
+	b, err := xfen.Board()
+	Expect(err).NotTo(HaveOccurred())
+	return b.(*rect.Board)
+}
+
+var _ = Describe("tablebase", func() {
+	opts := tablebase.Options{Width: 5, Height: 6, Settings: rect.StandardChessBoardSettings,
+		Pieces: rect.StandardPieceRegistry()}
+
+	generate := func(material string) *tablebase.Table {
+		t, err := tablebase.Generate(material, opts)
+		Expect(err).NotTo(HaveOccurred())
+		return t
+	}
+
+	Context("material", func() {
+		It("parses material", func() {
+			m, err := tablebase.ParseMaterial("kavk", opts.Pieces)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.String()).To(Equal("KAvK"))
+			Expect(m).To(Equal(tablebase.Material{
+				{Token: "k", Name: "king", Colour: White},
+				{Token: "a", Name: "archbishop", Colour: White},
+				{Token: "k", Name: "king", Colour: Black},
+			}))
+		})
+
+		It("rejects unsupported material", func() {
+			for _, s := range []string{"KRK", "KvKK", "RvK", "KZvK", ""} {
+				_, err := tablebase.ParseMaterial(s, opts.Pieces)
+				Expect(err).To(HaveOccurred(), s)
+			}
+		})
+
+		It("returns material of a board", func() {
+			m, err := tablebase.NewMaterial(board("k4/5/K4/5/5/4R w - - 0 1"), opts.Pieces)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.String()).To(Equal("KRvK"))
+		})
+	})
+
+	It("has only draws for two kings", func() {
+		s := generate("KvK").Stats()
+		Expect(s.Wins).To(BeEmpty())
+		Expect(s.Losses).To(BeEmpty())
+		Expect(s.Draws[White]).To(BeNumerically(">", 0))
+		Expect(s.Draws[Black]).To(Equal(s.Draws[White]))
+		Expect(s.MaxDTM).To(BeZero())
+	})
+
+	Context("KRvK", func() {
+		var t *tablebase.Table
+		BeforeEach(func() {
+			if t == nil {
+				t = generate("KRvK")
+			}
+		})
+
+		It("has wins only for the side with a rook", func() {
+			s := t.Stats()
+			Expect(s.Wins[Black]).To(BeZero())
+			Expect(s.Losses[White]).To(BeZero())
+			Expect(s.Wins[White]).To(BeNumerically(">", 0))
+			Expect(s.Losses[Black]).To(BeNumerically(">", 0))
+			Expect(s.MaxDTM).To(BeNumerically(">", 1))
+		})
+
+		It("finds mate in 1", func() {
+			res, err := t.Probe(board("k4/5/K4/5/5/4R w - - 0 1"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(Equal(tablebase.Result{WDL: tablebase.Win, DTM: 1, Move: "Re1-e6#"}))
+		})
+
+		It("probes checkmate", func() {
+			res, err := t.Probe(board("k3R/5/K4/5/5/5 b - - 0 1"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(Equal(tablebase.Result{WDL: tablebase.Loss}))
+		})
+
+		It("finds the longest resistance", func() {
+			res, err := t.Probe(board("k4/5/K4/5/5/R4 b - - 0 1"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.WDL).To(Equal(tablebase.Loss))
+			Expect(res.DTM).To(BeNumerically(">", 2))
+			Expect(res.Move).To(Equal("Ka6-b6"))
+		})
+
+		It("probes a draw after capture", func() {
+			res, err := t.Probe(board("kR3/5/5/5/5/4K b - - 0 1"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(Equal(tablebase.Result{WDL: tablebase.Draw, Move: "Ka6xb6"}))
+		})
+
+		It("follows the best moves to checkmate", func() {
+			b := board("5/2k2/5/5/5/K3R w - - 0 1")
+			res, err := t.Probe(b)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.WDL).To(Equal(tablebase.Win))
+			for dtm := res.DTM; dtm > 0; dtm-- {
+				res, err := t.Probe(b)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(res.DTM).To(Equal(dtm))
+				makeMove, err := rect.NewLongAlgebraicNotation().DecodeMove(b, res.Move)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(makeMove()).To(BeTrue())
+			}
+			Expect(b.InCheckmate(Black)).To(BeTrue())
+		})
+
+		It("rejects illegal positions and other materials", func() {
+			_, err := t.Probe(board("k4/R4/K4/5/5/5 w - - 0 1"))
+			Expect(err).To(HaveOccurred())
+			_, err = t.Probe(board("k4/5/K4/5/5/4Q w - - 0 1"))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("saves and loads tables", func() {
+			dir, err := ioutil.TempDir("", "tablebase")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			Expect(t.Save(dir)).To(Succeed())
+			Expect(filepath.Join(dir, "KRvK"+tablebase.FileExt)).To(BeAnExistingFile())
+			Expect(filepath.Join(dir, "KvK"+tablebase.FileExt)).To(BeAnExistingFile())
+
+			loaded, err := tablebase.Load(dir, "KRvK", opts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loaded.Stats()).To(Equal(t.Stats()))
+			res, err := loaded.Probe(board("k4/5/K4/5/5/4R w - - 0 1"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(Equal(tablebase.Result{WDL: tablebase.Win, DTM: 1, Move: "Re1-e6#"}))
+
+			otherOpts := opts
+			otherOpts.Width = 6
+			_, err = tablebase.Load(dir, "KRvK", otherOpts)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("KPvK", func() {
+		pawnOpts := opts
+		pawnOpts.Width, pawnOpts.Height = 3, 5
+		var t *tablebase.Table
+		BeforeEach(func() {
+			if t == nil {
+				var err error
+				t, err = tablebase.Generate("KPvK", pawnOpts)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		It("promotes pawns", func() {
+			res, err := t.Probe(board("k2/2P/K2/3/3 w - - 0 1"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(Equal(tablebase.Result{WDL: tablebase.Win, DTM: 1, Move: "c4-c5=R#"}))
+		})
+
+		It("underpromotes pawns to avoid stalemate", func() {
+			res, err := t.Probe(board("3/P1k/K2/3/3 w - - 0 1"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(Equal(tablebase.Result{WDL: tablebase.Win, DTM: 3, Move: "a4-a5=R"}))
+		})
+
+		It("saves and loads tables of promoted material", func() {
+			dir, err := ioutil.TempDir("", "tablebase")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			Expect(t.Save(dir)).To(Succeed())
+			for _, material := range []string{"KPvK", "KQvK", "KRvK", "KBvK", "KNvK", "KvK"} {
+				Expect(filepath.Join(dir, material+tablebase.FileExt)).To(BeAnExistingFile())
+			}
+			loaded, err := tablebase.Load(dir, "KPvK", pawnOpts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loaded.Stats()).To(Equal(t.Stats()))
+			res, err := loaded.Probe(board("3/P1k/K2/3/3 w - - 0 1"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(Equal(tablebase.Result{WDL: tablebase.Win, DTM: 3, Move: "a4-a5=R"}))
+		})
+	})
+
+	It("takes en passant captures into account", func() {
+		pawnOpts := opts
+		pawnOpts.Width, pawnOpts.Height = 2, 5
+		pawnOpts.Settings = func() *base.Settings {
+			s := rect.StandardChessBoardSettings()
+			s.AllowedPromotions = []string{base.QueenName}
+			return s
+		}
+		t, err := tablebase.Generate("KPvKP", pawnOpts)
+		Expect(err).NotTo(HaveOccurred())
+
+		// X-FEN doesn't describe boards of 2 files
+		b := rect.NewEmptyBoard(2, 5, pawnOpts.Settings())
+		b.PlacePiece(rect.Coord{X: 1, Y: 3}, rect.NewKing(White))
+		b.PlacePiece(rect.Coord{X: 2, Y: 2}, rect.NewPawn(White))
+		b.PlacePiece(rect.Coord{X: 1, Y: 4}, rect.NewPawn(Black))
+		b.PlacePiece(rect.Coord{X: 1, Y: 1}, rect.NewKing(Black))
+		b.SetSideToMove(White)
+		makeMove, err := rect.NewLongAlgebraicNotation().DecodeMove(b, "b2-b4")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(makeMove()).To(BeTrue())
+
+		res, err := t.Probe(b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res).To(Equal(tablebase.Result{WDL: tablebase.Draw, Move: "a4-b3"}))
+
+		b.SetCanCaptureEnPassantAt(nil)
+		b.SetEnPassantTargets(nil)
+		res, err = t.Probe(b)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.WDL).To(Equal(tablebase.Loss))
+	})
+})
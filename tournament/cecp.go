@@ -0,0 +1,87 @@
+package tournament
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mtfelian/mtfchess/base"
+)
+
+// cecpThinkingRegexp matches thinking output lines like "9 156 1084 48000 Nf3 Nc6 Nc3"
+var cecpThinkingRegexp = regexp.MustCompile(`^\s*\d+\s+(-?\d+)\s+`)
+
+// cecpEngine is an external engine talking by Chess Engine Communication Protocol (xboard)
+type cecpEngine struct{ p *process }
+
+// NewCECPEngine starts an external CECP engine of protocol version 2, sets its options
+// by "option" commands and a variant by the "variant" command
+func NewCECPEngine(c Command) (Engine, error) {
+	p, err := c.start()
+	if err != nil {
+		return nil, err
+	}
+	e := &cecpEngine{p: p}
+	if err := e.init(c); err != nil {
+		p.close("quit")
+		return nil, err
+	}
+	return e, nil
+}
+
+// init starts a new game
+func (e *cecpEngine) init(c Command) error {
+	e.p.send("xboard")
+	if err := e.p.send("protover 2"); err != nil {
+		return err
+	}
+	if _, err := e.p.expect(c.initTimeout(), func(line string) bool {
+		return strings.HasPrefix(line, "feature") && strings.Contains(line, "done=1")
+	}); err != nil {
+		return err
+	}
+	e.p.send("new")
+	if c.Variant != "" {
+		e.p.send("variant %s", c.Variant)
+	}
+	for name, value := range c.Options {
+		e.p.send("option %s=%s", name, value)
+	}
+	e.p.send("post")
+	return e.p.send("force")
+}
+
+// Move implements Engine
+func (e *cecpEngine) Move(b base.IBoard, notation base.INotation, limit time.Duration) (Move, error) {
+	rb, err := rectBoard(b)
+	if err != nil {
+		return Move{}, err
+	}
+	seconds := int((limit + time.Second - 1) / time.Second)
+	e.p.send("force")
+	e.p.send("setboard %s", fen(rb))
+	e.p.send("st %d", seconds)
+	if err := e.p.send("go"); err != nil {
+		return Move{}, err
+	}
+	res := Move{}
+	line, err := e.p.expect(time.Duration(seconds)*time.Second+moveTimeMargin, func(line string) bool {
+		if m := cecpThinkingRegexp.FindStringSubmatch(line); m != nil {
+			res.Score, _ = strconv.Atoi(m[1])
+			res.HasScore = true
+		}
+		return strings.HasPrefix(line, "move ") || strings.HasPrefix(line, "resign")
+	})
+	if err != nil {
+		return Move{}, err
+	}
+	if strings.HasPrefix(line, "resign") {
+		return Move{}, ErrResigned
+	}
+	res.Move, err = decodeEngineMove(rb, notation, strings.Fields(line)[1])
+	return res, err
+}
+
+// Close implements Engine
+func (e *cecpEngine) Close() error { return e.p.close("quit") }
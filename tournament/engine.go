@@ -0,0 +1,117 @@
+package tournament
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+// MateScore is a score of a checkmate in centipawns
+const MateScore = 100000
+
+// ErrNoMoves is returned by engines asked to move in a position without legal moves
+var ErrNoMoves = errors.New("no legal moves")
+
+// Move is a move chosen by an engine
+type Move struct {
+	Move     string // a move in notation given to an engine
+	Score    int    // a score of a position in centipawns from the side to move point of view
+	HasScore bool   // true if an engine reported a score
+}
+
+// Engine chooses moves. An engine plays a single game at once, it may keep a state between moves of a game.
+type Engine interface {
+	// Move returns a move for a position on board in notation, an engine should move within limit.
+	// A board is a copy which may be changed by an engine.
+	Move(b base.IBoard, notation base.INotation, limit time.Duration) (Move, error)
+
+	// Close releases resources of an engine
+	Close() error
+}
+
+// Player is a tournament participant. New is called for each game, so games may be played concurrently.
+type Player struct {
+	Name string
+	New  func() (Engine, error)
+}
+
+// randomEngine chooses random legal moves
+type randomEngine struct{ rnd *rand.Rand }
+
+// NewRandomEngine returns a built-in engine choosing random legal moves
+func NewRandomEngine(seed int64) Engine { return &randomEngine{rnd: rand.New(rand.NewSource(seed))} }
+
+// Move implements Engine
+func (e *randomEngine) Move(b base.IBoard, notation base.INotation, _ time.Duration) (Move, error) {
+	moves := b.LegalMoves(notation)
+	if len(moves) == 0 {
+		return Move{}, ErrNoMoves
+	}
+	return Move{Move: moves[e.rnd.Intn(len(moves))]}, nil
+}
+
+// Close implements Engine
+func (e *randomEngine) Close() error { return nil }
+
+// PieceValues are values of pieces in centipawns by piece names
+var PieceValues = map[string]int{
+	base.PawnName: 100, base.KnightName: 300, base.BishopName: 300, base.RookName: 500, base.QueenName: 900,
+	base.ArchbishopName: 700, base.ChancellorName: 800, base.UnicornName: 400,
+}
+
+// materialEngine chooses moves winning the most material, it mates in 1 when it can
+type materialEngine struct{ rnd *rand.Rand }
+
+// NewMaterialEngine returns a built-in engine looking one move ahead: it chooses a move with the best
+// material balance after it, random among equal ones, and reports the balance as a score
+func NewMaterialEngine(seed int64) Engine {
+	return &materialEngine{rnd: rand.New(rand.NewSource(seed))}
+}
+
+// material returns a material balance on b from colour point of view
+func material(b base.IBoard, colour Colour) int {
+	balance := 0
+	for _, piece := range b.FindPieces(base.PieceFilter{}) {
+		if piece.Colour() == colour {
+			balance += PieceValues[piece.Name()]
+		} else {
+			balance -= PieceValues[piece.Name()]
+		}
+	}
+	return balance
+}
+
+// Move implements Engine
+func (e *materialEngine) Move(b base.IBoard, notation base.INotation, _ time.Duration) (Move, error) {
+	side, best := b.SideToMove(), []Move{}
+	for _, m := range b.LegalMoves(notation) {
+		next := b.Copy()
+		makeMove, err := notation.DecodeMove(next, m)
+		if err != nil || !makeMove() {
+			continue
+		}
+		score := material(next, side)
+		switch outcome := next.Outcome(); {
+		case outcome.IsFinished() && outcome.Winner == side:
+			score = MateScore
+		case outcome.IsFinished():
+			score = 0
+		}
+		switch {
+		case len(best) == 0 || score > best[0].Score:
+			best = []Move{{Move: m, Score: score, HasScore: true}}
+		case score == best[0].Score:
+			best = append(best, Move{Move: m, Score: score, HasScore: true})
+		}
+	}
+	if len(best) == 0 {
+		return Move{}, ErrNoMoves
+	}
+	return best[e.rnd.Intn(len(best))], nil
+}
+
+// Close implements Engine
+func (e *materialEngine) Close() error { return nil }
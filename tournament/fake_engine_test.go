@@ -0,0 +1,104 @@
+package tournament_test
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/mtfelian/mtfchess/base"
+	"github.com/mtfelian/mtfchess/rect"
+	"github.com/mtfelian/mtfchess/tournament"
+)
+
+// fakeEngineArg is the first argument making the test binary run as a fake external engine
+const fakeEngineArg = "fake-engine"
+
+// fakeEngine returns a command running the test binary as a fake engine of protocol "uci" or "cecp" in mode:
+// "illegal" to answer with an illegal move, "hang" to never answer, otherwise to play normally
+func fakeEngine(protocol, mode string) tournament.Command {
+	return tournament.Command{Path: os.Args[0], Args: []string{fakeEngineArg, protocol, mode}}
+}
+
+// longMoveRegexp matches moves in long algebraic notation
+var longMoveRegexp = regexp.MustCompile(`([a-z]\d+)[-x]([a-z]\d+)(?:=([A-Z]))?`)
+
+// fakeMove returns a move of the side to move on a board of X-FEN in engine format: a castling if there is one,
+// otherwise a promotion if there is one, otherwise the first legal move
+func fakeMove(xfen string) string {
+	board, err := rect.XFEN(xfen).Board()
+	if err != nil {
+		return "0000"
+	}
+	b, notation := board.(*rect.Board), rect.NewLongAlgebraicNotation()
+	moves := b.LegalMoves(notation)
+	for _, castling := range b.Castlings(b.SideToMove()) {
+		for _, m := range moves {
+			if m == notation.EncodeCastling(b, castling) {
+				return coord(castling.Piece[0].Coord()) + coord(castling.To[0])
+			}
+		}
+	}
+	for _, m := range moves {
+		if parts := longMoveRegexp.FindStringSubmatch(m); parts != nil && parts[3] != "" {
+			return parts[1] + parts[2] + strings.ToLower(parts[3])
+		}
+	}
+	for _, m := range moves {
+		if parts := longMoveRegexp.FindStringSubmatch(m); parts != nil {
+			return parts[1] + parts[2]
+		}
+	}
+	return "0000"
+}
+
+// coord returns a coord in algebraic notation
+func coord(c base.ICoord) string {
+	notation := rect.NewLongAlgebraicNotation()
+	notation.SetCoord(c)
+	return notation.EncodeCoord()
+}
+
+// runFakeEngine runs a fake engine of protocol in mode reading commands from stdin
+func runFakeEngine(protocol, mode string) {
+	position := ""
+	answer := func(uci, cecp string) {
+		if protocol == "uci" {
+			fmt.Println(uci)
+		} else {
+			fmt.Println(cecp)
+		}
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		args := strings.Join(fields[1:], " ")
+		switch fields[0] {
+		case "uci":
+			fmt.Println("id name fake\nuciok")
+		case "isready":
+			fmt.Println("readyok")
+		case "protover":
+			fmt.Println("feature setboard=1 myname=\"fake\"\nfeature done=1")
+		case "position":
+			position = strings.TrimPrefix(args, "fen ")
+		case "setboard":
+			position = args
+		case "go":
+			move := fakeMove(position)
+			switch mode {
+			case "hang":
+				continue
+			case "illegal":
+				move = "e2e5"
+			}
+			answer("info depth 1 score cp 10 pv "+move+"\nbestmove "+move, "1 10 0 1 "+move+"\nmove "+move)
+		case "quit":
+			return
+		}
+	}
+}
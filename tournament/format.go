@@ -0,0 +1,161 @@
+package tournament
+
+import "sort"
+
+// Pairing is a pairing of two players by indices, White plays white in the first game of a pairing
+type Pairing struct{ White, Black int }
+
+// Format is a tournament format choosing pairings of rounds
+type Format interface {
+	// Rounds returns a number of rounds of a tournament of n players
+	Rounds(n int) int
+
+	// Pairings returns pairings of a round numbered from 0 and players getting a bye, results are results
+	// of previous rounds. Players neither paired nor getting a bye don't play in a round.
+	Pairings(round int, results *Results) (pairings []Pairing, byes []int)
+}
+
+// RoundRobin is a format where every player meets every other player once in each of Cycles,
+// pairings of rounds are made by Berger tables. Colours of pairings are swapped in even cycles.
+type RoundRobin struct{ Cycles int }
+
+// cycles returns a number of cycles which is at least 1
+func cycles(n int) int {
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// bergerSize returns a number of players of Berger tables for n players, it's even
+func bergerSize(n int) int { return n + n%2 }
+
+// Rounds implements Format
+func (f RoundRobin) Rounds(n int) int {
+	if n < 2 {
+		return 0
+	}
+	return (bergerSize(n) - 1) * cycles(f.Cycles)
+}
+
+// Pairings implements Format. A player paired with a missing player of an odd number of players doesn't play.
+func (f RoundRobin) Pairings(round int, results *Results) ([]Pairing, []int) {
+	n := len(results.Players)
+	size := bergerSize(n)
+	cycle, r := round/(size-1), round%(size-1)
+
+	// the circle method: player size-1 is fixed, others rotate by one place each round
+	at := func(i int) int {
+		if i == size-1 {
+			return size - 1
+		}
+		return (i + r) % (size - 1)
+	}
+	res := []Pairing{}
+	for i := 0; i < size/2; i++ {
+		p := Pairing{White: at(i), Black: at(size - 1 - i)}
+		if i == 0 && r%2 == 1 {
+			p.White, p.Black = p.Black, p.White
+		}
+		if cycle%2 == 1 {
+			p.White, p.Black = p.Black, p.White
+		}
+		if p.White < n && p.Black < n {
+			res = append(res, p)
+		}
+	}
+	return res, nil
+}
+
+// Gauntlet is a format where the first player meets every other player once in each of Cycles,
+// all its pairings of a cycle make a round. The first player plays white in odd cycles.
+type Gauntlet struct{ Cycles int }
+
+// Rounds implements Format
+func (f Gauntlet) Rounds(n int) int {
+	if n < 2 {
+		return 0
+	}
+	return cycles(f.Cycles)
+}
+
+// Pairings implements Format
+func (f Gauntlet) Pairings(round int, results *Results) ([]Pairing, []int) {
+	res := []Pairing{}
+	for i := 1; i < len(results.Players); i++ {
+		if round%2 == 0 {
+			res = append(res, Pairing{White: 0, Black: i})
+		} else {
+			res = append(res, Pairing{White: i, Black: 0})
+		}
+	}
+	return res, nil
+}
+
+// Swiss is a format of a given number of rounds where players with close scores meet each other.
+// Players are sorted by scores and each one is paired with the next one it hasn't met yet, if all
+// were met it's paired with the next one. For an odd number of players the lowest player of ones
+// which got the fewest byes gets a bye. A player which played less games with white gets white.
+type Swiss struct{ NumRounds int }
+
+// Rounds implements Format
+func (f Swiss) Rounds(n int) int {
+	if n < 2 {
+		return 0
+	}
+	return f.NumRounds
+}
+
+// Pairings implements Format
+func (f Swiss) Pairings(round int, results *Results) ([]Pairing, []int) {
+	scores := results.scores()
+	order := make([]int, len(results.Players))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return scores[order[i]] > scores[order[j]] })
+
+	byes := []int{}
+	if len(order)%2 == 1 {
+		had := map[int]int{}
+		for _, player := range results.Byes {
+			had[player.Player]++
+		}
+		i := len(order) - 1
+		for j := i - 1; j >= 0; j-- {
+			if had[order[j]] < had[order[i]] {
+				i = j
+			}
+		}
+		byes = append(byes, order[i])
+		order = append(order[:i:i], order[i+1:]...)
+	}
+
+	whites := results.whites()
+	res, paired := []Pairing{}, map[int]bool{}
+	for i, player := range order {
+		if paired[player] {
+			continue
+		}
+		opponent := -1
+		for _, other := range order[i+1:] {
+			if paired[other] {
+				continue
+			}
+			if opponent < 0 {
+				opponent = other
+			}
+			if results.met(player, other) == 0 {
+				opponent = other
+				break
+			}
+		}
+		paired[player], paired[opponent] = true, true
+		p := Pairing{White: player, Black: opponent}
+		if whites[opponent] < whites[player] {
+			p.White, p.Black = opponent, player
+		}
+		res = append(res, p)
+	}
+	return res, byes
+}
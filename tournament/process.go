@@ -0,0 +1,182 @@
+package tournament
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mtfelian/mtfchess/base"
+	"github.com/mtfelian/mtfchess/rect"
+)
+
+var (
+	// ErrNotRect is returned when external engines are asked to move on boards which are not rectangular
+	ErrNotRect = errors.New("external engines play on rectangular boards only")
+	// ErrTimeout is returned when an external engine doesn't respond in time
+	ErrTimeout = errors.New("engine doesn't respond in time")
+	// ErrResigned is returned when an engine resigns instead of making a move
+	ErrResigned = errors.New("engine resigned")
+)
+
+// engineMoveRegexp matches moves of external engines like "e2e4" or "e7e8q"
+var engineMoveRegexp = regexp.MustCompile(`^([a-z]\d{1,2})([a-z]\d{1,2})([a-z]?)$`)
+
+// DefaultInitTimeout is a default time for an external engine to start
+const DefaultInitTimeout = 10 * time.Second
+
+// moveTimeMargin is an extra time for an external engine to respond with a move
+const moveTimeMargin = time.Second
+
+// Command is a command starting an external engine
+type Command struct {
+	Path    string
+	Args    []string
+	Options map[string]string // engine options set after start, like "Hash" for UCI engines
+	Variant string            // a variant name for engines playing several variants, it's empty for standard chess
+
+	// InitTimeout is a time for an engine to start, DefaultInitTimeout is used if it's 0
+	InitTimeout time.Duration
+}
+
+// process is a running external engine talking by lines of text
+type process struct {
+	cmd   *exec.Cmd
+	in    io.WriteCloser
+	lines chan string // lines written by an engine, it's closed when the engine exits
+}
+
+// start starts an external engine process
+func (c Command) start() (*process, error) {
+	cmd := exec.Command(c.Path, c.Args...)
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	p := &process{cmd: cmd, in: in, lines: make(chan string, 64)}
+	go func() {
+		defer close(p.lines)
+		scanner := bufio.NewScanner(out)
+		for scanner.Scan() {
+			p.lines <- scanner.Text()
+		}
+	}()
+	return p, nil
+}
+
+// initTimeout returns a time for an engine to start
+func (c Command) initTimeout() time.Duration {
+	if c.InitTimeout > 0 {
+		return c.InitTimeout
+	}
+	return DefaultInitTimeout
+}
+
+// send writes a line to an engine
+func (p *process) send(format string, args ...interface{}) error {
+	_, err := fmt.Fprintf(p.in, format+"\n", args...)
+	return err
+}
+
+// expect returns the first line written by an engine within timeout for which match returns true,
+// other lines are passed to match and skipped
+func (p *process) expect(timeout time.Duration, match func(line string) bool) (string, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case line, ok := <-p.lines:
+			if !ok {
+				return "", io.ErrUnexpectedEOF
+			}
+			if match(line) {
+				return line, nil
+			}
+		case <-timer.C:
+			return "", ErrTimeout
+		}
+	}
+}
+
+// close asks an engine to quit with a quit command and kills it if it doesn't exit in time
+func (p *process) close(quit string) error {
+	p.send(quit)
+	p.in.Close()
+	done := make(chan error, 1)
+	go func() { done <- p.cmd.Wait() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(moveTimeMargin):
+		p.cmd.Process.Kill()
+		return <-done
+	}
+}
+
+// rectBoard returns a rectangular board of b
+func rectBoard(b base.IBoard) (*rect.Board, error) {
+	rb, ok := b.(*rect.Board)
+	if !ok {
+		return nil, ErrNotRect
+	}
+	return rb, nil
+}
+
+// fen returns an X-FEN of a board for an external engine
+func fen(b *rect.Board) string { return string(rect.NewXFEN(b)) }
+
+// decodeEngineMove returns a move of an external engine like "e2e4", "e7e8q" or "e1g1" converted to notation.
+// A king move to a cell of a castling partner or by more than one cell is a castling.
+func decodeEngineMove(b *rect.Board, notation base.INotation, move string) (string, error) {
+	m := engineMoveRegexp.FindStringSubmatch(strings.ToLower(move))
+	if m == nil {
+		return "", fmt.Errorf("wrong engine move format: %s", move)
+	}
+	an := rect.NewLongAlgebraicNotation()
+	if err := an.DecodeCoord(m[1]); err != nil {
+		return "", err
+	}
+	from := an.Coord.(rect.Coord)
+	if err := an.DecodeCoord(m[2]); err != nil {
+		return "", err
+	}
+	to := an.Coord.(rect.Coord)
+	piece := b.Piece(from)
+	if piece == nil || piece.Colour() != b.SideToMove() {
+		return "", fmt.Errorf("no piece to move: %s", move)
+	}
+
+	target := b.Piece(to)
+	if piece.Name() == base.KingName && (target != nil && target.Colour() == piece.Colour() ||
+		math.Abs(float64(to.X-from.X)) > 1) {
+		for _, castling := range b.Castlings(piece.Colour()) {
+			if castling.Piece[0].Coord().Equals(from) &&
+				(castling.To[0].Equals(to) || castling.Piece[1].Coord().Equals(to)) {
+				return notation.EncodeCastling(b, castling), nil
+			}
+		}
+		return "", fmt.Errorf("castling is not available: %s", move)
+	}
+
+	if m[3] != "" {
+		f, exists := rect.StandardPieceRegistry()[m[3]]
+		if !exists {
+			return "", fmt.Errorf("wrong promotion piece: %s", move)
+		}
+		piece = piece.Copy()
+		piece.SetPromote(f(piece.Colour()))
+	}
+	return notation.EncodeMove(b, piece, to), nil
+}
@@ -0,0 +1,199 @@
+package tournament
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+)
+
+// ByeScore is a score of a player for a bye
+const ByeScore = 1.0
+
+// GameResult is a result of a game of a tournament
+type GameResult struct {
+	Round        int // a round numbered from 0
+	White, Black int // indices of players
+	Opening      string
+	Moves        []string
+	Outcome      base.Outcome
+	// Termination describes how a game was finished, like "White won by checkmate" or "adjudication: tablebase"
+	Termination string
+}
+
+// Score returns a score of white: 1 for a win, 0.5 for a draw and 0 for a loss
+func (r GameResult) Score() float64 {
+	switch r.Outcome.Winner {
+	case White:
+		return 1
+	case Black:
+		return 0
+	}
+	return 0.5
+}
+
+// Bye is a bye of a player in a round
+type Bye struct{ Round, Player int }
+
+// Results are results of a tournament
+type Results struct {
+	Players []string // names of players
+	Games   []GameResult
+	Byes    []Bye
+}
+
+// scores returns scores of players including byes
+func (r *Results) scores() []float64 {
+	res := make([]float64, len(r.Players))
+	for _, g := range r.Games {
+		res[g.White] += g.Score()
+		res[g.Black] += 1 - g.Score()
+	}
+	for _, bye := range r.Byes {
+		res[bye.Player] += ByeScore
+	}
+	return res
+}
+
+// whites returns numbers of games played with white by players
+func (r *Results) whites() []int {
+	res := make([]int, len(r.Players))
+	for _, g := range r.Games {
+		res[g.White]++
+	}
+	return res
+}
+
+// met returns a number of games between players i and j
+func (r *Results) met(i, j int) int {
+	n := 0
+	for _, g := range r.Games {
+		if g.White == i && g.Black == j || g.White == j && g.Black == i {
+			n++
+		}
+	}
+	return n
+}
+
+// Standing is a result of a player in a tournament
+type Standing struct {
+	Player                     int // an index of a player
+	Name                       string
+	Games, Wins, Draws, Losses int
+	Byes                       int
+	Score                      float64 // a score of games and byes
+	Elo, EloError              float64 // a rating difference to opponents by a score of games and its 95% error margin
+}
+
+// Standings returns standings of players sorted by scores and then by Elo
+func (r *Results) Standings() []Standing {
+	res := make([]Standing, len(r.Players))
+	points := make([][]float64, len(r.Players)) // scores of games by players
+	for i, name := range r.Players {
+		res[i] = Standing{Player: i, Name: name}
+	}
+	add := func(player int, score float64) {
+		s := &res[player]
+		s.Games++
+		s.Score += score
+		switch score {
+		case 1:
+			s.Wins++
+		case 0:
+			s.Losses++
+		default:
+			s.Draws++
+		}
+		points[player] = append(points[player], score)
+	}
+	for _, g := range r.Games {
+		add(g.White, g.Score())
+		add(g.Black, 1-g.Score())
+	}
+	for _, bye := range r.Byes {
+		res[bye.Player].Byes++
+		res[bye.Player].Score += ByeScore
+	}
+	for i := range res {
+		res[i].Elo, res[i].EloError = elo(points[i])
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Score != res[j].Score {
+			return res[i].Score > res[j].Score
+		}
+		return res[i].Elo > res[j].Elo
+	})
+	return res
+}
+
+// eloDiff returns a rating difference giving an expected score p
+func eloDiff(p float64) float64 { return -400 * math.Log10(1/p-1) }
+
+// elo returns a rating difference by scores of games and its 95% error margin. A score of all games won
+// or lost is taken as if a half of a game was drawn, so the difference is finite.
+func elo(scores []float64) (float64, float64) {
+	n := float64(len(scores))
+	if n == 0 {
+		return 0, 0
+	}
+	sum := 0.0
+	for _, s := range scores {
+		sum += s
+	}
+	bound := 0.5 / (n + 1) // the smallest score ratio taken into account
+	clamp := func(p float64) float64 { return math.Max(bound, math.Min(1-bound, p)) }
+	p := sum / n
+
+	deviation := 0.0
+	for _, s := range scores {
+		deviation += (s - p) * (s - p)
+	}
+	margin := 1.96 * math.Sqrt(deviation/n) / math.Sqrt(n)
+	p = clamp(p)
+	return eloDiff(p), (eloDiff(clamp(p+margin)) - eloDiff(clamp(p-margin))) / 2
+}
+
+// Crosstable writes standings with scores of players against each other: a row of a player has its score
+// against a player of a column, "-" if they didn't meet
+func (r *Results) Crosstable(w io.Writer) error {
+	standings := r.Standings()
+	scores := make([][]float64, len(r.Players))
+	met := make([][]bool, len(r.Players))
+	for i := range scores {
+		scores[i], met[i] = make([]float64, len(r.Players)), make([]bool, len(r.Players))
+	}
+	for _, g := range r.Games {
+		scores[g.White][g.Black] += g.Score()
+		scores[g.Black][g.White] += 1 - g.Score()
+		met[g.White][g.Black], met[g.Black][g.White] = true, true
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "#\tPlayer\tGames\tScore\tElo\t+/-\t")
+	for i := range standings {
+		fmt.Fprintf(tw, "%d\t", i+1)
+	}
+	fmt.Fprintln(tw)
+	for i, s := range standings {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%.0f\t%.0f\t", i+1, s.Name, s.Games, formatScore(s.Score), s.Elo, s.EloError)
+		for j, opponent := range standings {
+			switch {
+			case i == j:
+				fmt.Fprint(tw, "*\t")
+			case !met[s.Player][opponent.Player]:
+				fmt.Fprint(tw, "-\t")
+			default:
+				fmt.Fprintf(tw, "%s\t", formatScore(scores[s.Player][opponent.Player]))
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+// formatScore returns a score like "2" or "2.5"
+func formatScore(score float64) string { return fmt.Sprintf("%g", score) }
@@ -0,0 +1,349 @@
+// Package tournament plays tournaments of chess engines: built-in ones and external processes talking
+// by UCI or CECP protocols. Games are played on any boards of two players from opening positions
+// in round-robin, gauntlet or Swiss formats, they may be adjudicated by scores of engines and by
+// tablebases. Results are summarized as standings with Elo estimates and a crosstable.
+package tournament
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	"github.com/mtfelian/mtfchess/rect"
+	"github.com/mtfelian/mtfchess/syzygy"
+	"github.com/mtfelian/mtfchess/tablebase"
+)
+
+var (
+	// ErrMultiPlayer is returned for boards of multi-player games
+	ErrMultiPlayer = errors.New("tournaments of multi-player games are not supported")
+	// ErrPlayers is returned for tournaments of less than two players
+	ErrPlayers = errors.New("at least two players are required")
+)
+
+// DefaultMoveTime is a default time of engines to make a move
+const DefaultMoveTime = 100 * time.Millisecond
+
+// Adjudication are rules finishing games before their natural end, zero values disable them
+type Adjudication struct {
+	// a side loses if its engine reports scores not greater than -ResignScore for ResignMoves moves in a row
+	ResignScore, ResignMoves int
+
+	// a game is drawn if both engines report scores with absolute values not greater than DrawScore
+	// for DrawMoves moves in a row each
+	DrawScore, DrawMoves int
+
+	// a game is drawn after QuietMoves moves of both sides in a row without captures and pawn moves
+	// counted by a half-move counter of a board, it's 50 for the fifty-move rule
+	QuietMoves int
+
+	// a game is drawn after MaxPlies moves of both sides
+	MaxPlies int
+
+	// Tablebase returns a winner of a position, Transparent for a draw, or false if it can't be probed
+	Tablebase func(b base.IBoard) (winner Colour, ok bool)
+}
+
+// TablebaseAdjudicator returns a tablebase adjudication by tables of package tablebase,
+// positions are probed by tables having their material
+func TablebaseAdjudicator(tables ...*tablebase.Table) func(b base.IBoard) (Colour, bool) {
+	return func(b base.IBoard) (Colour, bool) {
+		rb, ok := b.(*rect.Board)
+		if !ok {
+			return Transparent, false
+		}
+		for _, table := range tables {
+			res, err := table.Probe(rb)
+			if err != nil {
+				continue
+			}
+			switch res.WDL {
+			case tablebase.Win:
+				return rb.SideToMove(), true
+			case tablebase.Loss:
+				return rb.SideToMove().Invert(), true
+			}
+			return Transparent, true
+		}
+		return Transparent, false
+	}
+}
+
+// SyzygyAdjudicator returns a tablebase adjudication by Syzygy tables, cursed wins and blessed losses are draws
+func SyzygyAdjudicator(tb *syzygy.Tablebase) func(b base.IBoard) (Colour, bool) {
+	return func(b base.IBoard) (Colour, bool) {
+		rb, ok := b.(*rect.Board)
+		if !ok {
+			return Transparent, false
+		}
+		res, err := tb.Probe(rb)
+		if err != nil {
+			return Transparent, false
+		}
+		switch res.WDL {
+		case syzygy.Win:
+			return rb.SideToMove(), true
+		case syzygy.Loss:
+			return rb.SideToMove().Invert(), true
+		}
+		return Transparent, true
+	}
+}
+
+// Tournament is a tournament of engines
+type Tournament struct {
+	Players []Player
+	Format  Format
+
+	// NewBoard returns a board of an opening position
+	NewBoard func(opening string) (base.IBoard, error)
+	// Openings are opening positions, each pairing plays the next one in turn.
+	// An empty opening is passed to NewBoard if there are no openings.
+	Openings []string
+	// Notation returns a notation of moves of engines on a board
+	Notation func(b base.IBoard) base.INotation
+
+	MoveTime time.Duration
+	// GamesPerPairing is a number of games of each pairing, players swap colours after each game
+	GamesPerPairing int
+	Adjudication    Adjudication
+	// Concurrency is a number of games played at once
+	Concurrency int
+
+	// OnGame is called after each game if it's not nil, calls are serialized
+	OnGame func(r GameResult)
+}
+
+// New returns a tournament of players in format on standard chess boards from X-FEN openings,
+// with the standard starting position if there are no openings, and with moves in long algebraic
+// notation. Each pairing plays two games, one game is played at once.
+func New(players []Player, format Format) *Tournament {
+	return &Tournament{
+		Players:         players,
+		Format:          format,
+		NewBoard:        NewXFENBoard,
+		Notation:        func(base.IBoard) base.INotation { return rect.NewLongAlgebraicNotation() },
+		MoveTime:        DefaultMoveTime,
+		GamesPerPairing: 2,
+		Concurrency:     1,
+	}
+}
+
+// NewXFENBoard returns a rectangular board of an X-FEN opening,
+// it's the standard starting position if an opening is empty
+func NewXFENBoard(opening string) (base.IBoard, error) {
+	if opening == "" {
+		return rect.NewStandardChessStartingPosition().Board()
+	}
+	return rect.XFEN(opening).Board()
+}
+
+// ReadOpenings reads openings written one per line, empty lines and lines starting with "#" are skipped
+func ReadOpenings(r io.Reader) ([]string, error) {
+	res := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			res = append(res, line)
+		}
+	}
+	return res, scanner.Err()
+}
+
+// game is a game to play
+type game struct {
+	round, white, black int
+	opening             string
+}
+
+// Run plays a tournament and returns its results. Rounds are played one after another,
+// games of a round are played concurrently.
+func (t *Tournament) Run() (*Results, error) {
+	if len(t.Players) < 2 {
+		return nil, ErrPlayers
+	}
+	openings := t.Openings
+	if len(openings) == 0 {
+		openings = []string{""}
+	}
+	for _, opening := range openings {
+		b, err := t.NewBoard(opening)
+		if err != nil {
+			return nil, fmt.Errorf("opening %q: %v", opening, err)
+		}
+		if b.Settings().Players != nil {
+			return nil, ErrMultiPlayer
+		}
+	}
+
+	results := &Results{Players: make([]string, len(t.Players)), Games: []GameResult{}, Byes: []Bye{}}
+	for i, player := range t.Players {
+		results.Players[i] = player.Name
+	}
+	nextOpening := 0
+	for round := 0; round < t.Format.Rounds(len(t.Players)); round++ {
+		pairings, byes := t.Format.Pairings(round, results)
+		games := []game{}
+		for _, p := range pairings {
+			opening := openings[nextOpening%len(openings)]
+			nextOpening++
+			for i := 0; i < t.GamesPerPairing; i++ {
+				g := game{round: round, white: p.White, black: p.Black, opening: opening}
+				if i%2 == 1 {
+					g.white, g.black = g.black, g.white
+				}
+				games = append(games, g)
+			}
+		}
+		results.Games = append(results.Games, t.playAll(games)...)
+		for _, player := range byes {
+			results.Byes = append(results.Byes, Bye{Round: round, Player: player})
+		}
+	}
+	return results, nil
+}
+
+// playAll plays games by Concurrency workers and returns their results in the order of games
+func (t *Tournament) playAll(games []game) []GameResult {
+	res := make([]GameResult, len(games))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	workers := t.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				res[i] = t.play(games[i])
+				if t.OnGame != nil {
+					mu.Lock()
+					t.OnGame(res[i])
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range games {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return res
+}
+
+// play plays a game
+func (t *Tournament) play(g game) GameResult {
+	res := GameResult{Round: g.round, White: g.white, Black: g.black, Opening: g.opening, Moves: []string{}}
+	forfeit := func(loser Colour, termination string) GameResult {
+		res.Outcome, res.Termination = base.NewAdjudication(loser.Invert()), termination
+		return res
+	}
+
+	b, err := t.NewBoard(g.opening)
+	if err != nil { // openings are checked before, so it shouldn't happen
+		res.Outcome, res.Termination = base.NewAdjudication(Transparent), err.Error()
+		return res
+	}
+	engines := map[Colour]Engine{}
+	for _, side := range []struct {
+		colour Colour
+		player int
+	}{{White, g.white}, {Black, g.black}} {
+		e, err := t.Players[side.player].New()
+		if err != nil {
+			for _, started := range engines {
+				started.Close()
+			}
+			return forfeit(side.colour, "engine failed to start: "+err.Error())
+		}
+		engines[side.colour] = e
+	}
+	defer func() {
+		for _, e := range engines {
+			e.Close()
+		}
+	}()
+
+	notation, adj := t.Notation(b), newAdjudicator(t.Adjudication)
+	for !b.Outcome().IsFinished() {
+		side := b.SideToMove()
+		m, err := engines[side].Move(b.Copy(), notation, t.MoveTime)
+		switch {
+		case err == ErrResigned:
+			res.Outcome, res.Termination = base.NewResignation(side), "resignation"
+			return res
+		case err == ErrTimeout:
+			res.Outcome, res.Termination = base.NewTimeOver(side), "time forfeit"
+			return res
+		case err != nil:
+			return forfeit(side, "engine error: "+err.Error())
+		}
+		makeMove, err := notation.DecodeMove(b, m.Move)
+		if err != nil || !makeMove() {
+			return forfeit(side, "illegal move "+m.Move)
+		}
+		res.Moves = append(res.Moves, m.Move)
+		if b.Outcome().IsFinished() {
+			break
+		}
+		if winner, termination, ok := adj.adjudicate(b, side, m, len(res.Moves)); ok {
+			res.Outcome, res.Termination = base.NewAdjudication(winner), termination
+			return res
+		}
+	}
+	res.Outcome, res.Termination = b.Outcome(), b.Outcome().String()
+	return res
+}
+
+// adjudicator tracks scores of engines during a game to apply adjudication rules
+type adjudicator struct {
+	Adjudication
+	resign map[Colour]int // moves in a row with a losing score by sides
+	draw   int            // plies in a row with a drawish score
+}
+
+// newAdjudicator returns an adjudicator of rules a
+func newAdjudicator(a Adjudication) *adjudicator {
+	return &adjudicator{Adjudication: a, resign: map[Colour]int{}}
+}
+
+// adjudicate returns a winner of a game on b after a move m of side and a reason of adjudication,
+// it returns false if a game should go on
+func (a *adjudicator) adjudicate(b base.IBoard, side Colour, m Move, plies int) (Colour, string, bool) {
+	a.resign[side], a.draw = a.resign[side]+1, a.draw+1
+	if !m.HasScore || m.Score > -a.ResignScore {
+		a.resign[side] = 0
+	}
+	if !m.HasScore || m.Score > a.DrawScore || m.Score < -a.DrawScore {
+		a.draw = 0
+	}
+
+	switch {
+	case a.ResignMoves > 0 && a.resign[side] >= a.ResignMoves:
+		return side.Invert(), "adjudication: resign score", true
+	case a.DrawMoves > 0 && a.draw >= 2*a.DrawMoves:
+		return Transparent, "adjudication: draw score", true
+	case a.QuietMoves > 0 && b.HalfMoveCount() >= 2*a.QuietMoves:
+		return Transparent, "adjudication: quiet moves", true
+	}
+	if a.Tablebase != nil {
+		if winner, ok := a.Tablebase(b); ok {
+			return winner, "adjudication: tablebase", true
+		}
+	}
+	if a.MaxPlies > 0 && plies >= a.MaxPlies {
+		return Transparent, "adjudication: maximum plies", true
+	}
+	return Transparent, "", false
+}
@@ -0,0 +1,22 @@
+package tournament_test
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestMain(m *testing.M) {
+	if len(os.Args) == 4 && os.Args[1] == fakeEngineArg {
+		runFakeEngine(os.Args[2], os.Args[3])
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestTournament(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Tournament Suite")
+}
@@ -0,0 +1,315 @@
+package tournament_test
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"github.com/mtfelian/mtfchess/base"
+	. "github.com/mtfelian/mtfchess/colour"
+	"github.com/mtfelian/mtfchess/tournament"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// gardner is a starting position of Gardner minichess on a 5x5 board
+const gardner = "rnbqk/ppppp/5/PPPPP/RNBQK w - - 0 1"
+
+var _ = Describe("tournament", func() {
+	// random returns a player of a random engine
+	random := func(name string, seed int64) tournament.Player {
+		return tournament.Player{Name: name, New: func() (tournament.Engine, error) {
+			return tournament.NewRandomEngine(seed), nil
+		}}
+	}
+	// material returns a player of a material engine
+	material := func(name string, seed int64) tournament.Player {
+		return tournament.Player{Name: name, New: func() (tournament.Engine, error) {
+			return tournament.NewMaterialEngine(seed), nil
+		}}
+	}
+	// external returns a player of an external engine
+	external := func(name string, newEngine func(tournament.Command) (tournament.Engine, error),
+		cmd tournament.Command) tournament.Player {
+		return tournament.Player{Name: name, New: func() (tournament.Engine, error) { return newEngine(cmd) }}
+	}
+
+	// newTournament returns a tournament of players in format on Gardner minichess boards
+	// limited by 20 plies
+	newTournament := func(format tournament.Format, players ...tournament.Player) *tournament.Tournament {
+		t := tournament.New(players, format)
+		t.Openings = []string{gardner}
+		t.Adjudication.MaxPlies = 20
+		return t
+	}
+
+	// run returns results of t
+	run := func(t *tournament.Tournament) *tournament.Results {
+		res, err := t.Run()
+		Expect(err).NotTo(HaveOccurred())
+		return res
+	}
+
+	// pairs returns numbers of games of pairs of players by their indices with the lower index first
+	pairs := func(res *tournament.Results) map[[2]int]int {
+		pairs := map[[2]int]int{}
+		for _, g := range res.Games {
+			if g.White < g.Black {
+				pairs[[2]int{g.White, g.Black}]++
+			} else {
+				pairs[[2]int{g.Black, g.White}]++
+			}
+		}
+		return pairs
+	}
+
+	It("reads openings", func() {
+		openings, err := tournament.ReadOpenings(strings.NewReader("# Gardner\n" + gardner + "\n\n  k4/5/5/5/4K w - - 0 1 \n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(openings).To(Equal([]string{gardner, "k4/5/5/5/4K w - - 0 1"}))
+	})
+
+	It("rejects tournaments of less than two players and invalid openings", func() {
+		_, err := tournament.New([]tournament.Player{random("a", 1)}, tournament.RoundRobin{}).Run()
+		Expect(err).To(Equal(tournament.ErrPlayers))
+
+		t := newTournament(tournament.RoundRobin{}, random("a", 1), random("b", 2))
+		t.Openings = []string{"not an X-FEN"}
+		_, err = t.Run()
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("plays a round robin with colours swapped", func() {
+		t := newTournament(tournament.RoundRobin{Cycles: 1}, random("a", 1), random("b", 2), random("c", 3),
+			random("d", 4))
+		Expect(t.Format.Rounds(4)).To(Equal(3))
+		res := run(t)
+		Expect(res.Games).To(HaveLen(12))
+		Expect(pairs(res)).To(HaveLen(6))
+		for _, n := range pairs(res) {
+			Expect(n).To(Equal(2))
+		}
+		for i := 0; i < len(res.Games); i += 2 {
+			Expect(res.Games[i].White).To(Equal(res.Games[i+1].Black))
+			Expect(res.Games[i].Opening).To(Equal(gardner))
+		}
+		for _, g := range res.Games {
+			Expect(g.Outcome.IsFinished()).To(BeTrue())
+			Expect(len(g.Moves)).To(BeNumerically("<=", 20))
+		}
+	})
+
+	It("plays a round robin of an odd number of players in cycles", func() {
+		t := newTournament(tournament.RoundRobin{Cycles: 2}, random("a", 1), random("b", 2), random("c", 3))
+		t.GamesPerPairing = 1
+		Expect(t.Format.Rounds(3)).To(Equal(6))
+		res := run(t)
+		Expect(res.Games).To(HaveLen(6))
+		for _, n := range pairs(res) {
+			Expect(n).To(Equal(2))
+		}
+		whites := map[int]int{}
+		for _, g := range res.Games {
+			whites[g.White]++
+		}
+		Expect(whites).To(Equal(map[int]int{0: 2, 1: 2, 2: 2}))
+	})
+
+	It("plays a gauntlet", func() {
+		res := run(newTournament(tournament.Gauntlet{Cycles: 2}, material("m", 1), random("a", 2), random("b", 3)))
+		Expect(res.Games).To(HaveLen(8))
+		for _, g := range res.Games {
+			Expect(g.White == 0 || g.Black == 0).To(BeTrue())
+		}
+		Expect(pairs(res)).To(Equal(map[[2]int]int{{0, 1}: 4, {0, 2}: 4}))
+	})
+
+	Context("Swiss", func() {
+		It("pairs players with close scores which haven't met", func() {
+			res := &tournament.Results{Players: []string{"a", "b", "c", "d"}, Games: []tournament.GameResult{
+				{White: 0, Black: 1, Outcome: base.NewCheckmate(White)},
+				{White: 2, Black: 3, Outcome: base.NewCheckmate(White)},
+			}}
+			pairings, byes := tournament.Swiss{NumRounds: 3}.Pairings(1, res)
+			Expect(byes).To(BeEmpty())
+			Expect(pairings).To(Equal([]tournament.Pairing{{White: 0, Black: 2}, {White: 1, Black: 3}}))
+		})
+
+		It("gives byes to different players", func() {
+			res := run(newTournament(tournament.Swiss{NumRounds: 3}, random("a", 1), random("b", 2),
+				random("c", 3), random("d", 4), random("e", 5)))
+			Expect(res.Games).To(HaveLen(12))
+			Expect(res.Byes).To(HaveLen(3))
+			byes := map[int]bool{}
+			for _, bye := range res.Byes {
+				byes[bye.Player] = true
+			}
+			Expect(byes).To(HaveLen(3))
+
+			for _, s := range res.Standings() {
+				Expect(s.Score).To(BeNumerically("==", float64(s.Wins)+float64(s.Draws)/2+float64(s.Byes)))
+			}
+		})
+
+		It("gives a bye to the lowest player when every player got one", func() {
+			res := &tournament.Results{Players: []string{"a", "b", "c"},
+				Games: []tournament.GameResult{{White: 0, Black: 1, Outcome: base.NewCheckmate(White)}},
+				Byes:  []tournament.Bye{{Player: 0}, {Player: 1}, {Player: 2}}}
+			pairings, byes := tournament.Swiss{NumRounds: 4}.Pairings(3, res)
+			Expect(byes).To(Equal([]int{2}))
+			Expect(pairings).To(HaveLen(1))
+		})
+	})
+
+	Context("adjudication", func() {
+		It("adjudicates a game by a resign score", func() {
+			t := newTournament(tournament.RoundRobin{}, random("random", 1), material("material", 2))
+			t.Openings = []string{"4k3/8/8/8/8/8/8/QQR1K3 w - - 0 1"}
+			t.GamesPerPairing = 1
+			t.Adjudication.ResignScore, t.Adjudication.ResignMoves = 1000, 3
+			res := run(t)
+			Expect(res.Games[0].Termination).To(Equal("adjudication: resign score"))
+			Expect(res.Games[0].Outcome).To(Equal(base.NewAdjudication(White)))
+			Expect(res.Games[0].Moves).To(HaveLen(6))
+		})
+
+		It("adjudicates a draw by scores", func() {
+			t := newTournament(tournament.RoundRobin{}, material("a", 1), material("b", 2))
+			t.GamesPerPairing = 1
+			t.Adjudication.DrawScore, t.Adjudication.DrawMoves = 10000, 2
+			res := run(t)
+			Expect(res.Games[0].Termination).To(Equal("adjudication: draw score"))
+			Expect(res.Games[0].Outcome).To(Equal(base.NewAdjudication(Transparent)))
+			Expect(res.Games[0].Moves).To(HaveLen(4))
+		})
+
+		It("adjudicates a draw after moves without captures and pawn moves", func() {
+			t := newTournament(tournament.RoundRobin{}, random("a", 1), random("b", 2))
+			t.Openings = []string{"k4/5/5/5/1R2K w - - 7 1"}
+			t.Adjudication.QuietMoves = 4
+			for _, g := range run(t).Games {
+				Expect(g.Termination).To(Equal("adjudication: quiet moves"))
+				Expect(g.Outcome).To(Equal(base.NewAdjudication(Transparent)))
+				Expect(g.Moves).To(HaveLen(1))
+			}
+		})
+
+		It("adjudicates a game by a tablebase", func() {
+			t := newTournament(tournament.RoundRobin{}, random("a", 1), random("b", 2))
+			t.GamesPerPairing = 1
+			t.Adjudication.Tablebase = func(b base.IBoard) (Colour, bool) {
+				return Black, b.SideToMove() == Black
+			}
+			res := run(t)
+			Expect(res.Games[0].Termination).To(Equal("adjudication: tablebase"))
+			Expect(res.Games[0].Outcome).To(Equal(base.NewAdjudication(Black)))
+			Expect(res.Games[0].Moves).To(HaveLen(1))
+		})
+
+		It("adjudicates a draw after maximum plies", func() {
+			t := newTournament(tournament.RoundRobin{}, random("a", 1), random("b", 2))
+			t.Openings = []string{"k4/5/5/5/Q3K w - - 0 1"}
+			t.Adjudication.MaxPlies = 5
+			for _, g := range run(t).Games {
+				if g.Termination == "adjudication: maximum plies" {
+					Expect(g.Moves).To(HaveLen(5))
+					Expect(g.Outcome).To(Equal(base.NewAdjudication(Transparent)))
+				} else {
+					Expect(len(g.Moves)).To(BeNumerically("<", 5))
+				}
+			}
+		})
+	})
+
+	It("plays games concurrently", func() {
+		t := newTournament(tournament.RoundRobin{Cycles: 2}, random("a", 1), random("b", 2), random("c", 3))
+		t.Concurrency = 4
+		games := 0
+		t.OnGame = func(tournament.GameResult) { games++ }
+		res := run(t)
+		Expect(res.Games).To(HaveLen(12))
+		Expect(games).To(Equal(12))
+	})
+
+	Context("results", func() {
+		res := &tournament.Results{Players: []string{"a", "b", "c"}, Games: []tournament.GameResult{
+			{White: 0, Black: 1, Outcome: base.NewCheckmate(White)},
+			{White: 1, Black: 0, Outcome: base.NewCheckmate(Black)},
+			{White: 0, Black: 1, Outcome: base.NewStalemate()},
+			{White: 1, Black: 0, Outcome: base.NewResignation(White)},
+			{White: 2, Black: 1, Outcome: base.NewCheckmate(Black)},
+		}}
+
+		It("returns standings with Elo", func() {
+			standings := res.Standings()
+			Expect(standings).To(HaveLen(3))
+			a, b, c := standings[0], standings[1], standings[2]
+			Expect([]string{a.Name, b.Name, c.Name}).To(Equal([]string{"a", "b", "c"}))
+			Expect([]int{a.Games, a.Wins, a.Draws, a.Losses}).To(Equal([]int{4, 3, 1, 0}))
+			Expect(a.Score).To(BeNumerically("==", 3.5))
+			Expect(b.Score).To(BeNumerically("==", 1.5))
+			Expect(a.Elo).To(BeNumerically("~", 338.0, 0.5))
+			Expect(a.EloError).To(BeNumerically(">", 0))
+			Expect(b.Elo).To(BeNumerically("~", -147.2, 0.5))
+			Expect(c.Elo).To(BeNumerically("<", -100))
+		})
+
+		It("writes a crosstable", func() {
+			buf := &bytes.Buffer{}
+			Expect(res.Crosstable(buf)).To(Succeed())
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			Expect(lines).To(HaveLen(4))
+			Expect(strings.Fields(lines[0])).To(Equal([]string{"#", "Player", "Games", "Score", "Elo", "+/-",
+				"1", "2", "3"}))
+			Expect(strings.Fields(lines[1])).To(Equal([]string{"1", "a", "4", "3.5", "338",
+				strings.Fields(lines[1])[5], "*", "3.5", "-"}))
+			Expect(strings.Fields(lines[3])[6:]).To(Equal([]string{"-", "0", "*"}))
+		})
+	})
+
+	Context("external engines", func() {
+		It("plays UCI and CECP engines with castlings and promotions", func() {
+			t := tournament.New([]tournament.Player{
+				external("uci", tournament.NewUCIEngine, fakeEngine("uci", "")),
+				external("cecp", tournament.NewCECPEngine, fakeEngine("cecp", "")),
+			}, tournament.RoundRobin{})
+			t.Openings = []string{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "k7/4P3/8/8/8/8/8/K7 w - - 0 1"}
+			t.Adjudication.MaxPlies = 4
+			t.MoveTime = 10 * time.Millisecond
+			t.Format = tournament.RoundRobin{Cycles: 2}
+			res := run(t)
+			Expect(res.Games).To(HaveLen(4))
+			for i, g := range res.Games {
+				Expect(g.Termination).To(Equal("adjudication: maximum plies"))
+				if i < 2 {
+					Expect(g.Moves[0]).To(HavePrefix("O-O"))
+					Expect(g.Moves[1]).To(HavePrefix("O-O"))
+				} else {
+					Expect(g.Moves[0]).To(Equal("e7-e8=N"))
+				}
+			}
+		})
+
+		It("forfeits games of failing engines", func() {
+			t := tournament.New([]tournament.Player{
+				external("illegal", tournament.NewUCIEngine, fakeEngine("uci", "illegal")),
+				external("missing", tournament.NewUCIEngine, tournament.Command{Path: "/nonexistent/engine"}),
+				external("hang", tournament.NewCECPEngine, fakeEngine("cecp", "hang")),
+			}, tournament.Gauntlet{})
+			t.GamesPerPairing = 1
+			t.MoveTime = 10 * time.Millisecond
+			res := run(t)
+			Expect(res.Games).To(HaveLen(2))
+			Expect(res.Games[0].Outcome).To(Equal(base.NewAdjudication(White)))
+			Expect(res.Games[0].Termination).To(HavePrefix("engine failed to start"))
+			Expect(res.Games[1].Outcome).To(Equal(base.NewAdjudication(Black)))
+			Expect(res.Games[1].Termination).To(Equal("illegal move e2-e5"))
+
+			t.Players = []tournament.Player{t.Players[0], t.Players[2]}
+			t.Format = tournament.Gauntlet{Cycles: 2}
+			res = run(t)
+			Expect(res.Games[1].Termination).To(Equal("time forfeit"))
+			Expect(res.Games[1].Outcome).To(Equal(base.NewTimeOver(White)))
+		})
+	})
+})
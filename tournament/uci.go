@@ -0,0 +1,99 @@
+package tournament
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mtfelian/mtfchess/base"
+)
+
+// uciEngine is an external engine talking by Universal Chess Interface
+type uciEngine struct{ p *process }
+
+// NewUCIEngine starts an external UCI engine, sets its options and a variant by the UCI_Variant option
+func NewUCIEngine(c Command) (Engine, error) {
+	p, err := c.start()
+	if err != nil {
+		return nil, err
+	}
+	e := &uciEngine{p: p}
+	if err := e.init(c); err != nil {
+		p.close("quit")
+		return nil, err
+	}
+	return e, nil
+}
+
+// init starts a new game
+func (e *uciEngine) init(c Command) error {
+	if err := e.p.send("uci"); err != nil {
+		return err
+	}
+	if _, err := e.p.expect(c.initTimeout(), func(line string) bool { return line == "uciok" }); err != nil {
+		return err
+	}
+	if c.Variant != "" {
+		e.p.send("setoption name UCI_Variant value %s", c.Variant)
+	}
+	for name, value := range c.Options {
+		e.p.send("setoption name %s value %s", name, value)
+	}
+	e.p.send("ucinewgame")
+	if err := e.p.send("isready"); err != nil {
+		return err
+	}
+	_, err := e.p.expect(c.initTimeout(), func(line string) bool { return line == "readyok" })
+	return err
+}
+
+// Move implements Engine
+func (e *uciEngine) Move(b base.IBoard, notation base.INotation, limit time.Duration) (Move, error) {
+	rb, err := rectBoard(b)
+	if err != nil {
+		return Move{}, err
+	}
+	e.p.send("position fen %s", fen(rb))
+	if err := e.p.send("go movetime %d", limit/time.Millisecond); err != nil {
+		return Move{}, err
+	}
+	res := Move{}
+	line, err := e.p.expect(limit+moveTimeMargin, func(line string) bool {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && fields[0] == "info" {
+			res.Score, res.HasScore = uciScore(fields, res.Score, res.HasScore)
+		}
+		return len(fields) > 1 && fields[0] == "bestmove"
+	})
+	if err != nil {
+		return Move{}, err
+	}
+	res.Move, err = decodeEngineMove(rb, notation, strings.Fields(line)[1])
+	return res, err
+}
+
+// uciScore returns a score of fields of an info line like "info depth 5 score cp 31 pv e2e4",
+// or score and has if there is no score
+func uciScore(fields []string, score int, has bool) (int, bool) {
+	for i := 0; i+2 < len(fields); i++ {
+		if fields[i] != "score" {
+			continue
+		}
+		n, err := strconv.Atoi(fields[i+2])
+		if err != nil {
+			return score, has
+		}
+		switch {
+		case fields[i+1] == "cp":
+			return n, true
+		case fields[i+1] == "mate" && n > 0:
+			return MateScore - n, true
+		case fields[i+1] == "mate":
+			return -MateScore - n, true
+		}
+	}
+	return score, has
+}
+
+// Close implements Engine
+func (e *uciEngine) Close() error { return e.p.close("quit") }